@@ -18,6 +18,18 @@ import (
 
 const tokenSize = 32
 
+// the permission sets a token can be scoped to
+const (
+	// PermissionReadOnly covers the query endpoints
+	PermissionReadOnly = "read-only"
+	// PermissionWallet covers the wallet, key and transaction endpoints
+	PermissionWallet = "wallet"
+	// PermissionMining covers the mining work and policy endpoints
+	PermissionMining = "mining"
+	// PermissionAdmin covers everything, including node and token management
+	PermissionAdmin = "admin"
+)
+
 var (
 	// ErrBadID is returned when Create is called on an invalid id string.
 	ErrBadID = errors.New("invalid id")
@@ -25,6 +37,8 @@ var (
 	ErrDuplicateID = errors.New("duplicate access token ID")
 	// ErrBadType is returned when Create is called with a bad type.
 	ErrBadType = errors.New("type must be client or network")
+	// ErrBadPermission is returned when Create is called with an unknown permission.
+	ErrBadPermission = errors.New("unknown token permission")
 	// ErrNoMatchID is returned when Delete is called on nonexisting ID.
 	ErrNoMatchID = errors.New("nonexisting access token ID")
 	// ErrInvalidToken is returned when Check is called on invalid token
@@ -33,6 +47,13 @@ var (
 	// validIDRegexp checks that all characters are alphumeric, _ or -.
 	// It also must have a length of at least 1.
 	validIDRegexp = regexp.MustCompile(`^[\w-]+$`)
+
+	validPermissions = map[string]bool{
+		PermissionReadOnly: true,
+		PermissionWallet:   true,
+		PermissionMining:   true,
+		PermissionAdmin:    true,
+	}
 )
 
 // Token describe the access token.
@@ -41,6 +62,10 @@ type Token struct {
 	Token   string    `json:"token,omitempty"`
 	Type    string    `json:"type,omitempty"`
 	Created time.Time `json:"created_at"`
+
+	// Permissions scopes what the token may call, an empty list means a
+	// legacy token with full access
+	Permissions []string `json:"permissions,omitempty"`
 }
 
 // CredentialStore store user access credential.
@@ -55,11 +80,22 @@ func NewStore(db dbm.DB) *CredentialStore {
 	}
 }
 
-// Create generates a new access token with the given ID.
+// Create generates a new full-access token with the given ID.
 func (cs *CredentialStore) Create(id, typ string) (*Token, error) {
+	return cs.CreateWithPermissions(id, typ, nil)
+}
+
+// CreateWithPermissions generates a new access token scoped to the given
+// permission sets, an empty list grants full access.
+func (cs *CredentialStore) CreateWithPermissions(id, typ string, permissions []string) (*Token, error) {
 	if !validIDRegexp.MatchString(id) {
 		return nil, errors.WithDetailf(ErrBadID, "invalid id %q", id)
 	}
+	for _, permission := range permissions {
+		if !validPermissions[permission] {
+			return nil, errors.WithDetailf(ErrBadPermission, "unknown permission %q", permission)
+		}
+	}
 
 	key := []byte(id)
 	if cs.DB.Get(key) != nil {
@@ -75,10 +111,11 @@ func (cs *CredentialStore) Create(id, typ string) (*Token, error) {
 	sm3.Sum(hashedSecret, secret)
 
 	token := &Token{
-		ID:      id,
-		Token:   fmt.Sprintf("%s:%x", id, hashedSecret),
-		Type:    typ,
-		Created: time.Now(),
+		ID:          id,
+		Token:       fmt.Sprintf("%s:%x", id, hashedSecret),
+		Type:        typ,
+		Created:     time.Now(),
+		Permissions: permissions,
 	}
 
 	value, err := json.Marshal(token)
@@ -113,6 +150,44 @@ func (cs *CredentialStore) Check(id string, secret string) error {
 	return ErrInvalidToken
 }
 
+// GetPermissions returns the permission sets of a token, an empty list
+// means a legacy token with full access.
+func (cs *CredentialStore) GetPermissions(id string) ([]string, error) {
+	if !validIDRegexp.MatchString(id) {
+		return nil, errors.WithDetailf(ErrBadID, "invalid id %q", id)
+	}
+
+	value := cs.DB.Get([]byte(id))
+	if value == nil {
+		return nil, errors.WithDetailf(ErrNoMatchID, "get id %q nonexisting", id)
+	}
+
+	token := &Token{}
+	if err := json.Unmarshal(value, token); err != nil {
+		return nil, err
+	}
+	return token.Permissions, nil
+}
+
+// PermissionsCover reports whether the granted permission sets cover the
+// required one. An empty grant is a legacy full-access token, admin covers
+// everything, and wallet and mining tokens may also read.
+func PermissionsCover(granted []string, required string) bool {
+	if len(granted) == 0 {
+		return true
+	}
+
+	for _, permission := range granted {
+		if permission == required || permission == PermissionAdmin {
+			return true
+		}
+		if required == PermissionReadOnly && (permission == PermissionWallet || permission == PermissionMining) {
+			return true
+		}
+	}
+	return false
+}
+
 // List lists all access tokens.
 func (cs *CredentialStore) List() ([]*Token, error) {
 	tokens := make([]*Token, 0)
@@ -118,3 +118,53 @@ func mustCreateToken(ctx context.Context, t *testing.T, cs *CredentialStore, id,
 	}
 	return token
 }
+
+func TestCreateWithPermissions(t *testing.T) {
+	testDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+	cs := NewStore(testDB)
+
+	token, err := cs.CreateWithPermissions("scoped", "client", []string{PermissionReadOnly, PermissionMining})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token.Permissions) != 2 {
+		t.Errorf("got %d permissions, want 2", len(token.Permissions))
+	}
+
+	if _, err := cs.CreateWithPermissions("bad-perm", "client", []string{"root"}); errors.Root(err) != ErrBadPermission {
+		t.Errorf("CreateWithPermissions with unknown permission error = %s want %s", err, ErrBadPermission)
+	}
+
+	permissions, err := cs.GetPermissions("scoped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(permissions) != 2 || permissions[0] != PermissionReadOnly {
+		t.Errorf("GetPermissions returned %v", permissions)
+	}
+}
+
+func TestPermissionsCover(t *testing.T) {
+	cases := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{nil, PermissionAdmin, true}, // legacy tokens keep full access
+		{[]string{PermissionAdmin}, PermissionWallet, true},
+		{[]string{PermissionWallet}, PermissionWallet, true},
+		{[]string{PermissionWallet}, PermissionReadOnly, true},
+		{[]string{PermissionMining}, PermissionReadOnly, true},
+		{[]string{PermissionMining}, PermissionWallet, false},
+		{[]string{PermissionReadOnly}, PermissionWallet, false},
+		{[]string{PermissionReadOnly}, PermissionAdmin, false},
+		{[]string{PermissionWallet, PermissionMining}, PermissionMining, true},
+	}
+
+	for _, c := range cases {
+		if got := PermissionsCover(c.granted, c.required); got != c.want {
+			t.Errorf("PermissionsCover(%v, %s) = %v want %v", c.granted, c.required, got, c.want)
+		}
+	}
+}
@@ -79,6 +79,7 @@ type CtrlProgram struct {
 	KeyIndex       uint64
 	ControlProgram []byte
 	Change         bool // Mark whether this control program is for UTXO change
+	Imported       bool // Mark watch-only programs imported without an account
 }
 
 // Manager stores accounts and their associated control programs.
@@ -96,6 +97,7 @@ type Manager struct {
 
 	accIndexMu sync.Mutex
 	accountMu  sync.Mutex
+	policyMu   sync.Mutex
 }
 
 // NewManager creates a new account manager
@@ -154,6 +156,28 @@ func (m *Manager) CreateAddress(accountID string, change bool) (cp *CtrlProgram,
 	return m.createAddress(account, change)
 }
 
+// CreateBatchAddresses generate a batch of addresses with contiguous key
+// indexes for the select account
+func (m *Manager) CreateBatchAddresses(accountID string, change bool, count uint64) ([]*CtrlProgram, error) {
+	m.accountMu.Lock()
+	defer m.accountMu.Unlock()
+
+	account, err := m.FindByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	cps := []*CtrlProgram{}
+	for i := uint64(0); i < count; i++ {
+		cp, err := m.createAddress(account, change)
+		if err != nil {
+			return nil, err
+		}
+		cps = append(cps, cp)
+	}
+	return cps, nil
+}
+
 // DeleteAccount deletes the account's ID or alias matching accountInfo.
 func (m *Manager) DeleteAccount(aliasOrID string) (err error) {
 	account := &Account{}
@@ -298,6 +322,31 @@ func (m *Manager) GetCoinbaseCtrlProgram() (*CtrlProgram, error) {
 	return program, nil
 }
 
+// NextCoinbaseControlProgram derives a fresh address from the mining account
+// and returns its control program, so the miner can pay every block to a new
+// address. The program is persisted like any other account address.
+func (m *Manager) NextCoinbaseControlProgram() ([]byte, error) {
+	cp, err := m.GetCoinbaseCtrlProgram()
+	if err == ErrFindAccount {
+		log.Warningf("NextCoinbaseControlProgram: can't find any account in db")
+		return vmutil.DefaultCoinbaseProgram()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := m.FindByID(cp.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := m.createAddress(account, false)
+	if err != nil {
+		return nil, err
+	}
+	return program.ControlProgram, nil
+}
+
 // GetContractIndex return the current index
 func (m *Manager) GetContractIndex(accountID string) uint64 {
 	m.accIndexMu.Lock()
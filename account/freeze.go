@@ -0,0 +1,79 @@
+package account
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+var frozenUTXOPrefix = []byte("FrozenUTXO:")
+
+// pre-define frozen utxo errors
+var (
+	// ErrUTXOFrozen means the output is administratively frozen
+	ErrUTXOFrozen = errors.New("utxo is frozen")
+	// ErrNotFrozen means the output carries no freeze mark
+	ErrNotFrozen = errors.New("utxo is not frozen")
+)
+
+func frozenUTXOKey(outHash bc.Hash) []byte {
+	return append(frozenUTXOPrefix, outHash.Bytes()...)
+}
+
+// FrozenUTXO is an administrative freeze mark on one output, frozen outputs
+// are excluded from coin selection until unfrozen
+type FrozenUTXO struct {
+	OutputID bc.Hash `json:"output_id"`
+	Reason   string  `json:"reason"`
+	FrozenAt uint64  `json:"frozen_at"`
+}
+
+// FreezeUTXO mark the output as frozen so it can no longer be selected or
+// reserved for spending, the output must be known to the wallet
+func (m *Manager) FreezeUTXO(outHash bc.Hash, reason string) (*FrozenUTXO, error) {
+	if _, err := m.utxoKeeper.findUtxo(outHash, true); err != nil {
+		return nil, err
+	}
+
+	frozen := &FrozenUTXO{
+		OutputID: outHash,
+		Reason:   reason,
+		FrozenAt: uint64(time.Now().Unix()),
+	}
+
+	rawFrozen, err := json.Marshal(frozen)
+	if err != nil {
+		return nil, err
+	}
+
+	m.db.Set(frozenUTXOKey(outHash), rawFrozen)
+	return frozen, nil
+}
+
+// UnfreezeUTXO remove the freeze mark of the output
+func (m *Manager) UnfreezeUTXO(outHash bc.Hash) error {
+	if frozen := m.db.Get(frozenUTXOKey(outHash)); frozen == nil {
+		return ErrNotFrozen
+	}
+
+	m.db.Delete(frozenUTXOKey(outHash))
+	return nil
+}
+
+// ListFrozenUTXOs return every frozen output mark
+func (m *Manager) ListFrozenUTXOs() ([]*FrozenUTXO, error) {
+	frozenUTXOs := []*FrozenUTXO{}
+	frozenIter := m.db.IteratorPrefix(frozenUTXOPrefix)
+	defer frozenIter.Release()
+
+	for frozenIter.Next() {
+		frozen := &FrozenUTXO{}
+		if err := json.Unmarshal(frozenIter.Value(), frozen); err != nil {
+			return nil, err
+		}
+		frozenUTXOs = append(frozenUTXOs, frozen)
+	}
+	return frozenUTXOs, nil
+}
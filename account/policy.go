@@ -0,0 +1,228 @@
+package account
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bytom-gm/common"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+var (
+	spendPolicyPrefix = []byte("AccountPolicy:")
+	dailySpentPrefix  = []byte("AccountPolicySpent:")
+	chargedTxPrefix   = []byte("AccountPolicyCharged:")
+)
+
+// pre-define errors for supporting bytom errorFormatter
+var (
+	ErrPolicyTxAmount    = errors.New("transaction amount exceeds the account per-tx limit")
+	ErrPolicyDayAmount   = errors.New("transaction amount exceeds the account per-day limit")
+	ErrPolicyDestination = errors.New("transaction destination is not on the account whitelist")
+)
+
+func spendPolicyKey(accountID string) []byte {
+	return append(spendPolicyPrefix, []byte(accountID)...)
+}
+
+func dailySpentKey(accountID string, day string) []byte {
+	return append(dailySpentPrefix, []byte(accountID+":"+day)...)
+}
+
+func chargedTxKey(txID string) []byte {
+	return append(chargedTxPrefix, []byte(txID)...)
+}
+
+// SpendPolicy restricts what transactions may be signed for an account, a
+// zero limit or empty whitelist disables the corresponding restriction.
+type SpendPolicy struct {
+	AccountID        string   `json:"account_id"`
+	MaxTxAmount      uint64   `json:"max_tx_amount"`
+	MaxDayAmount     uint64   `json:"max_day_amount"`
+	AddressWhitelist []string `json:"address_whitelist"`
+}
+
+// SetSpendPolicy store the spend policy of the given account
+func (m *Manager) SetSpendPolicy(policy *SpendPolicy) error {
+	if _, err := m.FindByID(policy.AccountID); err != nil {
+		return err
+	}
+
+	for _, address := range policy.AddressWhitelist {
+		if _, err := m.getProgramByAddress(address); err != nil {
+			return err
+		}
+	}
+
+	rawPolicy, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	m.db.Set(spendPolicyKey(policy.AccountID), rawPolicy)
+	return nil
+}
+
+// GetSpendPolicy return the spend policy of the given account, a nil policy
+// means the account is unrestricted
+func (m *Manager) GetSpendPolicy(accountID string) (*SpendPolicy, error) {
+	rawPolicy := m.db.Get(spendPolicyKey(accountID))
+	if rawPolicy == nil {
+		return nil, nil
+	}
+
+	policy := &SpendPolicy{}
+	return policy, json.Unmarshal(rawPolicy, policy)
+}
+
+// DeleteSpendPolicy remove the spend policy of the given account
+func (m *Manager) DeleteSpendPolicy(accountID string) {
+	m.db.Delete(spendPolicyKey(accountID))
+}
+
+// ListSpendPolicies return all the stored account spend policies
+func (m *Manager) ListSpendPolicies() ([]*SpendPolicy, error) {
+	policies := []*SpendPolicy{}
+	policyIter := m.db.IteratorPrefix(spendPolicyPrefix)
+	defer policyIter.Release()
+
+	for policyIter.Next() {
+		policy := &SpendPolicy{}
+		if err := json.Unmarshal(policyIter.Value(), policy); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// getCtrlProgramByProg return the local control program matching prog, nil
+// when the program doesn't belong to this wallet
+func (m *Manager) getCtrlProgramByProg(prog []byte) *CtrlProgram {
+	var hash common.Hash
+	sm3.Sum(hash[:], prog)
+	rawProgram := m.db.Get(ContractKey(hash))
+	if rawProgram == nil {
+		return nil
+	}
+
+	cp := &CtrlProgram{}
+	if err := json.Unmarshal(rawProgram, cp); err != nil {
+		return nil
+	}
+	return cp
+}
+
+// EnforceSpendPolicy check the transaction against the stored account spend
+// policies and consume the daily allowance, it is meant to be called right
+// before signing. The allowance is charged at most once per transaction so
+// that multi-key templates signed by every key holder are not double counted.
+func (m *Manager) EnforceSpendPolicy(tx *types.Tx) error {
+	// net BTM outflow per account: spent inputs minus change back to the account
+	outflow := map[string]uint64{}
+	for _, input := range tx.Inputs {
+		if input.InputType() != types.SpendInputType || input.AssetID() != *consensus.BTMAssetID {
+			continue
+		}
+		if cp := m.getCtrlProgramByProg(input.ControlProgram()); cp != nil {
+			outflow[cp.AccountID] += input.Amount()
+		}
+	}
+
+	for _, output := range tx.Outputs {
+		if *output.AssetId != *consensus.BTMAssetID {
+			continue
+		}
+		if cp := m.getCtrlProgramByProg(output.ControlProgram); cp != nil {
+			if amount, ok := outflow[cp.AccountID]; ok {
+				if output.Amount > amount {
+					outflow[cp.AccountID] = 0
+				} else {
+					outflow[cp.AccountID] = amount - output.Amount
+				}
+			}
+		}
+	}
+
+	// the allowance read-modify-write below must not interleave between
+	// concurrent sign calls, otherwise both could pass the same remaining
+	// allowance and undercount
+	m.policyMu.Lock()
+	defer m.policyMu.Unlock()
+
+	day := time.Now().UTC().Format("20060102")
+	txID := tx.ID.String()
+	charged := m.db.Get(chargedTxKey(txID)) != nil
+	// charges are collected and written only once every account passes, so a
+	// rejected sign call never consumes allowance
+	pendingSpent := map[string]uint64{}
+	for accountID, amount := range outflow {
+		policy, err := m.GetSpendPolicy(accountID)
+		if err != nil {
+			return err
+		}
+		if policy == nil {
+			continue
+		}
+
+		if policy.MaxTxAmount > 0 && amount > policy.MaxTxAmount {
+			return ErrPolicyTxAmount
+		}
+
+		if len(policy.AddressWhitelist) > 0 {
+			if err := m.checkWhitelist(tx, accountID, policy.AddressWhitelist); err != nil {
+				return err
+			}
+		}
+
+		// a transaction already counted against the allowance, e.g. signed
+		// again by the next key of a quorum, must not be charged twice
+		if charged {
+			continue
+		}
+
+		spent := uint64(0)
+		if rawSpent := m.db.Get(dailySpentKey(accountID, day)); rawSpent != nil {
+			spent = common.BytesToUnit64(rawSpent)
+		}
+		if policy.MaxDayAmount > 0 && spent+amount > policy.MaxDayAmount {
+			return ErrPolicyDayAmount
+		}
+
+		pendingSpent[accountID] = spent + amount
+	}
+
+	for accountID, spent := range pendingSpent {
+		m.db.Set(dailySpentKey(accountID, day), common.Unit64ToBytes(spent))
+	}
+	if !charged && len(outflow) > 0 {
+		m.db.Set(chargedTxKey(txID), []byte(day))
+	}
+	return nil
+}
+
+// checkWhitelist verifies every output which doesn't return to the spending
+// account pays to a whitelisted address.
+func (m *Manager) checkWhitelist(tx *types.Tx, accountID string, whitelist []string) error {
+	allowed := map[string]bool{}
+	for _, address := range whitelist {
+		prog, err := m.getProgramByAddress(address)
+		if err != nil {
+			return err
+		}
+		allowed[string(prog)] = true
+	}
+
+	for _, output := range tx.Outputs {
+		if cp := m.getCtrlProgramByProg(output.ControlProgram); cp != nil && cp.AccountID == accountID {
+			continue
+		}
+		if !allowed[string(output.ControlProgram)] {
+			return ErrPolicyDestination
+		}
+	}
+	return nil
+}
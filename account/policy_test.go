@@ -0,0 +1,137 @@
+package account
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/bytom-gm/common"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// storeTestProgram registers prog as a control program of the given account so
+// EnforceSpendPolicy attributes spends of it to that account
+func (m *Manager) storeTestProgram(t testing.TB, accountID string, prog []byte) {
+	var hash common.Hash
+	sm3.Sum(hash[:], prog)
+	rawProgram, err := json.Marshal(&CtrlProgram{AccountID: accountID, ControlProgram: prog})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.db.Set(ContractKey(hash), rawProgram)
+}
+
+func policyTestTx(seed byte, prog []byte, amount uint64) *types.Tx {
+	return types.NewTx(types.TxData{
+		SerializedSize: 100,
+		Inputs: []*types.TxInput{
+			types.NewSpendInput(nil, bc.NewHash([32]byte{seed}), *consensus.BTMAssetID, amount, 0, prog),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*consensus.BTMAssetID, amount, []byte{0x51}),
+		},
+	})
+}
+
+func TestEnforceSpendPolicyTxLimit(t *testing.T) {
+	m := mockAccountManager(t)
+	account := m.createTestAccount(t, "policy-tx-limit", nil)
+	prog := []byte{0x00, 0x14, 0x01}
+	m.storeTestProgram(t, account.ID, prog)
+
+	if err := m.SetSpendPolicy(&SpendPolicy{AccountID: account.ID, MaxTxAmount: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.EnforceSpendPolicy(policyTestTx(1, prog, 150)); err != ErrPolicyTxAmount {
+		t.Errorf("amount over the per-tx limit: err = %v want ErrPolicyTxAmount", err)
+	}
+	if err := m.EnforceSpendPolicy(policyTestTx(2, prog, 100)); err != nil {
+		t.Errorf("amount at the per-tx limit: err = %v want nil", err)
+	}
+}
+
+func TestEnforceSpendPolicyChargeOnce(t *testing.T) {
+	m := mockAccountManager(t)
+	account := m.createTestAccount(t, "policy-charge-once", nil)
+	prog := []byte{0x00, 0x14, 0x02}
+	m.storeTestProgram(t, account.ID, prog)
+
+	if err := m.SetSpendPolicy(&SpendPolicy{AccountID: account.ID, MaxDayAmount: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same template signed once per key holder must only be charged once
+	tx := policyTestTx(1, prog, 60)
+	for i := 0; i < 3; i++ {
+		if err := m.EnforceSpendPolicy(tx); err != nil {
+			t.Fatalf("sign call %d of the same tx: err = %v want nil", i, err)
+		}
+	}
+
+	// a second transaction must see only the 60 charged so far
+	if err := m.EnforceSpendPolicy(policyTestTx(2, prog, 60)); err != ErrPolicyDayAmount {
+		t.Errorf("second tx over the per-day limit: err = %v want ErrPolicyDayAmount", err)
+	}
+	if err := m.EnforceSpendPolicy(policyTestTx(3, prog, 40)); err != nil {
+		t.Errorf("second tx within the per-day limit: err = %v want nil", err)
+	}
+}
+
+func TestEnforceSpendPolicyFailedSignNotCharged(t *testing.T) {
+	m := mockAccountManager(t)
+	account := m.createTestAccount(t, "policy-failed-sign", nil)
+	prog := []byte{0x00, 0x14, 0x03}
+	m.storeTestProgram(t, account.ID, prog)
+
+	if err := m.SetSpendPolicy(&SpendPolicy{AccountID: account.ID, MaxDayAmount: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.EnforceSpendPolicy(policyTestTx(1, prog, 150)); err != ErrPolicyDayAmount {
+		t.Fatalf("tx over the per-day limit: err = %v want ErrPolicyDayAmount", err)
+	}
+
+	// the rejected transaction must not have consumed any allowance
+	if err := m.EnforceSpendPolicy(policyTestTx(2, prog, 100)); err != nil {
+		t.Errorf("full allowance after a rejected tx: err = %v want nil", err)
+	}
+}
+
+func TestEnforceSpendPolicyConcurrent(t *testing.T) {
+	m := mockAccountManager(t)
+	account := m.createTestAccount(t, "policy-concurrent", nil)
+	prog := []byte{0x00, 0x14, 0x04}
+	m.storeTestProgram(t, account.ID, prog)
+
+	const workers = 16
+	const amount = uint64(10)
+	if err := m.SetSpendPolicy(&SpendPolicy{AccountID: account.ID, MaxDayAmount: workers * amount}); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = m.EnforceSpendPolicy(policyTestTx(byte(i+1), prog, amount))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("concurrent sign call %d: err = %v want nil", i, err)
+		}
+	}
+
+	// every concurrent charge must have been counted, leaving no allowance
+	if err := m.EnforceSpendPolicy(policyTestTx(workers+1, prog, 1)); err != ErrPolicyDayAmount {
+		t.Errorf("tx after the allowance is exhausted: err = %v want ErrPolicyDayAmount", err)
+	}
+}
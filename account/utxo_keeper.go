@@ -39,6 +39,7 @@ type UTXO struct {
 	ControlProgramIndex uint64
 	ValidHeight         uint64
 	Change              bool
+	Imported            bool
 }
 
 // reservation describes a reservation of a set of UTXOs
@@ -151,6 +152,10 @@ func (uk *utxoKeeper) ReserveParticular(outHash bc.Hash, useUnconfirmed bool, ex
 		return nil, ErrReserved
 	}
 
+	if uk.isFrozen(outHash) {
+		return nil, ErrUTXOFrozen
+	}
+
 	u, err := uk.findUtxo(outHash, useUnconfirmed)
 	if err != nil {
 		return nil, err
@@ -207,6 +212,9 @@ func (uk *utxoKeeper) findUtxos(accountID string, assetID *bc.AssetID, useUnconf
 		if u.AccountID != accountID || u.AssetID != *assetID {
 			return
 		}
+		if uk.isFrozen(u.OutputID) {
+			return
+		}
 		if u.ValidHeight > currentHeight {
 			immatureAmount += u.Amount
 		} else {
@@ -234,6 +242,11 @@ func (uk *utxoKeeper) findUtxos(accountID string, assetID *bc.AssetID, useUnconf
 	return utxos, immatureAmount
 }
 
+// isFrozen report whether the output carries an administrative freeze mark
+func (uk *utxoKeeper) isFrozen(outHash bc.Hash) bool {
+	return uk.db.Get(frozenUTXOKey(outHash)) != nil
+}
+
 func (uk *utxoKeeper) findUtxo(outHash bc.Hash, useUnconfirmed bool) (*UTXO, error) {
 	if u, ok := uk.unconfirmed[outHash]; useUnconfirmed && ok {
 		return u, nil
@@ -0,0 +1,299 @@
+package addrindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/common"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/consensus/segwit"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+var statusKey = []byte("addrIndexStatus")
+
+// Status is the chain position the index has processed up to, persisted so
+// the follower resumes and detaches orphaned blocks after a restart.
+type Status struct {
+	Height uint64  `json:"height"`
+	Hash   bc.Hash `json:"hash"`
+}
+
+// AddrIndex maintains per-address balances and transaction lists for every
+// address seen on chain, not only wallet-tracked ones. It follows the main
+// chain the same way the wallet does and is entirely optional.
+type AddrIndex struct {
+	db    dbm.DB
+	chain *protocol.Chain
+
+	rw     sync.RWMutex
+	status Status
+}
+
+// NewAddrIndex opens the index over db and starts the chain follower.
+func NewAddrIndex(db dbm.DB, chain *protocol.Chain) (*AddrIndex, error) {
+	ai := &AddrIndex{
+		db:    db,
+		chain: chain,
+	}
+
+	if rawStatus := db.Get(statusKey); rawStatus != nil {
+		if err := json.Unmarshal(rawStatus, &ai.status); err != nil {
+			return nil, err
+		}
+	} else {
+		block, err := chain.GetBlockByHeight(0)
+		if err != nil {
+			return nil, err
+		}
+		if err := ai.AttachBlock(block); err != nil {
+			return nil, err
+		}
+	}
+
+	go ai.indexUpdater()
+	return ai, nil
+}
+
+// BestHeight returns the height the index has processed up to.
+func (ai *AddrIndex) BestHeight() uint64 {
+	ai.rw.RLock()
+	defer ai.rw.RUnlock()
+	return ai.status.Height
+}
+
+// indexUpdater keeps the index in step with the main chain, detaching
+// orphaned blocks first like the wallet updater does.
+func (ai *AddrIndex) indexUpdater() {
+	for {
+		for ai.status.Height > 0 && !ai.chain.InMainChain(ai.status.Hash) {
+			block, err := ai.chain.GetBlockByHash(&ai.status.Hash)
+			if err != nil {
+				log.WithField("err", err).Error("addrindex updater GetBlockByHash")
+				return
+			}
+
+			if err := ai.DetachBlock(block); err != nil {
+				log.WithField("err", err).Error("addrindex updater DetachBlock stop")
+				return
+			}
+		}
+
+		block, _ := ai.chain.GetBlockByHeight(ai.BestHeight() + 1)
+		if block == nil {
+			<-ai.chain.BlockWaiter(ai.BestHeight() + 1)
+			continue
+		}
+
+		if err := ai.AttachBlock(block); err != nil {
+			log.WithField("err", err).Error("addrindex updater AttachBlock stop")
+			return
+		}
+	}
+}
+
+func balanceKey(address string, assetID string) []byte {
+	return []byte(fmt.Sprintf("AIB:%s:%s", address, assetID))
+}
+
+func balancePrefix(address string) []byte {
+	return []byte(fmt.Sprintf("AIB:%s:", address))
+}
+
+func txKey(address string, height uint64, txID *bc.Hash) []byte {
+	return []byte(fmt.Sprintf("AIT:%s:%016x:%s", address, height, txID.String()))
+}
+
+func txPrefix(address string) []byte {
+	return []byte(fmt.Sprintf("AIT:%s:", address))
+}
+
+// addressFromProgram derives the payment address of a standard control
+// program, non-standard programs yield an empty string and stay unindexed
+func addressFromProgram(prog []byte) string {
+	if segwit.IsP2WPKHScript(prog) {
+		if pubHash, err := segwit.GetHashFromStandardProg(prog); err == nil {
+			if address, err := common.NewAddressWitnessPubKeyHash(pubHash, &consensus.ActiveNetParams); err == nil {
+				return address.EncodeAddress()
+			}
+		}
+	} else if segwit.IsP2WSHScript(prog) {
+		if scriptHash, err := segwit.GetHashFromStandardProg(prog); err == nil {
+			if address, err := common.NewAddressWitnessScriptHash(scriptHash, &consensus.ActiveNetParams); err == nil {
+				return address.EncodeAddress()
+			}
+		}
+	}
+	return ""
+}
+
+// balanceDelta accumulates the net balance movement of one block per
+// address and asset before it is applied to the stored balances
+type balanceDelta map[string]map[string]int64
+
+func (d balanceDelta) add(address, assetID string, amount int64) {
+	if address == "" {
+		return
+	}
+	if d[address] == nil {
+		d[address] = map[string]int64{}
+	}
+	d[address][assetID] += amount
+}
+
+// applyBlock walks the block transactions with the given sign: +1 when
+// attaching, -1 when detaching. Spend inputs carry their own control
+// program and amount, so no utxo lookup is needed.
+func (ai *AddrIndex) applyBlock(batch dbm.Batch, block *types.Block, sign int64) error {
+	deltas := balanceDelta{}
+	for _, tx := range block.Transactions {
+		touched := map[string]bool{}
+
+		for _, input := range tx.Inputs {
+			address := addressFromProgram(input.ControlProgram())
+			if address == "" {
+				continue
+			}
+			assetID := input.AssetID()
+			deltas.add(address, assetID.String(), -sign*int64(input.Amount()))
+			touched[address] = true
+		}
+		for _, output := range tx.Outputs {
+			address := addressFromProgram(output.ControlProgram)
+			if address == "" {
+				continue
+			}
+			deltas.add(address, output.AssetId.String(), sign*int64(output.Amount))
+			touched[address] = true
+		}
+
+		for address := range touched {
+			key := txKey(address, block.Height, &tx.ID)
+			if sign > 0 {
+				batch.Set(key, []byte{})
+			} else {
+				batch.Delete(key)
+			}
+		}
+	}
+
+	for address, assets := range deltas {
+		for assetID, delta := range assets {
+			balance := int64(ai.getBalance(address, assetID)) + delta
+			if balance < 0 {
+				return fmt.Errorf("addrindex: balance of %s went negative at height %d", address, block.Height)
+			}
+			if balance == 0 {
+				batch.Delete(balanceKey(address, assetID))
+				continue
+			}
+			batch.Set(balanceKey(address, assetID), []byte(fmt.Sprintf("%d", balance)))
+		}
+	}
+	return nil
+}
+
+// AttachBlock indexes the addresses moved by a new main chain block.
+func (ai *AddrIndex) AttachBlock(block *types.Block) error {
+	ai.rw.Lock()
+	defer ai.rw.Unlock()
+
+	if block.Height > 0 && block.PreviousBlockHash != ai.status.Hash {
+		log.Warn("addrindex skip AttachBlock due to status hash not equal to previous hash")
+		return nil
+	}
+
+	batch := ai.db.NewBatch()
+	if err := ai.applyBlock(batch, block, 1); err != nil {
+		return err
+	}
+	return ai.commitStatus(batch, Status{Height: block.Height, Hash: block.Hash()})
+}
+
+// DetachBlock rolls the index back over an orphaned block.
+func (ai *AddrIndex) DetachBlock(block *types.Block) error {
+	ai.rw.Lock()
+	defer ai.rw.Unlock()
+
+	batch := ai.db.NewBatch()
+	if err := ai.applyBlock(batch, block, -1); err != nil {
+		return err
+	}
+	return ai.commitStatus(batch, Status{Height: block.Height - 1, Hash: block.PreviousBlockHash})
+}
+
+func (ai *AddrIndex) commitStatus(batch dbm.Batch, status Status) error {
+	rawStatus, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	batch.Set(statusKey, rawStatus)
+	batch.Write()
+	ai.status = status
+	return nil
+}
+
+func (ai *AddrIndex) getBalance(address, assetID string) uint64 {
+	rawBalance := ai.db.Get(balanceKey(address, assetID))
+	if rawBalance == nil {
+		return 0
+	}
+
+	balance := uint64(0)
+	fmt.Sscanf(string(rawBalance), "%d", &balance)
+	return balance
+}
+
+// GetBalances returns the indexed balance of one address per asset id.
+func (ai *AddrIndex) GetBalances(address string) map[string]uint64 {
+	ai.rw.RLock()
+	defer ai.rw.RUnlock()
+
+	balances := map[string]uint64{}
+	prefix := balancePrefix(address)
+	iter := ai.db.IteratorPrefix(prefix)
+	defer iter.Release()
+
+	for iter.Next() {
+		assetID := string(iter.Key()[len(prefix):])
+		balance := uint64(0)
+		fmt.Sscanf(string(iter.Value()), "%d", &balance)
+		balances[assetID] = balance
+	}
+	return balances
+}
+
+// AddressTx is one indexed confirmed transaction touching an address.
+type AddressTx struct {
+	TxID        string `json:"tx_id"`
+	BlockHeight uint64 `json:"block_height"`
+}
+
+// GetTransactions returns the confirmed transactions touching the address
+// in chain order.
+func (ai *AddrIndex) GetTransactions(address string) []*AddressTx {
+	ai.rw.RLock()
+	defer ai.rw.RUnlock()
+
+	txs := []*AddressTx{}
+	prefix := txPrefix(address)
+	iter := ai.db.IteratorPrefix(prefix)
+	defer iter.Release()
+
+	for iter.Next() {
+		entry := &AddressTx{}
+		suffix := string(iter.Key()[len(prefix):])
+		if _, err := fmt.Sscanf(suffix, "%016x:%s", &entry.BlockHeight, &entry.TxID); err != nil {
+			continue
+		}
+		txs = append(txs, entry)
+	}
+	return txs
+}
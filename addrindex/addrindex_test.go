@@ -0,0 +1,105 @@
+package addrindex
+
+import (
+	"testing"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/vm/vmutil"
+)
+
+func testProgramAndAddress(t *testing.T, seed byte) ([]byte, string) {
+	hash := make([]byte, 20)
+	for i := range hash {
+		hash[i] = seed
+	}
+
+	prog, err := vmutil.P2WPKHProgram(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := addressFromProgram(prog)
+	if address == "" {
+		t.Fatal("test program did not yield an address")
+	}
+	return prog, address
+}
+
+func TestAttachDetachBlock(t *testing.T) {
+	ai := &AddrIndex{db: dbm.NewMemDB()}
+
+	payProg, payAddress := testProgramAndAddress(t, 0x01)
+	changeProg, changeAddress := testProgramAndAddress(t, 0x02)
+
+	tx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs: []*types.TxInput{
+			types.NewSpendInput(nil, bc.Hash{V0: 1}, *consensus.BTMAssetID, 300, 0, changeProg),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*consensus.BTMAssetID, 200, payProg),
+			types.NewTxOutput(*consensus.BTMAssetID, 90, changeProg),
+		},
+	})
+	block := &types.Block{
+		BlockHeader:  types.BlockHeader{Height: 0},
+		Transactions: []*types.Tx{tx},
+	}
+
+	// the change address cannot go negative on attach without prior funds
+	if err := ai.AttachBlock(block); err == nil {
+		t.Fatal("expected negative balance error for unfunded spend")
+	}
+
+	// fund the change address first, then the spend block applies cleanly
+	fundTx := types.NewTx(types.TxData{
+		Version: 1,
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*consensus.BTMAssetID, 300, changeProg),
+		},
+	})
+	fundBlock := &types.Block{
+		BlockHeader:  types.BlockHeader{Height: 0},
+		Transactions: []*types.Tx{fundTx},
+	}
+	if err := ai.AttachBlock(fundBlock); err != nil {
+		t.Fatal(err)
+	}
+
+	block.BlockHeader = types.BlockHeader{Height: 1, PreviousBlockHash: fundBlock.Hash()}
+	if err := ai.AttachBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	assetID := consensus.BTMAssetID.String()
+	if balances := ai.GetBalances(payAddress); balances[assetID] != 200 {
+		t.Errorf("pay address balance = %d want 200", balances[assetID])
+	}
+	if balances := ai.GetBalances(changeAddress); balances[assetID] != 90 {
+		t.Errorf("change address balance = %d want 90", balances[assetID])
+	}
+
+	txs := ai.GetTransactions(payAddress)
+	if len(txs) != 1 || txs[0].TxID != tx.ID.String() || txs[0].BlockHeight != 1 {
+		t.Errorf("pay address transactions = %+v", txs)
+	}
+	if txs := ai.GetTransactions(changeAddress); len(txs) != 2 {
+		t.Errorf("change address transaction count = %d want 2", len(txs))
+	}
+
+	if err := ai.DetachBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if balances := ai.GetBalances(payAddress); len(balances) != 0 {
+		t.Errorf("pay address balances after detach = %v want empty", balances)
+	}
+	if balances := ai.GetBalances(changeAddress); balances[assetID] != 300 {
+		t.Errorf("change address balance after detach = %d want 300", balances[assetID])
+	}
+	if txs := ai.GetTransactions(payAddress); len(txs) != 0 {
+		t.Errorf("pay address transactions after detach = %+v want none", txs)
+	}
+}
@@ -7,10 +7,11 @@ import (
 )
 
 func (a *API) createAccessToken(ctx context.Context, x struct {
-	ID   string `json:"id"`
-	Type string `json:"type"`
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`
+	Permissions []string `json:"permissions"`
 }) Response {
-	token, err := a.accessTokens.Create(x.ID, x.Type)
+	token, err := a.accessTokens.CreateWithPermissions(x.ID, x.Type, x.Permissions)
 	if err != nil {
 		return NewErrorResponse(err)
 	}
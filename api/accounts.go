@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/hex"
 	"sort"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/blockchain/txbuilder"
 	"github.com/bytom-gm/common"
 	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto"
 	"github.com/bytom-gm/crypto/sm2/chainkd"
+	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/vm/vmutil"
 )
 
@@ -31,19 +37,145 @@ func (a *API) createAccount(ctx context.Context, ins struct {
 	return NewSuccessResponse(annotatedAccount)
 }
 
+// pre-define errors for account operations
+var (
+	// ErrAccountNotEmpty means deleting an account still holding funds without force
+	ErrAccountNotEmpty = errors.New("account has unspent outputs or unconfirmed transactions")
+	// ErrSweepInsufficient means the swept amount can't cover the transaction fee
+	ErrSweepInsufficient = errors.New("account balance is insufficient to pay the sweep fee")
+)
+
 // AccountInfo is request struct for deleteAccount
 type AccountInfo struct {
-	Info string `json:"account_info"`
+	Info  string `json:"account_info"`
+	Force bool   `json:"force"`
 }
 
 // POST /delete-account
 func (a *API) deleteAccount(ctx context.Context, in AccountInfo) Response {
+	acc, err := a.wallet.AccountMgr.FindByAlias(in.Info)
+	if err != nil {
+		if acc, err = a.wallet.AccountMgr.FindByID(in.Info); err != nil {
+			return NewErrorResponse(err)
+		}
+	}
+
+	if !in.Force {
+		if utxos := a.wallet.GetAccountUtxos(acc.ID, "", true, false); len(utxos) != 0 {
+			return NewErrorResponse(ErrAccountNotEmpty)
+		}
+
+		txs, err := a.wallet.GetUnconfirmedTxs(acc.ID)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+		if len(txs) != 0 {
+			return NewErrorResponse(ErrAccountNotEmpty)
+		}
+	}
+
 	if err := a.wallet.AccountMgr.DeleteAccount(in.Info); err != nil {
 		return NewErrorResponse(err)
 	}
 	return NewSuccessResponse(nil)
 }
 
+// POST /sweep-account
+// sweepAccount builds an unsigned transaction which moves all the spendable
+// funds of an account to the given address, it is intended to be used before
+// deleting the account.
+func (a *API) sweepAccount(ctx context.Context, ins struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+	Address      string `json:"address"`
+}) Response {
+	var acc *account.Account
+	var err error
+	if ins.AccountAlias != "" {
+		acc, err = a.wallet.AccountMgr.FindByAlias(ins.AccountAlias)
+	} else {
+		acc, err = a.wallet.AccountMgr.FindByID(ins.AccountID)
+	}
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	bestHeight := a.chain.BestBlockHeight()
+	actions := []map[string]interface{}{}
+	amounts := map[string]uint64{}
+	for _, utxo := range a.wallet.GetAccountUtxos(acc.ID, "", false, false) {
+		if utxo.ValidHeight > bestHeight {
+			continue
+		}
+		actions = append(actions, map[string]interface{}{
+			"type":      "spend_account_unspent_output",
+			"output_id": utxo.OutputID.String(),
+		})
+		amounts[utxo.AssetID.String()] += utxo.Amount
+	}
+
+	btmAmount := amounts[consensus.BTMAssetID.String()]
+	if btmAmount == 0 {
+		return NewErrorResponse(ErrSweepInsufficient)
+	}
+
+	buildOutputs := func(fee uint64) []map[string]interface{} {
+		outputs := []map[string]interface{}{}
+		for assetID, amount := range amounts {
+			if assetID == consensus.BTMAssetID.String() {
+				amount -= fee
+			}
+			outputs = append(outputs, map[string]interface{}{
+				"type":     "control_address",
+				"address":  ins.Address,
+				"asset_id": assetID,
+				"amount":   amount,
+			})
+		}
+		return outputs
+	}
+
+	mergeActions := func(fee uint64) []map[string]interface{} {
+		merged := append([]map[string]interface{}{}, actions...)
+		return append(merged, buildOutputs(fee)...)
+	}
+
+	// first pass with a zero fee to estimate the gas of the full sweep, the
+	// reservations are rolled back before the real build
+	acts, err := a.mergeSpendActions(&BuildRequest{Actions: mergeActions(0)})
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	builder := txbuilder.NewBuilder(time.Now().Add(defaultTxTTL))
+	defer builder.Rollback()
+	for _, act := range acts {
+		if err := act.Build(ctx, builder); err != nil {
+			return NewErrorResponse(err)
+		}
+	}
+
+	tpl, _, err := builder.Build()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	txGas, err := EstimateTxGas(*tpl)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	if btmAmount <= uint64(txGas.TotalNeu) {
+		return NewErrorResponse(ErrSweepInsufficient)
+	}
+	builder.Rollback()
+
+	tpl, err = a.buildSingle(ctx, &BuildRequest{Actions: mergeActions(uint64(txGas.TotalNeu))})
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(tpl)
+}
+
 type validateAddressResp struct {
 	Valid   bool `json:"valid"`
 	IsLocal bool `json:"is_local"`
@@ -171,3 +303,87 @@ func (a *API) setMiningAddress(ctx context.Context, in struct {
 		MiningAddress: miningAddress,
 	})
 }
+
+// POST /freeze-utxo
+// administratively exclude the output from coin selection, used with
+// regulated assets when an issuer requires specific holdings to be frozen
+func (a *API) freezeUTXO(ctx context.Context, ins struct {
+	OutputID bc.Hash `json:"output_id"`
+	Reason   string  `json:"reason"`
+}) Response {
+	frozen, err := a.wallet.AccountMgr.FreezeUTXO(ins.OutputID, ins.Reason)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(frozen)
+}
+
+// POST /unfreeze-utxo
+func (a *API) unfreezeUTXO(ctx context.Context, ins struct {
+	OutputID bc.Hash `json:"output_id"`
+}) Response {
+	if err := a.wallet.AccountMgr.UnfreezeUTXO(ins.OutputID); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
+
+// POST /list-frozen-utxos
+func (a *API) listFrozenUTXOs(ctx context.Context) Response {
+	frozenUTXOs, err := a.wallet.AccountMgr.ListFrozenUTXOs()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(frozenUTXOs)
+}
+
+// DeriveAddressResp is the stateless derivation result: nothing is stored on
+// the node
+type DeriveAddressResp struct {
+	Address        string             `json:"address"`
+	ControlProgram chainjson.HexBytes `json:"control_program"`
+	Pubkey         chainjson.HexBytes `json:"pubkey"`
+}
+
+// POST /derive-address
+// derive a P2WPKH deposit address from an xpub and derivation path using the
+// node's canonical logic, without touching any wallet state. The network
+// defaults to the node's active chain when empty.
+func (a *API) deriveAddress(ctx context.Context, ins struct {
+	XPub           chainkd.XPub         `json:"xpub"`
+	DerivationPath []chainjson.HexBytes `json:"derivation_path"`
+	Network        string               `json:"network"`
+}) Response {
+	params := consensus.ActiveNetParams
+	if ins.Network != "" {
+		netParams, ok := consensus.NetParams[ins.Network]
+		if !ok {
+			return NewErrorResponse(errors.New("unknown network: " + ins.Network))
+		}
+		params = netParams
+	}
+
+	path := make([][]byte, 0, len(ins.DerivationPath))
+	for _, component := range ins.DerivationPath {
+		path = append(path, component)
+	}
+
+	derivedPK := ins.XPub.Derive(path).PublicKey()
+	pubHash := crypto.Ripemd160(derivedPK)
+
+	address, err := common.NewAddressWitnessPubKeyHash(pubHash, &params)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	control, err := vmutil.P2WPKHProgram([]byte(pubHash))
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(&DeriveAddressResp{
+		Address:        address.EncodeAddress(),
+		ControlProgram: control,
+		Pubkey:         chainjson.HexBytes(derivedPK),
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bytom-gm/errors"
+)
+
+// errNoAddrIndex means the node runs without the optional address index
+var errNoAddrIndex = errors.New("address index is not enabled, restart with address_index set")
+
+// AddressBalance is the indexed holding of one address in one asset
+type AddressBalance struct {
+	AssetID string `json:"asset_id"`
+	Amount  uint64 `json:"amount"`
+}
+
+// POST /get-address-balance
+func (a *API) getAddressBalance(ctx context.Context, req struct {
+	Address string `json:"address"`
+}) Response {
+	if a.addrIndex == nil {
+		return NewErrorResponse(errNoAddrIndex)
+	}
+
+	balances := []*AddressBalance{}
+	for assetID, amount := range a.addrIndex.GetBalances(req.Address) {
+		balances = append(balances, &AddressBalance{AssetID: assetID, Amount: amount})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].AssetID < balances[j].AssetID })
+	return NewSuccessResponse(balances)
+}
+
+// POST /list-address-transactions
+func (a *API) listAddressTxs(ctx context.Context, req struct {
+	Address string `json:"address"`
+	From    uint   `json:"from"`
+	Count   uint   `json:"count"`
+}) Response {
+	if a.addrIndex == nil {
+		return NewErrorResponse(errNoAddrIndex)
+	}
+
+	txs := a.addrIndex.GetTransactions(req.Address)
+	start, end := getPageRange(len(txs), req.From, req.Count)
+	return NewSuccessResponse(txs[start:end])
+}
@@ -12,13 +12,16 @@ import (
 	cmn "github.com/tendermint/tmlibs/common"
 
 	"github.com/bytom-gm/accesstoken"
+	"github.com/bytom-gm/addrindex"
 	"github.com/bytom-gm/blockchain/txfeed"
+	"github.com/bytom-gm/common"
 	cfg "github.com/bytom-gm/config"
 	"github.com/bytom-gm/dashboard"
 	"github.com/bytom-gm/equity"
 	"github.com/bytom-gm/errors"
 	"github.com/bytom-gm/mining/cpuminer"
 	"github.com/bytom-gm/mining/miningpool"
+	"github.com/bytom-gm/mining/stratum"
 	"github.com/bytom-gm/net/http/authn"
 	"github.com/bytom-gm/net/http/gzip"
 	"github.com/bytom-gm/net/http/httpjson"
@@ -26,6 +29,8 @@ import (
 	"github.com/bytom-gm/netsync"
 	"github.com/bytom-gm/protocol"
 	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/txindex"
+	"github.com/bytom-gm/updater"
 	"github.com/bytom-gm/wallet"
 )
 
@@ -106,6 +111,8 @@ func (wh *waitHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 type API struct {
 	sync          *netsync.SyncManager
 	wallet        *wallet.Wallet
+	addrIndex     *addrindex.AddrIndex
+	txIndex       *txindex.TxIndex
 	accessTokens  *accesstoken.CredentialStore
 	chain         *protocol.Chain
 	server        *http.Server
@@ -113,6 +120,15 @@ type API struct {
 	txFeedTracker *txfeed.Tracker
 	cpuMiner      *cpuminer.CPUMiner
 	miningPool    *miningpool.MiningPool
+	stratumServer *stratum.Server
+	updater       *updater.Updater
+	subHub        *subHub
+	tlsConfig     *tls.Config
+	rateLimiter   *rateLimiter
+	healthConfig  *cfg.HealthConfig
+
+	// autoLockTimeout is the default unlock-wallet lifetime
+	autoLockTimeout time.Duration
 
 	newBlockCh chan *bc.Hash
 }
@@ -134,6 +150,12 @@ func (a *API) initServer(config *cfg.Config) {
 	secureheader.DefaultConfig.HTTPSRedirect = false
 	secureheader.DefaultConfig.Next = handler
 
+	tlsConfig, err := buildAPITLSConfig(config)
+	if err != nil {
+		cmn.Exit(cmn.Fmt("Failed to load api tls configuration: %v", err))
+	}
+	a.tlsConfig = tlsConfig
+
 	a.server = &http.Server{
 		// Note: we should not set TLSConfig here;
 		// we took care of TLS with the listener in maybeUseTLS.
@@ -156,6 +178,7 @@ func (a *API) StartServer(address string) {
 	if err != nil {
 		cmn.Exit(cmn.Fmt("Failed to register tcp port: %v", err))
 	}
+	listener = a.maybeUseTLS(listener)
 
 	// The `Serve` call has to happen in its own goroutine because
 	// it's blocking and we need to proceed to the rest of the core setup after
@@ -168,20 +191,30 @@ func (a *API) StartServer(address string) {
 }
 
 // NewAPI create and initialize the API
-func NewAPI(sync *netsync.SyncManager, wallet *wallet.Wallet, txfeeds *txfeed.Tracker, cpuMiner *cpuminer.CPUMiner, miningPool *miningpool.MiningPool, chain *protocol.Chain, config *cfg.Config, token *accesstoken.CredentialStore, newBlockCh chan *bc.Hash) *API {
+func NewAPI(sync *netsync.SyncManager, wallet *wallet.Wallet, addrIndex *addrindex.AddrIndex, txIndex *txindex.TxIndex, txfeeds *txfeed.Tracker, cpuMiner *cpuminer.CPUMiner, miningPool *miningpool.MiningPool, stratumServer *stratum.Server, chain *protocol.Chain, config *cfg.Config, token *accesstoken.CredentialStore, newBlockCh chan *bc.Hash, upd *updater.Updater) *API {
 	api := &API{
 		sync:          sync,
 		wallet:        wallet,
+		addrIndex:     addrIndex,
+		txIndex:       txIndex,
 		chain:         chain,
 		accessTokens:  token,
 		txFeedTracker: txfeeds,
 		cpuMiner:      cpuMiner,
 		miningPool:    miningPool,
+		stratumServer: stratumServer,
+		updater:       upd,
+		subHub:        newSubHub(),
+		rateLimiter:   newRateLimiter(config.RateLimit),
+		healthConfig:  config.Health,
+
+		autoLockTimeout: walletAutoLockTimeout(config.Wallet),
 
 		newBlockCh: newBlockCh,
 	}
 	api.buildHandler()
 	api.initServer(config)
+	api.startSubWatchers()
 
 	return api
 }
@@ -200,8 +233,15 @@ func (a *API) buildHandler() {
 		m.Handle("/create-account", jsonHandler(a.createAccount))
 		m.Handle("/list-accounts", jsonHandler(a.listAccounts))
 		m.Handle("/delete-account", jsonHandler(a.deleteAccount))
+		m.Handle("/sweep-account", jsonHandler(a.sweepAccount))
+
+		m.Handle("/set-spend-policy", jsonHandler(a.setSpendPolicy))
+		m.Handle("/get-spend-policy", jsonHandler(a.getSpendPolicy))
+		m.Handle("/delete-spend-policy", jsonHandler(a.deleteSpendPolicy))
+		m.Handle("/list-spend-policies", jsonHandler(a.listSpendPolicies))
 
 		m.Handle("/create-account-receiver", jsonHandler(a.createAccountReceiver))
+		m.Handle("/create-account-receivers", jsonHandler(a.createAccountReceivers))
 		m.Handle("/list-addresses", jsonHandler(a.listAddresses))
 		m.Handle("/validate-address", jsonHandler(a.validateAddress))
 		m.Handle("/list-pubkeys", jsonHandler(a.listPubKeys))
@@ -214,7 +254,11 @@ func (a *API) buildHandler() {
 
 		m.Handle("/create-asset", jsonHandler(a.createAsset))
 		m.Handle("/update-asset-alias", jsonHandler(a.updateAssetAlias))
+		m.Handle("/get-asset-alias-history", jsonHandler(a.getAssetAliasHistory))
 		m.Handle("/get-asset", jsonHandler(a.getAsset))
+		m.Handle("/get-asset-definition", jsonHandler(a.getAssetDefinition))
+		m.Handle("/get-asset-supply", jsonHandler(a.getAssetSupply))
+		m.Handle("/get-asset-circulation", jsonHandler(a.getAssetCirculation))
 		m.Handle("/list-assets", jsonHandler(a.listAssets))
 
 		m.Handle("/create-key", jsonHandler(a.pseudohsmCreateKey))
@@ -222,25 +266,69 @@ func (a *API) buildHandler() {
 		m.Handle("/delete-key", jsonHandler(a.pseudohsmDeleteKey))
 		m.Handle("/reset-key-password", jsonHandler(a.pseudohsmResetPassword))
 		m.Handle("/check-key-password", jsonHandler(a.pseudohsmCheckPassword))
+		m.Handle("/unlock-wallet", jsonHandler(a.unlockWallet))
+		m.Handle("/lock-wallet", jsonHandler(a.lockWallet))
 		m.Handle("/sign-message", jsonHandler(a.signMessage))
 
 		m.Handle("/build-transaction", jsonHandler(a.build))
 		m.Handle("/build-chain-transactions", jsonHandler(a.buildChainTxs))
+		m.Handle("/issue-assets", jsonHandler(a.issueAssets))
 		m.Handle("/sign-transaction", jsonHandler(a.signTemplate))
 		m.Handle("/sign-transactions", jsonHandler(a.signTemplates))
 
+		m.Handle("/schedule-transaction", jsonHandler(a.scheduleTx))
+		m.Handle("/list-scheduled-transactions", jsonHandler(a.listScheduledTxs))
+		m.Handle("/delete-scheduled-transaction", jsonHandler(a.deleteScheduledTx))
+
 		m.Handle("/get-transaction", jsonHandler(a.getTransaction))
 		m.Handle("/list-transactions", jsonHandler(a.listTransactions))
 
+		m.Handle("/export-transactions", jsonHandler(a.exportTransactions))
+
+		m.Handle("/set-transaction-metadata", jsonHandler(a.setTxMetadata))
+		m.Handle("/get-transaction-metadata", jsonHandler(a.getTxMetadata))
+		m.Handle("/delete-transaction-metadata", jsonHandler(a.deleteTxMetadata))
+		m.Handle("/list-transaction-metadata", jsonHandler(a.listTxMetadata))
+
 		m.Handle("/list-balances", jsonHandler(a.listBalances))
 		m.Handle("/list-unspent-outputs", jsonHandler(a.listUnspentOutputs))
+		m.Handle("/freeze-utxo", jsonHandler(a.freezeUTXO))
+		m.Handle("/unfreeze-utxo", jsonHandler(a.unfreezeUTXO))
+		m.Handle("/list-frozen-utxos", jsonHandler(a.listFrozenUTXOs))
+		m.Handle("/list-dust-utxos", jsonHandler(a.listDustUTXOs))
+		m.Handle("/build-consolidation", jsonHandler(a.buildConsolidation))
+		m.Handle("/build-send-all", jsonHandler(a.buildSendAll))
+
+		m.Handle("/list-unique-assets", jsonHandler(a.listUniqueAssets))
+		m.Handle("/transfer-unique-asset", jsonHandler(a.transferUniqueAsset))
+
+		m.Handle("/create-escrow", jsonHandler(a.createEscrow))
+		m.Handle("/get-escrow", jsonHandler(a.getEscrow))
+		m.Handle("/list-escrows", jsonHandler(a.listEscrows))
+		m.Handle("/build-escrow-settlement", jsonHandler(a.buildEscrowSettlement))
+
+		m.Handle("/create-recurring-payment", jsonHandler(a.createRecurringPayment))
+		m.Handle("/list-recurring-payments", jsonHandler(a.listRecurringPayments))
+		m.Handle("/pause-recurring-payment", jsonHandler(a.pauseRecurringPayment))
+		m.Handle("/resume-recurring-payment", jsonHandler(a.resumeRecurringPayment))
+		m.Handle("/delete-recurring-payment", jsonHandler(a.deleteRecurringPayment))
+
+		m.Handle("/import-watch-program", jsonHandler(a.importWatchProgram))
+		m.Handle("/import-watch-addresses", http.HandlerFunc(a.importWatchAddresses))
+		m.Handle("/list-watch-programs", jsonHandler(a.listWatchPrograms))
+		m.Handle("/delete-watch-program", jsonHandler(a.deleteWatchProgram))
 
 		m.Handle("/decode-program", jsonHandler(a.decodeProgram))
 
+		m.Handle("/websocket-events", a.walletEventsHandler())
+
 		m.Handle("/backup-wallet", jsonHandler(a.backupWalletImage))
 		m.Handle("/restore-wallet", jsonHandler(a.restoreWalletImage))
+		m.Handle("/backup-wallet-diff", jsonHandler(a.backupWalletDiff))
+		m.Handle("/restore-wallet-diff", jsonHandler(a.restoreWalletDiff))
 		m.Handle("/rescan-wallet", jsonHandler(a.rescanWallet))
 		m.Handle("/wallet-info", jsonHandler(a.getWalletInfo))
+		m.Handle("/wallet-audit", jsonHandler(a.walletAudit))
 	} else {
 		log.Warn("Please enable wallet")
 	}
@@ -261,10 +349,17 @@ func (a *API) buildHandler() {
 
 	m.Handle("/submit-transaction", jsonHandler(a.submit))
 	m.Handle("/submit-transactions", jsonHandler(a.submitTxs))
+	m.Handle("/test-transaction", jsonHandler(a.testTransaction))
 	m.Handle("/estimate-transaction-gas", jsonHandler(a.estimateTxGas))
+	m.Handle("/estimate-transaction-size", jsonHandler(a.estimateTxSize))
 
 	m.Handle("/get-unconfirmed-transaction", jsonHandler(a.getUnconfirmedTx))
 	m.Handle("/list-unconfirmed-transactions", jsonHandler(a.listUnconfirmedTxs))
+	m.Handle("/flush-mempool", jsonHandler(a.flushMempool))
+	m.Handle("/list-saved-mempool", jsonHandler(a.listSavedMempool))
+	m.Handle("/get-mempool", jsonHandler(a.getMempool))
+	m.Handle("/get-mempool-info", jsonHandler(a.getMempoolInfo))
+	m.Handle("/list-mempool-transactions", jsonHandler(a.listMempoolTxs))
 	m.Handle("/decode-raw-transaction", jsonHandler(a.decodeRawTransaction))
 
 	m.Handle("/get-block", jsonHandler(a.getBlock))
@@ -272,31 +367,78 @@ func (a *API) buildHandler() {
 	m.Handle("/get-block-hash", jsonHandler(a.getBestBlockHash))
 	m.Handle("/get-block-header", jsonHandler(a.getBlockHeader))
 	m.Handle("/get-block-count", jsonHandler(a.getBlockCount))
+	m.Handle("/get-chain-tips", jsonHandler(a.getChainTips))
+	m.Handle("/get-api-versions", jsonHandler(a.getAPIVersions))
+	m.Handle("/list-error-codes", jsonHandler(a.listErrorCodes))
+	m.Handle("/get-prune-height", jsonHandler(a.getPruneHeight))
+	m.Handle("/rollback-chain", jsonHandler(a.rollbackChain))
+	m.Handle("/get-softfork-status", jsonHandler(a.getSoftforkStatus))
+	m.Handle("/get-tx-cache-status", jsonHandler(a.getTxCacheStatus))
 	m.Handle("/get-difficulty", jsonHandler(a.getDifficulty))
 	m.Handle("/get-hash-rate", jsonHandler(a.getHashRate))
 
 	m.Handle("/is-mining", jsonHandler(a.isMining))
 	m.Handle("/set-mining", jsonHandler(a.setMining))
+	m.Handle("/get-mining-status", jsonHandler(a.getMiningStatus))
+	m.Handle("/get-mining-policy", jsonHandler(a.getMiningPolicy))
+	m.Handle("/set-mining-policy", jsonHandler(a.setMiningPolicy))
+	m.Handle("/get-coinbase-message", jsonHandler(a.getCoinbaseMessage))
+	m.Handle("/set-coinbase-message", jsonHandler(a.setCoinbaseMessage))
 
 	m.Handle("/get-work", jsonHandler(a.getWork))
 	m.Handle("/get-work-json", jsonHandler(a.getWorkJSON))
+	m.Handle("/get-work-longpoll", jsonHandler(a.getWorkLongPoll))
+	m.Handle("/websocket-work", a.workNotifyHandler())
 	m.Handle("/submit-block", jsonHandler(a.submitBlock))
 	m.Handle("/submit-work", jsonHandler(a.submitWork))
 	m.Handle("/submit-work-json", jsonHandler(a.submitWorkJSON))
+	m.Handle("/get-stratum-status", jsonHandler(a.getStratumStatus))
 
 	m.Handle("/verify-message", jsonHandler(a.verifyMessage))
 	m.Handle("/compile", jsonHandler(a.compileEquity))
 
 	m.Handle("/gas-rate", jsonHandler(a.gasRate))
+	m.Handle("/list-script-templates", jsonHandler(a.listScriptTemplates))
+	m.Handle("/derive-address", jsonHandler(a.deriveAddress))
+	m.Handle("/estimate-fee", http.HandlerFunc(a.estimateFee))
+	m.Handle("/replay-events", a.replayEventsHandler())
+	m.Handle("/websocket-subscribe", a.websocketSubscribeHandler())
 	m.Handle("/net-info", jsonHandler(a.getNetInfo))
+	m.Handle("/get-sync-status", jsonHandler(a.getSyncStatus))
+
+	m.Handle("/get-update-status", jsonHandler(a.getUpdateStatus))
+	m.Handle("/stage-update", jsonHandler(a.stageUpdate))
 
 	m.Handle("/list-peers", jsonHandler(a.listPeers))
 	m.Handle("/disconnect-peer", jsonHandler(a.disconnectPeer))
 	m.Handle("/connect-peer", jsonHandler(a.connectPeer))
+	m.Handle("/ban-peer", jsonHandler(a.banPeer))
+	m.Handle("/unban-peer", jsonHandler(a.unbanPeer))
+	m.Handle("/list-banned-peers", jsonHandler(a.listBannedPeers))
+	m.Handle("/whitelist-peer", jsonHandler(a.whitelistPeer))
+	m.Handle("/unwhitelist-peer", jsonHandler(a.unwhitelistPeer))
+	m.Handle("/list-whitelist", jsonHandler(a.listWhitelist))
 
 	m.Handle("/get-merkle-proof", jsonHandler(a.getMerkleProof))
 
+	m.Handle("/get-address-balance", jsonHandler(a.getAddressBalance))
+	m.Handle("/list-address-transactions", jsonHandler(a.listAddressTxs))
+	m.Handle("/get-raw-transaction", jsonHandler(a.getRawTransaction))
+
+	m.Handle("/batch", batchHandler(m))
+
+	m.Handle("/metrics", a.prometheusHandler())
+	m.Handle("/healthz", a.healthzHandler())
+	m.Handle("/readyz", a.readyzHandler())
+
+	m.Handle("/export-transaction-template", jsonHandler(a.exportTxTemplate))
+	m.Handle("/import-transaction-template", jsonHandler(a.importTxTemplate))
+
 	handler := latencyHandler(m, walletEnable)
+	handler = a.rateLimitHandler(handler)
+	handler = permissionHandler(handler)
+	handler = versionHandler(handler)
+	handler = recoverHandler(handler)
 	handler = webAssetsHandler(handler)
 	handler = gzip.Handler{Handler: handler}
 
@@ -317,6 +459,17 @@ func alwaysError(err error) http.Handler {
 	return jsonHandler(func() error { return err })
 }
 
+// recoverHandler turns a panic inside an API handler into an error response
+// instead of tearing down the server connection.
+func recoverHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		defer common.RecoverPanic("api", func(err error) {
+			httpjson.Write(req.Context(), rw, http.StatusInternalServerError, FormatErrResp(err))
+		})
+		next.ServeHTTP(rw, req)
+	})
+}
+
 func webAssetsHandler(next http.Handler) http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("/dashboard/", http.StripPrefix("/dashboard/", static.Handler{
@@ -365,7 +518,9 @@ func latencyHandler(m *http.ServeMux, walletEnable bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		// latency for the request url path
 		if l := latency(m, req); l != nil {
-			defer l.RecordSince(time.Now())
+			start := time.Now()
+			defer l.RecordSince(start)
+			defer observeAPILatency(req.URL.Path, start)
 		}
 
 		// when the wallet is not been opened and the url path is not been found, modify url path to error,
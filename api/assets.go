@@ -7,6 +7,7 @@ import (
 	"github.com/bytom-gm/asset"
 	"github.com/bytom-gm/crypto/sm2/chainkd"
 	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/errors"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -18,14 +19,41 @@ func (a *API) createAsset(ctx context.Context, ins struct {
 	Quorum          int                    `json:"quorum"`
 	Definition      map[string]interface{} `json:"definition"`
 	IssuanceProgram chainjson.HexBytes     `json:"issuance_program"`
+	MaxSupply       uint64                 `json:"max_supply"`
+	Unique          bool                   `json:"unique"`
 }) Response {
-	ass, err := a.wallet.AssetReg.Define(
-		ins.RootXPubs,
-		ins.Quorum,
-		ins.Definition,
-		strings.ToUpper(strings.TrimSpace(ins.Alias)),
-		ins.IssuanceProgram,
-	)
+	var ass *asset.Asset
+	var err error
+	if ins.Unique {
+		if len(ins.IssuanceProgram) != 0 || (ins.MaxSupply != 0 && ins.MaxSupply != 1) {
+			return NewErrorResponse(errors.New("unique asset cannot take a raw issuance program or another max supply"))
+		}
+		ass, err = a.wallet.AssetReg.DefineUnique(
+			ins.RootXPubs,
+			ins.Quorum,
+			ins.Definition,
+			strings.ToUpper(strings.TrimSpace(ins.Alias)),
+		)
+	} else if ins.MaxSupply > 0 {
+		if len(ins.IssuanceProgram) != 0 {
+			return NewErrorResponse(errors.New("max supply cannot be declared on a raw issuance program"))
+		}
+		ass, err = a.wallet.AssetReg.DefineCapped(
+			ins.RootXPubs,
+			ins.Quorum,
+			ins.Definition,
+			strings.ToUpper(strings.TrimSpace(ins.Alias)),
+			ins.MaxSupply,
+		)
+	} else {
+		ass, err = a.wallet.AssetReg.Define(
+			ins.RootXPubs,
+			ins.Quorum,
+			ins.Definition,
+			strings.ToUpper(strings.TrimSpace(ins.Alias)),
+			ins.IssuanceProgram,
+		)
+	}
 	if err != nil {
 		return NewErrorResponse(err)
 	}
@@ -51,3 +79,31 @@ func (a *API) updateAssetAlias(updateAlias struct {
 
 	return NewSuccessResponse(nil)
 }
+
+// AssetAliasHistoryResp hold the current alias of an asset and its retired
+// predecessors
+type AssetAliasHistoryResp struct {
+	AssetID string               `json:"asset_id"`
+	Alias   string               `json:"alias"`
+	History []*asset.AliasChange `json:"history"`
+}
+
+// POST /get-asset-alias-history
+func (a *API) getAssetAliasHistory(ctx context.Context, filter struct {
+	ID string `json:"id"`
+}) Response {
+	ass, err := a.wallet.AssetReg.GetAsset(filter.ID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	alias := ""
+	if ass.Alias != nil {
+		alias = *ass.Alias
+	}
+	return NewSuccessResponse(&AssetAliasHistoryResp{
+		AssetID: filter.ID,
+		Alias:   alias,
+		History: a.wallet.AssetReg.GetAliasHistory(&ass.AssetID),
+	})
+}
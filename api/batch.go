@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/net/http/httpjson"
+)
+
+const (
+	// maxBatchRequests caps how many calls one batch may carry
+	maxBatchRequests = 100
+	// maxBatchConcurrency bounds how many calls of one batch run at once,
+	// so a large batch cannot starve the interactive endpoints
+	maxBatchConcurrency = 8
+)
+
+var (
+	// ErrBatchTooLarge means the batch carried more calls than allowed
+	ErrBatchTooLarge = errors.New("batch exceeds the request limit")
+	// ErrBatchNested means a batch tried to call the batch endpoint
+	ErrBatchNested = errors.New("batch requests can not be nested")
+)
+
+// batchRequest is one call inside a batch, id is echoed back untouched so
+// clients can correlate the responses
+type batchRequest struct {
+	ID   json.RawMessage `json:"id,omitempty"`
+	Path string          `json:"path"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// batchResponse is the answer to one call of a batch, in the same order as
+// the requests
+type batchResponse struct {
+	ID       json.RawMessage `json:"id,omitempty"`
+	Path     string          `json:"path"`
+	Response json.RawMessage `json:"response"`
+}
+
+// batchRecorder buffers the response of one dispatched call
+type batchRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: http.Header{}, status: http.StatusOK}
+}
+
+func (r *batchRecorder) Header() http.Header         { return r.header }
+func (r *batchRecorder) Write(p []byte) (int, error) { return r.body.Write(p) }
+func (r *batchRecorder) WriteHeader(status int)      { r.status = status }
+
+// batchHandler accepts an array of requests in one HTTP call and returns the
+// correlated responses, so explorers don't pay a round trip per small call.
+// The calls run through the same mux as standalone requests, a bounded
+// number at a time.
+func batchHandler(m *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests := []*batchRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&requests); err != nil {
+			httpjson.Write(req.Context(), rw, http.StatusBadRequest, FormatErrResp(err))
+			return
+		}
+		if len(requests) > maxBatchRequests {
+			httpjson.Write(req.Context(), rw, http.StatusBadRequest, FormatErrResp(ErrBatchTooLarge))
+			return
+		}
+
+		responses := make([]*batchResponse, len(requests))
+		sem := make(chan struct{}, maxBatchConcurrency)
+		wg := sync.WaitGroup{}
+		for i, request := range requests {
+			wg.Add(1)
+			go func(i int, request *batchRequest) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				responses[i] = &batchResponse{
+					ID:       request.ID,
+					Path:     request.Path,
+					Response: dispatchBatchRequest(m, req, request),
+				}
+			}(i, request)
+		}
+		wg.Wait()
+
+		httpjson.Write(req.Context(), rw, http.StatusOK, responses)
+	})
+}
+
+// dispatchBatchRequest runs one call of a batch through the mux and returns
+// its raw JSON response
+func dispatchBatchRequest(m *http.ServeMux, batchReq *http.Request, request *batchRequest) json.RawMessage {
+	if request.Path == "/batch" {
+		return mustMarshalResponse(NewErrorResponse(ErrBatchNested))
+	}
+
+	subReq, err := http.NewRequest("POST", request.Path, bytes.NewReader(request.Data))
+	if err != nil {
+		return mustMarshalResponse(NewErrorResponse(err))
+	}
+	subReq = subReq.WithContext(batchReq.Context())
+	subReq.Header.Set("Content-Type", "application/json")
+
+	// the batch endpoint itself is read-only, each carried call still has
+	// to pass the token permission of its own path
+	if err := checkRoutePermission(subReq); err != nil {
+		return mustMarshalResponse(NewErrorResponse(err))
+	}
+
+	recorder := newBatchRecorder()
+	m.ServeHTTP(recorder, subReq)
+	return json.RawMessage(recorder.body.Bytes())
+}
+
+func mustMarshalResponse(resp Response) json.RawMessage {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return json.RawMessage(`{"status":"fail"}`)
+	}
+	return json.RawMessage(data)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func testBatchMux() *http.ServeMux {
+	m := http.NewServeMux()
+	m.Handle("/echo", jsonHandler(func(in struct {
+		Value string `json:"value"`
+	}) Response {
+		return NewSuccessResponse(in.Value)
+	}))
+	m.Handle("/batch", batchHandler(m))
+	return m
+}
+
+func runBatch(t *testing.T, m *http.ServeMux, body []byte) []*batchResponse {
+	req, err := http.NewRequest("POST", "/batch", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := newBatchRecorder()
+	m.ServeHTTP(recorder, req)
+
+	responses := []*batchResponse{}
+	if err := json.Unmarshal(recorder.body.Bytes(), &responses); err != nil {
+		t.Fatalf("batch answer is not a response array: %v", err)
+	}
+	return responses
+}
+
+func TestBatchCorrelation(t *testing.T) {
+	requests := []*batchRequest{}
+	for i := 0; i < 10; i++ {
+		requests = append(requests, &batchRequest{
+			ID:   json.RawMessage(fmt.Sprintf("%d", i)),
+			Path: "/echo",
+			Data: json.RawMessage(fmt.Sprintf(`{"value":"v%d"}`, i)),
+		})
+	}
+	body, err := json.Marshal(requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses := runBatch(t, testBatchMux(), body)
+	if len(responses) != len(requests) {
+		t.Fatalf("got %d responses, want %d", len(responses), len(requests))
+	}
+
+	for i, response := range responses {
+		if string(response.ID) != fmt.Sprintf("%d", i) {
+			t.Errorf("response %d echoes id %s, want %d", i, response.ID, i)
+		}
+
+		sub := &Response{}
+		if err := json.Unmarshal(response.Response, sub); err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("v%d", i); sub.Data != want {
+			t.Errorf("response %d carries %v, want %q", i, sub.Data, want)
+		}
+	}
+}
+
+func TestBatchSizeLimit(t *testing.T) {
+	requests := []*batchRequest{}
+	for i := 0; i < maxBatchRequests+1; i++ {
+		requests = append(requests, &batchRequest{Path: "/echo"})
+	}
+	body, err := json.Marshal(requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", "/batch", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := newBatchRecorder()
+	testBatchMux().ServeHTTP(recorder, req)
+
+	response := &Response{}
+	if err := json.Unmarshal(recorder.body.Bytes(), response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Status != FAIL {
+		t.Errorf("got status %q for an oversized batch, want %q", response.Status, FAIL)
+	}
+}
+
+func TestBatchRejectsNesting(t *testing.T) {
+	responses := runBatch(t, testBatchMux(), []byte(`[{"path":"/batch","data":"[]"}]`))
+	if len(responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(responses))
+	}
+
+	sub := &Response{}
+	if err := json.Unmarshal(responses[0].Response, sub); err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status != FAIL {
+		t.Errorf("got status %q for a nested batch, want %q", sub.Status, FAIL)
+	}
+}
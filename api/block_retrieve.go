@@ -2,6 +2,7 @@ package api
 
 import (
 	"math/big"
+	"sort"
 
 	"gopkg.in/fatih/set.v0"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/bytom-gm/errors"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/validation"
 )
 
 // return best block hash
@@ -25,6 +27,64 @@ func (a *API) getBlockCount() Response {
 	return NewSuccessResponse(blockHeight)
 }
 
+// return every known branch tip with height, hash, branch length and
+// status, the active tip first
+func (a *API) getChainTips() Response {
+	tips := a.chain.ChainTips()
+	sort.Slice(tips, func(i, j int) bool {
+		if tips[i].Status != tips[j].Status {
+			return tips[i].Status == "active"
+		}
+		if tips[i].Height != tips[j].Height {
+			return tips[i].Height > tips[j].Height
+		}
+		return tips[i].Hash.String() < tips[j].Hash.String()
+	})
+	return NewSuccessResponse(tips)
+}
+
+// RollbackChainReq is used to handle rollbackChain req
+type RollbackChainReq struct {
+	Height uint64 `json:"height"`
+}
+
+// rollbackChain detaches blocks from the best chain down to the given
+// height, an administrative recovery tool for corrupted tips and private
+// chain testing. The wallet rewinds its own indexes afterwards.
+func (a *API) rollbackChain(ins RollbackChainReq) Response {
+	if err := a.chain.Rollback(ins.Height); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(map[string]uint64{"best_height": a.chain.BestBlockHeight()})
+}
+
+// return the height up to which raw block data has been pruned, zero when
+// the node keeps every block
+func (a *API) getPruneHeight() Response {
+	pruneHeight := map[string]uint64{"prune_height": a.chain.PruneHeight()}
+	return NewSuccessResponse(pruneHeight)
+}
+
+// TxCacheStatusResp is the validation cache metrics for getTxCacheStatus
+type TxCacheStatusResp struct {
+	Hits       uint64 `json:"hits"`
+	Misses     uint64 `json:"misses"`
+	Entries    int    `json:"entries"`
+	MaxEntries int    `json:"max_entries"`
+}
+
+// return the hit/miss counters and occupancy of the transaction validation
+// result cache
+func (a *API) getTxCacheStatus() Response {
+	hits, misses, entries, maxEntries := validation.TxCacheStats()
+	return NewSuccessResponse(&TxCacheStatusResp{
+		Hits:       hits,
+		Misses:     misses,
+		Entries:    entries,
+		MaxEntries: maxEntries,
+	})
+}
+
 // BlockTx is the tx struct for getBlock func
 type BlockTx struct {
 	ID         bc.Hash                  `json:"id"`
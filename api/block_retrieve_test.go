@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// The submit-block request and get-raw-block response carry the block as
+// serialized hex through the Block text marshaller, this pins the round trip
+// external miners and test harnesses rely on.
+func TestRawBlockRoundTrip(t *testing.T) {
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Version:           1,
+			Height:            100,
+			PreviousBlockHash: bc.Hash{V0: 1},
+			Timestamp:         1540000000,
+			Nonce:             7,
+			Bits:              2305843009214532812,
+		},
+	}
+
+	rawResp, err := json.Marshal(GetRawBlockResp{RawBlock: block})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(rawResp), `"raw_block":"`) {
+		t.Errorf("get-raw-block response is not hex encoded: %s", rawResp)
+	}
+
+	submitReq := &SubmitBlockReq{}
+	if err := json.Unmarshal(rawResp, submitReq); err != nil {
+		t.Fatal(err)
+	}
+
+	if submitReq.Block.Height != block.Height {
+		t.Errorf("round trip height = %d want %d", submitReq.Block.Height, block.Height)
+	}
+	if submitReq.Block.Hash() != block.Hash() {
+		t.Errorf("round trip hash = %v want %v", submitReq.Block.Hash(), block.Hash())
+	}
+}
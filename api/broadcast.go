@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// BroadcastResult reports one secondary endpoint's acceptance of a submitted
+// transaction
+type BroadcastResult struct {
+	Endpoint string `json:"endpoint"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// broadcastEndpoints return the configured secondary broadcast targets and
+// the per-request timeout
+func broadcastEndpoints() ([]string, time.Duration) {
+	if cfg.CommonConfig == nil || cfg.CommonConfig.Broadcast == nil {
+		return nil, 0
+	}
+
+	timeout := time.Duration(cfg.CommonConfig.Broadcast.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return cfg.CommonConfig.Broadcast.Endpoints, timeout
+}
+
+// pushTxToEndpoint submit the raw transaction to another node's API, any
+// non-success API status is reported as an error
+func pushTxToEndpoint(endpoint string, rawTx []byte, timeout time.Duration) *BroadcastResult {
+	result := &BroadcastResult{Endpoint: endpoint}
+
+	payload, err := json.Marshal(&struct {
+		RawTransaction string `json:"raw_transaction"`
+	}{RawTransaction: string(rawTx)})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(endpoint+"/submit-transaction", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	apiResp := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(apiResp); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if apiResp.Status != SUCCESS {
+		result.Error = apiResp.Msg
+		return result
+	}
+	result.Success = true
+	return result
+}
+
+// broadcastToSecondaries push the transaction to every configured secondary
+// endpoint in parallel and collect the per-endpoint outcomes. The local
+// submit has already succeeded, failures here only weaken redundancy, so
+// they are reported instead of failing the request.
+func broadcastToSecondaries(tx *types.Tx) []*BroadcastResult {
+	endpoints, timeout := broadcastEndpoints()
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	rawTx, err := tx.TxData.MarshalText()
+	if err != nil {
+		log.WithField("err", err).Error("marshal tx for secondary broadcast")
+		return nil
+	}
+
+	results := make([]*BroadcastResult, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			results[i] = pushTxToEndpoint(endpoint, rawTx, timeout)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if !result.Success {
+			log.WithFields(log.Fields{"endpoint": result.Endpoint, "err": result.Error}).Warning("secondary broadcast failed")
+		}
+	}
+	return results
+}
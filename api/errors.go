@@ -12,6 +12,7 @@ import (
 	"github.com/bytom-gm/errors"
 	"github.com/bytom-gm/net/http/httperror"
 	"github.com/bytom-gm/net/http/httpjson"
+	"github.com/bytom-gm/protocol/policy"
 	"github.com/bytom-gm/protocol/validation"
 	"github.com/bytom-gm/protocol/vm"
 )
@@ -42,7 +43,8 @@ func isTemporary(info httperror.Info, err error) bool {
 }
 
 var respErrFormatter = map[error]httperror.Info{
-	ErrDefault: {500, "BTM000", "Bytom API Error"},
+	ErrDefault:       {500, "BTM000", "Bytom API Error"},
+	errBadAPIVersion: {400, "BTM002", "Unsupported API version"},
 
 	// Signers error namespace (2xx)
 	signers.ErrBadQuorum: {400, "BTM200", "Quorum must be greater than or equal to 1, and must be less than or equal to the length of xpubs"},
@@ -66,13 +68,20 @@ var respErrFormatter = map[error]httperror.Info{
 	txbuilder.ErrBadContractArgType: {400, "BTM711", "Invalid contract argument type"},
 	txbuilder.ErrOrphanTx:           {400, "BTM712", "Not found transaction input utxo"},
 	txbuilder.ErrExtTxFee:           {400, "BTM713", "Transaction fee exceed max limit"},
+	ErrAccountNotEmpty:              {400, "BTM714", "Account has unspent outputs or unconfirmed transactions"},
+	ErrSweepInsufficient:            {400, "BTM715", "Account balance is insufficient to pay the sweep fee"},
+	account.ErrPolicyTxAmount:       {400, "BTM716", "Transaction amount exceeds the account per-tx limit"},
+	account.ErrPolicyDayAmount:      {400, "BTM717", "Transaction amount exceeds the account per-day limit"},
+	account.ErrPolicyDestination:    {400, "BTM718", "Transaction destination is not on the account whitelist"},
 
 	// Submit transaction error namespace (73x ~ 79x)
 	// Validation error (73x ~ 75x)
 	validation.ErrTxVersion:                 {400, "BTM730", "Invalid transaction version"},
 	validation.ErrWrongTransactionSize:      {400, "BTM731", "Invalid transaction size"},
 	validation.ErrBadTimeRange:              {400, "BTM732", "Invalid transaction time range"},
-	validation.ErrNotStandardTx:             {400, "BTM733", "Not standard transaction"},
+	policy.ErrNonStandardProgram:            {400, "BTM733", "Not standard transaction"},
+	policy.ErrDustOutput:                    {400, "BTM749", "Transaction output is below the dust threshold"},
+	policy.ErrExceedWitnessLimit:            {400, "BTM750", "Transaction input exceeds the witness item limit"},
 	validation.ErrWrongCoinbaseTransaction:  {400, "BTM734", "Invalid coinbase transaction"},
 	validation.ErrWrongCoinbaseAsset:        {400, "BTM735", "Invalid coinbase assetID"},
 	validation.ErrCoinbaseArbitraryOversize: {400, "BTM736", "Invalid coinbase arbitrary size"},
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+
+	"github.com/bytom-gm/blockchain/txbuilder"
+	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/wallet"
+)
+
+// CreateEscrowResp return the tracked escrow together with the funding
+// transaction template
+type CreateEscrowResp struct {
+	Escrow    *wallet.Escrow      `json:"escrow"`
+	FundingTx *txbuilder.Template `json:"funding_transaction"`
+}
+
+// POST /create-escrow
+func (a *API) createEscrow(ctx context.Context, ins struct {
+	AccountID     string             `json:"account_id"`
+	Alias         string             `json:"alias"`
+	BuyerPubkey   chainjson.HexBytes `json:"buyer_pubkey"`
+	SellerPubkey  chainjson.HexBytes `json:"seller_pubkey"`
+	ArbiterPubkey chainjson.HexBytes `json:"arbiter_pubkey"`
+	AssetID       bc.AssetID         `json:"asset_id"`
+	Amount        uint64             `json:"amount"`
+}) Response {
+	escrow, tpl, err := a.wallet.CreateEscrow(ctx, ins.AccountID, ins.Alias, ins.BuyerPubkey, ins.SellerPubkey, ins.ArbiterPubkey, ins.AssetID, ins.Amount)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(&CreateEscrowResp{Escrow: escrow, FundingTx: tpl})
+}
+
+// POST /get-escrow
+func (a *API) getEscrow(ctx context.Context, ins struct {
+	ID string `json:"id"`
+}) Response {
+	status, err := a.wallet.GetEscrow(ins.ID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(status)
+}
+
+// POST /list-escrows
+func (a *API) listEscrows(ctx context.Context) Response {
+	escrows, err := a.wallet.ListEscrows()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(escrows)
+}
+
+// EscrowSettlementResp return the settlement template and which parties must
+// sign its witness
+type EscrowSettlementResp struct {
+	Transaction     *txbuilder.Template `json:"transaction"`
+	RequiredSigners []string            `json:"required_signers"`
+}
+
+// POST /build-escrow-settlement
+func (a *API) buildEscrowSettlement(ctx context.Context, ins struct {
+	ID      string `json:"id"`
+	Path    string `json:"path"`
+	Address string `json:"address"`
+	Fee     uint64 `json:"fee"`
+}) Response {
+	tpl, signers, err := a.wallet.BuildEscrowSettlement(ctx, ins.ID, ins.Path, ins.Address, ins.Fee)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(&EscrowSettlementResp{Transaction: tpl, RequiredSigners: signers})
+}
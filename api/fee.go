@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/net/http/httpjson"
+)
+
+// EstimateFeeResp is the recommended fee rate for a confirm target
+type EstimateFeeResp struct {
+	TargetBlocks uint64 `json:"target_blocks"`
+	FeeRate      uint64 `json:"fee_rate"`
+	// MinRelayFeeRate is the dynamic relay floor of the mempool, it rises
+	// while low fee-rate packages are being evicted
+	MinRelayFeeRate uint64 `json:"min_relay_fee_rate"`
+}
+
+// GET /estimate-fee?target_blocks=N
+// return the recommended fee rate (neu per byte) observed from recent mempool
+// confirmations, falling back to the static rate when history is too thin
+func (a *API) estimateFee(w http.ResponseWriter, r *http.Request) {
+	targetBlocks := uint64(1)
+	if raw := r.URL.Query().Get("target_blocks"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || parsed == 0 {
+			httpjson.Write(r.Context(), w, http.StatusOK, FormatErrResp(errors.WithDetail(httpjson.ErrBadRequest, "target_blocks must be a positive integer")))
+			return
+		}
+		targetBlocks = parsed
+	}
+
+	minRelayFeeRate := a.chain.GetTxPool().MinRelayFeeRate()
+	feeRate := txbuilder.EstimateFeeRate(targetBlocks)
+	if feeRate < minRelayFeeRate {
+		feeRate = minRelayFeeRate
+	}
+
+	resp := NewSuccessResponse(&EstimateFeeResp{
+		TargetBlocks:    targetBlocks,
+		FeeRate:         feeRate,
+		MinRelayFeeRate: minRelayFeeRate,
+	})
+	httpjson.Write(r.Context(), w, http.StatusOK, resp)
+}
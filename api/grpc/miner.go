@@ -0,0 +1,62 @@
+package apigrpc
+
+import (
+	context "golang.org/x/net/context"
+
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// SubscribeWork streams a sealed-header work unit whenever the block template
+// changes, so external miners never burn hashes on a stale template between
+// polls
+func (s *Server) SubscribeWork(req *SubscribeWorkRequest, stream Miner_SubscribeWorkServer) error {
+	for {
+		// grab the waiter before reading the work so a regeneration in
+		// between doesn't get lost
+		waiter := s.pool.WorkWaiter()
+		bh, err := s.pool.GetWork()
+		if err != nil {
+			return err
+		}
+
+		seed, err := s.chain.CalcNextSeed(&bh.PreviousBlockHash)
+		if err != nil {
+			return err
+		}
+
+		rawHeader, err := bh.MarshalText()
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(&WorkUnit{
+			Height:      bh.Height,
+			BlockHeader: string(rawHeader),
+			Seed:        seed.String(),
+			Bits:        bh.Bits,
+		}); err != nil {
+			return err
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-waiter:
+		}
+	}
+}
+
+// SubmitSolution hands a sealed header back to the mining pool. A rejection
+// comes back in the result instead of as an rpc error so miners can tell a
+// bad share from a broken connection
+func (s *Server) SubmitSolution(ctx context.Context, sol *Solution) (*SubmitResult, error) {
+	bh := &types.BlockHeader{}
+	if err := bh.UnmarshalText([]byte(sol.BlockHeader)); err != nil {
+		return &SubmitResult{RejectReason: err.Error()}, nil
+	}
+
+	if err := s.pool.SubmitWork(bh); err != nil {
+		return &SubmitResult{RejectReason: err.Error()}, nil
+	}
+	return &SubmitResult{Accepted: true}, nil
+}
@@ -0,0 +1,202 @@
+package apigrpc
+
+import (
+	context "golang.org/x/net/context"
+
+	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/version"
+)
+
+// GetStatus returns a snapshot of the chain and network state
+func (s *Server) GetStatus(ctx context.Context, req *StatusRequest) (*NodeStatus, error) {
+	status := &NodeStatus{
+		Height:        s.chain.BestBlockHeight(),
+		BestBlockHash: s.chain.BestBlockHash().String(),
+		Version:       version.Version,
+	}
+
+	if s.sync != nil {
+		status.Syncing = !s.sync.IsCaughtUp()
+		status.PeerCount = uint64(s.sync.Switch().Peers().Size())
+		status.NetworkId = s.sync.NodeInfo().Network
+	}
+	return status, nil
+}
+
+// GetBlock returns one main chain block by hash, or by height when the hash
+// is empty
+func (s *Server) GetBlock(ctx context.Context, req *GetBlockRequest) (*Block, error) {
+	var block *types.Block
+	var err error
+	if req.Hash != "" {
+		hash := bc.Hash{}
+		if err := hash.UnmarshalText([]byte(req.Hash)); err != nil {
+			return nil, err
+		}
+		block, err = s.chain.GetBlockByHash(&hash)
+	} else {
+		block, err = s.chain.GetBlockByHeight(req.Height)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buildBlockMsg(block)
+}
+
+// buildBlockMsg flattens a main chain block into its wire message
+func buildBlockMsg(block *types.Block) (*Block, error) {
+	rawBlock, err := block.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	txIDs := []string{}
+	for _, tx := range block.Transactions {
+		txIDs = append(txIDs, tx.ID.String())
+	}
+
+	blockHash := block.Hash()
+	return &Block{
+		Header: &BlockHeader{
+			Height:            block.Height,
+			Hash:              blockHash.String(),
+			PreviousBlockHash: block.PreviousBlockHash.String(),
+			Timestamp:         block.Timestamp,
+			Version:           block.Version,
+			TransactionCount:  uint64(len(block.Transactions)),
+		},
+		Raw:   string(rawBlock),
+		TxIds: txIDs,
+	}, nil
+}
+
+// SubmitTransaction broadcasts a raw signed transaction into the mempool and
+// out to the peers
+func (s *Server) SubmitTransaction(ctx context.Context, req *SubmitTransactionRequest) (*SubmitTransactionResult, error) {
+	tx := &types.Tx{}
+	if err := tx.UnmarshalText([]byte(req.RawTransaction)); err != nil {
+		return nil, err
+	}
+
+	if err := txbuilder.FinalizeTx(ctx, s.chain, tx); err != nil {
+		return nil, err
+	}
+	return &SubmitTransactionResult{TxId: tx.ID.String()}, nil
+}
+
+// SubscribeBlocks streams full main chain blocks from the requested height,
+// for services that index every transaction
+func (s *Server) SubscribeBlocks(req *SubscribeBlocksRequest, stream Node_SubscribeBlocksServer) error {
+	height := req.StartHeight
+	if height == 0 {
+		height = s.chain.BestBlockHeight() + 1
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-s.chain.BlockWaiter(height):
+		}
+
+		block, err := s.chain.GetBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+
+		msg, err := buildBlockMsg(block)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+		height++
+	}
+}
+
+// SubscribeTransactions streams transactions as the mempool accepts them,
+// optionally narrowed to the requested assets
+func (s *Server) SubscribeTransactions(req *SubscribeTransactionsRequest, stream Node_SubscribeTransactionsServer) error {
+	wantedAssets := map[string]bool{}
+	for _, rawAssetID := range req.AssetIds {
+		assetID := bc.AssetID{}
+		if err := assetID.UnmarshalText([]byte(rawAssetID)); err != nil {
+			return err
+		}
+		wantedAssets[assetID.String()] = true
+	}
+
+	txPool := s.chain.GetTxPool()
+	newTxCh := txPool.SubscribeNewTx()
+	defer txPool.UnsubscribeNewTx(newTxCh)
+
+	for {
+		var txD *protocol.TxDesc
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case got, ok := <-newTxCh:
+			if !ok {
+				return nil
+			}
+			txD = got
+		}
+
+		assetIDs := txAssetIDs(txD.Tx)
+		if len(wantedAssets) != 0 && !matchAnyAsset(wantedAssets, assetIDs) {
+			continue
+		}
+
+		rawTx, err := txD.Tx.MarshalText()
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&MempoolTransaction{
+			TxId:     txD.Tx.ID.String(),
+			Raw:      string(rawTx),
+			AssetIds: assetIDs,
+			Fee:      txD.Fee,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// txAssetIDs lists the distinct assets a transaction moves
+func txAssetIDs(tx *types.Tx) []string {
+	assetIDs := []string{}
+	seen := map[string]bool{}
+	for _, input := range tx.Inputs {
+		assetAmount := input.AssetAmount()
+		if assetAmount.AssetId == nil {
+			continue
+		}
+		if assetID := assetAmount.AssetId.String(); !seen[assetID] {
+			seen[assetID] = true
+			assetIDs = append(assetIDs, assetID)
+		}
+	}
+	for _, output := range tx.Outputs {
+		if output.AssetId == nil {
+			continue
+		}
+		if assetID := output.AssetId.String(); !seen[assetID] {
+			seen[assetID] = true
+			assetIDs = append(assetIDs, assetID)
+		}
+	}
+	return assetIDs
+}
+
+func matchAnyAsset(wanted map[string]bool, got []string) bool {
+	for _, assetID := range got {
+		if wanted[assetID] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Package apigrpc exposes the node over gRPC for backend services that want
+// typed streams instead of the JSON API.
+package apigrpc
+
+import (
+	"bytes"
+	"net"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/bytom-gm/mining/miningpool"
+	"github.com/bytom-gm/netsync"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+	w "github.com/bytom-gm/wallet"
+)
+
+// Server serves the Node, Wallet and Miner gRPC services
+type Server struct {
+	chain  *protocol.Chain
+	pool   *miningpool.MiningPool
+	wallet *w.Wallet
+	sync   *netsync.SyncManager
+}
+
+// NewServer creates a gRPC API server around the node internals, wallet may
+// be nil on a wallet-disabled node
+func NewServer(chain *protocol.Chain, pool *miningpool.MiningPool, wallet *w.Wallet, sync *netsync.SyncManager) *Server {
+	return &Server{chain: chain, pool: pool, wallet: wallet, sync: sync}
+}
+
+// Run listens on addr and serves the gRPC API until the listener fails
+func (s *Server) Run(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer()
+	RegisterNodeServer(grpcServer, s)
+	RegisterMinerServer(grpcServer, s)
+	if s.wallet != nil {
+		RegisterWalletServer(grpcServer, s)
+	}
+	log.WithField("addr", addr).Info("grpc api serving")
+	return grpcServer.Serve(listener)
+}
+
+// headerFilter is the compiled server side filter of one subscription
+type headerFilter struct {
+	everyN   uint64
+	assets   map[bc.AssetID]bool
+	programs [][]byte
+}
+
+func compileHeaderFilter(req *SubscribeHeadersRequest) (*headerFilter, error) {
+	filter := &headerFilter{everyN: req.EveryN, programs: req.ControlPrograms}
+	if filter.everyN == 0 {
+		filter.everyN = 1
+	}
+
+	if len(req.AssetIds) > 0 {
+		filter.assets = map[bc.AssetID]bool{}
+		for _, rawAssetID := range req.AssetIds {
+			assetID := bc.AssetID{}
+			if err := assetID.UnmarshalText([]byte(rawAssetID)); err != nil {
+				return nil, err
+			}
+			filter.assets[assetID] = true
+		}
+	}
+	return filter, nil
+}
+
+func (f *headerFilter) match(block *types.Block) bool {
+	if block.Height%f.everyN != 0 {
+		return false
+	}
+	if f.assets == nil && len(f.programs) == 0 {
+		return true
+	}
+
+	for _, tx := range block.Transactions {
+		for _, input := range tx.Inputs {
+			assetAmount := input.AssetAmount()
+			if f.assets != nil && assetAmount.AssetId != nil && f.assets[*assetAmount.AssetId] {
+				return true
+			}
+		}
+
+		for _, output := range tx.Outputs {
+			if f.assets != nil && output.AssetId != nil && f.assets[*output.AssetId] {
+				return true
+			}
+
+			for _, program := range f.programs {
+				if bytes.Equal(program, output.ControlProgram) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// SubscribeHeaders streams the headers of main chain blocks passing the
+// requested filters, so services that just track the tip don't have to pull
+// full block streams
+func (s *Server) SubscribeHeaders(req *SubscribeHeadersRequest, stream Node_SubscribeHeadersServer) error {
+	filter, err := compileHeaderFilter(req)
+	if err != nil {
+		return err
+	}
+
+	height := req.StartHeight
+	if height == 0 {
+		height = s.chain.BestBlockHeight() + 1
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-s.chain.BlockWaiter(height):
+		}
+
+		block, err := s.chain.GetBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+
+		if filter.match(block) {
+			blockHash := block.Hash()
+			if err := stream.Send(&BlockHeader{
+				Height:            block.Height,
+				Hash:              blockHash.String(),
+				PreviousBlockHash: block.PreviousBlockHash.String(),
+				Timestamp:         block.Timestamp,
+				Version:           block.Version,
+				TransactionCount:  uint64(len(block.Transactions)),
+			}); err != nil {
+				return err
+			}
+		}
+		height++
+	}
+}
@@ -0,0 +1,54 @@
+package apigrpc
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestHeaderFilterMatch(t *testing.T) {
+	assetID := bc.AssetID{V0: 1}
+	otherAssetID := bc.AssetID{V0: 2}
+	program := []byte{0x51}
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{Height: 6},
+		Transactions: []*types.Tx{
+			types.NewTx(types.TxData{
+				Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.Hash{}, assetID, 100, 0, []byte{0x52})},
+				Outputs: []*types.TxOutput{types.NewTxOutput(*consensus.BTMAssetID, 100, program)},
+			}),
+		},
+	}
+
+	cases := []struct {
+		req  *SubscribeHeadersRequest
+		want bool
+	}{
+		{req: &SubscribeHeadersRequest{}, want: true},
+		{req: &SubscribeHeadersRequest{EveryN: 3}, want: true},
+		{req: &SubscribeHeadersRequest{EveryN: 4}, want: false},
+		{req: &SubscribeHeadersRequest{AssetIds: []string{assetID.String()}}, want: true},
+		{req: &SubscribeHeadersRequest{AssetIds: []string{otherAssetID.String()}}, want: false},
+		{req: &SubscribeHeadersRequest{ControlPrograms: [][]byte{program}}, want: true},
+		{req: &SubscribeHeadersRequest{ControlPrograms: [][]byte{{0x53}}}, want: false},
+		{req: &SubscribeHeadersRequest{AssetIds: []string{otherAssetID.String()}, ControlPrograms: [][]byte{program}}, want: true},
+	}
+
+	for i, c := range cases {
+		filter, err := compileHeaderFilter(c.req)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if got := filter.match(block); got != c.want {
+			t.Errorf("case %d: got match %v want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestCompileHeaderFilterBadAsset(t *testing.T) {
+	if _, err := compileHeaderFilter(&SubscribeHeadersRequest{AssetIds: []string{"not-hex"}}); err == nil {
+		t.Error("expected error on malformed asset id")
+	}
+}
@@ -0,0 +1,838 @@
+// Hand-maintained Go mapping of service.proto. The build has no protoc step,
+// so keep this file in sync with the proto definition when either changes.
+
+package apigrpc
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// SubscribeHeadersRequest is the one message a SubscribeHeaders client sends,
+// carrying the start height and the optional server side filters
+type SubscribeHeadersRequest struct {
+	StartHeight     uint64   `protobuf:"varint,1,opt,name=start_height" json:"start_height,omitempty"`
+	EveryN          uint64   `protobuf:"varint,2,opt,name=every_n" json:"every_n,omitempty"`
+	AssetIds        []string `protobuf:"bytes,3,rep,name=asset_ids" json:"asset_ids,omitempty"`
+	ControlPrograms [][]byte `protobuf:"bytes,4,rep,name=control_programs" json:"control_programs,omitempty"`
+}
+
+func (m *SubscribeHeadersRequest) Reset()         { *m = SubscribeHeadersRequest{} }
+func (m *SubscribeHeadersRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeHeadersRequest) ProtoMessage()    {}
+
+// BlockHeader is one streamed main chain block header
+type BlockHeader struct {
+	Height            uint64 `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	Hash              string `protobuf:"bytes,2,opt,name=hash" json:"hash,omitempty"`
+	PreviousBlockHash string `protobuf:"bytes,3,opt,name=previous_block_hash" json:"previous_block_hash,omitempty"`
+	Timestamp         uint64 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	Version           uint64 `protobuf:"varint,5,opt,name=version" json:"version,omitempty"`
+	TransactionCount  uint64 `protobuf:"varint,6,opt,name=transaction_count" json:"transaction_count,omitempty"`
+}
+
+func (m *BlockHeader) Reset()         { *m = BlockHeader{} }
+func (m *BlockHeader) String() string { return proto.CompactTextString(m) }
+func (*BlockHeader) ProtoMessage()    {}
+
+// SubscribeWorkRequest is the one message a SubscribeWork client sends
+type SubscribeWorkRequest struct {
+}
+
+func (m *SubscribeWorkRequest) Reset()         { *m = SubscribeWorkRequest{} }
+func (m *SubscribeWorkRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeWorkRequest) ProtoMessage()    {}
+
+// WorkUnit is one sealed-header work unit streamed to an external miner
+type WorkUnit struct {
+	Height      uint64 `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	BlockHeader string `protobuf:"bytes,2,opt,name=block_header" json:"block_header,omitempty"`
+	Seed        string `protobuf:"bytes,3,opt,name=seed" json:"seed,omitempty"`
+	Bits        uint64 `protobuf:"varint,4,opt,name=bits" json:"bits,omitempty"`
+}
+
+func (m *WorkUnit) Reset()         { *m = WorkUnit{} }
+func (m *WorkUnit) String() string { return proto.CompactTextString(m) }
+func (*WorkUnit) ProtoMessage()    {}
+
+// Solution is a sealed header handed back by an external miner
+type Solution struct {
+	BlockHeader string `protobuf:"bytes,1,opt,name=block_header" json:"block_header,omitempty"`
+}
+
+func (m *Solution) Reset()         { *m = Solution{} }
+func (m *Solution) String() string { return proto.CompactTextString(m) }
+func (*Solution) ProtoMessage()    {}
+
+// SubmitResult reports whether the chain took a solution
+type SubmitResult struct {
+	Accepted     bool   `protobuf:"varint,1,opt,name=accepted" json:"accepted,omitempty"`
+	RejectReason string `protobuf:"bytes,2,opt,name=reject_reason" json:"reject_reason,omitempty"`
+}
+
+func (m *SubmitResult) Reset()         { *m = SubmitResult{} }
+func (m *SubmitResult) String() string { return proto.CompactTextString(m) }
+func (*SubmitResult) ProtoMessage()    {}
+
+// StatusRequest is the empty message a GetStatus client sends
+type StatusRequest struct {
+}
+
+func (m *StatusRequest) Reset()         { *m = StatusRequest{} }
+func (m *StatusRequest) String() string { return proto.CompactTextString(m) }
+func (*StatusRequest) ProtoMessage()    {}
+
+// NodeStatus is a snapshot of the chain and network state
+type NodeStatus struct {
+	Height        uint64 `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	BestBlockHash string `protobuf:"bytes,2,opt,name=best_block_hash" json:"best_block_hash,omitempty"`
+	Syncing       bool   `protobuf:"varint,3,opt,name=syncing" json:"syncing,omitempty"`
+	PeerCount     uint64 `protobuf:"varint,4,opt,name=peer_count" json:"peer_count,omitempty"`
+	NetworkId     string `protobuf:"bytes,5,opt,name=network_id" json:"network_id,omitempty"`
+	Version       string `protobuf:"bytes,6,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *NodeStatus) Reset()         { *m = NodeStatus{} }
+func (m *NodeStatus) String() string { return proto.CompactTextString(m) }
+func (*NodeStatus) ProtoMessage()    {}
+
+// GetBlockRequest selects one block by height or hash
+type GetBlockRequest struct {
+	Height uint64 `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	Hash   string `protobuf:"bytes,2,opt,name=hash" json:"hash,omitempty"`
+}
+
+func (m *GetBlockRequest) Reset()         { *m = GetBlockRequest{} }
+func (m *GetBlockRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBlockRequest) ProtoMessage()    {}
+
+// Block is one full main chain block
+type Block struct {
+	Header *BlockHeader `protobuf:"bytes,1,opt,name=header" json:"header,omitempty"`
+	Raw    string       `protobuf:"bytes,2,opt,name=raw" json:"raw,omitempty"`
+	TxIds  []string     `protobuf:"bytes,3,rep,name=tx_ids" json:"tx_ids,omitempty"`
+}
+
+func (m *Block) Reset()         { *m = Block{} }
+func (m *Block) String() string { return proto.CompactTextString(m) }
+func (*Block) ProtoMessage()    {}
+
+// SubmitTransactionRequest carries a raw signed transaction to broadcast
+type SubmitTransactionRequest struct {
+	RawTransaction string `protobuf:"bytes,1,opt,name=raw_transaction" json:"raw_transaction,omitempty"`
+}
+
+func (m *SubmitTransactionRequest) Reset()         { *m = SubmitTransactionRequest{} }
+func (m *SubmitTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*SubmitTransactionRequest) ProtoMessage()    {}
+
+// SubmitTransactionResult reports the id of the broadcast transaction
+type SubmitTransactionResult struct {
+	TxId string `protobuf:"bytes,1,opt,name=tx_id" json:"tx_id,omitempty"`
+}
+
+func (m *SubmitTransactionResult) Reset()         { *m = SubmitTransactionResult{} }
+func (m *SubmitTransactionResult) String() string { return proto.CompactTextString(m) }
+func (*SubmitTransactionResult) ProtoMessage()    {}
+
+// SubscribeBlocksRequest is the one message a SubscribeBlocks client sends
+type SubscribeBlocksRequest struct {
+	StartHeight uint64 `protobuf:"varint,1,opt,name=start_height" json:"start_height,omitempty"`
+}
+
+func (m *SubscribeBlocksRequest) Reset()         { *m = SubscribeBlocksRequest{} }
+func (m *SubscribeBlocksRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeBlocksRequest) ProtoMessage()    {}
+
+// SubscribeTransactionsRequest is the one message a SubscribeTransactions
+// client sends, carrying the optional asset filter
+type SubscribeTransactionsRequest struct {
+	AssetIds []string `protobuf:"bytes,1,rep,name=asset_ids" json:"asset_ids,omitempty"`
+}
+
+func (m *SubscribeTransactionsRequest) Reset()         { *m = SubscribeTransactionsRequest{} }
+func (m *SubscribeTransactionsRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeTransactionsRequest) ProtoMessage()    {}
+
+// MempoolTransaction is one streamed mempool acceptance
+type MempoolTransaction struct {
+	TxId     string   `protobuf:"bytes,1,opt,name=tx_id" json:"tx_id,omitempty"`
+	Raw      string   `protobuf:"bytes,2,opt,name=raw" json:"raw,omitempty"`
+	AssetIds []string `protobuf:"bytes,3,rep,name=asset_ids" json:"asset_ids,omitempty"`
+	Fee      uint64   `protobuf:"varint,4,opt,name=fee" json:"fee,omitempty"`
+}
+
+func (m *MempoolTransaction) Reset()         { *m = MempoolTransaction{} }
+func (m *MempoolTransaction) String() string { return proto.CompactTextString(m) }
+func (*MempoolTransaction) ProtoMessage()    {}
+
+// ListBalancesRequest restricts the balance answer to one account, empty
+// matches every account
+type ListBalancesRequest struct {
+	AccountId string `protobuf:"bytes,1,opt,name=account_id" json:"account_id,omitempty"`
+}
+
+func (m *ListBalancesRequest) Reset()         { *m = ListBalancesRequest{} }
+func (m *ListBalancesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListBalancesRequest) ProtoMessage()    {}
+
+// Balance is the confirmed amount of one asset held by one account
+type Balance struct {
+	AccountId    string `protobuf:"bytes,1,opt,name=account_id" json:"account_id,omitempty"`
+	AccountAlias string `protobuf:"bytes,2,opt,name=account_alias" json:"account_alias,omitempty"`
+	AssetId      string `protobuf:"bytes,3,opt,name=asset_id" json:"asset_id,omitempty"`
+	AssetAlias   string `protobuf:"bytes,4,opt,name=asset_alias" json:"asset_alias,omitempty"`
+	Amount       uint64 `protobuf:"varint,5,opt,name=amount" json:"amount,omitempty"`
+}
+
+func (m *Balance) Reset()         { *m = Balance{} }
+func (m *Balance) String() string { return proto.CompactTextString(m) }
+func (*Balance) ProtoMessage()    {}
+
+// BalanceList wraps the balances of a ListBalances answer
+type BalanceList struct {
+	Balances []*Balance `protobuf:"bytes,1,rep,name=balances" json:"balances,omitempty"`
+}
+
+func (m *BalanceList) Reset()         { *m = BalanceList{} }
+func (m *BalanceList) String() string { return proto.CompactTextString(m) }
+func (*BalanceList) ProtoMessage()    {}
+
+// Action is one typed transaction-building action, the fields used depend
+// on the type
+type Action struct {
+	Type           string `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
+	AccountId      string `protobuf:"bytes,2,opt,name=account_id" json:"account_id,omitempty"`
+	AssetId        string `protobuf:"bytes,3,opt,name=asset_id" json:"asset_id,omitempty"`
+	Amount         uint64 `protobuf:"varint,4,opt,name=amount" json:"amount,omitempty"`
+	Address        string `protobuf:"bytes,5,opt,name=address" json:"address,omitempty"`
+	ControlProgram []byte `protobuf:"bytes,6,opt,name=control_program" json:"control_program,omitempty"`
+}
+
+func (m *Action) Reset()         { *m = Action{} }
+func (m *Action) String() string { return proto.CompactTextString(m) }
+func (*Action) ProtoMessage()    {}
+
+// BuildTransactionRequest carries the actions of one template build
+type BuildTransactionRequest struct {
+	Actions    []*Action `protobuf:"bytes,1,rep,name=actions" json:"actions,omitempty"`
+	TtlSeconds uint64    `protobuf:"varint,2,opt,name=ttl_seconds" json:"ttl_seconds,omitempty"`
+}
+
+func (m *BuildTransactionRequest) Reset()         { *m = BuildTransactionRequest{} }
+func (m *BuildTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*BuildTransactionRequest) ProtoMessage()    {}
+
+// SignTransactionRequest carries a template through a signing pass
+type SignTransactionRequest struct {
+	TemplateJson string `protobuf:"bytes,1,opt,name=template_json" json:"template_json,omitempty"`
+	Password     string `protobuf:"bytes,2,opt,name=password" json:"password,omitempty"`
+}
+
+func (m *SignTransactionRequest) Reset()         { *m = SignTransactionRequest{} }
+func (m *SignTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*SignTransactionRequest) ProtoMessage()    {}
+
+// TransactionTemplate wraps the canonical JSON template, which carries the
+// signing instructions, together with the raw transaction for convenience
+type TransactionTemplate struct {
+	TemplateJson   string `protobuf:"bytes,1,opt,name=template_json" json:"template_json,omitempty"`
+	RawTransaction string `protobuf:"bytes,2,opt,name=raw_transaction" json:"raw_transaction,omitempty"`
+	SignComplete   bool   `protobuf:"varint,3,opt,name=sign_complete" json:"sign_complete,omitempty"`
+}
+
+func (m *TransactionTemplate) Reset()         { *m = TransactionTemplate{} }
+func (m *TransactionTemplate) String() string { return proto.CompactTextString(m) }
+func (*TransactionTemplate) ProtoMessage()    {}
+
+func init() {
+	proto.RegisterType((*SubscribeHeadersRequest)(nil), "apigrpc.SubscribeHeadersRequest")
+	proto.RegisterType((*BlockHeader)(nil), "apigrpc.BlockHeader")
+	proto.RegisterType((*SubscribeWorkRequest)(nil), "apigrpc.SubscribeWorkRequest")
+	proto.RegisterType((*WorkUnit)(nil), "apigrpc.WorkUnit")
+	proto.RegisterType((*Solution)(nil), "apigrpc.Solution")
+	proto.RegisterType((*SubmitResult)(nil), "apigrpc.SubmitResult")
+	proto.RegisterType((*StatusRequest)(nil), "apigrpc.StatusRequest")
+	proto.RegisterType((*NodeStatus)(nil), "apigrpc.NodeStatus")
+	proto.RegisterType((*GetBlockRequest)(nil), "apigrpc.GetBlockRequest")
+	proto.RegisterType((*Block)(nil), "apigrpc.Block")
+	proto.RegisterType((*SubmitTransactionRequest)(nil), "apigrpc.SubmitTransactionRequest")
+	proto.RegisterType((*SubmitTransactionResult)(nil), "apigrpc.SubmitTransactionResult")
+	proto.RegisterType((*SubscribeBlocksRequest)(nil), "apigrpc.SubscribeBlocksRequest")
+	proto.RegisterType((*SubscribeTransactionsRequest)(nil), "apigrpc.SubscribeTransactionsRequest")
+	proto.RegisterType((*MempoolTransaction)(nil), "apigrpc.MempoolTransaction")
+	proto.RegisterType((*ListBalancesRequest)(nil), "apigrpc.ListBalancesRequest")
+	proto.RegisterType((*Balance)(nil), "apigrpc.Balance")
+	proto.RegisterType((*BalanceList)(nil), "apigrpc.BalanceList")
+	proto.RegisterType((*Action)(nil), "apigrpc.Action")
+	proto.RegisterType((*BuildTransactionRequest)(nil), "apigrpc.BuildTransactionRequest")
+	proto.RegisterType((*SignTransactionRequest)(nil), "apigrpc.SignTransactionRequest")
+	proto.RegisterType((*TransactionTemplate)(nil), "apigrpc.TransactionTemplate")
+}
+
+// Client API for Node service
+
+type NodeClient interface {
+	GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*NodeStatus, error)
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	SubmitTransaction(ctx context.Context, in *SubmitTransactionRequest, opts ...grpc.CallOption) (*SubmitTransactionResult, error)
+	SubscribeHeaders(ctx context.Context, in *SubscribeHeadersRequest, opts ...grpc.CallOption) (Node_SubscribeHeadersClient, error)
+	SubscribeBlocks(ctx context.Context, in *SubscribeBlocksRequest, opts ...grpc.CallOption) (Node_SubscribeBlocksClient, error)
+	SubscribeTransactions(ctx context.Context, in *SubscribeTransactionsRequest, opts ...grpc.CallOption) (Node_SubscribeTransactionsClient, error)
+}
+
+type nodeClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewNodeClient(cc *grpc.ClientConn) NodeClient {
+	return &nodeClient{cc}
+}
+
+func (c *nodeClient) GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*NodeStatus, error) {
+	out := new(NodeStatus)
+	err := grpc.Invoke(ctx, "/apigrpc.Node/GetStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := grpc.Invoke(ctx, "/apigrpc.Node/GetBlock", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) SubmitTransaction(ctx context.Context, in *SubmitTransactionRequest, opts ...grpc.CallOption) (*SubmitTransactionResult, error) {
+	out := new(SubmitTransactionResult)
+	err := grpc.Invoke(ctx, "/apigrpc.Node/SubmitTransaction", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) SubscribeHeaders(ctx context.Context, in *SubscribeHeadersRequest, opts ...grpc.CallOption) (Node_SubscribeHeadersClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Node_serviceDesc.Streams[0], c.cc, "/apigrpc.Node/SubscribeHeaders", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodeSubscribeHeadersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Node_SubscribeHeadersClient interface {
+	Recv() (*BlockHeader, error)
+	grpc.ClientStream
+}
+
+type nodeSubscribeHeadersClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodeSubscribeHeadersClient) Recv() (*BlockHeader, error) {
+	m := new(BlockHeader)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nodeClient) SubscribeBlocks(ctx context.Context, in *SubscribeBlocksRequest, opts ...grpc.CallOption) (Node_SubscribeBlocksClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Node_serviceDesc.Streams[1], c.cc, "/apigrpc.Node/SubscribeBlocks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodeSubscribeBlocksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Node_SubscribeBlocksClient interface {
+	Recv() (*Block, error)
+	grpc.ClientStream
+}
+
+type nodeSubscribeBlocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodeSubscribeBlocksClient) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nodeClient) SubscribeTransactions(ctx context.Context, in *SubscribeTransactionsRequest, opts ...grpc.CallOption) (Node_SubscribeTransactionsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Node_serviceDesc.Streams[2], c.cc, "/apigrpc.Node/SubscribeTransactions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodeSubscribeTransactionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Node_SubscribeTransactionsClient interface {
+	Recv() (*MempoolTransaction, error)
+	grpc.ClientStream
+}
+
+type nodeSubscribeTransactionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodeSubscribeTransactionsClient) Recv() (*MempoolTransaction, error) {
+	m := new(MempoolTransaction)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for Node service
+
+type NodeServer interface {
+	GetStatus(context.Context, *StatusRequest) (*NodeStatus, error)
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	SubmitTransaction(context.Context, *SubmitTransactionRequest) (*SubmitTransactionResult, error)
+	SubscribeHeaders(*SubscribeHeadersRequest, Node_SubscribeHeadersServer) error
+	SubscribeBlocks(*SubscribeBlocksRequest, Node_SubscribeBlocksServer) error
+	SubscribeTransactions(*SubscribeTransactionsRequest, Node_SubscribeTransactionsServer) error
+}
+
+func RegisterNodeServer(s *grpc.Server, srv NodeServer) {
+	s.RegisterService(&_Node_serviceDesc, srv)
+}
+
+func _Node_SubscribeHeaders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeHeadersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeServer).SubscribeHeaders(m, &nodeSubscribeHeadersServer{stream})
+}
+
+type Node_SubscribeHeadersServer interface {
+	Send(*BlockHeader) error
+	grpc.ServerStream
+}
+
+type nodeSubscribeHeadersServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodeSubscribeHeadersServer) Send(m *BlockHeader) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Node_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apigrpc.Node/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetStatus(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apigrpc.Node/GetBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_SubmitTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).SubmitTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apigrpc.Node/SubmitTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).SubmitTransaction(ctx, req.(*SubmitTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_SubscribeBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeBlocksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeServer).SubscribeBlocks(m, &nodeSubscribeBlocksServer{stream})
+}
+
+type Node_SubscribeBlocksServer interface {
+	Send(*Block) error
+	grpc.ServerStream
+}
+
+type nodeSubscribeBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodeSubscribeBlocksServer) Send(m *Block) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Node_SubscribeTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeServer).SubscribeTransactions(m, &nodeSubscribeTransactionsServer{stream})
+}
+
+type Node_SubscribeTransactionsServer interface {
+	Send(*MempoolTransaction) error
+	grpc.ServerStream
+}
+
+type nodeSubscribeTransactionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodeSubscribeTransactionsServer) Send(m *MempoolTransaction) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Node_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "apigrpc.Node",
+	HandlerType: (*NodeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _Node_GetStatus_Handler,
+		},
+		{
+			MethodName: "GetBlock",
+			Handler:    _Node_GetBlock_Handler,
+		},
+		{
+			MethodName: "SubmitTransaction",
+			Handler:    _Node_SubmitTransaction_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeHeaders",
+			Handler:       _Node_SubscribeHeaders_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeBlocks",
+			Handler:       _Node_SubscribeBlocks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeTransactions",
+			Handler:       _Node_SubscribeTransactions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
+
+// Client API for Wallet service
+
+type WalletClient interface {
+	ListBalances(ctx context.Context, in *ListBalancesRequest, opts ...grpc.CallOption) (*BalanceList, error)
+	BuildTransaction(ctx context.Context, in *BuildTransactionRequest, opts ...grpc.CallOption) (*TransactionTemplate, error)
+	SignTransaction(ctx context.Context, in *SignTransactionRequest, opts ...grpc.CallOption) (*TransactionTemplate, error)
+}
+
+type walletClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewWalletClient(cc *grpc.ClientConn) WalletClient {
+	return &walletClient{cc}
+}
+
+func (c *walletClient) ListBalances(ctx context.Context, in *ListBalancesRequest, opts ...grpc.CallOption) (*BalanceList, error) {
+	out := new(BalanceList)
+	err := grpc.Invoke(ctx, "/apigrpc.Wallet/ListBalances", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) BuildTransaction(ctx context.Context, in *BuildTransactionRequest, opts ...grpc.CallOption) (*TransactionTemplate, error) {
+	out := new(TransactionTemplate)
+	err := grpc.Invoke(ctx, "/apigrpc.Wallet/BuildTransaction", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletClient) SignTransaction(ctx context.Context, in *SignTransactionRequest, opts ...grpc.CallOption) (*TransactionTemplate, error) {
+	out := new(TransactionTemplate)
+	err := grpc.Invoke(ctx, "/apigrpc.Wallet/SignTransaction", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Wallet service
+
+type WalletServer interface {
+	ListBalances(context.Context, *ListBalancesRequest) (*BalanceList, error)
+	BuildTransaction(context.Context, *BuildTransactionRequest) (*TransactionTemplate, error)
+	SignTransaction(context.Context, *SignTransactionRequest) (*TransactionTemplate, error)
+}
+
+func RegisterWalletServer(s *grpc.Server, srv WalletServer) {
+	s.RegisterService(&_Wallet_serviceDesc, srv)
+}
+
+func _Wallet_ListBalances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBalancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).ListBalances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apigrpc.Wallet/ListBalances",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).ListBalances(ctx, req.(*ListBalancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_BuildTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).BuildTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apigrpc.Wallet/BuildTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).BuildTransaction(ctx, req.(*BuildTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Wallet_SignTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServer).SignTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apigrpc.Wallet/SignTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServer).SignTransaction(ctx, req.(*SignTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Wallet_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "apigrpc.Wallet",
+	HandlerType: (*WalletServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListBalances",
+			Handler:    _Wallet_ListBalances_Handler,
+		},
+		{
+			MethodName: "BuildTransaction",
+			Handler:    _Wallet_BuildTransaction_Handler,
+		},
+		{
+			MethodName: "SignTransaction",
+			Handler:    _Wallet_SignTransaction_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "service.proto",
+}
+
+// Client API for Miner service
+
+type MinerClient interface {
+	SubscribeWork(ctx context.Context, in *SubscribeWorkRequest, opts ...grpc.CallOption) (Miner_SubscribeWorkClient, error)
+	SubmitSolution(ctx context.Context, in *Solution, opts ...grpc.CallOption) (*SubmitResult, error)
+}
+
+type minerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMinerClient(cc *grpc.ClientConn) MinerClient {
+	return &minerClient{cc}
+}
+
+func (c *minerClient) SubscribeWork(ctx context.Context, in *SubscribeWorkRequest, opts ...grpc.CallOption) (Miner_SubscribeWorkClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Miner_serviceDesc.Streams[0], c.cc, "/apigrpc.Miner/SubscribeWork", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &minerSubscribeWorkClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Miner_SubscribeWorkClient interface {
+	Recv() (*WorkUnit, error)
+	grpc.ClientStream
+}
+
+type minerSubscribeWorkClient struct {
+	grpc.ClientStream
+}
+
+func (x *minerSubscribeWorkClient) Recv() (*WorkUnit, error) {
+	m := new(WorkUnit)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *minerClient) SubmitSolution(ctx context.Context, in *Solution, opts ...grpc.CallOption) (*SubmitResult, error) {
+	out := new(SubmitResult)
+	err := grpc.Invoke(ctx, "/apigrpc.Miner/SubmitSolution", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Miner service
+
+type MinerServer interface {
+	SubscribeWork(*SubscribeWorkRequest, Miner_SubscribeWorkServer) error
+	SubmitSolution(context.Context, *Solution) (*SubmitResult, error)
+}
+
+func RegisterMinerServer(s *grpc.Server, srv MinerServer) {
+	s.RegisterService(&_Miner_serviceDesc, srv)
+}
+
+func _Miner_SubscribeWork_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeWorkRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MinerServer).SubscribeWork(m, &minerSubscribeWorkServer{stream})
+}
+
+type Miner_SubscribeWorkServer interface {
+	Send(*WorkUnit) error
+	grpc.ServerStream
+}
+
+type minerSubscribeWorkServer struct {
+	grpc.ServerStream
+}
+
+func (x *minerSubscribeWorkServer) Send(m *WorkUnit) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Miner_SubmitSolution_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Solution)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MinerServer).SubmitSolution(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apigrpc.Miner/SubmitSolution",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MinerServer).SubmitSolution(ctx, req.(*Solution))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Miner_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "apigrpc.Miner",
+	HandlerType: (*MinerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitSolution",
+			Handler:    _Miner_SubmitSolution_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeWork",
+			Handler:       _Miner_SubscribeWork_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
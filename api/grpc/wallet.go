@@ -0,0 +1,158 @@
+package apigrpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	context "golang.org/x/net/context"
+
+	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/crypto/sm2/chainkd"
+)
+
+// defaultTemplateTTL bounds a built template when the request leaves the
+// validity window at zero, matching the JSON build API default
+const defaultTemplateTTL = 5 * time.Minute
+
+// errNoWallet rejects wallet calls on a node running with the wallet disabled
+var errNoWallet = errors.New("wallet is disabled on this node")
+
+// ListBalances returns the confirmed wallet balances, optionally of one
+// account
+func (s *Server) ListBalances(ctx context.Context, req *ListBalancesRequest) (*BalanceList, error) {
+	if s.wallet == nil {
+		return nil, errNoWallet
+	}
+
+	balances, err := s.wallet.GetAccountBalances(req.AccountId, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &BalanceList{Balances: []*Balance{}}
+	for _, balance := range balances {
+		list.Balances = append(list.Balances, &Balance{
+			AccountId:    balance.AccountID,
+			AccountAlias: balance.Alias,
+			AssetId:      balance.AssetID,
+			AssetAlias:   balance.AssetAlias,
+			Amount:       balance.Amount,
+		})
+	}
+	return list, nil
+}
+
+// decodeAction turns one typed action message into a txbuilder action by way
+// of the JSON decoders the build API already uses
+func (s *Server) decodeAction(action *Action) (txbuilder.Action, error) {
+	fields := map[string]interface{}{
+		"type":   action.Type,
+		"amount": action.Amount,
+	}
+	if action.AccountId != "" {
+		fields["account_id"] = action.AccountId
+	}
+	if action.AssetId != "" {
+		fields["asset_id"] = action.AssetId
+	}
+	if action.Address != "" {
+		fields["address"] = action.Address
+	}
+	if len(action.ControlProgram) != 0 {
+		fields["control_program"] = hex.EncodeToString(action.ControlProgram)
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	switch action.Type {
+	case "spend_account":
+		return s.wallet.AccountMgr.DecodeSpendAction(data)
+	case "control_address":
+		return txbuilder.DecodeControlAddressAction(data)
+	case "control_program":
+		return txbuilder.DecodeControlProgramAction(data)
+	case "issue":
+		return s.wallet.AssetReg.DecodeIssueAction(data)
+	case "retire":
+		return txbuilder.DecodeRetireAction(data)
+	}
+	return nil, errors.New("unknown action type " + action.Type)
+}
+
+// buildTemplateMsg flattens a transaction template into its wire message,
+// the canonical JSON keeps the signing instructions round-trippable
+func buildTemplateMsg(tpl *txbuilder.Template) (*TransactionTemplate, error) {
+	templateJSON, err := json.Marshal(tpl)
+	if err != nil {
+		return nil, err
+	}
+
+	rawTx, err := tpl.Transaction.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TransactionTemplate{
+		TemplateJson:   string(templateJSON),
+		RawTransaction: string(rawTx),
+		SignComplete:   txbuilder.SignProgress(tpl),
+	}, nil
+}
+
+// BuildTransaction assembles an unsigned transaction template from typed
+// actions
+func (s *Server) BuildTransaction(ctx context.Context, req *BuildTransactionRequest) (*TransactionTemplate, error) {
+	if s.wallet == nil {
+		return nil, errNoWallet
+	}
+
+	actions := []txbuilder.Action{}
+	for _, rawAction := range req.Actions {
+		action, err := s.decodeAction(rawAction)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	ttl := defaultTemplateTTL
+	if req.TtlSeconds != 0 {
+		ttl = time.Duration(req.TtlSeconds) * time.Second
+	}
+
+	tpl, err := txbuilder.Build(ctx, nil, actions, time.Now().Add(ttl), 0)
+	if err != nil {
+		return nil, err
+	}
+	return buildTemplateMsg(tpl)
+}
+
+// SignTransaction runs one signing pass over a template with the wallet keys
+func (s *Server) SignTransaction(ctx context.Context, req *SignTransactionRequest) (*TransactionTemplate, error) {
+	if s.wallet == nil {
+		return nil, errNoWallet
+	}
+
+	tpl := &txbuilder.Template{}
+	if err := json.Unmarshal([]byte(req.TemplateJson), tpl); err != nil {
+		return nil, err
+	}
+
+	if err := s.wallet.AccountMgr.EnforceSpendPolicy(tpl.Transaction); err != nil {
+		return nil, err
+	}
+
+	if err := txbuilder.Sign(ctx, tpl, req.Password, s.pseudohsmSignTemplate); err != nil {
+		return nil, err
+	}
+	return buildTemplateMsg(tpl)
+}
+
+func (s *Server) pseudohsmSignTemplate(ctx context.Context, xpub chainkd.XPub, path [][]byte, data [32]byte, password string) ([]byte, error) {
+	return s.wallet.Hsm.XSign(xpub, path, data[:], password)
+}
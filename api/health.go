@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	cfg "github.com/bytom-gm/config"
+)
+
+// probeCheck is the result of one liveness or readiness check
+type probeCheck struct {
+	Name string `json:"name"`
+	OK   bool   `json:"ok"`
+	Info string `json:"info,omitempty"`
+}
+
+// probeResult is the body of a health or readiness probe response
+type probeResult struct {
+	Status string       `json:"status"`
+	Checks []probeCheck `json:"checks"`
+}
+
+// writeProbeResult answers 200 when every check passed and 503 otherwise,
+// with the per-check details in the body
+func writeProbeResult(rw http.ResponseWriter, checks []probeCheck) {
+	result := probeResult{Status: "ok", Checks: checks}
+	status := http.StatusOK
+	for _, check := range checks {
+		if !check.OK {
+			result.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(result)
+}
+
+// healthThresholds falls back to the defaults for API values built without a
+// config, as some tests do
+func (a *API) healthThresholds() *cfg.HealthConfig {
+	if a.healthConfig != nil {
+		return a.healthConfig
+	}
+	return cfg.DefaultHealthConfig()
+}
+
+// healthzHandler is the liveness probe: the process serves requests and its
+// storage answers
+func (a *API) healthzHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		checks := []probeCheck{{
+			Name: "chain",
+			OK:   a.chain.BestBlockHash() != nil,
+			Info: fmt.Sprintf("height %d", a.chain.BestBlockHeight()),
+		}}
+
+		if a.wallet != nil {
+			checks = append(checks, probeCheck{
+				Name: "wallet_db",
+				OK:   a.wallet.DB.Stats() != nil,
+			})
+		}
+		writeProbeResult(rw, checks)
+	})
+}
+
+// syncReady reports whether chainHeight trails bestKnown by no more than
+// maxLag blocks
+func syncReady(chainHeight, bestKnown, maxLag uint64) bool {
+	return chainHeight+maxLag >= bestKnown
+}
+
+// bestPeerHeight is the highest block height the connected peers claim, zero
+// without peers
+func (a *API) bestPeerHeight() uint64 {
+	if a.sync == nil {
+		return 0
+	}
+
+	best := uint64(0)
+	for _, peerInfo := range a.sync.GetPeerInfos() {
+		if peerInfo.Height > best {
+			best = peerInfo.Height
+		}
+	}
+	return best
+}
+
+// readyzHandler is the readiness probe: the chain is within the configured
+// lag of the best peer and the wallet index has caught up
+func (a *API) readyzHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		thresholds := a.healthThresholds()
+		chainHeight := a.chain.BestBlockHeight()
+
+		checks := []probeCheck{}
+		if a.sync != nil {
+			bestKnown := a.bestPeerHeight()
+			checks = append(checks, probeCheck{
+				Name: "synced",
+				OK:   a.sync.IsCaughtUp() && syncReady(chainHeight, bestKnown, thresholds.MaxBlockLag),
+				Info: fmt.Sprintf("height %d, best peer %d", chainHeight, bestKnown),
+			})
+		}
+
+		if a.wallet != nil {
+			walletHeight := a.wallet.GetWalletStatusInfo().BestHeight
+			checks = append(checks, probeCheck{
+				Name: "wallet_indexed",
+				OK:   syncReady(walletHeight, chainHeight, thresholds.MaxWalletLag),
+				Info: fmt.Sprintf("wallet %d, chain %d", walletHeight, chainHeight),
+			})
+		}
+		writeProbeResult(rw, checks)
+	})
+}
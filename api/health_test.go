@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSyncReady(t *testing.T) {
+	cases := []struct {
+		chainHeight uint64
+		bestKnown   uint64
+		maxLag      uint64
+		want        bool
+	}{
+		{chainHeight: 100, bestKnown: 100, maxLag: 0, want: true},
+		{chainHeight: 100, bestKnown: 103, maxLag: 3, want: true},
+		{chainHeight: 100, bestKnown: 104, maxLag: 3, want: false},
+		{chainHeight: 100, bestKnown: 0, maxLag: 0, want: true},
+		{chainHeight: 0, bestKnown: 1, maxLag: 0, want: false},
+	}
+
+	for _, c := range cases {
+		if got := syncReady(c.chainHeight, c.bestKnown, c.maxLag); got != c.want {
+			t.Errorf("syncReady(%d, %d, %d) = %v want %v", c.chainHeight, c.bestKnown, c.maxLag, got, c.want)
+		}
+	}
+}
+
+func TestWriteProbeResult(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeProbeResult(w, []probeCheck{{Name: "a", OK: true}, {Name: "b", OK: true}})
+	if w.Code != 200 {
+		t.Errorf("all checks passing: status = %d want 200", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	writeProbeResult(w, []probeCheck{{Name: "a", OK: true}, {Name: "b", OK: false}})
+	if w.Code != 503 {
+		t.Errorf("failing check: status = %d want 503", w.Code)
+	}
+}
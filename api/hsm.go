@@ -2,13 +2,60 @@ package api
 
 import (
 	"context"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/bytom-gm/blockchain/txbuilder"
+	cfg "github.com/bytom-gm/config"
 	"github.com/bytom-gm/crypto/sm2/chainkd"
 )
 
+// defaultAutoLockTimeout bounds unlock-wallet when neither the request nor
+// the config gives a timeout
+const defaultAutoLockTimeout = 5 * time.Minute
+
+// walletAutoLockTimeout reads the configured unlock-wallet lifetime, zero
+// when no config is present
+func walletAutoLockTimeout(config *cfg.WalletConfig) time.Duration {
+	if config == nil {
+		return 0
+	}
+	return time.Duration(config.AutoLockSeconds) * time.Second
+}
+
+// POST /unlock-wallet
+func (a *API) unlockWallet(ctx context.Context, ins struct {
+	XPub           chainkd.XPub `json:"xpub"`
+	Password       string       `json:"password"`
+	TimeoutSeconds uint64       `json:"timeout_seconds"`
+}) Response {
+	timeout := a.autoLockTimeout
+	if timeout == 0 {
+		timeout = defaultAutoLockTimeout
+	}
+	if ins.TimeoutSeconds > 0 {
+		timeout = time.Duration(ins.TimeoutSeconds) * time.Second
+	}
+
+	if err := a.wallet.Hsm.Unlock(ins.XPub, ins.Password, timeout); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(map[string]interface{}{"unlocked_until": time.Now().Add(timeout).Unix()})
+}
+
+// POST /lock-wallet
+func (a *API) lockWallet(ctx context.Context, ins struct {
+	XPub *chainkd.XPub `json:"xpub"`
+}) Response {
+	if ins.XPub != nil {
+		a.wallet.Hsm.Lock(*ins.XPub)
+	} else {
+		a.wallet.Hsm.LockAll()
+	}
+	return NewSuccessResponse(true)
+}
+
 type createKeyResp struct {
 	Alias    string       `json:"alias"`
 	XPub     chainkd.XPub `json:"xpub"`
@@ -62,6 +109,10 @@ func (a *API) signTemplate(ctx context.Context, x struct {
 	Password string             `json:"password"`
 	Txs      txbuilder.Template `json:"transaction"`
 }) Response {
+	if err := a.wallet.AccountMgr.EnforceSpendPolicy(x.Txs.Transaction); err != nil {
+		return NewErrorResponse(err)
+	}
+
 	if err := txbuilder.Sign(ctx, &x.Txs, x.Password, a.pseudohsmSignTemplate); err != nil {
 		log.WithField("build err", err).Error("fail on sign transaction.")
 		return NewErrorResponse(err)
@@ -81,6 +132,10 @@ func (a *API) signTemplates(ctx context.Context, x struct {
 }) Response {
 	signComplete := true
 	for _, tx := range x.Txs {
+		if err := a.wallet.AccountMgr.EnforceSpendPolicy(tx.Transaction); err != nil {
+			return NewErrorResponse(err)
+		}
+
 		if err := txbuilder.Sign(ctx, tx, x.Password, a.pseudohsmSignTemplate); err != nil {
 			log.WithField("build err", err).Error("fail on sign transaction.")
 			return NewErrorResponse(err)
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bytom-gm/protocol"
+)
+
+// feeRateBuckets are the fee histogram bucket upper bounds, in neu per byte,
+// everything above the last bound lands in the open-ended bucket
+var feeRateBuckets = []uint64{1, 5, 10, 50, 100, 500, 1000}
+
+type feeHistogramEntry struct {
+	// MaxFeeRate is the bucket upper bound in neu per byte, zero marks the
+	// open-ended last bucket
+	MaxFeeRate uint64 `json:"max_fee_rate"`
+	Count      int    `json:"count"`
+	Fees       uint64 `json:"fees"`
+}
+
+type mempoolInfoResp struct {
+	Count        int                 `json:"count"`
+	TotalBytes   uint64              `json:"total_bytes"`
+	TotalFees    uint64              `json:"total_fees"`
+	FeeHistogram []feeHistogramEntry `json:"fee_histogram"`
+}
+
+// txFeeRate is the fee of one pending transaction per serialized byte
+func txFeeRate(txD *protocol.TxDesc) uint64 {
+	if txD.Tx.SerializedSize == 0 {
+		return 0
+	}
+	return txD.Fee / txD.Tx.SerializedSize
+}
+
+// POST /get-mempool-info
+func (a *API) getMempoolInfo(ctx context.Context) Response {
+	txDescs := a.chain.GetTxPool().GetTransactions()
+
+	histogram := make([]feeHistogramEntry, len(feeRateBuckets)+1)
+	for i, bound := range feeRateBuckets {
+		histogram[i].MaxFeeRate = bound
+	}
+
+	resp := &mempoolInfoResp{Count: len(txDescs), FeeHistogram: histogram}
+	for _, txD := range txDescs {
+		resp.TotalBytes += txD.Tx.SerializedSize
+		resp.TotalFees += txD.Fee
+
+		feeRate := txFeeRate(txD)
+		idx := sort.Search(len(feeRateBuckets), func(i int) bool { return feeRate <= feeRateBuckets[i] })
+		histogram[idx].Count++
+		histogram[idx].Fees += txD.Fee
+	}
+	return NewSuccessResponse(resp)
+}
+
+type mempoolTxResp struct {
+	TxID       string `json:"tx_id"`
+	Size       uint64 `json:"size"`
+	Fee        uint64 `json:"fee"`
+	FeeRate    uint64 `json:"fee_rate"`
+	Added      int64  `json:"added_timestamp"`
+	StatusFail bool   `json:"status_fail"`
+	Local      bool   `json:"local"`
+}
+
+// mempoolTxMatchesAsset reports whether any input or output of the pending
+// transaction moves the given asset
+func mempoolTxMatchesAsset(txD *protocol.TxDesc, assetID string) bool {
+	for _, input := range txD.Tx.Inputs {
+		inputAssetID := input.AssetID()
+		if inputAssetID.String() == assetID {
+			return true
+		}
+	}
+	for _, output := range txD.Tx.Outputs {
+		if output.AssetId.String() == assetID {
+			return true
+		}
+	}
+	return false
+}
+
+// mempoolTxMatchesAddress reports whether any input or output program of the
+// pending transaction decodes to the given address
+func mempoolTxMatchesAddress(txD *protocol.TxDesc, address string) bool {
+	for _, input := range txD.Tx.Inputs {
+		if addressFromControlProgram(input.ControlProgram()) == address {
+			return true
+		}
+	}
+	for _, output := range txD.Tx.Outputs {
+		if addressFromControlProgram(output.ControlProgram) == address {
+			return true
+		}
+	}
+	return false
+}
+
+// POST /list-mempool-transactions
+func (a *API) listMempoolTxs(ctx context.Context, filter struct {
+	AssetID    string `json:"asset_id"`
+	Address    string `json:"address"`
+	MinFeeRate uint64 `json:"min_fee_rate"`
+	MaxFeeRate uint64 `json:"max_fee_rate"`
+	From       uint   `json:"from"`
+	Count      uint   `json:"count"`
+}) Response {
+	txDescs := a.chain.GetTxPool().GetTransactions()
+	sort.Slice(txDescs, func(i, j int) bool { return txDescs[i].Added.Before(txDescs[j].Added) })
+
+	txs := []*mempoolTxResp{}
+	for _, txD := range txDescs {
+		feeRate := txFeeRate(txD)
+		if feeRate < filter.MinFeeRate {
+			continue
+		}
+		if filter.MaxFeeRate != 0 && feeRate > filter.MaxFeeRate {
+			continue
+		}
+		if filter.AssetID != "" && !mempoolTxMatchesAsset(txD, filter.AssetID) {
+			continue
+		}
+		if filter.Address != "" && !mempoolTxMatchesAddress(txD, filter.Address) {
+			continue
+		}
+
+		txs = append(txs, &mempoolTxResp{
+			TxID:       txD.Tx.ID.String(),
+			Size:       txD.Tx.SerializedSize,
+			Fee:        txD.Fee,
+			FeeRate:    feeRate,
+			Added:      txD.Added.Unix(),
+			StatusFail: txD.StatusFail,
+			Local:      txD.Local,
+		})
+	}
+
+	start, end := getPageRange(len(txs), filter.From, filter.Count)
+	return NewSuccessResponse(txs[start:end])
+}
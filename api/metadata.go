@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+)
+
+// POST /set-transaction-metadata
+func (a *API) setTxMetadata(ctx context.Context, ins struct {
+	TxID   string            `json:"tx_id"`
+	Labels map[string]string `json:"labels"`
+}) Response {
+	metadata, err := a.wallet.SetTxMetadata(ins.TxID, ins.Labels)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(metadata)
+}
+
+// POST /get-transaction-metadata
+func (a *API) getTxMetadata(ctx context.Context, ins struct {
+	TxID string `json:"tx_id"`
+}) Response {
+	metadata, err := a.wallet.GetTxMetadata(ins.TxID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(metadata)
+}
+
+// POST /delete-transaction-metadata
+func (a *API) deleteTxMetadata(ctx context.Context, ins struct {
+	TxID string `json:"tx_id"`
+}) Response {
+	if err := a.wallet.DeleteTxMetadata(ins.TxID); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
+
+// POST /list-transaction-metadata
+func (a *API) listTxMetadata(ctx context.Context, ins struct {
+	LabelKey   string `json:"label_key"`
+	LabelValue string `json:"label_value"`
+}) Response {
+	metadatas, err := a.wallet.ListTxMetadata(ins.LabelKey, ins.LabelValue)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(metadatas)
+}
@@ -3,9 +3,12 @@ package api
 import (
 	"context"
 	"strconv"
+	"time"
 
 	chainjson "github.com/bytom-gm/encoding/json"
 	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/mining"
+	"github.com/bytom-gm/mining/stratum"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
 )
@@ -180,11 +183,119 @@ func (a *API) GetWorkJSON() (*GetWorkJSONResp, error) {
 	}, nil
 }
 
+// getMiningPolicy returns the active transaction selection policy
+func (a *API) getMiningPolicy() Response {
+	policy := mining.GetSelectionPolicy()
+	return NewSuccessResponse(&policy)
+}
+
+// setMiningPolicy replaces the transaction selection policy at runtime, it
+// applies from the next template build
+func (a *API) setMiningPolicy(ctx context.Context, ins mining.SelectionPolicy) Response {
+	mining.SetSelectionPolicy(ins)
+	return a.getMiningPolicy()
+}
+
+// CoinbaseMessage is req and resp struct for the coinbase message APIs
+type CoinbaseMessage struct {
+	Message        chainjson.HexBytes `json:"message"`
+	ExtranonceSize uint64             `json:"extranonce_size"`
+}
+
+// getCoinbaseMessage returns the operator coinbase message and the reserved
+// extranonce size
+func (a *API) getCoinbaseMessage() Response {
+	message, extranonceSize := mining.GetCoinbaseMessage()
+	return NewSuccessResponse(&CoinbaseMessage{
+		Message:        message,
+		ExtranonceSize: extranonceSize,
+	})
+}
+
+// setCoinbaseMessage replaces the operator coinbase message and the reserved
+// extranonce space at runtime, it applies from the next template build. Unlike
+// set-coinbase-arbitrary this works without a wallet, the message is appended
+// after the account arbitrary data
+func (a *API) setCoinbaseMessage(ctx context.Context, ins CoinbaseMessage) Response {
+	if err := mining.SetCoinbaseMessage(ins.Message, ins.ExtranonceSize); err != nil {
+		return NewErrorResponse(err)
+	}
+	return a.getCoinbaseMessage()
+}
+
+// defaultWorkPollTimeout bounds how long a get-work-longpoll request hangs
+// before the unchanged template is returned anyway
+const defaultWorkPollTimeout = 90 * time.Second
+
+// getWorkLongPoll hangs until the block template differs from the one the
+// miner reports to be working on, so miners stop mining stale templates
+// between timer polls. A request with empty fields returns immediately
+func (a *API) getWorkLongPoll(ctx context.Context, ins struct {
+	PreviousBlockHash      *bc.Hash `json:"previous_block_hash"`
+	TransactionsMerkleRoot *bc.Hash `json:"transactions_merkle_root"`
+}) Response {
+	for {
+		// grab the waiter before inspecting the template so a regeneration
+		// in between doesn't get lost
+		waiter := a.miningPool.WorkWaiter()
+		work, err := a.GetWorkJSON()
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+
+		if workChanged(work.BlockHeader, ins.PreviousBlockHash, ins.TransactionsMerkleRoot) {
+			return NewSuccessResponse(work)
+		}
+
+		select {
+		case <-waiter:
+		case <-time.After(defaultWorkPollTimeout):
+			return NewSuccessResponse(work)
+		case <-ctx.Done():
+			return NewErrorResponse(ctx.Err())
+		}
+	}
+}
+
+// workChanged reports whether the template differs from the miner's view
+func workChanged(bh *BlockHeaderJSON, prevHash, merkleRoot *bc.Hash) bool {
+	if prevHash == nil {
+		return true
+	}
+	if *prevHash != bh.PreviousBlockHash {
+		return true
+	}
+	return merkleRoot != nil && *merkleRoot != bh.BlockCommitment.TransactionsMerkleRoot
+}
+
 // SubmitWork tries to submit work to the chain
 func (a *API) SubmitWork(bh *types.BlockHeader) error {
 	return a.miningPool.SubmitWork(bh)
 }
 
+// StratumStatusResp is resp struct for get-stratum-status API
+type StratumStatusResp struct {
+	Enabled bool                  `json:"enabled"`
+	Workers []stratum.WorkerStats `json:"workers"`
+}
+
+// getStratumStatus returns the per-worker share statistics of the stratum server
+func (a *API) getStratumStatus() Response {
+	resp := &StratumStatusResp{Workers: []stratum.WorkerStats{}}
+	if a.stratumServer != nil {
+		resp.Enabled = true
+		resp.Workers = a.stratumServer.WorkerStats()
+	}
+	return NewSuccessResponse(resp)
+}
+
+// getMiningStatus reports the built-in miner hashrate, its accepted and stale
+// block counters and the age of the template being solved
+func (a *API) getMiningStatus() Response {
+	status := a.cpuMiner.Status()
+	return NewSuccessResponse(&status)
+}
+
 func (a *API) setMining(in struct {
 	IsMining bool `json:"is_mining"`
 }) Response {
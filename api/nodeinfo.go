@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net"
+	"time"
 
 	"github.com/bytom-gm/errors"
 	"github.com/bytom-gm/netsync"
@@ -93,6 +94,11 @@ func (a *API) getNetInfo() Response {
 	return NewSuccessResponse(a.GetNodeInfo())
 }
 
+// getSyncStatus return the sync progress, the block rates and the ETA
+func (a *API) getSyncStatus() Response {
+	return NewSuccessResponse(a.sync.SyncStatus())
+}
+
 // isMining return is in mining or not
 func (a *API) isMining() Response {
 	IsMining := map[string]bool{"is_mining": a.IsMining()}
@@ -104,9 +110,89 @@ func (a *API) IsMining() bool {
 	return a.cpuMiner.IsMining()
 }
 
+// PeerStats is the sync level info of a peer together with its connection
+// level detail
+type PeerStats struct {
+	*netsync.PeerInfo
+	Outbound    bool   `json:"outbound"`
+	Version     string `json:"version"`
+	Moniker     string `json:"moniker"`
+	Whitelisted bool   `json:"whitelisted"`
+	LatencyMS   int64  `json:"latency_ms"`
+	ClockSkewS  int64  `json:"clock_skew_seconds"`
+}
+
 // return the peers of current node
 func (a *API) listPeers() Response {
-	return NewSuccessResponse(a.sync.GetPeerInfos())
+	sw := a.sync.Switch()
+	peerStats := []*PeerStats{}
+	for _, peerInfo := range a.sync.GetPeerInfos() {
+		stats := &PeerStats{PeerInfo: peerInfo}
+		if peer := sw.Peers().Get(peerInfo.ID); peer != nil {
+			stats.Outbound = peer.IsOutbound()
+			stats.Version = peer.NodeInfo.Version
+			stats.Moniker = peer.NodeInfo.Moniker
+			stats.Whitelisted = sw.IsWhitelisted(peer.RemoteAddrHost())
+			stats.LatencyMS = peer.Latency().Milliseconds()
+			if skew, ok := peer.ClockSkew(); ok {
+				stats.ClockSkewS = int64(skew.Seconds())
+			}
+		}
+		peerStats = append(peerStats, stats)
+	}
+	return NewSuccessResponse(peerStats)
+}
+
+// BanPeerReq is used to handle banPeer req
+type BanPeerReq struct {
+	Address string `json:"address"`
+	Seconds uint64 `json:"seconds"`
+	Reason  string `json:"reason"`
+}
+
+// ban an IP or subnet for the given duration and drop the matching
+// connections, the default duration is used when seconds is zero
+func (a *API) banPeer(ins BanPeerReq) Response {
+	record, err := a.sync.Switch().BanPeer(ins.Address, time.Duration(ins.Seconds)*time.Second, ins.Reason)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(record)
+}
+
+// lift the ban of an IP or subnet
+func (a *API) unbanPeer(ins struct {
+	Address string `json:"address"`
+}) Response {
+	return NewSuccessResponse(map[string]bool{"unbanned": a.sync.Switch().UnbanPeer(ins.Address)})
+}
+
+// return the active ban records
+func (a *API) listBannedPeers() Response {
+	return NewSuccessResponse(a.sync.Switch().BannedPeers())
+}
+
+// exempt an IP or subnet from bans
+func (a *API) whitelistPeer(ins struct {
+	Address string `json:"address"`
+}) Response {
+	cidr, err := a.sync.Switch().AddWhitelist(ins.Address)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(map[string]string{"cidr": cidr})
+}
+
+// drop an IP or subnet from the whitelist
+func (a *API) unwhitelistPeer(ins struct {
+	Address string `json:"address"`
+}) Response {
+	return NewSuccessResponse(map[string]bool{"removed": a.sync.Switch().RemoveWhitelist(ins.Address)})
+}
+
+// return the subnets exempt from bans
+func (a *API) listWhitelist() Response {
+	return NewSuccessResponse(a.sync.Switch().Whitelist())
 }
 
 // disconnect peer
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/accesstoken"
+)
+
+func TestRequiredPermission(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/get-block", accesstoken.PermissionReadOnly},
+		{"/list-balances", accesstoken.PermissionReadOnly},
+		{"/net-info", accesstoken.PermissionReadOnly},
+		{"/batch", accesstoken.PermissionReadOnly},
+		{"/get-work", accesstoken.PermissionMining},
+		{"/submit-work", accesstoken.PermissionMining},
+		{"/set-mining", accesstoken.PermissionMining},
+		{"/build-transaction", accesstoken.PermissionWallet},
+		{"/sign-transaction", accesstoken.PermissionWallet},
+		{"/submit-transaction", accesstoken.PermissionWallet},
+		{"/create-account", accesstoken.PermissionWallet},
+		{"/create-access-token", accesstoken.PermissionAdmin},
+		{"/ban-peer", accesstoken.PermissionAdmin},
+		{"/rollback-chain", accesstoken.PermissionAdmin},
+	}
+
+	for _, c := range cases {
+		if got := requiredPermission(c.path); got != c.want {
+			t.Errorf("requiredPermission(%s) = %s want %s", c.path, got, c.want)
+		}
+	}
+}
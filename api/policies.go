@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+
+	"github.com/bytom-gm/account"
+)
+
+// POST /set-spend-policy
+func (a *API) setSpendPolicy(ctx context.Context, ins struct {
+	AccountID        string   `json:"account_id"`
+	AccountAlias     string   `json:"account_alias"`
+	MaxTxAmount      uint64   `json:"max_tx_amount"`
+	MaxDayAmount     uint64   `json:"max_day_amount"`
+	AddressWhitelist []string `json:"address_whitelist"`
+}) Response {
+	accountID := ins.AccountID
+	if ins.AccountAlias != "" {
+		acc, err := a.wallet.AccountMgr.FindByAlias(ins.AccountAlias)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+		accountID = acc.ID
+	}
+
+	policy := &account.SpendPolicy{
+		AccountID:        accountID,
+		MaxTxAmount:      ins.MaxTxAmount,
+		MaxDayAmount:     ins.MaxDayAmount,
+		AddressWhitelist: ins.AddressWhitelist,
+	}
+	if err := a.wallet.AccountMgr.SetSpendPolicy(policy); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(policy)
+}
+
+// POST /get-spend-policy
+func (a *API) getSpendPolicy(ctx context.Context, ins struct {
+	AccountID string `json:"account_id"`
+}) Response {
+	policy, err := a.wallet.AccountMgr.GetSpendPolicy(ins.AccountID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(policy)
+}
+
+// POST /delete-spend-policy
+func (a *API) deleteSpendPolicy(ctx context.Context, ins struct {
+	AccountID string `json:"account_id"`
+}) Response {
+	a.wallet.AccountMgr.DeleteSpendPolicy(ins.AccountID)
+	return NewSuccessResponse(nil)
+}
+
+// POST /list-spend-policies
+func (a *API) listSpendPolicies(ctx context.Context) Response {
+	policies, err := a.wallet.AccountMgr.ListSpendPolicies()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(policies)
+}
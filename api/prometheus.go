@@ -0,0 +1,180 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bytom-gm/metrics"
+)
+
+// apiLatencyBounds are the histogram buckets of the request timings, in
+// seconds
+var apiLatencyBounds = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+var (
+	apiLatencyMu  sync.Mutex
+	apiLatencyMap = map[string]*metrics.PromHistogram{}
+)
+
+// observeAPILatency records one request timing for the metrics endpoint,
+// keyed on the (already validated) request path
+func observeAPILatency(path string, since time.Time) {
+	apiLatencyMu.Lock()
+	histogram, ok := apiLatencyMap[path]
+	if !ok {
+		histogram = metrics.NewPromHistogram(apiLatencyBounds...)
+		apiLatencyMap[path] = histogram
+	}
+	apiLatencyMu.Unlock()
+
+	histogram.Observe(time.Since(since).Seconds())
+}
+
+// writeMetricHeader emits the HELP and TYPE lines of one metric
+func writeMetricHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// writeGauge emits a single unlabeled gauge sample with its header
+func writeGauge(w io.Writer, name, help string, value float64) {
+	writeMetricHeader(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s %v\n", name, value)
+}
+
+// writeHistogram emits one histogram in the cumulative bucket form, labels
+// may be empty
+func writeHistogram(w io.Writer, name, labels string, histogram *metrics.PromHistogram) {
+	bounds, cumulative, sum, count := histogram.Snapshot()
+	for i, bound := range bounds {
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%v\"} %d\n", name, labels, bound, cumulative[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, cumulative[len(cumulative)-1])
+	fmt.Fprintf(w, "%s_sum{%s} %v\n", name, trimLabelComma(labels), sum)
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, trimLabelComma(labels), count)
+}
+
+func trimLabelComma(labels string) string {
+	if len(labels) > 0 && labels[len(labels)-1] == ',' {
+		return labels[:len(labels)-1]
+	}
+	return labels
+}
+
+// prometheusHandler renders the node state in the Prometheus text format
+func (a *API) prometheusHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		writeGauge(rw, "bytom_chain_height", "Best main chain block height.", float64(a.chain.BestBlockHeight()))
+
+		a.writeMempoolMetrics(rw)
+		a.writeNetworkMetrics(rw)
+		a.writeWalletMetrics(rw)
+
+		writeMetricHeader(rw, "bytom_block_process_seconds", "Block validation and insertion timings.", "histogram")
+		writeHistogram(rw, "bytom_block_process_seconds", "", a.chain.BlockProcessTimes())
+
+		writeAPILatencyMetrics(rw)
+	})
+}
+
+func (a *API) writeMempoolMetrics(w io.Writer) {
+	txDescs := a.chain.GetTxPool().GetTransactions()
+	totalFees := uint64(0)
+	totalBytes := uint64(0)
+	for _, txD := range txDescs {
+		totalFees += txD.Fee
+		totalBytes += txD.Tx.SerializedSize
+	}
+
+	writeGauge(w, "bytom_mempool_transactions", "Transactions waiting in the mempool.", float64(len(txDescs)))
+	writeGauge(w, "bytom_mempool_bytes", "Summed serialized size of the mempool transactions.", float64(totalBytes))
+	writeGauge(w, "bytom_mempool_fees", "Summed fees of the mempool transactions.", float64(totalFees))
+}
+
+func (a *API) writeNetworkMetrics(w io.Writer) {
+	if a.sync == nil {
+		return
+	}
+
+	bytesSent := uint64(0)
+	bytesRecv := uint64(0)
+	peerInfos := a.sync.GetPeerInfos()
+	for _, peerInfo := range peerInfos {
+		for _, size := range peerInfo.BytesSent {
+			bytesSent += size
+		}
+		for _, size := range peerInfo.BytesRecv {
+			bytesRecv += size
+		}
+	}
+
+	writeGauge(w, "bytom_peers", "Connected peers.", float64(len(peerInfos)))
+	writeMetricHeader(w, "bytom_peer_bytes_sent_total", "Bytes sent to the connected peers.", "counter")
+	fmt.Fprintf(w, "bytom_peer_bytes_sent_total %d\n", bytesSent)
+	writeMetricHeader(w, "bytom_peer_bytes_received_total", "Bytes received from the connected peers.", "counter")
+	fmt.Fprintf(w, "bytom_peer_bytes_received_total %d\n", bytesRecv)
+
+	syncing := float64(0)
+	if !a.sync.IsCaughtUp() {
+		syncing = 1
+	}
+	writeGauge(w, "bytom_syncing", "Whether the node considers itself behind the network.", syncing)
+}
+
+func (a *API) writeWalletMetrics(w io.Writer) {
+	if a.wallet == nil {
+		return
+	}
+
+	walletHeight := a.wallet.GetWalletStatusInfo().BestHeight
+	chainHeight := a.chain.BestBlockHeight()
+	lag := float64(0)
+	if chainHeight > walletHeight {
+		lag = float64(chainHeight - walletHeight)
+	}
+	writeGauge(w, "bytom_wallet_height", "Best block height indexed by the wallet.", float64(walletHeight))
+	writeGauge(w, "bytom_wallet_index_lag", "Blocks the wallet index trails the chain by.", lag)
+
+	writeLevelDBMetrics(w, a.wallet.DB.Stats())
+}
+
+// writeLevelDBMetrics exports the numeric entries of the leveldb stats map
+func writeLevelDBMetrics(w io.Writer, stats map[string]string) {
+	keys := []string{}
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	writeMetricHeader(w, "bytom_leveldb_stat", "Numeric database backend statistics, keyed by stat name.", "gauge")
+	for _, key := range keys {
+		value, err := strconv.ParseFloat(stats[key], 64)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(w, "bytom_leveldb_stat{name=%q} %v\n", key, value)
+	}
+}
+
+func writeAPILatencyMetrics(w io.Writer) {
+	apiLatencyMu.Lock()
+	paths := []string{}
+	histograms := map[string]*metrics.PromHistogram{}
+	for path, histogram := range apiLatencyMap {
+		paths = append(paths, path)
+		histograms[path] = histogram
+	}
+	apiLatencyMu.Unlock()
+	sort.Strings(paths)
+
+	writeMetricHeader(w, "bytom_api_request_duration_seconds", "API request timings, keyed by path.", "histogram")
+	for _, path := range paths {
+		writeHistogram(w, "bytom_api_request_duration_seconds", fmt.Sprintf("path=%q,", path), histograms[path])
+	}
+}
@@ -3,11 +3,13 @@ package api
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/asset"
 	"github.com/bytom-gm/blockchain/query"
 	"github.com/bytom-gm/blockchain/signers"
 	"github.com/bytom-gm/blockchain/txbuilder"
@@ -17,6 +19,8 @@ import (
 	"github.com/bytom-gm/errors"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/vm/vmutil"
+	"github.com/bytom-gm/wallet"
 )
 
 // POST /list-accounts
@@ -60,6 +64,101 @@ func (a *API) getAsset(ctx context.Context, filter struct {
 	return NewSuccessResponse(asset)
 }
 
+// AssetDefinitionResp bundle the anchored asset definition with its
+// verification result
+type AssetDefinitionResp struct {
+	*asset.DefinitionAnchor
+	Definition map[string]interface{} `json:"definition"`
+	Verified   bool                   `json:"verified"`
+}
+
+// POST /get-asset-definition
+func (a *API) getAssetDefinition(ctx context.Context, filter struct {
+	ID string `json:"id"`
+}) Response {
+	assetID := &bc.AssetID{}
+	if err := assetID.UnmarshalText([]byte(filter.ID)); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	anchor, err := a.wallet.AssetReg.GetDefinitionAnchor(assetID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	definitionMap := map[string]interface{}{}
+	json.Unmarshal(anchor.RawDefinition, &definitionMap)
+	return NewSuccessResponse(&AssetDefinitionResp{
+		DefinitionAnchor: anchor,
+		Definition:       definitionMap,
+		Verified:         anchor.Verify(),
+	})
+}
+
+// AssetSupplyResp report the declared cap and observed issuance of an asset
+type AssetSupplyResp struct {
+	AssetID   string `json:"asset_id"`
+	MaxSupply uint64 `json:"max_supply"`
+	Issued    uint64 `json:"issued"`
+	Remaining uint64 `json:"remaining"`
+}
+
+// POST /get-asset-supply
+func (a *API) getAssetSupply(ctx context.Context, filter struct {
+	ID string `json:"id"`
+}) Response {
+	ass, err := a.wallet.AssetReg.GetAsset(filter.ID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	assetID := &bc.AssetID{}
+	if err := assetID.UnmarshalText([]byte(filter.ID)); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	resp := &AssetSupplyResp{
+		AssetID:   filter.ID,
+		MaxSupply: ass.MaxSupply,
+		Issued:    a.wallet.AssetReg.IssuedAmount(assetID),
+	}
+	if resp.MaxSupply > resp.Issued {
+		resp.Remaining = resp.MaxSupply - resp.Issued
+	}
+	return NewSuccessResponse(resp)
+}
+
+// AssetCirculationResp report the observed issuance, retirement and the net
+// circulating amount of an asset
+type AssetCirculationResp struct {
+	AssetID     string `json:"asset_id"`
+	Issued      uint64 `json:"issued"`
+	Retired     uint64 `json:"retired"`
+	Circulating uint64 `json:"circulating"`
+}
+
+// POST /get-asset-circulation
+// the counters are maintained by the wallet supply index on block
+// attach/detach, the circulating amount is issued minus retired
+func (a *API) getAssetCirculation(ctx context.Context, filter struct {
+	ID string `json:"id"`
+}) Response {
+	assetID := &bc.AssetID{}
+	if err := assetID.UnmarshalText([]byte(filter.ID)); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	resp := &AssetCirculationResp{
+		AssetID: filter.ID,
+		Issued:  a.wallet.AssetReg.IssuedAmount(assetID),
+		Retired: a.wallet.AssetReg.RetiredAmount(assetID),
+	}
+	if resp.Issued > resp.Retired {
+		resp.Circulating = resp.Issued - resp.Retired
+	}
+	return NewSuccessResponse(resp)
+}
+
 // POST /list-assets
 func (a *API) listAssets(ctx context.Context, filter struct {
 	ID string `json:"id"`
@@ -75,8 +174,9 @@ func (a *API) listAssets(ctx context.Context, filter struct {
 
 // POST /list-balances
 func (a *API) listBalances(ctx context.Context, filter struct {
-	AccountID    string `json:"account_id"`
-	AccountAlias string `json:"account_alias"`
+	AccountID     string `json:"account_id"`
+	AccountAlias  string `json:"account_alias"`
+	IncludeDenied bool   `json:"include_denied_assets"`
 }) Response {
 	accountID := filter.AccountID
 	if filter.AccountAlias != "" {
@@ -87,7 +187,7 @@ func (a *API) listBalances(ctx context.Context, filter struct {
 		accountID = acc.ID
 	}
 
-	balances, err := a.wallet.GetAccountBalances(accountID, "")
+	balances, err := a.wallet.GetAccountBalances(accountID, "", filter.IncludeDenied)
 	if err != nil {
 		return NewErrorResponse(err)
 	}
@@ -106,21 +206,39 @@ func (a *API) getTransaction(ctx context.Context, txInfo struct {
 		// transaction not found in blockchain db, search it from unconfirmed db
 		annotatedTx, err = a.wallet.GetUnconfirmedTxByTxID(txInfo.TxID)
 		if err != nil {
-			return NewErrorResponse(err)
+			// not wallet related, the optional txindex covers the rest of
+			// the chain
+			if annotatedTx, err = a.getIndexedAnnotatedTx(txInfo.TxID); err != nil {
+				return NewErrorResponse(err)
+			}
 		}
 	}
 
 	return NewSuccessResponse(annotatedTx)
 }
 
+// txPageResp is the cursor-paged reply of list-transactions, last is the
+// pagination key to pass back as after for the next page
+type txPageResp struct {
+	Transactions []*query.AnnotatedTx `json:"transactions"`
+	Last         string               `json:"last"`
+}
+
 // POST /list-transactions
 func (a *API) listTransactions(ctx context.Context, filter struct {
-	ID          string `json:"id"`
-	AccountID   string `json:"account_id"`
-	Detail      bool   `json:"detail"`
-	Unconfirmed bool   `json:"unconfirmed"`
-	From        uint   `json:"from"`
-	Count       uint   `json:"count"`
+	ID            string `json:"id"`
+	AccountID     string `json:"account_id"`
+	AssetID       string `json:"asset_id"`
+	Direction     string `json:"direction"`
+	StartTime     uint64 `json:"start_time"`
+	EndTime       uint64 `json:"end_time"`
+	MinAmount     uint64 `json:"min_amount"`
+	After         string `json:"after"`
+	IncludeDenied bool   `json:"include_denied_assets"`
+	Detail        bool   `json:"detail"`
+	Unconfirmed   bool   `json:"unconfirmed"`
+	From          uint   `json:"from"`
+	Count         uint   `json:"count"`
 }) Response {
 	transactions := []*query.AnnotatedTx{}
 	var err error
@@ -135,6 +253,23 @@ func (a *API) listTransactions(ctx context.Context, filter struct {
 			}
 		}
 		transactions = []*query.AnnotatedTx{transaction}
+	} else if filter.AssetID != "" || filter.Direction != "" || filter.StartTime != 0 ||
+		filter.EndTime != 0 || filter.MinAmount != 0 || filter.After != "" || filter.IncludeDenied {
+		txFilter := wallet.TxFilter{
+			AccountID:     filter.AccountID,
+			AssetID:       filter.AssetID,
+			Direction:     filter.Direction,
+			StartTime:     filter.StartTime,
+			EndTime:       filter.EndTime,
+			MinAmount:     filter.MinAmount,
+			IncludeDenied: filter.IncludeDenied,
+		}
+
+		transactions, last, err := a.wallet.GetTransactionsByFilter(txFilter, filter.After, int(filter.Count))
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+		return NewSuccessResponse(&txPageResp{Transactions: transactions, Last: last})
 	} else {
 		transactions, err = a.wallet.GetTransactions(filter.AccountID)
 		if err != nil {
@@ -223,6 +358,74 @@ func (a *API) listUnconfirmedTxs(ctx context.Context) Response {
 	})
 }
 
+// flushMempool writes the current pool contents to the persisted mempool
+// file immediately, instead of waiting for shutdown
+func (a *API) flushMempool() Response {
+	count, err := a.chain.SaveMempool()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(map[string]int{"saved": count})
+}
+
+// listSavedMempool lists the transactions sitting in the persisted mempool
+// file without touching the live pool
+func (a *API) listSavedMempool() Response {
+	txIDs, err := a.chain.SavedMempool()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(&unconfirmedTxsResp{
+		Total: uint64(len(txIDs)),
+		TxIDs: txIDs,
+	})
+}
+
+// MempoolTx describe one pool transaction together with its unconfirmed chain stats
+type MempoolTx struct {
+	TxID        bc.Hash `json:"tx_id"`
+	Size        uint64  `json:"size"`
+	Fee         uint64  `json:"fee"`
+	StatusFail  bool    `json:"status_fail"`
+	Added       int64   `json:"added"`
+	Ancestors   int     `json:"ancestors"`
+	Descendants int     `json:"descendants"`
+}
+
+type mempoolResp struct {
+	Total uint64       `json:"total"`
+	Txs   []*MempoolTx `json:"transactions"`
+}
+
+// POST /get-mempool
+func (a *API) getMempool(ctx context.Context) Response {
+	txPool := a.chain.GetTxPool()
+
+	mempoolTxs := []*MempoolTx{}
+	for _, txDesc := range txPool.GetTransactions() {
+		ancestors, descendants, err := txPool.TxChainStats(&txDesc.Tx.ID)
+		if err != nil {
+			// the transaction left the pool between the two snapshots
+			continue
+		}
+
+		mempoolTxs = append(mempoolTxs, &MempoolTx{
+			TxID:        txDesc.Tx.ID,
+			Size:        txDesc.Tx.SerializedSize,
+			Fee:         txDesc.Fee,
+			StatusFail:  txDesc.StatusFail,
+			Added:       txDesc.Added.Unix(),
+			Ancestors:   ancestors,
+			Descendants: descendants,
+		})
+	}
+
+	return NewSuccessResponse(&mempoolResp{
+		Total: uint64(len(mempoolTxs)),
+		Txs:   mempoolTxs,
+	})
+}
+
 // RawTx is the tx struct for getRawTransaction
 type RawTx struct {
 	ID        bc.Hash                  `json:"tx_id"`
@@ -248,16 +451,139 @@ func (a *API) decodeRawTransaction(ctx context.Context, ins struct {
 	}
 
 	for i := range ins.Tx.Inputs {
-		tx.Inputs = append(tx.Inputs, a.wallet.BuildAnnotatedInput(&ins.Tx, uint32(i)))
+		tx.Inputs = append(tx.Inputs, a.annotateDecodedInput(&ins.Tx, uint32(i)))
 	}
 	for i := range ins.Tx.Outputs {
-		tx.Outputs = append(tx.Outputs, a.wallet.BuildAnnotatedOutput(&ins.Tx, i))
+		tx.Outputs = append(tx.Outputs, a.annotateDecodedOutput(&ins.Tx, i))
 	}
 
 	tx.Fee = txbuilder.CalculateTxFee(&ins.Tx)
 	return NewSuccessResponse(tx)
 }
 
+// annotateDecodedInput annotates one input of a decoded transaction as far
+// as the running node allows: addresses always, asset aliases and
+// spent-output details only when the wallet knows them
+func (a *API) annotateDecodedInput(tx *types.Tx, i uint32) *query.AnnotatedInput {
+	if a.wallet == nil {
+		return buildBareAnnotatedInput(tx, i)
+	}
+
+	in := a.wallet.BuildAnnotatedInput(tx, i)
+	a.annotateAssetInfo(&in.AssetID, &in.AssetAlias, &in.AssetDefinition)
+	if in.SpentOutputID != nil {
+		a.annotateSpentOutput(in)
+	}
+	return in
+}
+
+// annotateDecodedOutput annotates one output of a decoded transaction
+func (a *API) annotateDecodedOutput(tx *types.Tx, idx int) *query.AnnotatedOutput {
+	if a.wallet == nil {
+		return buildBareAnnotatedOutput(tx, idx)
+	}
+
+	out := a.wallet.BuildAnnotatedOutput(tx, idx)
+	a.annotateAssetInfo(&out.AssetID, &out.AssetAlias, &out.AssetDefinition)
+	return out
+}
+
+// annotateAssetInfo fills in the asset alias and definition when the wallet
+// knows the asset
+func (a *API) annotateAssetInfo(assetID *bc.AssetID, alias *string, definition **json.RawMessage) {
+	gotAlias, gotDefinition := a.wallet.GetAliasDefinition(*assetID)
+	if gotAlias == "" {
+		return
+	}
+
+	*alias = gotAlias
+	rawDefinition := json.RawMessage(gotDefinition)
+	*definition = &rawDefinition
+}
+
+// annotateSpentOutput fills in the account owning the spent output when it
+// sits in the wallet UTXO index
+func (a *API) annotateSpentOutput(in *query.AnnotatedInput) {
+	for _, utxo := range a.wallet.GetAccountUtxos("", in.SpentOutputID.String(), true, false) {
+		if utxo.OutputID != *in.SpentOutputID {
+			continue
+		}
+
+		in.AccountID = utxo.AccountID
+		if acc, err := a.wallet.AccountMgr.FindByID(utxo.AccountID); err == nil {
+			in.AccountAlias = acc.Alias
+		}
+		return
+	}
+}
+
+// decodedEmptyAssetDefinition backs the asset definitions of bare decodes
+var decodedEmptyAssetDefinition = json.RawMessage(`{}`)
+
+// buildBareAnnotatedInput annotates an input without the wallet index, so
+// decoding stays available on wallet-disabled nodes
+func buildBareAnnotatedInput(tx *types.Tx, i uint32) *query.AnnotatedInput {
+	orig := tx.Inputs[i]
+	in := &query.AnnotatedInput{
+		AssetDefinition: &decodedEmptyAssetDefinition,
+	}
+	if orig.InputType() != types.CoinbaseInputType {
+		in.AssetID = orig.AssetID()
+		in.Amount = orig.Amount()
+		if in.AssetID.String() == consensus.BTMAssetID.String() {
+			in.AssetAlias = consensus.BTMAlias
+		}
+	}
+
+	id := tx.Tx.InputIDs[i]
+	in.InputID = id
+	switch e := tx.Entries[id].(type) {
+	case *bc.Spend:
+		in.Type = "spend"
+		in.ControlProgram = orig.ControlProgram()
+		in.Address = addressFromControlProgram(in.ControlProgram)
+		in.SpentOutputID = e.SpentOutputId
+		for _, arg := range orig.Arguments() {
+			in.WitnessArguments = append(in.WitnessArguments, arg)
+		}
+	case *bc.Issuance:
+		in.Type = "issue"
+		in.IssuanceProgram = orig.IssuanceProgram()
+		for _, arg := range orig.Arguments() {
+			in.WitnessArguments = append(in.WitnessArguments, arg)
+		}
+	case *bc.Coinbase:
+		in.Type = "coinbase"
+		in.Arbitrary = e.Arbitrary
+	}
+	return in
+}
+
+// buildBareAnnotatedOutput annotates an output without the wallet index
+func buildBareAnnotatedOutput(tx *types.Tx, idx int) *query.AnnotatedOutput {
+	orig := tx.Outputs[idx]
+	outid := tx.OutputID(idx)
+	out := &query.AnnotatedOutput{
+		OutputID:        *outid,
+		Position:        idx,
+		AssetID:         *orig.AssetId,
+		AssetDefinition: &decodedEmptyAssetDefinition,
+		Amount:          orig.Amount,
+		ControlProgram:  orig.ControlProgram,
+		Address:         addressFromControlProgram(orig.ControlProgram),
+	}
+	if out.AssetID.String() == consensus.BTMAssetID.String() {
+		out.AssetAlias = consensus.BTMAlias
+	}
+
+	if vmutil.IsUnspendable(out.ControlProgram) {
+		out.Type = "retire"
+	} else {
+		out.Type = "control"
+	}
+	return out
+}
+
 // POST /list-unspent-outputs
 func (a *API) listUnspentOutputs(ctx context.Context, filter struct {
 	AccountID     string `json:"account_id"`
@@ -294,6 +620,7 @@ func (a *API) listUnspentOutputs(ctx context.Context, filter struct {
 			Alias:               a.wallet.AccountMgr.GetAliasByID(utxo.AccountID),
 			AssetAlias:          a.wallet.AssetReg.GetAliasByID(utxo.AssetID.String()),
 			Change:              utxo.Change,
+			Imported:            utxo.Imported,
 		}}, UTXOs...)
 	}
 	start, end := getPageRange(len(UTXOs), filter.From, filter.Count)
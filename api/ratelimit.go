@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/net/http/authn"
+	"github.com/bytom-gm/net/http/httpjson"
+)
+
+// rateLimitPruneAfter is how long an idle client bucket is kept around
+const rateLimitPruneAfter = 10 * time.Minute
+
+var (
+	// errRateLimited means one client went over its request budget
+	errRateLimited = errors.New("too many requests, slow down")
+	// errServerBusy means the expensive-call capacity is exhausted
+	errServerBusy = errors.New("too many expensive calls in flight, retry later")
+)
+
+// expensivePaths are guarded by the global in-flight cap on top of the
+// per-client rate, they hold locks or scan the whole wallet
+var expensivePaths = map[string]bool{
+	"/build-transaction":        true,
+	"/build-chain-transactions": true,
+	"/build-consolidation":      true,
+	"/build-send-all":           true,
+	"/issue-assets":             true,
+	"/sweep-account":            true,
+	"/rescan-wallet":            true,
+	"/wallet-audit":             true,
+}
+
+// rateBucket is the token bucket of one client
+type rateBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// rateLimiter holds the per-client buckets and the expensive-call slots
+type rateLimiter struct {
+	mtx     sync.Mutex
+	buckets map[string]*rateBucket
+	rate    float64
+	burst   float64
+
+	inFlight chan struct{}
+
+	// now is swapped out by tests
+	now func() time.Time
+}
+
+func newRateLimiter(config *cfg.RateLimitConfig) *rateLimiter {
+	limiter := &rateLimiter{
+		buckets: map[string]*rateBucket{},
+		now:     time.Now,
+	}
+	if config == nil {
+		return limiter
+	}
+
+	limiter.rate = float64(config.RequestsPerSecond)
+	limiter.burst = float64(config.Burst)
+	if limiter.burst < limiter.rate {
+		limiter.burst = limiter.rate
+	}
+	if config.MaxInFlightExpensive > 0 {
+		limiter.inFlight = make(chan struct{}, config.MaxInFlightExpensive)
+	}
+	return limiter
+}
+
+// allow draws one call from the client's bucket, refilling it by the
+// sustained rate first
+func (l *rateLimiter) allow(client string) bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+	bucket, ok := l.buckets[client]
+	if !ok {
+		l.pruneIdle(now)
+		bucket = &rateBucket{tokens: l.burst}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.lastSeen).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// pruneIdle drops buckets idle long enough to be full again, called with the
+// lock held when a new client shows up
+func (l *rateLimiter) pruneIdle(now time.Time) {
+	for client, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) > rateLimitPruneAfter {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// acquireExpensive claims one expensive-call slot, false when the cap is hit
+func (l *rateLimiter) acquireExpensive() bool {
+	if l.inFlight == nil {
+		return true
+	}
+	select {
+	case l.inFlight <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *rateLimiter) releaseExpensive() {
+	if l.inFlight != nil {
+		<-l.inFlight
+	}
+}
+
+// clientKey identifies the caller for rate limiting: the token id when one
+// was presented, else the source address
+func clientKey(req *http.Request) string {
+	if token := authn.Token(req.Context()); token != "" {
+		return token
+	}
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// rateLimitHandler enforces the per-client request budget and the global
+// in-flight cap on expensive calls, answering 429 when a guard trips
+func (a *API) rateLimitHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if a.rateLimiter == nil {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		if !a.rateLimiter.allow(clientKey(req)) {
+			httpjson.Write(req.Context(), rw, http.StatusTooManyRequests, FormatErrResp(errRateLimited))
+			return
+		}
+
+		if expensivePaths[req.URL.Path] {
+			if !a.rateLimiter.acquireExpensive() {
+				httpjson.Write(req.Context(), rw, http.StatusTooManyRequests, FormatErrResp(errServerBusy))
+				return
+			}
+			defer a.rateLimiter.releaseExpensive()
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
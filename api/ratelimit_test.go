@@ -0,0 +1,60 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	cfg "github.com/bytom-gm/config"
+)
+
+func TestRateLimiterBudget(t *testing.T) {
+	limiter := newRateLimiter(&cfg.RateLimitConfig{RequestsPerSecond: 2, Burst: 4})
+	now := time.Unix(1000, 0)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		if !limiter.allow("client") {
+			t.Fatalf("call %d inside the burst got limited", i)
+		}
+	}
+	if limiter.allow("client") {
+		t.Error("call over the burst got through")
+	}
+	if !limiter.allow("other") {
+		t.Error("a different client shares the budget")
+	}
+
+	// one second refills the sustained rate worth of calls
+	now = now.Add(time.Second)
+	if !limiter.allow("client") || !limiter.allow("client") {
+		t.Error("refill after one second missing")
+	}
+	if limiter.allow("client") {
+		t.Error("refill exceeded the sustained rate")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := newRateLimiter(&cfg.RateLimitConfig{RequestsPerSecond: 0})
+	for i := 0; i < 1000; i++ {
+		if !limiter.allow("client") {
+			t.Fatal("disabled limiter rejected a call")
+		}
+	}
+}
+
+func TestRateLimiterExpensiveCap(t *testing.T) {
+	limiter := newRateLimiter(&cfg.RateLimitConfig{MaxInFlightExpensive: 2})
+
+	if !limiter.acquireExpensive() || !limiter.acquireExpensive() {
+		t.Fatal("slots under the cap got rejected")
+	}
+	if limiter.acquireExpensive() {
+		t.Error("slot over the cap got through")
+	}
+
+	limiter.releaseExpensive()
+	if !limiter.acquireExpensive() {
+		t.Error("released slot not reusable")
+	}
+}
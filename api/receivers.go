@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/net/http/httpjson"
 )
 
 func (a *API) createAccountReceiver(ctx context.Context, ins struct {
@@ -30,3 +32,41 @@ func (a *API) createAccountReceiver(ctx context.Context, ins struct {
 		Address:        program.Address,
 	})
 }
+
+// maxBatchReceiverCount is the upper bound of addresses derived by one
+// create-account-receivers call
+const maxBatchReceiverCount = 10000
+
+func (a *API) createAccountReceivers(ctx context.Context, ins struct {
+	AccountID    string `json:"account_id"`
+	AccountAlias string `json:"account_alias"`
+	Count        uint64 `json:"count"`
+}) Response {
+	accountID := ins.AccountID
+	if ins.AccountAlias != "" {
+		account, err := a.wallet.AccountMgr.FindByAlias(ins.AccountAlias)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+
+		accountID = account.ID
+	}
+
+	if ins.Count == 0 || ins.Count > maxBatchReceiverCount {
+		return NewErrorResponse(errors.WithDetailf(httpjson.ErrBadRequest, "count must be between 1 and %d", maxBatchReceiverCount))
+	}
+
+	programs, err := a.wallet.AccountMgr.CreateBatchAddresses(accountID, false, ins.Count)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	receivers := []*txbuilder.Receiver{}
+	for _, program := range programs {
+		receivers = append(receivers, &txbuilder.Receiver{
+			ControlProgram: program.ControlProgram,
+			Address:        program.Address,
+		})
+	}
+	return NewSuccessResponse(receivers)
+}
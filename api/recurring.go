@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// POST /create-recurring-payment
+func (a *API) createRecurringPayment(ctx context.Context, ins struct {
+	AccountID       string     `json:"account_id"`
+	Alias           string     `json:"alias"`
+	Address         string     `json:"address"`
+	AssetID         bc.AssetID `json:"asset_id"`
+	Amount          uint64     `json:"amount"`
+	IntervalSeconds uint64     `json:"interval_seconds"`
+	StartAt         uint64     `json:"start_at"`
+	Password        string     `json:"password"`
+}) Response {
+	payment, err := a.wallet.CreateRecurringPayment(ins.AccountID, ins.Alias, ins.Address, ins.Password, ins.AssetID, ins.Amount, ins.IntervalSeconds, ins.StartAt)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(payment)
+}
+
+// POST /list-recurring-payments
+func (a *API) listRecurringPayments(ctx context.Context) Response {
+	payments, err := a.wallet.ListRecurringPayments()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(payments)
+}
+
+// POST /pause-recurring-payment
+func (a *API) pauseRecurringPayment(ctx context.Context, ins struct {
+	ID string `json:"id"`
+}) Response {
+	if err := a.wallet.PauseRecurringPayment(ins.ID); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
+
+// POST /resume-recurring-payment
+// the password is only needed when the payment isn't armed, e.g. after a
+// node restart
+func (a *API) resumeRecurringPayment(ctx context.Context, ins struct {
+	ID       string `json:"id"`
+	Password string `json:"password"`
+}) Response {
+	if err := a.wallet.ResumeRecurringPayment(ins.ID, ins.Password); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
+
+// POST /delete-recurring-payment
+func (a *API) deleteRecurringPayment(ctx context.Context, ins struct {
+	ID string `json:"id"`
+}) Response {
+	if err := a.wallet.DeleteRecurringPayment(ins.ID); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+// replayRequest is the first message a replay client sends, a non-empty
+// resume token from an earlier session overrides the start height
+type replayRequest struct {
+	StartHeight uint64 `json:"start_height"`
+	ResumeToken string `json:"resume_token"`
+}
+
+// ReplayEvent is one entry of the ordered block/tx history stream. Block
+// events carry the resume token to persist, it resumes the stream right
+// after the block it was attached to
+type ReplayEvent struct {
+	Type        string `json:"type"`
+	BlockHeight uint64 `json:"block_height"`
+	BlockHash   string `json:"block_hash,omitempty"`
+	Timestamp   uint64 `json:"timestamp,omitempty"`
+	TxID        string `json:"tx_id,omitempty"`
+	Position    int    `json:"position,omitempty"`
+	StatusFail  bool   `json:"status_fail,omitempty"`
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+const (
+	replayEventBlock = "block"
+	replayEventTx    = "tx"
+	replayEventDone  = "done"
+)
+
+// replayEventsHandler upgrades the connection to a websocket and streams the
+// ordered block and transaction history from the requested height to the
+// current tip, so downstream indexers recovering from downtime don't need
+// custom backfill code. The client sends one replayRequest JSON object first.
+func (a *API) replayEventsHandler() http.Handler {
+	return websocket.Handler(func(ws *websocket.Conn) {
+		req := &replayRequest{}
+		if err := websocket.JSON.Receive(ws, req); err != nil {
+			log.WithField("err", err).Warning("websocket fail on receive replay request")
+			return
+		}
+
+		startHeight := req.StartHeight
+		if req.ResumeToken != "" {
+			resumed, err := strconv.ParseUint(req.ResumeToken, 10, 64)
+			if err != nil {
+				log.WithField("err", err).Warning("websocket got a bad replay resume token")
+				return
+			}
+			startHeight = resumed + 1
+		}
+
+		for height := startHeight; height <= a.chain.BestBlockHeight(); height++ {
+			block, err := a.chain.GetBlockByHeight(height)
+			if err != nil {
+				log.WithField("err", err).Error("replay fail on get block")
+				return
+			}
+
+			blockHash := block.Hash()
+			txStatus, err := a.chain.GetTransactionStatus(&blockHash)
+			if err != nil {
+				log.WithField("err", err).Error("replay fail on get transaction status")
+				return
+			}
+
+			token := strconv.FormatUint(height, 10)
+			if err := websocket.JSON.Send(ws, &ReplayEvent{
+				Type:        replayEventBlock,
+				BlockHeight: height,
+				BlockHash:   blockHash.String(),
+				Timestamp:   block.Timestamp,
+				ResumeToken: token,
+			}); err != nil {
+				return
+			}
+
+			for i, tx := range block.Transactions {
+				statusFail, err := txStatus.GetStatus(i)
+				if err != nil {
+					log.WithField("err", err).Error("replay fail on get tx status")
+					return
+				}
+
+				if err := websocket.JSON.Send(ws, &ReplayEvent{
+					Type:        replayEventTx,
+					BlockHeight: height,
+					TxID:        tx.ID.String(),
+					Position:    i,
+					StatusFail:  statusFail,
+					ResumeToken: token,
+				}); err != nil {
+					return
+				}
+			}
+		}
+
+		websocket.JSON.Send(ws, &ReplayEvent{
+			Type:        replayEventDone,
+			BlockHeight: a.chain.BestBlockHeight(),
+		})
+	})
+}
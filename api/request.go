@@ -23,6 +23,9 @@ type BuildRequest struct {
 	Actions   []map[string]interface{} `json:"actions"`
 	TTL       json.Duration            `json:"ttl"`
 	TimeRange uint64                   `json:"time_range"`
+	// GasRate is an optional per-request fee rate override in neu per byte,
+	// bounded by the node policy floor/ceiling
+	GasRate uint64 `json:"gas_rate"`
 }
 
 func (a *API) completeMissingIDs(ctx context.Context, br *BuildRequest) error {
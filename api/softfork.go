@@ -0,0 +1,30 @@
+package api
+
+// SoftForkResp describes one versionbits deployment for getSoftforkStatus
+type SoftForkResp struct {
+	Name          string `json:"name"`
+	Bit           uint8  `json:"bit"`
+	StartHeight   uint64 `json:"start_height"`
+	TimeoutHeight uint64 `json:"timeout_height"`
+	Status        string `json:"status"`
+	SignalCount   uint64 `json:"signal_count"`
+	WindowStart   uint64 `json:"window_start"`
+}
+
+// return the versionbits state of every softfork deployment of the active
+// network, with the signal count of the still open window
+func (a *API) getSoftforkStatus() Response {
+	resp := []*SoftForkResp{}
+	for _, fork := range a.chain.SoftForks() {
+		resp = append(resp, &SoftForkResp{
+			Name:          fork.Name,
+			Bit:           fork.Bit,
+			StartHeight:   fork.StartHeight,
+			TimeoutHeight: fork.TimeoutHeight,
+			Status:        fork.State.String(),
+			SignalCount:   fork.SignalCount,
+			WindowStart:   fork.WindowStart,
+		})
+	}
+	return NewSuccessResponse(resp)
+}
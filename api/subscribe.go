@@ -0,0 +1,332 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+
+	"github.com/bytom-gm/common"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/consensus/segwit"
+)
+
+const (
+	//SubChannelBlocks streams every block accepted into the main chain
+	SubChannelBlocks = "blocks"
+	//SubChannelTransactions streams every transaction accepted into the mempool
+	SubChannelTransactions = "transactions"
+	//SubChannelStatus streams chain status changes (syncing flips, new tip)
+	SubChannelStatus = "status"
+
+	// maxSubHistory is how many events are kept for resuming, a client
+	// whose resume_seq fell out of the window gets a gap event first
+	maxSubHistory = 1024
+	// a slow subscriber is disconnected once this many events queue up,
+	// it can reconnect with its last seen sequence number
+	subscriberQueueSize = 256
+	// statusPollInterval bounds how stale the status channel can get when
+	// no blocks arrive
+	statusPollInterval = 10 * time.Second
+)
+
+// subscribeRequest is the first message a subscription client sends.
+// ResumeSeq replays the buffered events after that sequence number before
+// the live stream starts.
+type subscribeRequest struct {
+	Channels  []string `json:"channels"`
+	Assets    []string `json:"asset_filter"`
+	Addresses []string `json:"address_filter"`
+	ResumeSeq uint64   `json:"resume_seq"`
+}
+
+// SubscribeEvent is one entry of the subscription stream, Seq increases by
+// one per published event so clients can detect drops and resume
+type SubscribeEvent struct {
+	Seq         uint64   `json:"seq"`
+	Channel     string   `json:"channel"`
+	Type        string   `json:"type"`
+	BlockHeight uint64   `json:"block_height,omitempty"`
+	BlockHash   string   `json:"block_hash,omitempty"`
+	TxID        string   `json:"tx_id,omitempty"`
+	Assets      []string `json:"assets,omitempty"`
+	Addresses   []string `json:"addresses,omitempty"`
+	Syncing     *bool    `json:"syncing,omitempty"`
+	Timestamp   uint64   `json:"timestamp"`
+}
+
+// event types inside the channels
+const (
+	subEventBlock    = "block"
+	subEventTx       = "tx"
+	subEventStatus   = "status"
+	subEventGap      = "gap" // the resume window did not cover resume_seq
+	subEventOverflow = "overflow"
+)
+
+// subFilter narrows one subscription, empty fields match everything
+type subFilter struct {
+	channels  map[string]bool
+	assets    map[string]bool
+	addresses map[string]bool
+}
+
+func newSubFilter(req *subscribeRequest) *subFilter {
+	f := &subFilter{
+		channels:  map[string]bool{},
+		assets:    map[string]bool{},
+		addresses: map[string]bool{},
+	}
+	for _, channel := range req.Channels {
+		f.channels[channel] = true
+	}
+	for _, asset := range req.Assets {
+		f.assets[asset] = true
+	}
+	for _, address := range req.Addresses {
+		f.addresses[address] = true
+	}
+	return f
+}
+
+func (f *subFilter) match(event *SubscribeEvent) bool {
+	if len(f.channels) != 0 && !f.channels[event.Channel] {
+		return false
+	}
+	if event.Channel != SubChannelTransactions {
+		return true
+	}
+
+	if len(f.assets) != 0 && !matchAny(f.assets, event.Assets) {
+		return false
+	}
+	if len(f.addresses) != 0 && !matchAny(f.addresses, event.Addresses) {
+		return false
+	}
+	return true
+}
+
+func matchAny(wanted map[string]bool, got []string) bool {
+	for _, s := range got {
+		if wanted[s] {
+			return true
+		}
+	}
+	return false
+}
+
+// subHub assigns sequence numbers to chain events, keeps a resume window of
+// recent ones and fans them out to the websocket subscriptions
+type subHub struct {
+	mtx     sync.Mutex
+	nextSeq uint64
+	history []*SubscribeEvent
+	subs    map[chan *SubscribeEvent]*subFilter
+}
+
+func newSubHub() *subHub {
+	return &subHub{
+		nextSeq: 1,
+		subs:    map[chan *SubscribeEvent]*subFilter{},
+	}
+}
+
+// subscribe registers a filter, replaying the buffered events after
+// resumeSeq into the fresh queue first
+func (h *subHub) subscribe(filter *subFilter, resumeSeq uint64) chan *SubscribeEvent {
+	ch := make(chan *SubscribeEvent, subscriberQueueSize)
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if resumeSeq != 0 {
+		if len(h.history) == 0 || h.history[0].Seq > resumeSeq+1 {
+			ch <- &SubscribeEvent{Channel: SubChannelStatus, Type: subEventGap, Timestamp: uint64(time.Now().Unix())}
+		}
+		for _, event := range h.history {
+			if event.Seq > resumeSeq && filter.match(event) && len(ch) < cap(ch) {
+				ch <- event
+			}
+		}
+	}
+
+	h.subs[ch] = filter
+	return ch
+}
+
+// unsubscribe drops the subscription and closes its queue
+func (h *subHub) unsubscribe(ch chan *SubscribeEvent) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// publish stamps the event with the next sequence number and delivers it to
+// every matching subscription. A full queue closes the subscription instead
+// of blocking the chain watchers, the client resumes with its last seq.
+func (h *subHub) publish(event *SubscribeEvent) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	event.Seq = h.nextSeq
+	h.nextSeq++
+	if event.Timestamp == 0 {
+		event.Timestamp = uint64(time.Now().Unix())
+	}
+
+	h.history = append(h.history, event)
+	if len(h.history) > maxSubHistory {
+		h.history = h.history[1:]
+	}
+
+	for ch, filter := range h.subs {
+		if !filter.match(event) {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// startSubWatchers launches the chain and mempool watchers feeding the hub,
+// it is called once from NewAPI
+func (a *API) startSubWatchers() {
+	go a.subBlockWatcher()
+	go a.subTxWatcher()
+}
+
+// subBlockWatcher publishes a block event per accepted block and a status
+// event whenever the syncing flag flips or a poll interval passes idle
+func (a *API) subBlockWatcher() {
+	height := a.chain.BestBlockHeight()
+	lastSyncing := !a.sync.IsCaughtUp()
+	a.publishStatus(lastSyncing)
+
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.chain.BlockWaiter(height + 1):
+			height++
+			header, err := a.chain.GetHeaderByHeight(height)
+			if err != nil {
+				log.WithField("err", err).Error("subscription fail on get block header")
+				continue
+			}
+
+			headerHash := header.Hash()
+			a.subHub.publish(&SubscribeEvent{
+				Channel:     SubChannelBlocks,
+				Type:        subEventBlock,
+				BlockHeight: height,
+				BlockHash:   headerHash.String(),
+				Timestamp:   header.Timestamp,
+			})
+
+		case <-ticker.C:
+		}
+
+		if syncing := !a.sync.IsCaughtUp(); syncing != lastSyncing {
+			lastSyncing = syncing
+			a.publishStatus(syncing)
+		}
+	}
+}
+
+func (a *API) publishStatus(syncing bool) {
+	header := a.chain.BestBlockHeader()
+	headerHash := header.Hash()
+	a.subHub.publish(&SubscribeEvent{
+		Channel:     SubChannelStatus,
+		Type:        subEventStatus,
+		BlockHeight: header.Height,
+		BlockHash:   headerHash.String(),
+		Syncing:     &syncing,
+	})
+}
+
+// subTxWatcher publishes a transaction event per mempool acceptance, with
+// the output assets and addresses attached for filtering
+func (a *API) subTxWatcher() {
+	ch := a.chain.GetTxPool().SubscribeNewTx()
+	defer a.chain.GetTxPool().UnsubscribeNewTx(ch)
+
+	for txD := range ch {
+		assets := []string{}
+		addresses := []string{}
+		seenAsset := map[string]bool{}
+		for _, output := range txD.Tx.Outputs {
+			if asset := output.AssetAmount.AssetId.String(); !seenAsset[asset] {
+				seenAsset[asset] = true
+				assets = append(assets, asset)
+			}
+			if address := addressFromControlProgram(output.ControlProgram); address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+
+		a.subHub.publish(&SubscribeEvent{
+			Channel:   SubChannelTransactions,
+			Type:      subEventTx,
+			TxID:      txD.Tx.ID.String(),
+			Assets:    assets,
+			Addresses: addresses,
+		})
+	}
+}
+
+// addressFromControlProgram derives the payment address of a standard
+// control program, non-standard programs yield an empty string
+func addressFromControlProgram(prog []byte) string {
+	if segwit.IsP2WPKHScript(prog) {
+		if pubHash, err := segwit.GetHashFromStandardProg(prog); err == nil {
+			if address, err := common.NewAddressWitnessPubKeyHash(pubHash, &consensus.ActiveNetParams); err == nil {
+				return address.EncodeAddress()
+			}
+		}
+	} else if segwit.IsP2WSHScript(prog) {
+		if scriptHash, err := segwit.GetHashFromStandardProg(prog); err == nil {
+			if address, err := common.NewAddressWitnessScriptHash(scriptHash, &consensus.ActiveNetParams); err == nil {
+				return address.EncodeAddress()
+			}
+		}
+	}
+	return ""
+}
+
+// websocketSubscribeHandler upgrades the connection to a websocket and
+// streams the subscribed channels. The client sends one subscribeRequest
+// JSON object first; the stream ends when the client falls too far behind,
+// reconnecting with resume_seq picks it back up.
+func (a *API) websocketSubscribeHandler() http.Handler {
+	return websocket.Handler(func(ws *websocket.Conn) {
+		req := &subscribeRequest{}
+		if err := websocket.JSON.Receive(ws, req); err != nil {
+			log.WithField("err", err).Warning("websocket fail on receive subscribe request")
+			return
+		}
+
+		ch := a.subHub.subscribe(newSubFilter(req), req.ResumeSeq)
+		defer a.subHub.unsubscribe(ch)
+
+		for event := range ch {
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		}
+
+		// the hub closed the queue because this client lagged behind
+		websocket.JSON.Send(ws, &SubscribeEvent{Channel: SubChannelStatus, Type: subEventOverflow, Timestamp: uint64(time.Now().Unix())})
+	})
+}
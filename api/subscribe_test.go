@@ -0,0 +1,77 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestSubHubSequenceAndFilter(t *testing.T) {
+	hub := newSubHub()
+	ch := hub.subscribe(newSubFilter(&subscribeRequest{
+		Channels: []string{SubChannelTransactions},
+		Assets:   []string{"asset-a"},
+	}), 0)
+	defer hub.unsubscribe(ch)
+
+	hub.publish(&SubscribeEvent{Channel: SubChannelBlocks, Type: subEventBlock})
+	hub.publish(&SubscribeEvent{Channel: SubChannelTransactions, Type: subEventTx, Assets: []string{"asset-b"}})
+	hub.publish(&SubscribeEvent{Channel: SubChannelTransactions, Type: subEventTx, Assets: []string{"asset-a"}})
+
+	if got := len(ch); got != 1 {
+		t.Fatalf("got %d events through the filter, want 1", got)
+	}
+	event := <-ch
+	if event.Seq != 3 {
+		t.Errorf("got seq %d, want 3", event.Seq)
+	}
+}
+
+func TestSubHubResume(t *testing.T) {
+	hub := newSubHub()
+	for i := 0; i < 5; i++ {
+		hub.publish(&SubscribeEvent{Channel: SubChannelBlocks, Type: subEventBlock})
+	}
+
+	ch := hub.subscribe(newSubFilter(&subscribeRequest{}), 2)
+	defer hub.unsubscribe(ch)
+
+	if got := len(ch); got != 3 {
+		t.Fatalf("got %d replayed events, want 3", got)
+	}
+	if event := <-ch; event.Seq != 3 {
+		t.Errorf("replay starts at seq %d, want 3", event.Seq)
+	}
+}
+
+func TestSubHubResumeGap(t *testing.T) {
+	hub := newSubHub()
+	for i := 0; i < maxSubHistory+10; i++ {
+		hub.publish(&SubscribeEvent{Channel: SubChannelBlocks, Type: subEventBlock})
+	}
+
+	ch := hub.subscribe(newSubFilter(&subscribeRequest{}), 1)
+	defer hub.unsubscribe(ch)
+
+	if event := <-ch; event.Type != subEventGap {
+		t.Errorf("got %q first, want a gap event when resume_seq fell out of the window", event.Type)
+	}
+}
+
+func TestSubHubOverflowDropsSubscriber(t *testing.T) {
+	hub := newSubHub()
+	ch := hub.subscribe(newSubFilter(&subscribeRequest{}), 0)
+
+	for i := 0; i < subscriberQueueSize+1; i++ {
+		hub.publish(&SubscribeEvent{Channel: SubChannelBlocks, Type: subEventBlock})
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	if received != subscriberQueueSize {
+		t.Errorf("got %d events before the close, want %d", received, subscriberQueueSize)
+	}
+
+	// the hub already dropped the subscription, a second unsubscribe is a no-op
+	hub.unsubscribe(ch)
+}
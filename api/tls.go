@@ -0,0 +1,131 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/errors"
+)
+
+// selfSignedValidity is how long an auto-generated certificate lasts
+const selfSignedValidity = 10 * 365 * 24 * time.Hour
+
+// buildAPITLSConfig loads (or, when allowed, generates) the server keypair
+// and returns the tls configuration of the API listener, nil when TLS is
+// disabled
+func buildAPITLSConfig(config *cfg.Config) (*tls.Config, error) {
+	if config.TLS == nil || !config.TLS.Enable {
+		return nil, nil
+	}
+
+	certFile, keyFile := config.TLSCertFile(), config.TLSKeyFile()
+	if _, err := os.Stat(certFile); os.IsNotExist(err) {
+		if !config.TLS.AutoGenerate {
+			return nil, errors.New("tls enabled but cert file is missing: " + certFile)
+		}
+		if err := generateSelfSignedCert(certFile, keyFile); err != nil {
+			return nil, errors.Wrap(err, "generate self-signed api certificate")
+		}
+		log.WithField("cert", certFile).Info("generated self-signed api certificate")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load api certificate")
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if caFile := config.TLSClientCAFile(); caFile != "" {
+		caPEM, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read client ca file")
+		}
+
+		caPool := x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("client ca file holds no usable certificate")
+		}
+		tlsConfig.ClientCAs = caPool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// maybeUseTLS wraps the plain listener when the API is configured for HTTPS
+func (a *API) maybeUseTLS(listener net.Listener) net.Listener {
+	if a.tlsConfig == nil {
+		return listener
+	}
+	return tls.NewListener(listener, a.tlsConfig)
+}
+
+// generateSelfSignedCert writes a fresh self-signed keypair for loopback and
+// local hostname use
+func generateSelfSignedCert(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "bytom api"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(selfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0700); err != nil {
+		return err
+	}
+	if err := writePEMFile(certFile, "CERTIFICATE", der, 0644); err != nil {
+		return err
+	}
+	return writePEMFile(keyFile, "EC PRIVATE KEY", keyDER, 0600)
+}
+
+func writePEMFile(path, blockType string, der []byte, mode os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return pem.Encode(file, &pem.Block{Type: blockType, Bytes: der})
+}
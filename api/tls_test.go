@@ -0,0 +1,75 @@
+package api
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cfg "github.com/bytom-gm/config"
+)
+
+func TestBuildAPITLSConfigAutoGenerate(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "api-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	config := cfg.DefaultConfig()
+	config.RootDir = tmpDir
+	config.TLS.Enable = true
+
+	tlsConfig, err := buildAPITLSConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig == nil || len(tlsConfig.Certificates) != 1 {
+		t.Fatal("auto-generation yielded no server certificate")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "tls", "api.key")); err != nil {
+		t.Errorf("key file missing after auto-generation: %v", err)
+	}
+
+	// a second build reuses the generated pair instead of regenerating
+	if _, err := buildAPITLSConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	config.TLS.ClientCAFile = config.TLS.CertFile
+	tlsConfig, err = buildAPITLSConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Error("client ca file did not switch on client certificate authentication")
+	}
+}
+
+func TestBuildAPITLSConfigDisabled(t *testing.T) {
+	tlsConfig, err := buildAPITLSConfig(cfg.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig != nil {
+		t.Error("got a tls config with tls disabled")
+	}
+}
+
+func TestBuildAPITLSConfigMissingCert(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "api-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	config := cfg.DefaultConfig()
+	config.RootDir = tmpDir
+	config.TLS.Enable = true
+	config.TLS.AutoGenerate = false
+
+	if _, err := buildAPITLSConfig(config); err == nil {
+		t.Error("missing cert with auto_generate off should be an error")
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"math"
 	"strings"
 	"time"
@@ -11,13 +12,16 @@ import (
 
 	"github.com/bytom-gm/account"
 	"github.com/bytom-gm/blockchain/txbuilder"
+	cfg "github.com/bytom-gm/config"
 	"github.com/bytom-gm/consensus"
 	"github.com/bytom-gm/consensus/segwit"
 	"github.com/bytom-gm/errors"
 	"github.com/bytom-gm/math/checked"
+	"github.com/bytom-gm/net/http/httpjson"
 	"github.com/bytom-gm/net/http/reqid"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/vm/vmutil"
 )
 
 var (
@@ -30,6 +34,7 @@ func (a *API) actionDecoder(action string) (func([]byte) (txbuilder.Action, erro
 	decoders := map[string]func([]byte) (txbuilder.Action, error){
 		"control_address":              txbuilder.DecodeControlAddressAction,
 		"control_program":              txbuilder.DecodeControlProgramAction,
+		"control_template":             txbuilder.DecodeControlTemplateAction,
 		"issue":                        a.wallet.AssetReg.DecodeIssueAction,
 		"retire":                       txbuilder.DecodeRetireAction,
 		"spend_account":                a.wallet.AccountMgr.DecodeSpendAction,
@@ -89,15 +94,64 @@ func (a *API) buildSingle(ctx context.Context, req *BuildRequest) (*txbuilder.Te
 	return tpl, nil
 }
 
+// BuildTxResp wrap the built template with the fee projection at the
+// caller's gas rate override
+type BuildTxResp struct {
+	*txbuilder.Template
+	GasRate        uint64 `json:"gas_rate"`
+	RecommendedFee uint64 `json:"recommended_fee"`
+	Warning        string `json:"warning,omitempty"`
+}
+
+// checkGasRateBounds enforce the node policy floor/ceiling on a per-request
+// gas rate override, zero bounds are disabled
+func checkGasRateBounds(gasRate uint64) error {
+	if cfg.CommonConfig == nil || cfg.CommonConfig.Policy == nil {
+		return nil
+	}
+
+	if minRate := cfg.CommonConfig.Policy.MinGasRate; minRate > 0 && gasRate < minRate {
+		return errors.WithDetailf(httpjson.ErrBadRequest, "gas rate %d below the node floor %d", gasRate, minRate)
+	}
+	if maxRate := cfg.CommonConfig.Policy.MaxGasRate; maxRate > 0 && gasRate > maxRate {
+		return errors.WithDetailf(httpjson.ErrBadRequest, "gas rate %d above the node ceiling %d", gasRate, maxRate)
+	}
+	return nil
+}
+
 // POST /build-transaction
 func (a *API) build(ctx context.Context, buildReqs *BuildRequest) Response {
+	if buildReqs.GasRate != 0 {
+		if err := checkGasRateBounds(buildReqs.GasRate); err != nil {
+			return NewErrorResponse(err)
+		}
+	}
+
 	subctx := reqid.NewSubContext(ctx, reqid.New())
 	tmpl, err := a.buildSingle(subctx, buildReqs)
 	if err != nil {
 		return NewErrorResponse(err)
 	}
 
-	return NewSuccessResponse(tmpl)
+	if buildReqs.GasRate == 0 {
+		return NewSuccessResponse(tmpl)
+	}
+
+	rawTx, err := tmpl.Transaction.TxData.MarshalText()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	size := uint64(int64(len(rawTx)) + estimateSignSize(tmpl.SigningInstructions))
+
+	resp := &BuildTxResp{
+		Template:       tmpl,
+		GasRate:        buildReqs.GasRate,
+		RecommendedFee: size * buildReqs.GasRate,
+	}
+	if estimatedRate := txbuilder.EstimateFeeRate(1); buildReqs.GasRate < estimatedRate {
+		resp.Warning = fmt.Sprintf("gas rate %d is below the current estimate %d neu/byte, the transaction may not confirm promptly", buildReqs.GasRate, estimatedRate)
+	}
+	return NewSuccessResponse(resp)
 }
 func (a *API) checkRequestValidity(ctx context.Context, req *BuildRequest) error {
 	if err := a.completeMissingIDs(ctx, req); err != nil {
@@ -189,7 +243,8 @@ func (a *API) buildChainTxs(ctx context.Context, buildReqs *BuildRequest) Respon
 }
 
 type submitTxResp struct {
-	TxID *bc.Hash `json:"tx_id"`
+	TxID       *bc.Hash           `json:"tx_id"`
+	Broadcasts []*BroadcastResult `json:"broadcasts,omitempty"`
 }
 
 // POST /submit-transaction
@@ -201,7 +256,39 @@ func (a *API) submit(ctx context.Context, ins struct {
 	}
 
 	log.WithField("tx_id", ins.Tx.ID.String()).Info("submit single tx")
-	return NewSuccessResponse(&submitTxResp{TxID: &ins.Tx.ID})
+	return NewSuccessResponse(&submitTxResp{TxID: &ins.Tx.ID, Broadcasts: broadcastToSecondaries(&ins.Tx)})
+}
+
+// TestTransactionResp reports the dry-run validation outcome of one raw
+// transaction, the gas fields are meaningful even when validation failed
+type TestTransactionResp struct {
+	TxID       *bc.Hash `json:"tx_id"`
+	Valid      bool     `json:"valid"`
+	GasUsed    int64    `json:"gas_used"`
+	StorageGas int64    `json:"storage_gas"`
+	BTMValue   uint64   `json:"btm_value"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// POST /test-transaction
+func (a *API) testTransaction(ctx context.Context, ins struct {
+	Tx types.Tx `json:"raw_transaction"`
+}) Response {
+	gasStatus, err := a.chain.DryRunTx(&ins.Tx)
+
+	resp := &TestTransactionResp{TxID: &ins.Tx.ID, Valid: err == nil}
+	if gasStatus != nil {
+		resp.GasUsed = gasStatus.GasUsed
+		resp.StorageGas = gasStatus.StorageGas
+		resp.BTMValue = gasStatus.BTMValue
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		if detail := errors.Detail(err); detail != err.Error() {
+			resp.Error = detail
+		}
+	}
+	return NewSuccessResponse(resp)
 }
 
 type submitTxsResp struct {
@@ -223,6 +310,63 @@ func (a *API) submitTxs(ctx context.Context, ins struct {
 	return NewSuccessResponse(&submitTxsResp{TxID: txHashs})
 }
 
+// POST /export-transaction-template
+func (a *API) exportTxTemplate(ctx context.Context, ins struct {
+	Transaction txbuilder.Template `json:"transaction"`
+}) Response {
+	payload, err := txbuilder.ExportTemplate(&ins.Transaction)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(struct {
+		Payload string `json:"payload"`
+	}{Payload: payload})
+}
+
+// POST /import-transaction-template
+func (a *API) importTxTemplate(ctx context.Context, ins struct {
+	Payload string `json:"payload"`
+}) Response {
+	tpl, err := txbuilder.ImportTemplate(ins.Payload)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(tpl)
+}
+
+// POST /schedule-transaction
+func (a *API) scheduleTx(ctx context.Context, ins struct {
+	Tx                types.Tx `json:"raw_transaction"`
+	SubmitAfterHeight uint64   `json:"submit_after_height"`
+	SubmitAfterTime   uint64   `json:"submit_after_time"`
+}) Response {
+	if err := a.wallet.ScheduleTx(&ins.Tx, ins.SubmitAfterHeight, ins.SubmitAfterTime); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	log.WithField("tx_id", ins.Tx.ID.String()).Info("schedule tx for later submit")
+	return NewSuccessResponse(&submitTxResp{TxID: &ins.Tx.ID})
+}
+
+// POST /list-scheduled-transactions
+func (a *API) listScheduledTxs(ctx context.Context) Response {
+	scheduledTxs, err := a.wallet.GetScheduledTxs()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(scheduledTxs)
+}
+
+// POST /delete-scheduled-transaction
+func (a *API) deleteScheduledTx(ctx context.Context, ins struct {
+	TxID string `json:"tx_id"`
+}) Response {
+	if err := a.wallet.RemoveScheduledTx(ins.TxID); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
+
 // EstimateTxGasResp estimate transaction consumed gas
 type EstimateTxGasResp struct {
 	TotalNeu   int64 `json:"total_neu"`
@@ -281,7 +425,11 @@ func EstimateTxGas(template txbuilder.Template) (*EstimateTxGasResp, error) {
 	roundingNeu := math.Ceil(totalNeu)
 	estimateNeu := int64(roundingNeu) * int64(defaultBaseRate)
 
-	// TODO add priority
+	// never recommend less than the mempool backed fee rate suggests,
+	// static estimates alone leave transactions stuck during fee bursts
+	if dynamicNeu := int64(txbuilder.EstimateFee(uint64(baseTxSize+signSize), 1)); dynamicNeu > estimateNeu {
+		estimateNeu = dynamicNeu
+	}
 
 	return &EstimateTxGasResp{
 		TotalNeu:   estimateNeu,
@@ -337,3 +485,174 @@ func (a *API) estimateTxGas(ctx context.Context, in struct {
 	}
 	return NewSuccessResponse(txGasResp)
 }
+
+// approximate serialized sizes used by the pre-selection size simulation,
+// they intentionally overshoot slightly so the projection is a safe budget
+const (
+	baseTxOverheadSize = int64(70)
+	baseSpendInputSize = int64(150)
+	baseIssuanceSize   = int64(250)
+	baseOutputSize     = int64(90)
+	baseP2WPKHVMGas    = int64(1419)
+	baseP2WSHVMGas     = int64(738)
+)
+
+// MultisigSpec describes one multisig (P2WSH) input of a simulated transaction
+type MultisigSpec struct {
+	Keys   int64 `json:"keys"`
+	Quorum int64 `json:"quorum"`
+}
+
+// EstimateTxSizeResp is the projected size and gas of a simulated transaction
+type EstimateTxSizeResp struct {
+	Size       int64 `json:"size"`
+	TotalNeu   int64 `json:"total_neu"`
+	StorageNeu int64 `json:"storage_neu"`
+	VMNeu      int64 `json:"vm_neu"`
+}
+
+// POST /estimate-transaction-size
+// project serialized size and gas from a transaction shape spec, before any
+// UTXO selection, so services can plan batching and fee budgets offline
+func (a *API) estimateTxSize(ctx context.Context, ins struct {
+	P2WPKHInputs   int64          `json:"p2wpkh_inputs"`
+	P2WSHInputs    []MultisigSpec `json:"p2wsh_inputs"`
+	IssuanceInputs int64          `json:"issuance_inputs"`
+	Outputs        int64          `json:"outputs"`
+}) Response {
+	if ins.P2WPKHInputs < 0 || ins.IssuanceInputs < 0 || ins.Outputs < 0 {
+		return NewErrorResponse(errors.WithDetail(httpjson.ErrBadRequest, "input and output counts must not be negative"))
+	}
+
+	baseWitnessSize := int64(300)
+	size := baseTxOverheadSize
+	vmGas := int64(0)
+
+	size += ins.P2WPKHInputs * (baseSpendInputSize + baseWitnessSize)
+	vmGas += ins.P2WPKHInputs * baseP2WPKHVMGas
+
+	for _, spec := range ins.P2WSHInputs {
+		if spec.Keys <= 0 || spec.Quorum <= 0 || spec.Quorum > spec.Keys {
+			return NewErrorResponse(errors.WithDetailf(httpjson.ErrBadRequest, "invalid multisig spec %d of %d", spec.Quorum, spec.Keys))
+		}
+
+		// witness holds quorum signatures plus the multisig script with all pubkeys
+		size += baseSpendInputSize + spec.Quorum*baseWitnessSize + spec.Keys*35 + 10
+		vmGas += baseP2WSHVMGas + (984*spec.Keys - 72*spec.Quorum - 63)
+	}
+
+	size += ins.IssuanceInputs * (baseIssuanceSize + baseWitnessSize)
+	vmGas += ins.IssuanceInputs * baseP2WPKHVMGas
+
+	size += ins.Outputs * baseOutputSize
+
+	storageGas, ok := checked.MulInt64(size, consensus.StorageGasRate)
+	if !ok {
+		return NewErrorResponse(errors.New("calculate txsize gas got a math error"))
+	}
+
+	totalGas := storageGas + vmGas + flexibleGas
+	totalNeu := float64(totalGas*consensus.VMGasRate) / defaultBaseRate
+	estimateNeu := int64(math.Ceil(totalNeu)) * int64(defaultBaseRate)
+	if dynamicNeu := int64(txbuilder.EstimateFee(uint64(size), 1)); dynamicNeu > estimateNeu {
+		estimateNeu = dynamicNeu
+	}
+
+	return NewSuccessResponse(&EstimateTxSizeResp{
+		Size:       size,
+		TotalNeu:   estimateNeu,
+		StorageNeu: storageGas * consensus.VMGasRate,
+		VMNeu:      vmGas * consensus.VMGasRate,
+	})
+}
+
+// POST /list-script-templates
+func (a *API) listScriptTemplates() Response {
+	return NewSuccessResponse(vmutil.ListScriptTemplates())
+}
+
+// IssuanceRequest is one entry of a batch issuance: issue amount units of the
+// aliased asset to the destination address
+type IssuanceRequest struct {
+	AssetAlias string `json:"asset_alias"`
+	Amount     uint64 `json:"amount"`
+	Address    string `json:"address"`
+}
+
+// POST /issue-assets
+// build a single transaction issuing several assets at once, the account pays
+// the gas. The builder emits one signing instruction per input in input
+// order, so the issuance witnesses line up with their inputs like any
+// build-transaction result.
+func (a *API) issueAssets(ctx context.Context, ins struct {
+	AccountID string            `json:"account_id"`
+	Issuances []IssuanceRequest `json:"issuances"`
+	Fee       uint64            `json:"fee"`
+}) Response {
+	if len(ins.Issuances) == 0 {
+		return NewErrorResponse(errors.WithDetail(httpjson.ErrBadRequest, "issuances must not be empty"))
+	}
+
+	actions := []txbuilder.Action{}
+	for i, issuance := range ins.Issuances {
+		if issuance.Amount == 0 {
+			return NewErrorResponse(errors.WithDetailf(httpjson.ErrBadRequest, "zero amount on issuance %d", i))
+		}
+
+		ass, err := a.wallet.AssetReg.FindByAlias(strings.ToUpper(strings.TrimSpace(issuance.AssetAlias)))
+		if err != nil {
+			return NewErrorResponse(errors.WithDetailf(err, "on issuance %d", i))
+		}
+
+		assetID := ass.AssetID
+		actions = append(actions, a.wallet.AssetReg.NewIssueAction(bc.AssetAmount{AssetId: &assetID, Amount: issuance.Amount}))
+
+		controlData, err := json.Marshal(&struct {
+			bc.AssetAmount
+			Address string `json:"address"`
+		}{
+			AssetAmount: bc.AssetAmount{AssetId: &assetID, Amount: issuance.Amount},
+			Address:     issuance.Address,
+		})
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+
+		controlAction, err := txbuilder.DecodeControlAddressAction(controlData)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+		actions = append(actions, controlAction)
+	}
+
+	fee := ins.Fee
+	if fee == 0 {
+		// one gas input plus an issuance input and two outputs per entry
+		size := baseTxOverheadSize + baseSpendInputSize + int64(300) + baseOutputSize
+		size += int64(len(ins.Issuances)) * (baseIssuanceSize + int64(300) + baseOutputSize)
+		fee = txbuilder.EstimateFee(uint64(size), 1)
+	}
+
+	feeData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		AccountID string `json:"account_id"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: consensus.BTMAssetID, Amount: fee},
+		AccountID:   ins.AccountID,
+	})
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	feeAction, err := a.wallet.AccountMgr.DecodeSpendAction(feeData)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	actions = append(actions, feeAction)
+
+	tpl, err := txbuilder.Build(ctx, nil, actions, time.Now().Add(defaultTxTTL), 0)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(tpl)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+
+	"github.com/bytom-gm/blockchain/query"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// errNoTxIndex means the node runs without the optional transaction index
+var errNoTxIndex = errors.New("transaction index is not enabled, restart with tx_index set")
+
+// lookupIndexedTx resolves a transaction id through the txindex to the
+// containing block, the transaction itself and its status
+func (a *API) lookupIndexedTx(txID string) (*types.Block, *types.Tx, bool, uint32, error) {
+	var hash bc.Hash
+	if err := hash.UnmarshalText([]byte(txID)); err != nil {
+		return nil, nil, false, 0, err
+	}
+
+	entry, err := a.txIndex.Get(&hash)
+	if err != nil {
+		return nil, nil, false, 0, err
+	}
+
+	block, err := a.chain.GetBlockByHash(&entry.BlockHash)
+	if err != nil {
+		return nil, nil, false, 0, err
+	}
+	if int(entry.Position) >= len(block.Transactions) {
+		return nil, nil, false, 0, errors.New("transaction index entry out of block range")
+	}
+
+	txStatus, err := a.chain.GetTransactionStatus(&entry.BlockHash)
+	if err != nil {
+		return nil, nil, false, 0, err
+	}
+	statusFail, err := txStatus.GetStatus(int(entry.Position))
+	if err != nil {
+		return nil, nil, false, 0, err
+	}
+	return block, block.Transactions[entry.Position], statusFail, entry.Position, nil
+}
+
+// getIndexedAnnotatedTx is the txindex fallback of get-transaction for
+// transactions the wallet does not track
+func (a *API) getIndexedAnnotatedTx(txID string) (*query.AnnotatedTx, error) {
+	if a.txIndex == nil {
+		return nil, errNoTxIndex
+	}
+
+	block, tx, statusFail, position, err := a.lookupIndexedTx(txID)
+	if err != nil {
+		return nil, err
+	}
+	return a.wallet.BuildAnnotatedTransaction(tx, block, statusFail, int(position)), nil
+}
+
+// RawTransactionResp carries one indexed transaction with its serialized
+// form and chain location
+type RawTransactionResp struct {
+	TxID           string    `json:"tx_id"`
+	RawTransaction *types.Tx `json:"raw_transaction"`
+	BlockHash      bc.Hash   `json:"block_hash"`
+	BlockHeight    uint64    `json:"block_height"`
+	Position       uint32    `json:"position"`
+	StatusFail     bool      `json:"status_fail"`
+}
+
+// POST /get-raw-transaction
+func (a *API) getRawTransaction(ctx context.Context, req struct {
+	TxID string `json:"tx_id"`
+}) Response {
+	if a.txIndex == nil {
+		return NewErrorResponse(errNoTxIndex)
+	}
+
+	block, tx, statusFail, position, err := a.lookupIndexedTx(req.TxID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewSuccessResponse(&RawTransactionResp{
+		TxID:           tx.ID.String(),
+		RawTransaction: tx,
+		BlockHash:      block.Hash(),
+		BlockHeight:    block.Height,
+		Position:       position,
+		StatusFail:     statusFail,
+	})
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/bytom-gm/errors"
+)
+
+var errUpdaterDisabled = errors.New("update check is disabled, enable it in the update config section")
+
+// POST /get-update-status
+func (a *API) getUpdateStatus() Response {
+	if a.updater == nil {
+		return NewErrorResponse(errUpdaterDisabled)
+	}
+	return NewSuccessResponse(a.updater.GetStatus())
+}
+
+// POST /stage-update
+func (a *API) stageUpdate() Response {
+	if a.updater == nil {
+		return NewErrorResponse(errUpdaterDisabled)
+	}
+
+	stagedPath, err := a.updater.Stage()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(struct {
+		StagedPath string `json:"staged_path"`
+	}{StagedPath: stagedPath})
+}
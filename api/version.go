@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/net/http/httpjson"
+)
+
+// supportedAPIVersions are the accepted route prefixes; both currently
+// serve the same surface, the prefix gives client SDKs a stable base path
+// across releases
+var supportedAPIVersions = []string{"v1", "v2"}
+
+// errBadAPIVersion means a versioned route prefix the node does not speak
+var errBadAPIVersion = errors.New("unsupported api version")
+
+// splitAPIVersion peels a version segment like /v1 off the path, the
+// version is empty for unversioned paths
+func splitAPIVersion(path string) (version, rest string) {
+	trimmed := strings.TrimPrefix(path, "/")
+	idx := strings.Index(trimmed, "/")
+	if idx < 0 {
+		idx = len(trimmed)
+	}
+
+	segment := trimmed[:idx]
+	if len(segment) < 2 || segment[0] != 'v' {
+		return "", path
+	}
+	for _, c := range segment[1:] {
+		if c < '0' || c > '9' {
+			return "", path
+		}
+	}
+
+	rest = trimmed[idx:]
+	if rest == "" {
+		rest = "/"
+	}
+	return segment, rest
+}
+
+func isSupportedAPIVersion(version string) bool {
+	for _, supported := range supportedAPIVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// versionHandler strips a supported version prefix off the request path and
+// rejects unknown versions with a structured error
+func versionHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		version, rest := splitAPIVersion(req.URL.Path)
+		if version == "" {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		if !isSupportedAPIVersion(version) {
+			err := errors.WithDetailf(errBadAPIVersion, "version %q, supported: %s", version, strings.Join(supportedAPIVersions, ", "))
+			httpjson.Write(req.Context(), rw, http.StatusBadRequest, FormatErrResp(err))
+			return
+		}
+
+		req.URL.Path = rest
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// POST /get-api-versions
+func (a *API) getAPIVersions(ctx context.Context) Response {
+	return NewSuccessResponse(map[string]interface{}{
+		"versions": supportedAPIVersions,
+		"default":  supportedAPIVersions[len(supportedAPIVersions)-1],
+	})
+}
+
+// ErrorCodeInfo is one entry of the machine-readable error code catalogue
+type ErrorCodeInfo struct {
+	Code       string `json:"code"`
+	HTTPStatus int    `json:"http_status"`
+	Message    string `json:"message"`
+}
+
+// POST /list-error-codes
+func (a *API) listErrorCodes(ctx context.Context) Response {
+	codes := []*ErrorCodeInfo{}
+	for _, info := range respErrFormatter {
+		codes = append(codes, &ErrorCodeInfo{
+			Code:       info.ChainCode,
+			HTTPStatus: info.HTTPStatus,
+			Message:    info.Message,
+		})
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return NewSuccessResponse(codes)
+}
@@ -0,0 +1,34 @@
+package api
+
+import "testing"
+
+func TestSplitAPIVersion(t *testing.T) {
+	cases := []struct {
+		path        string
+		wantVersion string
+		wantRest    string
+	}{
+		{path: "/v1/get-block", wantVersion: "v1", wantRest: "/get-block"},
+		{path: "/v2/list-accounts", wantVersion: "v2", wantRest: "/list-accounts"},
+		{path: "/v3/get-block", wantVersion: "v3", wantRest: "/get-block"},
+		{path: "/get-block", wantVersion: "", wantRest: "/get-block"},
+		{path: "/validate-address", wantVersion: "", wantRest: "/validate-address"},
+		{path: "/verify-message", wantVersion: "", wantRest: "/verify-message"},
+		{path: "/v1", wantVersion: "v1", wantRest: "/"},
+		{path: "/", wantVersion: "", wantRest: "/"},
+	}
+
+	for _, c := range cases {
+		version, rest := splitAPIVersion(c.path)
+		if version != c.wantVersion || rest != c.wantRest {
+			t.Errorf("splitAPIVersion(%q) = %q, %q want %q, %q", c.path, version, rest, c.wantVersion, c.wantRest)
+		}
+	}
+
+	if !isSupportedAPIVersion("v1") || !isSupportedAPIVersion("v2") {
+		t.Error("v1 and v2 must be supported")
+	}
+	if isSupportedAPIVersion("v3") {
+		t.Error("v3 must not be supported")
+	}
+}
@@ -1,12 +1,20 @@
 package api
 
 import (
+	"compress/gzip"
 	"context"
+	"io"
+	"net/http"
+	"strconv"
 
 	"github.com/bytom-gm/account"
 	"github.com/bytom-gm/asset"
 	"github.com/bytom-gm/blockchain/pseudohsm"
+	chainjson "github.com/bytom-gm/encoding/json"
 	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/net/http/httpjson"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/wallet"
 )
 
 // POST /wallet error
@@ -57,23 +65,250 @@ func (a *API) backupWalletImage() Response {
 	return NewSuccessResponse(image)
 }
 
-func (a *API) rescanWallet() Response {
-	a.wallet.RescanBlocks()
+// POST /backup-wallet-diff
+func (a *API) backupWalletDiff() Response {
+	image, err := a.wallet.BackupDiff()
+	if err != nil {
+		return NewErrorResponse(errors.Wrap(err, "backup wallet diff"))
+	}
+	return NewSuccessResponse(image)
+}
+
+// POST /restore-wallet-diff
+func (a *API) restoreWalletDiff(ctx context.Context, image wallet.DiffImage) Response {
+	if err := a.wallet.RestoreDiff(&image); err != nil {
+		return NewErrorResponse(errors.Wrap(err, "restore wallet diff"))
+	}
+
+	if image.BaseHeight == 0 {
+		a.wallet.RescanBlocks()
+	} else {
+		a.wallet.RescanBlocksFrom(image.BaseHeight)
+	}
 	return NewSuccessResponse(nil)
 }
 
+func (a *API) rescanWallet(ctx context.Context, ins struct {
+	StartHeight uint64   `json:"start_height"`
+	BlockHash   *bc.Hash `json:"block_hash"`
+}) Response {
+	startHeight := ins.StartHeight
+	if ins.BlockHash != nil {
+		block, err := a.chain.GetBlockByHash(ins.BlockHash)
+		if err != nil {
+			return NewErrorResponse(errors.Wrap(err, "get rescan start block"))
+		}
+		startHeight = block.Height
+	}
+
+	if startHeight == 0 {
+		a.wallet.RescanBlocks()
+	} else {
+		a.wallet.RescanBlocksFrom(startHeight)
+	}
+	return NewSuccessResponse(nil)
+}
+
+// ExportedTransactions hold the rendered transaction history document
+type ExportedTransactions struct {
+	Format string `json:"format"`
+	Data   string `json:"data"`
+}
+
+func (a *API) exportTransactions(ctx context.Context, ins struct {
+	AccountID string `json:"account_id"`
+	Format    string `json:"format"`
+}) Response {
+	data, err := a.wallet.ExportTransactions(ins.AccountID, ins.Format)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	format := ins.Format
+	if format == "" {
+		format = wallet.ExportFormatCSV
+	}
+	return NewSuccessResponse(&ExportedTransactions{Format: format, Data: data})
+}
+
 // WalletInfo return wallet information
 type WalletInfo struct {
-	BestBlockHeight uint64 `json:"best_block_height"`
-	WalletHeight    uint64 `json:"wallet_height"`
+	BestBlockHeight uint64  `json:"best_block_height"`
+	WalletHeight    uint64  `json:"wallet_height"`
+	ScanProgress    float64 `json:"scan_progress"`
 }
 
 func (a *API) getWalletInfo() Response {
 	bestBlockHeight := a.chain.BestBlockHeight()
 	walletStatus := a.wallet.GetWalletStatusInfo()
 
+	scanProgress := float64(1)
+	if bestBlockHeight > 0 && walletStatus.WorkHeight < bestBlockHeight {
+		scanProgress = float64(walletStatus.WorkHeight) / float64(bestBlockHeight)
+	}
+
 	return NewSuccessResponse(&WalletInfo{
 		BestBlockHeight: bestBlockHeight,
 		WalletHeight:    walletStatus.WorkHeight,
+		ScanProgress:    scanProgress,
+	})
+}
+
+// POST /wallet-audit
+func (a *API) walletAudit() Response {
+	report, err := a.wallet.AuditAccountUtxos()
+	if err != nil {
+		return NewErrorResponse(errors.Wrap(err, "audit wallet"))
+	}
+	return NewSuccessResponse(report)
+}
+
+// DustUTXOsResp summarize the fragmented outputs of an account
+type DustUTXOsResp struct {
+	DustThreshold uint64          `json:"dust_threshold"`
+	TotalAmount   uint64          `json:"total_amount"`
+	Utxos         []*account.UTXO `json:"utxos"`
+}
+
+// POST /list-dust-utxos
+func (a *API) listDustUTXOs(ctx context.Context, ins struct {
+	AccountID     string `json:"account_id"`
+	DustThreshold uint64 `json:"dust_threshold"`
+}) Response {
+	utxos, err := a.wallet.ListDustUTXOs(ins.AccountID, ins.DustThreshold)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	totalAmount := uint64(0)
+	for _, utxo := range utxos {
+		totalAmount += utxo.Amount
+	}
+	return NewSuccessResponse(&DustUTXOsResp{
+		DustThreshold: wallet.DustThreshold(ins.DustThreshold),
+		TotalAmount:   totalAmount,
+		Utxos:         utxos,
 	})
 }
+
+// POST /build-consolidation
+// the returned template goes through sign-transaction, then either
+// submit-transaction or schedule-transaction for low fee periods
+func (a *API) buildConsolidation(ctx context.Context, ins struct {
+	AccountID     string `json:"account_id"`
+	DustThreshold uint64 `json:"dust_threshold"`
+	FeeBudget     uint64 `json:"fee_budget"`
+	MaxInputs     int    `json:"max_inputs"`
+}) Response {
+	tpl, err := a.wallet.BuildConsolidationTx(ctx, ins.AccountID, ins.DustThreshold, ins.FeeBudget, ins.MaxInputs)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(tpl)
+}
+
+// POST /build-send-all
+// sweep every spendable utxo of the asset to the address, for BTM the fee
+// comes out of the swept amount so the transaction has no change
+func (a *API) buildSendAll(ctx context.Context, ins struct {
+	AccountID string     `json:"account_id"`
+	AssetID   bc.AssetID `json:"asset_id"`
+	Address   string     `json:"address"`
+}) Response {
+	tpl, err := a.wallet.BuildSendAllTx(ctx, ins.AccountID, ins.AssetID, ins.Address)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(tpl)
+}
+
+// POST /import-watch-addresses
+// importWatchAddresses bulk imports newline separated bech32 addresses from
+// the raw request body, gzip compressed when the Content-Encoding header says
+// so, and triggers one rescan from the optional start_height query parameter.
+// The streaming format keeps multi-million address migrations off the heap
+func (a *API) importWatchAddresses(rw http.ResponseWriter, req *http.Request) {
+	startHeight := uint64(0)
+	if raw := req.URL.Query().Get("start_height"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			httpjson.Write(req.Context(), rw, http.StatusOK, FormatErrResp(errors.WithDetail(httpjson.ErrBadRequest, "start_height must be a non-negative integer")))
+			return
+		}
+		startHeight = parsed
+	}
+
+	body := io.Reader(req.Body)
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(req.Body)
+		if err != nil {
+			httpjson.Write(req.Context(), rw, http.StatusOK, FormatErrResp(err))
+			return
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+
+	result, err := a.wallet.ImportWatchAddressStream(body, startHeight)
+	if err != nil {
+		httpjson.Write(req.Context(), rw, http.StatusOK, FormatErrResp(err))
+		return
+	}
+	httpjson.Write(req.Context(), rw, http.StatusOK, NewSuccessResponse(result))
+}
+
+// POST /import-watch-program
+func (a *API) importWatchProgram(ctx context.Context, ins struct {
+	Alias   string             `json:"alias"`
+	Address string             `json:"address"`
+	Program chainjson.HexBytes `json:"program"`
+}) Response {
+	watched, err := a.wallet.ImportWatchProgram(ins.Alias, ins.Address, ins.Program)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(watched)
+}
+
+// POST /list-watch-programs
+func (a *API) listWatchPrograms(ctx context.Context) Response {
+	watched, err := a.wallet.ListWatchPrograms()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(watched)
+}
+
+// POST /delete-watch-program
+func (a *API) deleteWatchProgram(ctx context.Context, ins struct {
+	Program chainjson.HexBytes `json:"program"`
+}) Response {
+	if err := a.wallet.DeleteWatchProgram(ins.Program); err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(nil)
+}
+
+// POST /list-unique-assets
+func (a *API) listUniqueAssets(ctx context.Context, ins struct {
+	AccountID string `json:"account_id"`
+}) Response {
+	tokens, err := a.wallet.ListUniqueAssets(ins.AccountID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(tokens)
+}
+
+// POST /transfer-unique-asset
+func (a *API) transferUniqueAsset(ctx context.Context, ins struct {
+	AccountID string     `json:"account_id"`
+	AssetID   bc.AssetID `json:"asset_id"`
+	Address   string     `json:"address"`
+}) Response {
+	tpl, err := a.wallet.TransferUniqueAsset(ctx, ins.AccountID, ins.AssetID, ins.Address)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewSuccessResponse(tpl)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+
+	"github.com/bytom-gm/wallet"
+)
+
+// walletEventsHandler upgrades the connection to a websocket and streams
+// wallet events. The client sends one EventFilter JSON object first, empty
+// filter fields match everything.
+func (a *API) walletEventsHandler() http.Handler {
+	return websocket.Handler(func(ws *websocket.Conn) {
+		filter := &wallet.EventFilter{}
+		if err := websocket.JSON.Receive(ws, filter); err != nil {
+			log.WithField("err", err).Warning("websocket fail on receive event filter")
+			return
+		}
+
+		ch := a.wallet.Hub.Subscribe(filter)
+		defer a.wallet.Hub.Unsubscribe(ch)
+
+		for event := range ch {
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// workNotifyHandler upgrades the connection to a websocket and pushes the
+// work template whenever a chain or mempool event regenerates it, so miners
+// don't poll on a timer
+func (a *API) workNotifyHandler() http.Handler {
+	return websocket.Handler(func(ws *websocket.Conn) {
+		for {
+			waiter := a.miningPool.WorkWaiter()
+			work, err := a.GetWorkJSON()
+			if err != nil {
+				log.WithField("err", err).Warning("websocket fail on get work")
+			} else if err := websocket.JSON.Send(ws, work); err != nil {
+				return
+			}
+			<-waiter
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package asset
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+var aliasHistoryPrefix = []byte("AssetAliasHistory:")
+
+// alias change sources recorded in the history
+const (
+	// AliasSourceRename marks an alias replaced through update-asset-alias
+	AliasSourceRename = "rename"
+	// AliasSourceImport marks an alias that lost a conflict during a wallet
+	// image restore
+	AliasSourceImport = "import_conflict"
+)
+
+// ErrReservedAlias means the alias collides with a reserved prefix from the
+// node configuration
+var ErrReservedAlias = errors.New("asset alias uses a reserved prefix")
+
+func aliasHistoryKey(id *bc.AssetID) []byte {
+	return append(aliasHistoryPrefix, id.Bytes()...)
+}
+
+// AliasChange is one retired alias of an asset: what it was, when it was
+// replaced and why
+type AliasChange struct {
+	Alias      string `json:"alias"`
+	ReplacedAt uint64 `json:"replaced_at"`
+	Source     string `json:"source"`
+}
+
+// checkReservedAlias reject aliases matching a reserved prefix from the node
+// config, the comparison is case insensitive like alias normalization
+func checkReservedAlias(alias string) error {
+	if cfg.CommonConfig == nil || cfg.CommonConfig.Wallet == nil {
+		return nil
+	}
+
+	for _, prefix := range cfg.CommonConfig.Wallet.ReservedAssetPrefixes {
+		if strings.HasPrefix(alias, strings.ToUpper(strings.TrimSpace(prefix))) {
+			return errors.WithDetailf(ErrReservedAlias, "prefix: %s", prefix)
+		}
+	}
+	return nil
+}
+
+// appendAliasHistory record a retired alias of the asset into the batch
+func appendAliasHistory(db dbm.DB, batch dbm.Batch, id *bc.AssetID, alias, source string) {
+	history := getAliasHistory(db, id)
+	history = append(history, &AliasChange{
+		Alias:      alias,
+		ReplacedAt: uint64(time.Now().Unix()),
+		Source:     source,
+	})
+
+	if rawHistory, err := json.Marshal(history); err == nil {
+		batch.Set(aliasHistoryKey(id), rawHistory)
+	}
+}
+
+func getAliasHistory(db dbm.DB, id *bc.AssetID) []*AliasChange {
+	history := []*AliasChange{}
+	if rawHistory := db.Get(aliasHistoryKey(id)); rawHistory != nil {
+		json.Unmarshal(rawHistory, &history)
+	}
+	return history
+}
+
+// GetAliasHistory return the retired aliases of the asset, oldest first
+func (reg *Registry) GetAliasHistory(id *bc.AssetID) []*AliasChange {
+	return getAliasHistory(reg.db, id)
+}
+
+// deconflictAlias derive a free alias for an imported asset whose alias is
+// already taken, by suffixing a short form of the asset id
+func (reg *Registry) deconflictAlias(alias string, id *bc.AssetID) string {
+	suffixed := alias + "~" + strings.ToUpper(id.String()[:8])
+	if existed := reg.db.Get(aliasKey(suffixed)); existed == nil {
+		return suffixed
+	}
+	// the full id is unique by construction
+	return alias + "~" + strings.ToUpper(id.String())
+}
@@ -0,0 +1,53 @@
+package asset
+
+import (
+	"testing"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/crypto/sm2/chainkd"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/testutil"
+)
+
+func TestAliasHistoryOnRename(t *testing.T) {
+	reg := mockNewRegistry(t)
+
+	asset, err := reg.Define([]chainkd.XPub{testutil.TestXPub}, 1, nil, "FIRST_NAME", nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	if err := reg.UpdateAssetAlias(asset.AssetID.String(), "SECOND_NAME"); err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	history := reg.GetAliasHistory(&asset.AssetID)
+	if len(history) != 1 {
+		t.Fatalf("got %d history entries, want 1", len(history))
+	}
+	if history[0].Alias != "FIRST_NAME" || history[0].Source != AliasSourceRename {
+		t.Errorf("got history entry %+v, want FIRST_NAME retired by rename", history[0])
+	}
+}
+
+func TestReservedAliasPrefix(t *testing.T) {
+	reg := mockNewRegistry(t)
+
+	oldConfig := cfg.CommonConfig
+	cfg.CommonConfig = cfg.DefaultConfig()
+	cfg.CommonConfig.Wallet.ReservedAssetPrefixes = []string{"SYS_"}
+	defer func() { cfg.CommonConfig = oldConfig }()
+
+	if _, err := reg.Define([]chainkd.XPub{testutil.TestXPub}, 1, nil, "SYS_GOLD", nil); errors.Root(err) != ErrReservedAlias {
+		t.Errorf("got error %v, want ErrReservedAlias", err)
+	}
+
+	asset, err := reg.Define([]chainkd.XPub{testutil.TestXPub}, 1, nil, "GOLD", nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	if err := reg.UpdateAssetAlias(asset.AssetID.String(), "sys_gold"); errors.Root(err) != ErrReservedAlias {
+		t.Errorf("got error %v, want ErrReservedAlias on rename", err)
+	}
+}
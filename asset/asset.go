@@ -110,6 +110,7 @@ type Asset struct {
 	IssuanceProgram   chainjson.HexBytes     `json:"issue_program"`
 	RawDefinitionByte chainjson.HexBytes     `json:"raw_definition_byte"`
 	DefinitionMap     map[string]interface{} `json:"definition"`
+	MaxSupply         uint64                 `json:"max_supply,omitempty"`
 }
 
 func (reg *Registry) getNextAssetIndex() uint64 {
@@ -139,6 +140,10 @@ func (reg *Registry) Define(xpubs []chainkd.XPub, quorum int, definition map[str
 		return nil, ErrInternalAsset
 	}
 
+	if err := checkReservedAlias(alias); err != nil {
+		return nil, err
+	}
+
 	rawDefinition, err := serializeAssetDef(definition)
 	if err != nil {
 		return nil, ErrSerializing
@@ -385,6 +390,10 @@ func (reg *Registry) UpdateAssetAlias(id, newAlias string) error {
 		return ErrNullAlias
 	}
 
+	if err := checkReservedAlias(newAlias); err != nil {
+		return err
+	}
+
 	reg.assetMu.Lock()
 	defer reg.assetMu.Unlock()
 
@@ -408,6 +417,7 @@ func (reg *Registry) UpdateAssetAlias(id, newAlias string) error {
 	storeBatch.Set(Key(assetID), rawAsset)
 	storeBatch.Set(aliasKey(newAlias), []byte(assetID.String()))
 	storeBatch.Delete(aliasKey(oldAlias))
+	appendAliasHistory(reg.db, storeBatch, assetID, oldAlias, AliasSourceRename)
 	storeBatch.Write()
 
 	reg.cacheMu.Lock()
@@ -10,6 +10,8 @@ import (
 
 	"github.com/bytom-gm/blockchain/signers"
 	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/errors"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
 )
@@ -45,9 +47,24 @@ func (a *issueAction) Build(ctx context.Context, builder *txbuilder.TemplateBuil
 		return err
 	}
 
+	if asset.MaxSupply > 0 {
+		remaining := uint64(0)
+		if issued := a.assets.IssuedAmount(a.AssetId); issued < asset.MaxSupply {
+			remaining = asset.MaxSupply - issued
+		}
+		if a.Amount > remaining {
+			return errors.WithDetailf(ErrExceedMaxSupply, "remaining mintable amount: %d", remaining)
+		}
+	}
+
 	var nonce [8]byte
-	_, err = rand.Read(nonce[:])
-	if err != nil {
+	if asset.IsUnique() {
+		// bind the one-off issuance to a nonce derived from the issuance
+		// program, a second mint attempt produces the same issuance entry
+		// instead of a fresh supply
+		progHash := sm3.Sum256(asset.IssuanceProgram)
+		copy(nonce[:], progHash[:8])
+	} else if _, err = rand.Read(nonce[:]); err != nil {
 		return err
 	}
 
@@ -0,0 +1,71 @@
+package asset
+
+import (
+	"encoding/json"
+
+	"github.com/bytom-gm/crypto/sm3"
+	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+var defAnchorPrefix = []byte("AssetDefAnchor:")
+
+// ErrNoDefinitionAnchor means no definition is known for the asset
+var ErrNoDefinitionAnchor = errors.New("no anchored definition for asset")
+
+// DefinitionAnchorKey store the on-chain definition anchor of an asset
+func DefinitionAnchorKey(id *bc.AssetID) []byte {
+	return append(defAnchorPrefix, id.Bytes()...)
+}
+
+// DefinitionAnchor records where an asset definition was committed on chain
+// together with everything needed to re-derive the asset id from it. The
+// asset id itself is the anchor: it commits to the definition hash and the
+// issuance program, so a verified anchor cannot be forged
+type DefinitionAnchor struct {
+	AssetID         bc.AssetID         `json:"asset_id"`
+	DefinitionHash  bc.Hash            `json:"definition_hash"`
+	RawDefinition   chainjson.HexBytes `json:"raw_definition"`
+	IssuanceProgram chainjson.HexBytes `json:"issuance_program"`
+	VMVersion       uint64             `json:"vm_version"`
+	BlockHeight     uint64             `json:"block_height"`
+	TxID            bc.Hash            `json:"tx_id"`
+}
+
+// Verify recompute the definition hash and asset id from the anchored raw
+// definition, both must match for the definition to be trustworthy
+func (anchor *DefinitionAnchor) Verify() bool {
+	defHash := bc.NewHash(sm3.Sum256(anchor.RawDefinition))
+	if defHash != anchor.DefinitionHash {
+		return false
+	}
+	return bc.ComputeAssetID(anchor.IssuanceProgram, anchor.VMVersion, &defHash) == anchor.AssetID
+}
+
+// GetDefinitionAnchor look up the indexed anchor of an asset. Locally defined
+// assets that were never issued have no on-chain anchor yet, for those the
+// anchor is rebuilt from the registry record with a zero block height
+func (reg *Registry) GetDefinitionAnchor(id *bc.AssetID) (*DefinitionAnchor, error) {
+	if rawAnchor := reg.db.Get(DefinitionAnchorKey(id)); rawAnchor != nil {
+		anchor := &DefinitionAnchor{}
+		if err := json.Unmarshal(rawAnchor, anchor); err != nil {
+			return nil, err
+		}
+		return anchor, nil
+	}
+
+	localAsset, err := reg.FindByID(nil, id)
+	if err != nil {
+		return nil, errors.Sub(ErrNoDefinitionAnchor, err)
+	}
+
+	defHash := bc.NewHash(sm3.Sum256(localAsset.RawDefinitionByte))
+	return &DefinitionAnchor{
+		AssetID:         *id,
+		DefinitionHash:  defHash,
+		RawDefinition:   localAsset.RawDefinitionByte,
+		IssuanceProgram: localAsset.IssuanceProgram,
+		VMVersion:       localAsset.VMVersion,
+	}, nil
+}
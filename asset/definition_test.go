@@ -0,0 +1,36 @@
+package asset
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/crypto/sm2/chainkd"
+	"github.com/bytom-gm/testutil"
+)
+
+func TestDefinitionAnchorVerify(t *testing.T) {
+	reg := mockNewRegistry(t)
+	asset, err := reg.Define([]chainkd.XPub{testutil.TestXPub}, 1, map[string]interface{}{"symbol": "TEST", "decimals": 8}, "anchored", nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	anchor, err := reg.GetDefinitionAnchor(&asset.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !anchor.Verify() {
+		t.Error("anchor of a locally defined asset should verify")
+	}
+
+	anchor.RawDefinition = append(anchor.RawDefinition, ' ')
+	if anchor.Verify() {
+		t.Error("tampered definition should not verify")
+	}
+}
+
+func TestGetDefinitionAnchorNotFound(t *testing.T) {
+	reg := mockNewRegistry(t)
+	if _, err := reg.GetDefinitionAnchor(&DefaultNativeAsset.AssetID); err == nil {
+		t.Error("expected error for asset without anchored definition")
+	}
+}
@@ -38,11 +38,23 @@ func (reg *Registry) Restore(image *Image) error {
 	storeBatch := reg.db.NewBatch()
 	for _, asset := range image.Assets {
 		if existed := reg.db.Get(Key(&asset.AssetID)); existed != nil {
+			// the local alias wins, but retain the imported one in the alias
+			// history so the image's naming isn't silently lost
+			local := &Asset{}
+			if err := json.Unmarshal(existed, local); err == nil && local.Alias != nil && *local.Alias != *asset.Alias {
+				appendAliasHistory(reg.db, storeBatch, &asset.AssetID, *asset.Alias, AliasSourceImport)
+			}
 			log.WithFields(log.Fields{"alias": asset.Alias, "id": asset.AssetID}).Warning("skip restore asset due to already existed")
 			continue
 		}
 		if existed := reg.db.Get(aliasKey(*asset.Alias)); existed != nil {
-			return ErrDuplicateAlias
+			// the alias belongs to a different local asset, rename the
+			// imported one deterministically and keep its original alias in
+			// the history
+			deconflicted := reg.deconflictAlias(*asset.Alias, &asset.AssetID)
+			appendAliasHistory(reg.db, storeBatch, &asset.AssetID, *asset.Alias, AliasSourceImport)
+			log.WithFields(log.Fields{"alias": *asset.Alias, "renamed": deconflicted, "id": asset.AssetID}).Warning("restore asset alias conflicts, renamed")
+			asset.Alias = &deconflicted
 		}
 
 		rawAsset, err := json.Marshal(asset)
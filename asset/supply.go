@@ -0,0 +1,205 @@
+package asset
+
+import (
+	"math"
+	"strings"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/blockchain/signers"
+	"github.com/bytom-gm/common"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto/sm2"
+	"github.com/bytom-gm/crypto/sm2/chainkd"
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/vm"
+	"github.com/bytom-gm/protocol/vm/vmutil"
+)
+
+var (
+	issuedPrefix  = []byte("AssetIssued:")
+	retiredPrefix = []byte("AssetRetired:")
+)
+
+// pre-define capped supply errors
+var (
+	ErrBadMaxSupply    = errors.New("bad asset max supply")
+	ErrExceedMaxSupply = errors.New("issuance exceeds the asset max supply")
+)
+
+// IssuedAmountKey store the cumulative issued amount of an asset
+func IssuedAmountKey(id *bc.AssetID) []byte {
+	return append(issuedPrefix, id.Bytes()...)
+}
+
+// AddIssuance accumulate the issued counter of an asset, called when a block
+// carrying an issuance is attached
+func AddIssuance(db dbm.DB, id *bc.AssetID, amount uint64) {
+	issued := GetIssuedAmount(db, id)
+	db.Set(IssuedAmountKey(id), common.Unit64ToBytes(issued+amount))
+}
+
+// SubIssuance roll back the issued counter of an asset, called when a block
+// carrying an issuance is detached
+func SubIssuance(db dbm.DB, id *bc.AssetID, amount uint64) {
+	issued := GetIssuedAmount(db, id)
+	if amount > issued {
+		amount = issued
+	}
+	db.Set(IssuedAmountKey(id), common.Unit64ToBytes(issued-amount))
+}
+
+// GetIssuedAmount return the cumulative issued amount of an asset
+func GetIssuedAmount(db dbm.DB, id *bc.AssetID) uint64 {
+	if raw := db.Get(IssuedAmountKey(id)); raw != nil {
+		return common.BytesToUnit64(raw)
+	}
+	return 0
+}
+
+// IssuedAmount return the cumulative issued amount observed on chain
+func (reg *Registry) IssuedAmount(id *bc.AssetID) uint64 {
+	return GetIssuedAmount(reg.db, id)
+}
+
+// RetiredAmountKey store the cumulative retired amount of an asset
+func RetiredAmountKey(id *bc.AssetID) []byte {
+	return append(retiredPrefix, id.Bytes()...)
+}
+
+// AddRetirement accumulate the retired counter of an asset, called when a
+// block carrying an unspendable output is attached
+func AddRetirement(db dbm.DB, id *bc.AssetID, amount uint64) {
+	retired := GetRetiredAmount(db, id)
+	db.Set(RetiredAmountKey(id), common.Unit64ToBytes(retired+amount))
+}
+
+// SubRetirement roll back the retired counter of an asset, called when a
+// block carrying an unspendable output is detached
+func SubRetirement(db dbm.DB, id *bc.AssetID, amount uint64) {
+	retired := GetRetiredAmount(db, id)
+	if amount > retired {
+		amount = retired
+	}
+	db.Set(RetiredAmountKey(id), common.Unit64ToBytes(retired-amount))
+}
+
+// GetRetiredAmount return the cumulative retired amount of an asset
+func GetRetiredAmount(db dbm.DB, id *bc.AssetID) uint64 {
+	if raw := db.Get(RetiredAmountKey(id)); raw != nil {
+		return common.BytesToUnit64(raw)
+	}
+	return 0
+}
+
+// RetiredAmount return the cumulative retired amount observed on chain
+func (reg *Registry) RetiredAmount(id *bc.AssetID) uint64 {
+	return GetRetiredAmount(reg.db, id)
+}
+
+// DefineCapped defines a new asset with a declared maximum supply. The cap is
+// written into the definition so the asset id commits to it, the issuance
+// program bounds every single issuance by the cap in the VM, and the registry
+// tracks the cumulative issued counter to refuse minting past the remainder
+func (reg *Registry) DefineCapped(xpubs []chainkd.XPub, quorum int, definition map[string]interface{}, alias string, maxSupply uint64) (*Asset, error) {
+	if maxSupply == 0 || maxSupply > math.MaxInt64 {
+		return nil, ErrBadMaxSupply
+	}
+
+	alias = strings.ToUpper(strings.TrimSpace(alias))
+	if alias == "" {
+		return nil, errors.Wrap(ErrNullAlias)
+	}
+
+	if alias == consensus.BTMAlias {
+		return nil, ErrInternalAsset
+	}
+
+	if err := checkReservedAlias(alias); err != nil {
+		return nil, err
+	}
+
+	if len(xpubs) == 0 {
+		return nil, errors.Wrap(signers.ErrNoXPubs)
+	}
+
+	if definition == nil {
+		definition = map[string]interface{}{}
+	}
+	definition["max_supply"] = maxSupply
+
+	rawDefinition, err := serializeAssetDef(definition)
+	if err != nil {
+		return nil, ErrSerializing
+	}
+
+	nextAssetIndex := reg.getNextAssetIndex()
+	assetSigner, err := signers.Create("asset", xpubs, quorum, nextAssetIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	path := signers.Path(assetSigner, signers.AssetKeySpace)
+	derivedXPubs := chainkd.DeriveXPubs(assetSigner.XPubs, path)
+	derivedPKs := chainkd.XPubKeys(derivedXPubs)
+	issuanceProgram, vmver, err := cappedIssuanceProgram(derivedPKs, assetSigner.Quorum, int64(maxSupply))
+	if err != nil {
+		return nil, err
+	}
+
+	defHash := bc.NewHash(sm3.Sum256(rawDefinition))
+	a := &Asset{
+		DefinitionMap:     definition,
+		RawDefinitionByte: rawDefinition,
+		VMVersion:         vmver,
+		IssuanceProgram:   issuanceProgram,
+		AssetID:           bc.ComputeAssetID(issuanceProgram, vmver, &defHash),
+		Signer:            assetSigner,
+		Alias:             &alias,
+		MaxSupply:         maxSupply,
+	}
+	return a, reg.SaveAsset(a, alias)
+}
+
+// cappedIssuanceProgram prefixes the regular multisig issuance program with a
+// VM enforced bound on the issued amount
+func cappedIssuanceProgram(pubkeys []sm2.PubKey, nrequired int, maxSupply int64) (program []byte, vmversion uint64, err error) {
+	issuanceProg, err := vmutil.P2SPMultiSigProgram(pubkeys, nrequired)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	builder := vmutil.NewBuilder()
+	builder.AddOp(vm.OP_AMOUNT)
+	builder.AddInt64(maxSupply)
+	builder.AddOp(vm.OP_LESSTHANOREQUAL)
+	builder.AddOp(vm.OP_VERIFY)
+	builder.AddRawBytes(issuanceProg)
+	prog, err := builder.Build()
+	return prog, 1, err
+}
+
+// DefineUnique defines a one-off asset: a capped supply of one enforced the
+// same way as any capped asset, with a uniqueness marker committed in the
+// definition. The issue action additionally binds the single issuance to a
+// deterministic nonce derived from the issuance program
+func (reg *Registry) DefineUnique(xpubs []chainkd.XPub, quorum int, definition map[string]interface{}, alias string) (*Asset, error) {
+	if definition == nil {
+		definition = map[string]interface{}{}
+	}
+	definition["unique"] = true
+	return reg.DefineCapped(xpubs, quorum, definition, alias, 1)
+}
+
+// IsUnique report whether the asset is a one-off token, either defined
+// locally with a supply of one or observed on chain with a unique marker in
+// its anchored definition
+func (a *Asset) IsUnique() bool {
+	if a.MaxSupply == 1 {
+		return true
+	}
+	unique, ok := a.DefinitionMap["unique"].(bool)
+	return ok && unique
+}
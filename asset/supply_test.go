@@ -0,0 +1,86 @@
+package asset
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/crypto/sm2/chainkd"
+	"github.com/bytom-gm/testutil"
+)
+
+func TestDefineCapped(t *testing.T) {
+	reg := mockNewRegistry(t)
+	asset, err := reg.DefineCapped([]chainkd.XPub{testutil.TestXPub}, 1, nil, "capped", 1000000)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	if asset.MaxSupply != 1000000 {
+		t.Errorf("got max supply %d, want 1000000", asset.MaxSupply)
+	}
+	if _, ok := asset.DefinitionMap["max_supply"]; !ok {
+		t.Error("max supply should be committed in the asset definition")
+	}
+
+	found, err := reg.FindByID(nil, &asset.AssetID)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if found.MaxSupply != asset.MaxSupply {
+		t.Errorf("got stored max supply %d, want %d", found.MaxSupply, asset.MaxSupply)
+	}
+}
+
+func TestDefineCappedBadSupply(t *testing.T) {
+	reg := mockNewRegistry(t)
+	if _, err := reg.DefineCapped([]chainkd.XPub{testutil.TestXPub}, 1, nil, "capped", 0); err != ErrBadMaxSupply {
+		t.Errorf("got error %v, want %v", err, ErrBadMaxSupply)
+	}
+}
+
+func TestIssuedCounter(t *testing.T) {
+	reg := mockNewRegistry(t)
+	asset, err := reg.DefineCapped([]chainkd.XPub{testutil.TestXPub}, 1, nil, "counted", 1000)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	AddIssuance(reg.db, &asset.AssetID, 600)
+	AddIssuance(reg.db, &asset.AssetID, 150)
+	if issued := reg.IssuedAmount(&asset.AssetID); issued != 750 {
+		t.Errorf("got issued %d, want 750", issued)
+	}
+
+	SubIssuance(reg.db, &asset.AssetID, 150)
+	if issued := reg.IssuedAmount(&asset.AssetID); issued != 600 {
+		t.Errorf("got issued %d, want 600", issued)
+	}
+
+	SubIssuance(reg.db, &asset.AssetID, 10000)
+	if issued := reg.IssuedAmount(&asset.AssetID); issued != 0 {
+		t.Errorf("got issued %d, want 0", issued)
+	}
+}
+
+func TestRetiredCounter(t *testing.T) {
+	reg := mockNewRegistry(t)
+	asset, err := reg.DefineCapped([]chainkd.XPub{testutil.TestXPub}, 1, nil, "burned", 1000)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	AddRetirement(reg.db, &asset.AssetID, 200)
+	AddRetirement(reg.db, &asset.AssetID, 50)
+	if retired := reg.RetiredAmount(&asset.AssetID); retired != 250 {
+		t.Errorf("got retired %d, want 250", retired)
+	}
+
+	SubRetirement(reg.db, &asset.AssetID, 50)
+	if retired := reg.RetiredAmount(&asset.AssetID); retired != 200 {
+		t.Errorf("got retired %d, want 200", retired)
+	}
+
+	SubRetirement(reg.db, &asset.AssetID, 10000)
+	if retired := reg.RetiredAmount(&asset.AssetID); retired != 0 {
+		t.Errorf("got retired %d, want 0", retired)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pborman/uuid"
 
@@ -28,6 +29,17 @@ type HSM struct {
 	keyStore keyStore
 	cache    *keyCache
 	//kdCache  map[chainkd.XPub]chainkd.XPrv
+
+	// unlocked holds decrypted keys between Unlock and Lock or expiry, so
+	// interactive callers don't resend the passphrase on every signature
+	unlockMu sync.Mutex
+	unlocked map[chainkd.XPub]*unlockedKey
+}
+
+// unlockedKey is one cached decrypted key with its auto-lock deadline
+type unlockedKey struct {
+	xprv      chainkd.XPrv
+	expiresAt time.Time
 }
 
 // XPub type for pubkey for anyone can see
@@ -44,6 +56,7 @@ func New(keypath string) (*HSM, error) {
 		keyStore: &keyStorePassphrase{keydir, LightScryptN, LightScryptP},
 		cache:    newKeyCache(keydir),
 		//kdCache:  make(map[chainkd.XPub]chainkd.XPrv),
+		unlocked: make(map[chainkd.XPub]*unlockedKey),
 	}, nil
 }
 
@@ -152,21 +165,73 @@ func (h *HSM) XSign(xpub chainkd.XPub, path [][]byte, msg []byte, auth string) (
 
 //LoadChainKDKey get xprv from xpub
 func (h *HSM) LoadChainKDKey(xpub chainkd.XPub, auth string) (xprv chainkd.XPrv, err error) {
+	if xprv, ok := h.lookupUnlocked(xpub); ok {
+		return xprv, nil
+	}
+
 	h.cacheMu.Lock()
 	defer h.cacheMu.Unlock()
 
-	//if xprv, ok := h.kdCache[xpub]; ok {
-	//	return xprv, nil
-	//}
-
 	_, xkey, err := h.loadDecryptedKey(xpub, auth)
 	if err != nil {
 		return xprv, ErrLoadKey
 	}
-	//h.kdCache[xpb.XPub] = xkey.XPrv
 	return xkey.XPrv, nil
 }
 
+// Unlock decrypts the key and caches it for timeout, signing calls may then
+// pass an empty passphrase until Lock or expiry.
+func (h *HSM) Unlock(xpub chainkd.XPub, auth string, timeout time.Duration) error {
+	h.cacheMu.Lock()
+	_, xkey, err := h.loadDecryptedKey(xpub, auth)
+	h.cacheMu.Unlock()
+	if err != nil {
+		return ErrLoadKey
+	}
+
+	h.unlockMu.Lock()
+	h.unlocked[xpub] = &unlockedKey{xprv: xkey.XPrv, expiresAt: time.Now().Add(timeout)}
+	h.unlockMu.Unlock()
+	return nil
+}
+
+// Lock forgets the cached decrypted key of one xpub.
+func (h *HSM) Lock(xpub chainkd.XPub) {
+	h.unlockMu.Lock()
+	delete(h.unlocked, xpub)
+	h.unlockMu.Unlock()
+}
+
+// LockAll forgets every cached decrypted key.
+func (h *HSM) LockAll() {
+	h.unlockMu.Lock()
+	h.unlocked = make(map[chainkd.XPub]*unlockedKey)
+	h.unlockMu.Unlock()
+}
+
+// IsUnlocked reports whether the key is cached and not yet expired.
+func (h *HSM) IsUnlocked(xpub chainkd.XPub) bool {
+	_, ok := h.lookupUnlocked(xpub)
+	return ok
+}
+
+// lookupUnlocked returns the cached xprv when present and fresh, dropping
+// expired entries on the way
+func (h *HSM) lookupUnlocked(xpub chainkd.XPub) (chainkd.XPrv, bool) {
+	h.unlockMu.Lock()
+	defer h.unlockMu.Unlock()
+
+	key, ok := h.unlocked[xpub]
+	if !ok {
+		return chainkd.XPrv{}, false
+	}
+	if time.Now().After(key.expiresAt) {
+		delete(h.unlocked, xpub)
+		return chainkd.XPrv{}, false
+	}
+	return key.xprv, true
+}
+
 // XDelete deletes the key matched by xpub if the passphrase is correct.
 // If a contains no filename, the address must match a unique key.
 func (h *HSM) XDelete(xpub chainkd.XPub, auth string) error {
@@ -191,6 +256,10 @@ func (h *HSM) XDelete(xpub chainkd.XPub, auth string) error {
 		h.cache.delete(xpb)
 	}
 	h.cacheMu.Unlock()
+
+	if err == nil {
+		h.Lock(xpub)
+	}
 	return err
 }
 
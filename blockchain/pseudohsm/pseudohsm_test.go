@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bytom-gm/crypto/sm2"
 	"github.com/bytom-gm/errors"
@@ -202,3 +203,46 @@ func BenchmarkSign(b *testing.B) {
 		b.Fatal(err)
 	}
 }
+
+func TestUnlockAndLock(t *testing.T) {
+	hsm, _ := New(dirPath)
+	xpub, _, err := hsm.XCreate("test_unlock", "password", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hsm.XDelete(xpub.XPub, "password")
+
+	msg := []byte("unlock test")
+	if _, err := hsm.XSign(xpub.XPub, nil, msg, ""); err != ErrLoadKey {
+		t.Errorf("signing while locked with empty passphrase: err = %v want ErrLoadKey", err)
+	}
+
+	if err := hsm.Unlock(xpub.XPub, "badpassword", time.Minute); err != ErrLoadKey {
+		t.Errorf("unlock with wrong passphrase: err = %v want ErrLoadKey", err)
+	}
+	if err := hsm.Unlock(xpub.XPub, "password", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if !hsm.IsUnlocked(xpub.XPub) {
+		t.Error("key should report unlocked")
+	}
+	if _, err := hsm.XSign(xpub.XPub, nil, msg, ""); err != nil {
+		t.Errorf("signing while unlocked with empty passphrase: %v", err)
+	}
+
+	hsm.Lock(xpub.XPub)
+	if hsm.IsUnlocked(xpub.XPub) {
+		t.Error("key should report locked after Lock")
+	}
+	if _, err := hsm.XSign(xpub.XPub, nil, msg, ""); err != ErrLoadKey {
+		t.Errorf("signing after Lock with empty passphrase: err = %v want ErrLoadKey", err)
+	}
+
+	// the auto-lock deadline expires the cached key
+	if err := hsm.Unlock(xpub.XPub, "password", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if hsm.IsUnlocked(xpub.XPub) {
+		t.Error("key should report locked after the timeout passed")
+	}
+}
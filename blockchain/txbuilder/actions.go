@@ -7,6 +7,7 @@ import (
 
 	"github.com/bytom-gm/common"
 	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto/sm2"
 	"github.com/bytom-gm/encoding/json"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
@@ -102,6 +103,62 @@ func (a *controlProgramAction) ActionType() string {
 	return "control_program"
 }
 
+// DecodeControlTemplateAction convert input data to action struct
+func DecodeControlTemplateAction(data []byte) (Action, error) {
+	a := new(controlTemplateAction)
+	err := stdjson.Unmarshal(data, a)
+	return a, err
+}
+
+type controlTemplateAction struct {
+	bc.AssetAmount
+	TemplateID  string          `json:"template_id"`
+	Pubkeys     []json.HexBytes `json:"pubkeys"`
+	Quorum      int             `json:"quorum"`
+	Hash        json.HexBytes   `json:"hash"`
+	BlockHeight int64           `json:"block_height"`
+}
+
+func (a *controlTemplateAction) Build(ctx context.Context, b *TemplateBuilder) error {
+	var missing []string
+	if a.TemplateID == "" {
+		missing = append(missing, "template_id")
+	}
+	if a.AssetId.IsZero() {
+		missing = append(missing, "asset_id")
+	}
+	if a.Amount == 0 {
+		missing = append(missing, "amount")
+	}
+	if len(missing) > 0 {
+		return MissingFieldsError(missing...)
+	}
+
+	args := &vmutil.TemplateArgs{
+		Quorum:      a.Quorum,
+		Hash:        a.Hash,
+		BlockHeight: a.BlockHeight,
+	}
+	for _, pubkey := range a.Pubkeys {
+		if len(pubkey) != sm2.PubKeySize {
+			return errors.New("bad pubkey length in template parameters")
+		}
+		args.Pubkeys = append(args.Pubkeys, sm2.PubKey(pubkey))
+	}
+
+	program, err := vmutil.BuildTemplate(a.TemplateID, args)
+	if err != nil {
+		return err
+	}
+
+	out := types.NewTxOutput(*a.AssetId, a.Amount, program)
+	return b.AddOutput(out)
+}
+
+func (a *controlTemplateAction) ActionType() string {
+	return "control_template"
+}
+
 // DecodeRetireAction convert input data to action struct
 func DecodeRetireAction(data []byte) (Action, error) {
 	a := new(retireAction)
@@ -0,0 +1,48 @@
+package txbuilder
+
+import (
+	"github.com/bytom-gm/consensus"
+)
+
+// defaultConfirmTarget is the confirm target used when callers don't care
+const defaultConfirmTarget = uint64(1)
+
+// FeeEstimater provide dynamic fee rates observed from the mempool
+type FeeEstimater interface {
+	EstimateFeeRate(targetBlocks uint64) (uint64, error)
+}
+
+var feeEstimater FeeEstimater
+
+// SetFeeEstimater install the mempool backed fee estimater, it is called once
+// on node startup and replaces the static fee fallback for fee suggestions
+func SetFeeEstimater(fe FeeEstimater) {
+	feeEstimater = fe
+}
+
+// defaultFeeRate is the static per-byte fallback used before the estimater
+// has observed enough confirmations
+func defaultFeeRate() uint64 {
+	return uint64(consensus.StorageGasRate * consensus.VMGasRate)
+}
+
+// EstimateFeeRate return the recommended fee rate (neu per byte) for the
+// given confirm target, falling back to the static rate when the mempool
+// history is too thin
+func EstimateFeeRate(targetBlocks uint64) uint64 {
+	if targetBlocks == 0 {
+		targetBlocks = defaultConfirmTarget
+	}
+	if feeEstimater != nil {
+		if feeRate, err := feeEstimater.EstimateFeeRate(targetBlocks); err == nil && feeRate > defaultFeeRate() {
+			return feeRate
+		}
+	}
+	return defaultFeeRate()
+}
+
+// EstimateFee return the recommended fee for a transaction of the given
+// serialized size at the given confirm target
+func EstimateFee(txSize uint64, targetBlocks uint64) uint64 {
+	return txSize * EstimateFeeRate(targetBlocks)
+}
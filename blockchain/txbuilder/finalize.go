@@ -55,6 +55,8 @@ func FinalizeTx(ctx context.Context, c *protocol.Chain, tx *types.Tx) error {
 	if isOrphan {
 		return ErrOrphanTx
 	}
+
+	c.GetTxPool().MarkLocal(&tx.ID)
 	return nil
 }
 
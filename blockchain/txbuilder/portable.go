@@ -0,0 +1,73 @@
+package txbuilder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/bytom-gm/errors"
+)
+
+// PortableTemplateVersion is the current version of the portable
+// partially-signed template serialization. The version is bumped whenever the
+// wire layout of Template changes incompatibly, so an air-gapped signer can
+// refuse payloads it does not understand.
+const PortableTemplateVersion = 1
+
+var (
+	// ErrBadTemplatePayload means the portable template payload could not be decoded
+	ErrBadTemplatePayload = errors.New("malformed portable template payload")
+	// ErrTemplateVersion means the portable template was produced by an incompatible version
+	ErrTemplateVersion = errors.New("unsupported portable template version")
+)
+
+// portableTemplate is the stable envelope used to move a partially signed
+// Template between an online node and an offline signer
+type portableTemplate struct {
+	Version  int             `json:"version"`
+	Template json.RawMessage `json:"template"`
+}
+
+// ExportTemplate serialize the template (raw transaction, signing
+// instructions and partial witnesses) into a versioned base64 payload
+func ExportTemplate(tpl *Template) (string, error) {
+	rawTemplate, err := json.Marshal(tpl)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := json.Marshal(&portableTemplate{
+		Version:  PortableTemplateVersion,
+		Template: rawTemplate,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// ImportTemplate decode a payload produced by ExportTemplate, rejecting
+// payloads of an incompatible version
+func ImportTemplate(payload string) (*Template, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload))
+	if err != nil {
+		return nil, errors.Sub(ErrBadTemplatePayload, err)
+	}
+
+	envelope := &portableTemplate{}
+	if err := json.Unmarshal(raw, envelope); err != nil {
+		return nil, errors.Sub(ErrBadTemplatePayload, err)
+	}
+	if envelope.Version != PortableTemplateVersion {
+		return nil, errors.WithDetailf(ErrTemplateVersion, "payload version %d, supported version %d", envelope.Version, PortableTemplateVersion)
+	}
+
+	tpl := &Template{}
+	if err := json.Unmarshal(envelope.Template, tpl); err != nil {
+		return nil, errors.Sub(ErrBadTemplatePayload, err)
+	}
+	if tpl.Transaction == nil {
+		return nil, errors.WithDetail(ErrBadTemplatePayload, "missing raw transaction")
+	}
+	return tpl, nil
+}
@@ -0,0 +1,65 @@
+package txbuilder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/testutil"
+)
+
+func TestPortableTemplateRoundTrip(t *testing.T) {
+	assetID := bc.AssetID{V0: 1}
+	tpl := &Template{
+		Transaction: types.NewTx(types.TxData{
+			Version: 1,
+			Inputs: []*types.TxInput{
+				types.NewSpendInput(nil, bc.NewHash([32]byte{0xff}), assetID, 5, 0, nil),
+			},
+			Outputs: []*types.TxOutput{
+				types.NewTxOutput(assetID, 5, []byte("dest")),
+			},
+		}),
+		SigningInstructions: []*SigningInstruction{{Position: 0}},
+		AllowAdditional:     true,
+	}
+
+	payload, err := ExportTemplate(tpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ImportTemplate(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Transaction.ID != tpl.Transaction.ID {
+		t.Errorf("got tx id %s, want %s", got.Transaction.ID.String(), tpl.Transaction.ID.String())
+	}
+	if !testutil.DeepEqual(got.SigningInstructions, tpl.SigningInstructions) {
+		t.Errorf("got signing instructions %v, want %v", got.SigningInstructions, tpl.SigningInstructions)
+	}
+	if got.AllowAdditional != tpl.AllowAdditional {
+		t.Errorf("got allow additional %v, want %v", got.AllowAdditional, tpl.AllowAdditional)
+	}
+}
+
+func TestImportTemplateErrors(t *testing.T) {
+	if _, err := ImportTemplate("not base64!"); errors.Root(err) != ErrBadTemplatePayload {
+		t.Errorf("got error %v, want %v", err, ErrBadTemplatePayload)
+	}
+
+	raw, _ := json.Marshal(&portableTemplate{Version: PortableTemplateVersion + 1, Template: json.RawMessage("{}")})
+	if _, err := ImportTemplate(base64.StdEncoding.EncodeToString(raw)); errors.Root(err) != ErrTemplateVersion {
+		t.Errorf("got error %v, want %v", err, ErrTemplateVersion)
+	}
+
+	raw, _ = json.Marshal(&portableTemplate{Version: PortableTemplateVersion, Template: json.RawMessage("{}")})
+	if _, err := ImportTemplate(base64.StdEncoding.EncodeToString(raw)); errors.Root(err) != ErrBadTemplatePayload {
+		t.Errorf("got error %v, want %v", err, ErrBadTemplatePayload)
+	}
+}
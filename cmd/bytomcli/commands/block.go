@@ -19,13 +19,33 @@ func init() {
 
 	getHashRateCmd.PersistentFlags().StringVar(&blockHash, "hash", "", "hash of block")
 	getHashRateCmd.PersistentFlags().IntVar(&blockHeight, "height", 0, "height of block")
+
+	rollbackChainCmd.PersistentFlags().Uint64Var(&rollbackHeight, "height", 0, "height to roll the chain back to")
 }
 
 var (
-	blockHash   = ""
-	blockHeight = 0
+	blockHash      = ""
+	blockHeight    = 0
+	rollbackHeight = uint64(0)
 )
 
+var rollbackChainCmd = &cobra.Command{
+	Use:   "rollback-chain",
+	Short: "Roll the chain back to the given height, detaching the blocks above it",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		req := &struct {
+			Height uint64 `json:"height"`
+		}{Height: rollbackHeight}
+
+		data, exitCode := util.ClientCall("/rollback-chain", req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSON(data)
+	},
+}
+
 var getBlockHashCmd = &cobra.Command{
 	Use:   "get-block-hash",
 	Short: "Get the hash of most recent block",
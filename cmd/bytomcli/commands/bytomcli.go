@@ -126,6 +126,7 @@ func AddCommands() {
 
 	BytomcliCmd.AddCommand(getTransactionCmd)
 	BytomcliCmd.AddCommand(listTransactionsCmd)
+	BytomcliCmd.AddCommand(exportTransactionsCmd)
 
 	BytomcliCmd.AddCommand(getUnconfirmedTransactionCmd)
 	BytomcliCmd.AddCommand(listUnconfirmedTransactionsCmd)
@@ -140,6 +141,8 @@ func AddCommands() {
 	BytomcliCmd.AddCommand(buildTransactionCmd)
 	BytomcliCmd.AddCommand(signTransactionCmd)
 	BytomcliCmd.AddCommand(submitTransactionCmd)
+	BytomcliCmd.AddCommand(exportTemplateCmd)
+	BytomcliCmd.AddCommand(importTemplateCmd)
 	BytomcliCmd.AddCommand(estimateTransactionGasCmd)
 
 	BytomcliCmd.AddCommand(getBlockCountCmd)
@@ -148,6 +151,7 @@ func AddCommands() {
 	BytomcliCmd.AddCommand(getBlockHeaderCmd)
 	BytomcliCmd.AddCommand(getDifficultyCmd)
 	BytomcliCmd.AddCommand(getHashRateCmd)
+	BytomcliCmd.AddCommand(rollbackChainCmd)
 
 	BytomcliCmd.AddCommand(createKeyCmd)
 	BytomcliCmd.AddCommand(deleteKeyCmd)
@@ -169,6 +173,15 @@ func AddCommands() {
 	BytomcliCmd.AddCommand(setMiningCmd)
 
 	BytomcliCmd.AddCommand(netInfoCmd)
+	BytomcliCmd.AddCommand(listPeersCmd)
+	BytomcliCmd.AddCommand(connectPeerCmd)
+	BytomcliCmd.AddCommand(disconnectPeerCmd)
+	BytomcliCmd.AddCommand(banPeerCmd)
+	BytomcliCmd.AddCommand(unbanPeerCmd)
+	BytomcliCmd.AddCommand(listBannedPeersCmd)
+	BytomcliCmd.AddCommand(whitelistPeerCmd)
+	BytomcliCmd.AddCommand(unwhitelistPeerCmd)
+	BytomcliCmd.AddCommand(listWhitelistCmd)
 	BytomcliCmd.AddCommand(gasRateCmd)
 
 	BytomcliCmd.AddCommand(versionCmd)
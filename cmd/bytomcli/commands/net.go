@@ -2,12 +2,24 @@ package commands
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
 
 	"github.com/bytom-gm/util"
 )
 
+func init() {
+	banPeerCmd.PersistentFlags().Uint64Var(&banSeconds, "seconds", 0, "ban duration in seconds, 0 for the default")
+	banPeerCmd.PersistentFlags().StringVar(&banReason, "reason", "", "reason recorded with the ban")
+}
+
+var (
+	banSeconds uint64
+	banReason  string
+)
+
 var netInfoCmd = &cobra.Command{
 	Use:   "net-info",
 	Short: "Print the summary of network",
@@ -20,3 +32,152 @@ var netInfoCmd = &cobra.Command{
 		printJSON(data)
 	},
 }
+
+var listPeersCmd = &cobra.Command{
+	Use:   "list-peers",
+	Short: "List the connected peers with their stats",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, exitCode := util.ClientCall("/list-peers")
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSONList(data)
+	},
+}
+
+var connectPeerCmd = &cobra.Command{
+	Use:   "connect-peer <ip> <port>",
+	Short: "Connect a peer by ip and port",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		port, err := strconv.ParseUint(args[1], 10, 16)
+		if err != nil {
+			jww.ERROR.Println("invalid port:", err)
+			os.Exit(util.ErrLocalExe)
+		}
+
+		var req = struct {
+			Ip   string `json:"ip"`
+			Port uint16 `json:"port"`
+		}{Ip: args[0], Port: uint16(port)}
+
+		data, exitCode := util.ClientCall("/connect-peer", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSON(data)
+	},
+}
+
+var disconnectPeerCmd = &cobra.Command{
+	Use:   "disconnect-peer <peerID>",
+	Short: "Disconnect a peer by the peer id",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var req = struct {
+			PeerID string `json:"peer_id"`
+		}{PeerID: args[0]}
+
+		if _, exitCode := util.ClientCall("/disconnect-peer", &req); exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		jww.FEEDBACK.Println("Successfully disconnect peer")
+	},
+}
+
+var banPeerCmd = &cobra.Command{
+	Use:   "ban-peer <ip|subnet>",
+	Short: "Ban an IP or subnet and disconnect the matching peers",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var req = struct {
+			Address string `json:"address"`
+			Seconds uint64 `json:"seconds"`
+			Reason  string `json:"reason"`
+		}{Address: args[0], Seconds: banSeconds, Reason: banReason}
+
+		data, exitCode := util.ClientCall("/ban-peer", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSON(data)
+	},
+}
+
+var unbanPeerCmd = &cobra.Command{
+	Use:   "unban-peer <ip|subnet>",
+	Short: "Lift the ban of an IP or subnet",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var req = struct {
+			Address string `json:"address"`
+		}{Address: args[0]}
+
+		data, exitCode := util.ClientCall("/unban-peer", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSON(data)
+	},
+}
+
+var listBannedPeersCmd = &cobra.Command{
+	Use:   "list-banned-peers",
+	Short: "List the active ban records",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, exitCode := util.ClientCall("/list-banned-peers")
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSONList(data)
+	},
+}
+
+var whitelistPeerCmd = &cobra.Command{
+	Use:   "whitelist-peer <ip|subnet>",
+	Short: "Exempt an IP or subnet from bans",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var req = struct {
+			Address string `json:"address"`
+		}{Address: args[0]}
+
+		data, exitCode := util.ClientCall("/whitelist-peer", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSON(data)
+	},
+}
+
+var unwhitelistPeerCmd = &cobra.Command{
+	Use:   "unwhitelist-peer <ip|subnet>",
+	Short: "Drop an IP or subnet from the whitelist",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var req = struct {
+			Address string `json:"address"`
+		}{Address: args[0]}
+
+		data, exitCode := util.ClientCall("/unwhitelist-peer", &req)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSON(data)
+	},
+}
+
+var listWhitelistCmd = &cobra.Command{
+	Use:   "list-whitelist",
+	Short: "List the subnets exempt from bans",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, exitCode := util.ClientCall("/list-whitelist")
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+		printJSONList(data)
+	},
+}
@@ -36,6 +36,9 @@ func init() {
 	listTransactionsCmd.PersistentFlags().StringVar(&account, "account_id", "", "account id")
 	listTransactionsCmd.PersistentFlags().BoolVar(&detail, "detail", false, "list transactions details")
 	listTransactionsCmd.PersistentFlags().BoolVar(&unconfirmed, "unconfirmed", false, "list unconfirmed transactions")
+
+	exportTransactionsCmd.PersistentFlags().StringVar(&account, "account_id", "", "account id")
+	exportTransactionsCmd.PersistentFlags().StringVar(&exportFormat, "format", "csv", "export format, csv or ofx")
 }
 
 var (
@@ -53,6 +56,7 @@ var (
 	arbitrary       = ""
 	program         = ""
 	contractName    = ""
+	exportFormat    = ""
 )
 
 var buildIssueReqFmt = `
@@ -373,6 +377,75 @@ var listTransactionsCmd = &cobra.Command{
 	},
 }
 
+var exportTemplateCmd = &cobra.Command{
+	Use:   "export-template <json template>",
+	Short: "Export a transaction template as a portable payload for offline signing",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		template := txbuilder.Template{}
+		if err := json.Unmarshal([]byte(args[0]), &template); err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(util.ErrLocalExe)
+		}
+
+		ins := struct {
+			Transaction txbuilder.Template `json:"transaction"`
+		}{Transaction: template}
+
+		data, exitCode := util.ClientCall("/export-transaction-template", &ins)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		if exported, ok := data.(map[string]interface{}); ok {
+			jww.FEEDBACK.Println(exported["payload"])
+			return
+		}
+		printJSON(data)
+	},
+}
+
+var importTemplateCmd = &cobra.Command{
+	Use:   "import-template <payload>",
+	Short: "Import a portable template payload back into a transaction template",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ins := struct {
+			Payload string `json:"payload"`
+		}{Payload: args[0]}
+
+		data, exitCode := util.ClientCall("/import-transaction-template", &ins)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		printJSON(data)
+	},
+}
+
+var exportTransactionsCmd = &cobra.Command{
+	Use:   "export-transactions",
+	Short: "Export the transaction history as CSV or OFX",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ins := struct {
+			AccountID string `json:"account_id"`
+			Format    string `json:"format"`
+		}{AccountID: account, Format: exportFormat}
+
+		data, exitCode := util.ClientCall("/export-transactions", &ins)
+		if exitCode != util.Success {
+			os.Exit(exitCode)
+		}
+
+		if exported, ok := data.(map[string]interface{}); ok {
+			fmt.Print(exported["data"])
+			return
+		}
+		printJSON(data)
+	},
+}
+
 var getUnconfirmedTransactionCmd = &cobra.Command{
 	Use:   "get-unconfirmed-transaction <hash>",
 	Short: "get unconfirmed transaction by matching the given transaction hash",
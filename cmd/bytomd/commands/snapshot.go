@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	cmn "github.com/tendermint/tmlibs/common"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/database/leveldb"
+)
+
+var exportSnapshotCmd = &cobra.Command{
+	Use:   "export-snapshot <file>",
+	Short: "Export the utxo set and chain metadata into a verifiable snapshot file",
+	Long:  "Export the utxo set and chain metadata at the current best height into a snapshot file carrying a utxo commitment. Run it while the node is stopped.",
+	Args:  cobra.ExactArgs(1),
+	Run:   exportSnapshot,
+}
+
+var importSnapshotCmd = &cobra.Command{
+	Use:   "import-snapshot <file>",
+	Short: "Bootstrap an empty node from a chain state snapshot file",
+	Long:  "Verify a chain state snapshot against its utxo commitment and seed an empty core database from it, the node then syncs forward normally from the snapshot height.",
+	Args:  cobra.ExactArgs(1),
+	Run:   importSnapshot,
+}
+
+func init() {
+	RootCmd.AddCommand(exportSnapshotCmd)
+	RootCmd.AddCommand(importSnapshotCmd)
+}
+
+func exportSnapshot(cmd *cobra.Command, args []string) {
+	file, err := os.Create(args[0])
+	if err != nil {
+		cmn.Exit("Error: " + err.Error())
+	}
+	defer file.Close()
+
+	coreDB := dbm.NewDB("core", config.DBBackend, config.DBDir())
+	defer coreDB.Close()
+
+	if err := leveldb.NewStore(coreDB).ExportSnapshot(file); err != nil {
+		cmn.Exit("Error: " + err.Error())
+	}
+	log.WithField("snapshot", args[0]).Info("exported chain state snapshot")
+}
+
+func importSnapshot(cmd *cobra.Command, args []string) {
+	file, err := os.Open(args[0])
+	if err != nil {
+		cmn.Exit("Error: " + err.Error())
+	}
+	defer file.Close()
+
+	coreDB := dbm.NewDB("core", config.DBBackend, config.DBDir())
+	defer coreDB.Close()
+
+	state, err := leveldb.ImportSnapshot(coreDB, file)
+	if err != nil {
+		cmn.Exit("Error: " + err.Error())
+	}
+	log.WithFields(log.Fields{"height": state.Height, "hash": state.Hash.String()}).Info("imported chain state snapshot")
+}
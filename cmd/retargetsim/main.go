@@ -0,0 +1,99 @@
+// Command retargetsim replays historical block timestamps through a difficulty
+// retarget algorithm and prints the retarget trace, one CSV line per block:
+// height,timestamp,solvetime,bits. Input is one unix timestamp per line, read
+// from the file given with -in or from stdin.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/consensus/difficulty"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+var (
+	algoName = flag.String("algo", "epoch", "retarget algorithm: epoch or lwma")
+	window   = flag.Int("window", 90, "solve time window of the lwma algorithm")
+	bits     = flag.Uint64("bits", 2305843009214532812, "compact target of the first block")
+	input    = flag.String("in", "", "timestamp file, stdin when empty")
+)
+
+func main() {
+	flag.Parse()
+
+	var algorithm difficulty.RetargetAlgorithm
+	switch *algoName {
+	case "epoch":
+		algorithm = difficulty.EpochRetarget{}
+	case "lwma":
+		algorithm = &difficulty.LWMARetarget{TargetSeconds: int64(consensus.TargetSecondsPerBlock), Window: *window}
+	default:
+		log.Fatalf("unknown retarget algorithm %q", *algoName)
+	}
+
+	var in io.Reader = os.Stdin
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	timestamps, err := readTimestamps(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// headers holds the simulated chain newest first, the way the
+	// algorithms consume it
+	headers := []*types.BlockHeader{}
+	nextBits := *bits
+	for height, timestamp := range timestamps {
+		header := &types.BlockHeader{
+			Height:    uint64(height),
+			Timestamp: timestamp,
+			Bits:      nextBits,
+		}
+		headers = append([]*types.BlockHeader{header}, headers...)
+
+		solveTime := int64(0)
+		if len(headers) > 1 {
+			solveTime = int64(header.Timestamp) - int64(headers[1].Timestamp)
+		}
+		fmt.Printf("%d,%d,%d,%d\n", header.Height, header.Timestamp, solveTime, header.Bits)
+
+		window := headers
+		if needed := algorithm.HeadersNeeded(); len(window) > needed {
+			window = window[:needed]
+		}
+		nextBits = algorithm.NextRequiredDifficulty(window)
+	}
+}
+
+func readTimestamps(in io.Reader) ([]uint64, error) {
+	timestamps := []uint64{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		timestamp, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		timestamps = append(timestamps, timestamp)
+	}
+	return timestamps, scanner.Err()
+}
@@ -0,0 +1,33 @@
+package common
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RecoverPanic is meant to be deferred at a subsystem boundary, it converts an
+// in-flight panic into a structured error event with the captured stack and
+// hands the error to the optional callback instead of crashing the whole node.
+func RecoverPanic(module string, onPanic func(err error)) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	err, ok := r.(error)
+	if !ok {
+		err = fmt.Errorf("%v", r)
+	}
+
+	log.WithFields(log.Fields{
+		"module": module,
+		"err":    err,
+		"stack":  string(debug.Stack()),
+	}).Error("recovered from panic")
+
+	if onPanic != nil {
+		onPanic(err)
+	}
+}
@@ -18,11 +18,19 @@ type Config struct {
 	// Top level options use an anonymous struct
 	BaseConfig `mapstructure:",squash"`
 	// Options for services
-	P2P    *P2PConfig     `mapstructure:"p2p"`
-	Wallet *WalletConfig  `mapstructure:"wallet"`
-	Auth   *RPCAuthConfig `mapstructure:"auth"`
-	Web    *WebConfig     `mapstructure:"web"`
-	Simd   *SimdConfig    `mapstructure:"simd"`
+	P2P       *P2PConfig       `mapstructure:"p2p"`
+	Wallet    *WalletConfig    `mapstructure:"wallet"`
+	Auth      *RPCAuthConfig   `mapstructure:"auth"`
+	Web       *WebConfig       `mapstructure:"web"`
+	Simd      *SimdConfig      `mapstructure:"simd"`
+	Policy    *PolicyConfig    `mapstructure:"policy"`
+	Update    *UpdateConfig    `mapstructure:"update"`
+	Broadcast *BroadcastConfig `mapstructure:"broadcast"`
+	Stratum   *StratumConfig   `mapstructure:"stratum"`
+	Miner     *MinerConfig     `mapstructure:"miner"`
+	TLS       *TLSConfig       `mapstructure:"tls"`
+	RateLimit *RateLimitConfig `mapstructure:"rate_limit"`
+	Health    *HealthConfig    `mapstructure:"health"`
 }
 
 // Default configurable parameters.
@@ -34,6 +42,14 @@ func DefaultConfig() *Config {
 		Auth:       DefaultRPCAuthConfig(),
 		Web:        DefaultWebConfig(),
 		Simd:       DefaultSimdConfig(),
+		Policy:     DefaultPolicyConfig(),
+		Update:     DefaultUpdateConfig(),
+		Broadcast:  DefaultBroadcastConfig(),
+		Stratum:    DefaultStratumConfig(),
+		Miner:      DefaultMinerConfig(),
+		TLS:        DefaultTLSConfig(),
+		RateLimit:  DefaultRateLimitConfig(),
+		Health:     DefaultHealthConfig(),
 	}
 }
 
@@ -70,15 +86,45 @@ type BaseConfig struct {
 	// Database directory
 	DBPath string `mapstructure:"db_dir"`
 
+	// Entry bound of the in-memory utxo cache, zero keeps the default
+	UtxoCacheSize int `mapstructure:"utxo_cache_size"`
+
+	// AssumeValid overrides the assume-valid checkpoint of the network as
+	// "<height>:<block hash>", under it initial sync skips program and
+	// signature checks. Empty keeps the network default.
+	AssumeValid string `mapstructure:"assume_valid"`
+
+	// Prune discards raw block data once processed, keeping block headers
+	// and the utxo set
+	Prune bool `mapstructure:"prune"`
+
+	// Number of recent blocks prune mode keeps on disk for reorg safety,
+	// zero keeps the default
+	PruneKeepBlocks uint64 `mapstructure:"prune_keep_blocks"`
+
+	// AddressIndex maintains balances and transaction lists for every
+	// address on chain, not only wallet-tracked ones
+	AddressIndex bool `mapstructure:"address_index"`
+
+	// TxIndex maps every confirmed transaction id to its block and
+	// position, backfilling in the background when first enabled
+	TxIndex bool `mapstructure:"tx_index"`
+
 	// Keystore directory
 	KeysPath string `mapstructure:"keys_dir"`
 
 	ApiAddress string `mapstructure:"api_addr"`
 
+	// TCP address for the gRPC API to listen on, empty keeps it disabled
+	GRPCAddress string `mapstructure:"grpc_addr"`
+
 	VaultMode bool `mapstructure:"vault_mode"`
 
 	// log file name
 	LogFile string `mapstructure:"log_file"`
+
+	// Path of a pre-packaged block headers file loaded at first start
+	HeadersFile string `mapstructure:"headers_file"`
 }
 
 // Default configurable base parameters.
@@ -109,24 +155,75 @@ type P2PConfig struct {
 	MaxNumPeers      int    `mapstructure:"max_num_peers"`
 	HandshakeTimeout int    `mapstructure:"handshake_timeout"`
 	DialTimeout      int    `mapstructure:"dial_timeout"`
+	// MaxNumInboundPeers caps accepted inbound connections, a full table
+	// evicts the most disposable inbound peer to make room
+	MaxNumInboundPeers int `mapstructure:"max_num_inbound_peers"`
+	// MaxNumOutboundPeers caps the connections we dial out, 0 disables
+	// the check
+	MaxNumOutboundPeers int `mapstructure:"max_num_outbound_peers"`
+	// SendRate/RecvRate cap the bandwidth of a single peer in bytes per
+	// second, 0 keeps the built-in default
+	SendRate int64 `mapstructure:"send_rate"`
+	RecvRate int64 `mapstructure:"recv_rate"`
+	// GlobalSendRate/GlobalRecvRate cap the total bandwidth shared by
+	// every peer in bytes per second, 0 leaves a direction unlimited
+	GlobalSendRate int64 `mapstructure:"global_send_rate"`
+	GlobalRecvRate int64 `mapstructure:"global_recv_rate"`
+	// BlocksOnly disables the relay of loose transactions entirely, the
+	// node neither requests nor announces them
+	BlocksOnly bool `mapstructure:"blocks_only"`
+	// PreferredFamily steers address selection when both IP families are
+	// available, "ipv4" or "ipv6", empty keeps no preference
+	PreferredFamily string `mapstructure:"preferred_family"`
+	// MaxPeersPerGroup caps outbound connections per ASN/network group, 0 disables the check
+	MaxPeersPerGroup int `mapstructure:"max_peers_per_group"`
+	// ASNFile is an optional static IP-to-ASN mapping file, one "CIDR ASN" per line
+	ASNFile string `mapstructure:"asn_file"`
+	// ProxyAddress routes all outbound connections through a SOCKS5 proxy
+	// (host:port), e.g. a local Tor daemon on 127.0.0.1:9050
+	ProxyAddress  string `mapstructure:"proxy_address"`
+	ProxyUsername string `mapstructure:"proxy_username"`
+	ProxyPassword string `mapstructure:"proxy_password"`
+	// ProxyIsolation derives distinct proxy credentials per peer so a Tor
+	// proxy keeps every peer on its own circuit
+	ProxyIsolation bool `mapstructure:"proxy_isolation"`
+	// OnionAddress is the hidden service address advertised as our listener
+	OnionAddress string `mapstructure:"onion_address"`
+	// NATPMPGateway enables NAT-PMP port mapping negotiation with the given
+	// router address instead of UPnP discovery
+	NATPMPGateway string `mapstructure:"natpmp_gateway"`
 }
 
 // Default configurable p2p parameters.
 func DefaultP2PConfig() *P2PConfig {
 	return &P2PConfig{
-		ListenAddress:    "tcp://0.0.0.0:46656",
-		SkipUPNP:         false,
-		MaxNumPeers:      50,
-		HandshakeTimeout: 30,
-		DialTimeout:      3,
+		ListenAddress:       "tcp://0.0.0.0:46656",
+		SkipUPNP:            false,
+		MaxNumPeers:         50,
+		MaxNumInboundPeers:  40,
+		MaxNumOutboundPeers: 10,
+		HandshakeTimeout:    30,
+		DialTimeout:         3,
+		MaxPeersPerGroup:    3,
 	}
 }
 
 //-----------------------------------------------------------------------------
 type WalletConfig struct {
-	Disable  bool   `mapstructure:"disable"`
-	Rescan   bool   `mapstructure:"rescan"`
-	MaxTxFee uint64 `mapstructure:"max_tx_fee"`
+	Disable         bool     `mapstructure:"disable"`
+	Rescan          bool     `mapstructure:"rescan"`
+	MaxTxFee        uint64   `mapstructure:"max_tx_fee"`
+	AssetAllowlist  []string `mapstructure:"asset_allowlist"`
+	AssetDenylist   []string `mapstructure:"asset_denylist"`
+	MigrationDryRun bool     `mapstructure:"migration_dry_run"`
+	MigrationBackup bool     `mapstructure:"migration_backup"`
+	// ReservedAssetPrefixes lists alias prefixes that locally defined assets
+	// may not use, matched case insensitively
+	ReservedAssetPrefixes []string `mapstructure:"reserved_asset_prefixes"`
+
+	// AutoLockSeconds is how long unlock-wallet keeps decrypted key
+	// material cached when the request gives no timeout of its own
+	AutoLockSeconds uint64 `mapstructure:"auto_lock_seconds"`
 }
 
 type RPCAuthConfig struct {
@@ -141,6 +238,30 @@ type SimdConfig struct {
 	Enable bool `mapstructure:"enable"`
 }
 
+// PolicyConfig holds the node-local transaction standardness parameters,
+// they tune mempool/relay acceptance and never affect consensus validation.
+type PolicyConfig struct {
+	DustThreshold           uint64 `mapstructure:"dust_threshold"`
+	MaxWitnessItems         int    `mapstructure:"max_witness_items"`
+	AllowNonStandardProgram bool   `mapstructure:"allow_non_standard_program"`
+	// MaxChainDepth caps the unconfirmed ancestors/descendants one mempool
+	// transaction may have, zero disables the check
+	MaxChainDepth int `mapstructure:"max_chain_depth"`
+	// MaxAncestors/MaxDescendants override MaxChainDepth for one direction
+	// of the unconfirmed chain, zero falls back to max_chain_depth
+	MaxAncestors   int `mapstructure:"max_ancestors"`
+	MaxDescendants int `mapstructure:"max_descendants"`
+	// MinGasRate/MaxGasRate bound the per-request gas rate override of
+	// build-transaction in neu per byte, zero disables the bound
+	MinGasRate uint64 `mapstructure:"min_gas_rate"`
+	MaxGasRate uint64 `mapstructure:"max_gas_rate"`
+	// MaxMempoolTxs/MaxMempoolBytes bound the mempool by transaction count
+	// and summed serialized size, the lowest fee-rate packages get evicted
+	// when a bound is hit, zero keeps the defaults
+	MaxMempoolTxs   int    `mapstructure:"max_mempool_txs"`
+	MaxMempoolBytes uint64 `mapstructure:"max_mempool_bytes"`
+}
+
 // Default configurable rpc's auth parameters.
 func DefaultRPCAuthConfig() *RPCAuthConfig {
 	return &RPCAuthConfig{
@@ -158,9 +279,11 @@ func DefaultWebConfig() *WebConfig {
 // Default configurable wallet parameters.
 func DefaultWalletConfig() *WalletConfig {
 	return &WalletConfig{
-		Disable:  false,
-		Rescan:   false,
-		MaxTxFee: uint64(1000000000),
+		Disable:         false,
+		Rescan:          false,
+		MaxTxFee:        uint64(1000000000),
+		MigrationBackup: true,
+		AutoLockSeconds: 300,
 	}
 }
 
@@ -171,6 +294,203 @@ func DefaultSimdConfig() *SimdConfig {
 	}
 }
 
+// UpdateConfig drives the optional signed release auto-update check, the
+// manifest signature must verify against one of the maintainer pubkeys.
+type UpdateConfig struct {
+	Enable       bool     `mapstructure:"enable"`
+	ManifestURL  string   `mapstructure:"manifest_url"`
+	Pubkeys      []string `mapstructure:"pubkeys"`
+	CheckMinutes int      `mapstructure:"check_minutes"`
+	StagePath    string   `mapstructure:"stage_dir"`
+}
+
+// Default configurable update parameters.
+func DefaultUpdateConfig() *UpdateConfig {
+	return &UpdateConfig{
+		Enable:       false,
+		CheckMinutes: 60,
+		StagePath:    "update",
+	}
+}
+
+// TLSConfig serves the JSON API over HTTPS. With auto_generate set a
+// missing cert/key pair is created self-signed on first start, good enough
+// for local use. Setting client_ca_file additionally demands a client
+// certificate signed by that CA, for machine-to-machine deployments.
+type TLSConfig struct {
+	Enable       bool   `mapstructure:"enable"`
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	AutoGenerate bool   `mapstructure:"auto_generate"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+}
+
+// Default configurable tls parameters.
+func DefaultTLSConfig() *TLSConfig {
+	return &TLSConfig{
+		Enable:       false,
+		CertFile:     "tls/api.crt",
+		KeyFile:      "tls/api.key",
+		AutoGenerate: true,
+	}
+}
+
+// TLSCertFile returns the cert file path rooted in the node directory
+func (cfg *Config) TLSCertFile() string {
+	return rootify(cfg.TLS.CertFile, cfg.RootDir)
+}
+
+// TLSKeyFile returns the key file path rooted in the node directory
+func (cfg *Config) TLSKeyFile() string {
+	return rootify(cfg.TLS.KeyFile, cfg.RootDir)
+}
+
+// TLSClientCAFile returns the client CA file path rooted in the node
+// directory, empty when client certificates are not demanded
+func (cfg *Config) TLSClientCAFile() string {
+	if cfg.TLS.ClientCAFile == "" {
+		return ""
+	}
+	return rootify(cfg.TLS.ClientCAFile, cfg.RootDir)
+}
+
+// RateLimitConfig guards the JSON API against misbehaving clients. The
+// per-client budget is keyed on the access token id, or the source IP when
+// no token was presented.
+type RateLimitConfig struct {
+	// RequestsPerSecond caps the sustained call rate of one client, zero
+	// disables the per-client limit
+	RequestsPerSecond int `mapstructure:"requests_per_second"`
+	// Burst is how many calls a client may make at once before the
+	// sustained rate applies
+	Burst int `mapstructure:"burst"`
+	// MaxInFlightExpensive caps how many expensive calls (transaction
+	// building, rescans) run at the same time, zero disables the cap
+	MaxInFlightExpensive int `mapstructure:"max_inflight_expensive"`
+}
+
+// Default configurable rate limit parameters.
+func DefaultRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		RequestsPerSecond:    0,
+		Burst:                100,
+		MaxInFlightExpensive: 8,
+	}
+}
+
+// HealthConfig tunes the readiness probe thresholds.
+type HealthConfig struct {
+	// MaxBlockLag is how many blocks the chain may trail the best peer
+	// before the node reports not ready
+	MaxBlockLag uint64 `mapstructure:"max_block_lag"`
+
+	// MaxWalletLag is how many blocks the wallet index may trail the
+	// chain before the node reports not ready
+	MaxWalletLag uint64 `mapstructure:"max_wallet_lag"`
+}
+
+// Default configurable health probe parameters.
+func DefaultHealthConfig() *HealthConfig {
+	return &HealthConfig{
+		MaxBlockLag:  3,
+		MaxWalletLag: 10,
+	}
+}
+
+// BroadcastConfig lists the APIs of trusted secondary nodes which receive
+// every locally submitted transaction in parallel with the local mempool,
+// an empty endpoint list disables the redundant broadcast.
+type BroadcastConfig struct {
+	Endpoints      []string `mapstructure:"endpoints"`
+	TimeoutSeconds int      `mapstructure:"timeout_seconds"`
+}
+
+// Default configurable broadcast parameters.
+func DefaultBroadcastConfig() *BroadcastConfig {
+	return &BroadcastConfig{
+		TimeoutSeconds: 5,
+	}
+}
+
+// MinerConfig tunes the built-in miner and seeds the block template
+// transaction selection policy, a zero value disables the knob
+type MinerConfig struct {
+	// minimum fee per weight unit of a template candidate
+	MinFeeRate uint64 `mapstructure:"min_fee_rate"`
+
+	// template gas budget below the consensus maximum
+	MaxBlockGasTarget uint64 `mapstructure:"max_block_gas_target"`
+
+	// place locally submitted transactions before relayed ones
+	LocalTxPriority bool `mapstructure:"local_tx_priority"`
+
+	// hex encoded control programs never paid by a template
+	BannedPrograms []string `mapstructure:"banned_programs"`
+
+	// operator message appended to the coinbase arbitrary data
+	CoinbaseMessage string `mapstructure:"coinbase_message"`
+
+	// zeroed bytes reserved at the end of the coinbase arbitrary data for
+	// pools to roll
+	ExtranonceSize uint64 `mapstructure:"extranonce_size"`
+
+	// number of CPU mining worker goroutines, zero keeps the single
+	// worker default
+	CPUWorkers int `mapstructure:"cpu_workers"`
+
+	// percentage of wall time each CPU mining worker spends hashing,
+	// zero or 100 hashes flat out
+	CPUThrottle uint64 `mapstructure:"cpu_throttle"`
+
+	// hex encoded control programs the coinbase of height h pays in turn,
+	// overriding the mining address
+	PayoutPrograms []string `mapstructure:"payout_programs"`
+
+	// derive a fresh mining account address per block instead of reusing
+	// the mining address
+	RotatePayoutAddresses bool `mapstructure:"rotate_payout_addresses"`
+}
+
+// Default configurable miner parameters.
+func DefaultMinerConfig() *MinerConfig {
+	return &MinerConfig{}
+}
+
+// StratumConfig drives the stratum mining server which hands block template
+// work to external miners, disabled by default.
+type StratumConfig struct {
+	Enable        bool   `mapstructure:"enable"`
+	ListenAddress string `mapstructure:"laddr"`
+
+	// starting share target multiplier of a new worker, larger means easier shares
+	InitialShareMultiplier uint64 `mapstructure:"initial_share_multiplier"`
+
+	// vardiff retunes a worker so it submits one share per target interval
+	VardiffTargetSeconds uint64 `mapstructure:"vardiff_target_seconds"`
+	VardiffWindow        int    `mapstructure:"vardiff_window"`
+}
+
+// Default configurable stratum parameters.
+func DefaultStratumConfig() *StratumConfig {
+	return &StratumConfig{
+		Enable:                 false,
+		ListenAddress:          "0.0.0.0:3333",
+		InitialShareMultiplier: 1024,
+		VardiffTargetSeconds:   15,
+		VardiffWindow:          8,
+	}
+}
+
+// Default configurable policy parameters.
+func DefaultPolicyConfig() *PolicyConfig {
+	return &PolicyConfig{
+		DustThreshold:           0,
+		MaxWitnessItems:         100,
+		AllowNonStandardProgram: false,
+		MaxChainDepth:           25,
+	}
+}
+
 //-----------------------------------------------------------------------------
 // Utils
 
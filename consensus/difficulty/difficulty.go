@@ -4,7 +4,6 @@ import (
 	"math/big"
 
 	"github.com/bytom-gm/consensus"
-	"github.com/bytom-gm/mining/tensority"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
 )
@@ -117,8 +116,10 @@ func BigToCompact(n *big.Int) uint64 {
 }
 
 // CheckProofOfWork checks whether the hash is valid for a given difficulty.
+// The comparison hash comes from the proof-of-work hasher the active network
+// params select.
 func CheckProofOfWork(hash, seed *bc.Hash, bits uint64) bool {
-	compareHash := tensority.AIHash.Hash(hash, seed)
+	compareHash := activePoWHasher().Hash(hash, seed)
 	return HashToBig(compareHash).Cmp(CompactToBig(bits)) <= 0
 }
 
@@ -0,0 +1,34 @@
+package difficulty
+
+import (
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/mining/tensority"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// PoWHasher maps a header hash and seed to the comparison hash checked
+// against the difficulty target
+type PoWHasher interface {
+	Hash(hash, seed *bc.Hash) *bc.Hash
+}
+
+// sm3Hasher hashes the header hash and seed with bare SM3. Private and
+// consortium chains pick it over tensority when ASIC resistance doesn't
+// matter and verification has to be cheap.
+type sm3Hasher struct{}
+
+func (sm3Hasher) Hash(hash, seed *bc.Hash) *bc.Hash {
+	data := append(hash.Bytes(), seed.Bytes()...)
+	digest := bc.NewHash(sm3.Sum256(data))
+	return &digest
+}
+
+// activePoWHasher returns the proof-of-work hasher the active network params
+// select, so the verifier follows the chain id. Tensority is the default.
+func activePoWHasher() PoWHasher {
+	if consensus.ActiveNetParams.PoWAlgorithm == consensus.PoWSM3 {
+		return sm3Hasher{}
+	}
+	return tensority.AIHash
+}
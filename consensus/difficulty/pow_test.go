@@ -0,0 +1,41 @@
+package difficulty
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/mining/tensority"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+func TestSM3HasherDeterministic(t *testing.T) {
+	hash := bc.NewHash([32]byte{0x01})
+	seed := bc.NewHash([32]byte{0x02})
+
+	first := sm3Hasher{}.Hash(&hash, &seed)
+	second := sm3Hasher{}.Hash(&hash, &seed)
+	if *first != *second {
+		t.Error("sm3 hasher should be deterministic")
+	}
+
+	otherSeed := bc.NewHash([32]byte{0x03})
+	third := sm3Hasher{}.Hash(&hash, &otherSeed)
+	if *first == *third {
+		t.Error("sm3 hasher should depend on the seed")
+	}
+}
+
+func TestActivePoWHasher(t *testing.T) {
+	originPoW := consensus.ActiveNetParams.PoWAlgorithm
+	defer func() { consensus.ActiveNetParams.PoWAlgorithm = originPoW }()
+
+	consensus.ActiveNetParams.PoWAlgorithm = ""
+	if activePoWHasher() != tensority.AIHash {
+		t.Error("empty pow algorithm should keep tensority")
+	}
+
+	consensus.ActiveNetParams.PoWAlgorithm = consensus.PoWSM3
+	if _, ok := activePoWHasher().(sm3Hasher); !ok {
+		t.Error("sm3 pow algorithm should select the sm3 hasher")
+	}
+}
@@ -0,0 +1,109 @@
+package difficulty
+
+import (
+	"math/big"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// defaultLWMAWindow is the number of solve times the LWMA retarget averages
+// when the network params don't pick one
+const defaultLWMAWindow = 90
+
+// RetargetAlgorithm computes the compact difficulty target of the next block
+// from the recent header chain, ordered newest first. headers[0] is the last
+// sealed block, an algorithm gets at least HeadersNeeded headers when that
+// much history exists.
+type RetargetAlgorithm interface {
+	HeadersNeeded() int
+	NextRequiredDifficulty(headers []*types.BlockHeader) uint64
+}
+
+// ActiveRetarget returns the alternative retarget algorithm the active
+// network params put in force at height, nil keeps the epoch retarget
+func ActiveRetarget(height uint64) RetargetAlgorithm {
+	params := consensus.ActiveNetParams.Retarget
+	if params == nil || params.ActivationHeight == 0 || height < params.ActivationHeight {
+		return nil
+	}
+
+	switch params.Algorithm {
+	case consensus.RetargetLWMA:
+		window := int(params.Window)
+		if window == 0 {
+			window = defaultLWMAWindow
+		}
+		return &LWMARetarget{TargetSeconds: int64(consensus.TargetSecondsPerBlock), Window: window}
+	}
+	return nil
+}
+
+// EpochRetarget is the original retarget recomputing the target once every
+// BlocksPerRetarget blocks from the time span of the finished epoch
+type EpochRetarget struct{}
+
+// HeadersNeeded covers the whole epoch so the boundary header is available
+func (EpochRetarget) HeadersNeeded() int {
+	return int(consensus.BlocksPerRetarget) + 1
+}
+
+// NextRequiredDifficulty keeps the last target inside an epoch and rescales
+// it on the boundary like CalcNextRequiredDifficulty
+func (EpochRetarget) NextRequiredDifficulty(headers []*types.BlockHeader) uint64 {
+	lastBH := headers[0]
+	if lastBH.Height%consensus.BlocksPerRetarget != 0 || lastBH.Height == 0 {
+		return lastBH.Bits
+	}
+	return CalcNextRequiredDifficulty(lastBH, headers[len(headers)-1])
+}
+
+// LWMARetarget recomputes the target every block from a linearly weighted
+// moving average of the last Window solve times, reacting to hashrate swings
+// within a few blocks instead of a whole epoch
+type LWMARetarget struct {
+	TargetSeconds int64
+	Window        int
+}
+
+// HeadersNeeded covers Window solve times plus the header before the window
+func (l *LWMARetarget) HeadersNeeded() int {
+	return l.Window + 1
+}
+
+// NextRequiredDifficulty scales the average target over the window by the
+// weighted solve time against the target spacing, more recent solve times
+// weigh more. Solve times are clamped so one wild timestamp can't collapse
+// the difficulty.
+func (l *LWMARetarget) NextRequiredDifficulty(headers []*types.BlockHeader) uint64 {
+	if len(headers) < l.HeadersNeeded() {
+		// not enough history yet, keep the last target
+		return headers[0].Bits
+	}
+
+	weightedSolveTime := int64(0)
+	sumTarget := new(big.Int)
+	// headers are newest first, walk the window oldest to newest so the
+	// newest solve time gets the biggest weight
+	for i := l.Window; i >= 1; i-- {
+		solveTime := int64(headers[i-1].Timestamp) - int64(headers[i].Timestamp)
+		if solveTime < 1 {
+			solveTime = 1
+		}
+		if max := 6 * l.TargetSeconds; solveTime > max {
+			solveTime = max
+		}
+
+		weight := int64(l.Window - i + 1)
+		weightedSolveTime += solveTime * weight
+		sumTarget.Add(sumTarget, CompactToBig(headers[i-1].Bits))
+	}
+
+	// denominator of the weighted average: 1 + 2 + ... + Window
+	weightSum := int64(l.Window) * int64(l.Window+1) / 2
+
+	avgTarget := sumTarget.Div(sumTarget, big.NewInt(int64(l.Window)))
+	nextTarget := avgTarget.Mul(avgTarget, big.NewInt(weightedSolveTime))
+	nextTarget.Div(nextTarget, big.NewInt(weightSum*l.TargetSeconds))
+	return BigToCompact(nextTarget)
+}
@@ -0,0 +1,98 @@
+package difficulty
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// lwmaHeaders builds a header chain newest first with a constant solve time
+func lwmaHeaders(count int, bits uint64, solveTime uint64) []*types.BlockHeader {
+	headers := []*types.BlockHeader{}
+	for i := 0; i < count; i++ {
+		headers = append(headers, &types.BlockHeader{
+			Height:    uint64(count - i),
+			Timestamp: uint64(count-i) * solveTime,
+			Bits:      bits,
+		})
+	}
+	return headers
+}
+
+func TestLWMANextRequiredDifficulty(t *testing.T) {
+	targetSeconds := int64(consensus.TargetSecondsPerBlock)
+	algorithm := &LWMARetarget{TargetSeconds: targetSeconds, Window: 30}
+	bits := uint64(2305843009214532812)
+
+	cases := []struct {
+		desc      string
+		headers   []*types.BlockHeader
+		compare   int // -1 easier than bits, 0 unchanged, 1 harder than bits
+		shortOnly bool
+	}{
+		{
+			desc:    "solve times on target keep the difficulty",
+			headers: lwmaHeaders(algorithm.HeadersNeeded(), bits, uint64(targetSeconds)),
+			compare: 0,
+		},
+		{
+			desc:    "fast solve times raise the difficulty",
+			headers: lwmaHeaders(algorithm.HeadersNeeded(), bits, uint64(targetSeconds)/3),
+			compare: 1,
+		},
+		{
+			desc:    "slow solve times lower the difficulty",
+			headers: lwmaHeaders(algorithm.HeadersNeeded(), bits, uint64(targetSeconds)*3),
+			compare: -1,
+		},
+		{
+			desc:    "too little history keeps the last target",
+			headers: lwmaHeaders(algorithm.HeadersNeeded()-1, bits, uint64(targetSeconds)/3),
+			compare: 0,
+		},
+	}
+
+	for _, c := range cases {
+		got := algorithm.NextRequiredDifficulty(c.headers)
+		oldTarget := CompactToBig(bits)
+		newTarget := CompactToBig(got)
+
+		// a smaller target is harder to hit
+		switch c.compare {
+		case 0:
+			if newTarget.Cmp(oldTarget) != 0 {
+				t.Errorf("%s: got bits %d want %d", c.desc, got, bits)
+			}
+		case 1:
+			if newTarget.Cmp(oldTarget) >= 0 {
+				t.Errorf("%s: target did not shrink", c.desc)
+			}
+		case -1:
+			if newTarget.Cmp(oldTarget) <= 0 {
+				t.Errorf("%s: target did not grow", c.desc)
+			}
+		}
+	}
+}
+
+func TestActiveRetarget(t *testing.T) {
+	originRetarget := consensus.ActiveNetParams.Retarget
+	defer func() { consensus.ActiveNetParams.Retarget = originRetarget }()
+
+	consensus.ActiveNetParams.Retarget = nil
+	if algorithm := ActiveRetarget(100); algorithm != nil {
+		t.Error("no retarget params should keep the epoch retarget")
+	}
+
+	consensus.ActiveNetParams.Retarget = &consensus.RetargetParams{
+		Algorithm:        consensus.RetargetLWMA,
+		ActivationHeight: 50,
+	}
+	if algorithm := ActiveRetarget(49); algorithm != nil {
+		t.Error("lwma should stay inactive below the activation height")
+	}
+	if algorithm := ActiveRetarget(50); algorithm == nil {
+		t.Error("lwma should be active from the activation height")
+	}
+}
@@ -86,12 +86,78 @@ type Checkpoint struct {
 	Hash   bc.Hash
 }
 
+// RetargetLWMA names the linearly weighted moving average retarget algorithm
+const RetargetLWMA = "lwma"
+
+// PoWSM3 names the bare SM3 proof-of-work hasher for private chains
+const PoWSM3 = "sm3"
+
+// versionbits softfork deployment parameters
+const (
+	// VersionBitsWindow is the size in blocks of a softfork signaling window
+	VersionBitsWindow = uint64(2016)
+	// VersionBitsThreshold is how many blocks of one window must signal
+	// before a deployment locks in
+	VersionBitsThreshold = uint64(1916)
+)
+
+// Deployment defines one versionbits softfork deployment of a network
+type Deployment struct {
+	// Bit is the block version bit miners set to signal readiness
+	Bit uint8
+	// StartHeight is the first window boundary the deployment collects
+	// signals from
+	StartHeight uint64
+	// TimeoutHeight fails the deployment when reached before lock-in
+	TimeoutHeight uint64
+}
+
+// RetargetParams selects an alternative difficulty retarget algorithm for a
+// network. The epoch retarget stays in force below the activation height.
+type RetargetParams struct {
+	// Algorithm names the retarget function, see the Retarget* constants
+	Algorithm string
+	// ActivationHeight is the first height the algorithm computes the
+	// target for, zero disables it
+	ActivationHeight uint64
+	// Window is the number of recent blocks a windowed algorithm looks
+	// at, zero picks the algorithm default
+	Window uint64
+}
+
 // Params store the config for different network
 type Params struct {
 	// Name defines a human-readable identifier for the network.
 	Name            string
 	Bech32HRPSegwit string
 	Checkpoints     []Checkpoint
+
+	// Retarget selects an alternative difficulty retarget algorithm with
+	// its activation height, nil keeps the epoch retarget
+	Retarget *RetargetParams
+
+	// PoWAlgorithm selects the proof-of-work hasher, see the PoW*
+	// constants, empty keeps tensority
+	PoWAlgorithm string
+
+	// AssumeValid pins a block under which initial sync trusts programs
+	// and signatures, nil validates everything
+	AssumeValid *Checkpoint
+
+	// Deployments holds the versionbits softfork deployments of the
+	// network, keyed by rule name
+	Deployments map[string]*Deployment
+
+	// DNSSeeds are hostnames whose resolved addresses are used to
+	// discover seed nodes of the network
+	DNSSeeds []string
+
+	// SeedNodes are hardcoded host:port fallback seeds of the network
+	SeedNodes []string
+
+	// SeedPort is the p2p port assumed for addresses resolved from the
+	// DNS seeds
+	SeedPort uint16
 }
 
 // ActiveNetParams is ...
@@ -110,6 +176,9 @@ var TestNetParams = Params{
 	Checkpoints: []Checkpoint{
 		{12187, bc.NewHash([32]byte{0x76, 0xe7, 0x18, 0xd7, 0xa3, 0x61, 0xc1, 0x2c, 0x57, 0x88, 0xcd, 0x9d, 0x8a, 0xd8, 0xf2, 0x7f, 0xbc, 0x12, 0x4f, 0xdc, 0x11, 0x3b, 0xb6, 0x1f, 0x3b, 0x89, 0x48, 0x93, 0xbc, 0x95, 0xa7, 0xb1})},
 	},
+	DNSSeeds:  []string{"testnet-seed.bytom-gm.io"},
+	SeedNodes: []string{"47.75.116.232:46659", "139.224.132.186:46659"},
+	SeedPort:  46659,
 }
 
 // SoloNetParams is the config for test-net
@@ -11,6 +11,10 @@ const (
 	SFFastSync
 	// SFSPV indicate peer support spv mode
 	SFSPV
+	// SFBlocksOnly indicate peer does not relay loose transactions
+	SFBlocksOnly
+	// SFCompactBlocks indicate peer support compact block relay
+	SFCompactBlocks
 	// DefaultServices is the server that this node support
 	DefaultServices = SFFullNode | SFFastSync | SFSPV
 )
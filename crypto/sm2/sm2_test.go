@@ -25,12 +25,19 @@ import (
 	"math/big"
 	"net"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 )
 
 func TestSm2(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "sm2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
 	priv, err := GenerateKey() // 生成密钥对
 	if err != nil {
 		log.Fatal(err)
@@ -49,38 +56,38 @@ func TestSm2(t *testing.T) {
 		fmt.Printf("Error: failed to decrypt: %v\n", err)
 	}
 	fmt.Printf("clear text = %s\n", d1)
-	ok, err := WritePrivateKeytoPem("priv.pem", priv, nil) // 生成密钥文件
+	ok, err := WritePrivateKeytoPem(filepath.Join(tmpDir, "priv.pem"), priv, nil) // 生成密钥文件
 	if ok != true {
 		log.Fatal(err)
 	}
 	pubKey, _ := priv.Public().(*PublicKey)
-	ok, err = WritePublicKeytoPem("pub.pem", pubKey, nil) // 生成公钥文件
+	ok, err = WritePublicKeytoPem(filepath.Join(tmpDir, "pub.pem"), pubKey, nil) // 生成公钥文件
 	if ok != true {
 		log.Fatal(err)
 	}
 	msg = []byte("test")
-	err = ioutil.WriteFile("ifile", msg, os.FileMode(0644)) // 生成测试文件
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "ifile"), msg, os.FileMode(0644)) // 生成测试文件
 	if err != nil {
 		log.Fatal(err)
 	}
-	privKey, err := ReadPrivateKeyFromPem("priv.pem", nil) // 读取密钥
+	privKey, err := ReadPrivateKeyFromPem(filepath.Join(tmpDir, "priv.pem"), nil) // 读取密钥
 	if err != nil {
 		log.Fatal(err)
 	}
-	pubKey, err = ReadPublicKeyFromPem("pub.pem", nil) // 读取公钥
+	pubKey, err = ReadPublicKeyFromPem(filepath.Join(tmpDir, "pub.pem"), nil) // 读取公钥
 	if err != nil {
 		log.Fatal(err)
 	}
-	msg, _ = ioutil.ReadFile("ifile")                // 从文件读取数据
-	sign, err := privKey.Sign(rand.Reader, msg, nil) // 签名
+	msg, _ = ioutil.ReadFile(filepath.Join(tmpDir, "ifile")) // 从文件读取数据
+	sign, err := privKey.Sign(rand.Reader, msg, nil)         // 签名
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = ioutil.WriteFile("ofile", sign, os.FileMode(0644))
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "ofile"), sign, os.FileMode(0644))
 	if err != nil {
 		log.Fatal(err)
 	}
-	signdata, _ := ioutil.ReadFile("ofile")
+	signdata, _ := ioutil.ReadFile(filepath.Join(tmpDir, "ofile"))
 	ok = privKey.Verify(msg, signdata) // 密钥验证
 	if ok != true {
 		fmt.Printf("Verify error\n")
@@ -101,11 +108,11 @@ func TestSm2(t *testing.T) {
 		//		SignatureAlgorithm: ECDSAWithSHA256,
 		SignatureAlgorithm: SM2WithSM3,
 	}
-	_, err = CreateCertificateRequestToPem("req.pem", &templateReq, privKey)
+	_, err = CreateCertificateRequestToPem(filepath.Join(tmpDir, "req.pem"), &templateReq, privKey)
 	if err != nil {
 		log.Fatal(err)
 	}
-	req, err := ReadCertificateRequestFromPem("req.pem")
+	req, err := ReadCertificateRequestFromPem(filepath.Join(tmpDir, "req.pem"))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -182,11 +189,11 @@ func TestSm2(t *testing.T) {
 		},
 	}
 	pubKey, _ = priv.Public().(*PublicKey)
-	ok, _ = CreateCertificateToPem("cert.pem", &template, &template, pubKey, privKey)
+	ok, _ = CreateCertificateToPem(filepath.Join(tmpDir, "cert.pem"), &template, &template, pubKey, privKey)
 	if ok != true {
 		fmt.Printf("failed to create cert file\n")
 	}
-	cert, err := ReadCertificateFromPem("cert.pem")
+	cert, err := ReadCertificateFromPem(filepath.Join(tmpDir, "cert.pem"))
 	if err != nil {
 		fmt.Printf("failed to read cert file")
 	}
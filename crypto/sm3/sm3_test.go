@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -32,12 +33,18 @@ func byteToString(b []byte) string {
 	return ret
 }
 func TestSm3(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "sm3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
 	msg := []byte("test")
-	err := ioutil.WriteFile("ifile", msg, os.FileMode(0644)) // 生成测试文件
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "ifile"), msg, os.FileMode(0644)) // 生成测试文件
 	if err != nil {
 		log.Fatal(err)
 	}
-	msg, err = ioutil.ReadFile("ifile")
+	msg, err = ioutil.ReadFile(filepath.Join(tmpDir, "ifile"))
 	if err != nil {
 		log.Fatal(err)
 	}
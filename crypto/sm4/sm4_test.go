@@ -17,17 +17,27 @@ package sm4
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
 
 func TestSM4(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "sm4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
 	key := []byte("1234567890abcdef")
 	fmt.Printf("key = %v\n", key)
 	data := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10}
-	WriteKeyToPem("key.pem", key, nil)
-	key, err := ReadKeyFromPem("key.pem", nil)
+	keyPem := filepath.Join(tmpDir, "key.pem")
+	WriteKeyToPem(keyPem, key, nil)
+	key, err = ReadKeyFromPem(keyPem, nil)
 	fmt.Printf("key = %v\n", key)
 	if err != nil {
 		log.Fatal(err)
@@ -50,10 +60,17 @@ func TestSM4(t *testing.T) {
 
 func BenchmarkSM4(t *testing.B) {
 	t.ReportAllocs()
+	tmpDir, err := ioutil.TempDir("", "sm4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
 	key := []byte("1234567890abcdef")
 	data := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10}
-	WriteKeyToPem("key.pem", key, nil)
-	key, err := ReadKeyFromPem("key.pem", nil)
+	keyPem := filepath.Join(tmpDir, "key.pem")
+	WriteKeyToPem(keyPem, key, nil)
+	key, err = ReadKeyFromPem(keyPem, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,127 @@
+package leveldb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// defaultPruneKeepBlocks is how many recent blocks prune mode keeps on disk
+// so a reorg never reaches for a discarded block
+const defaultPruneKeepBlocks = uint64(1000)
+
+var pruneStateKey = []byte("pruneState")
+
+var pruneKeepBlocks = uint64(0)
+
+// SetPruneMode enables prune mode, which discards the raw data and the
+// transaction status of blocks once they sit keepBlocks under the chain tip.
+// Block headers and the utxo set always stay on disk. A keepBlocks of zero
+// keeps the default. Call it before NewStore.
+func SetPruneMode(enabled bool, keepBlocks uint64) {
+	if !enabled {
+		pruneKeepBlocks = 0
+		return
+	}
+	if keepBlocks == 0 {
+		keepBlocks = defaultPruneKeepBlocks
+	}
+	pruneKeepBlocks = keepBlocks
+}
+
+// pruneState records how far block data has been discarded, it shares the
+// json persistence style of BlockStoreState
+type pruneState struct {
+	Height uint64
+}
+
+func loadPruneStateJSON(db dbm.DB) *pruneState {
+	bytes := db.Get(pruneStateKey)
+	if bytes == nil {
+		return nil
+	}
+	ps := &pruneState{}
+	if err := json.Unmarshal(bytes, ps); err != nil {
+		return nil
+	}
+	return ps
+}
+
+// PruneHeight returns the height up to which raw block data has been
+// discarded, zero when nothing has been pruned
+func (s *Store) PruneHeight() uint64 {
+	return atomic.LoadUint64(&s.pruneHeight)
+}
+
+// pruneBlocks discards the raw data and the transaction status of blocks
+// buried more than pruneKeep under bestHeight, then nudges the backend to
+// compact the ranges the deletes landed in
+func (s *Store) pruneBlocks(bestHeight uint64) error {
+	if s.pruneKeep == 0 || bestHeight <= s.pruneKeep {
+		return nil
+	}
+
+	target := bestHeight - s.pruneKeep
+	pruned := atomic.LoadUint64(&s.pruneHeight)
+	if target <= pruned {
+		return nil
+	}
+
+	batch := s.db.NewBatch()
+	deleted := 0
+	bhIter := s.db.IteratorPrefix(blockHeaderPrefix)
+	defer bhIter.Release()
+
+	for bhIter.Next() {
+		key := bhIter.Key()
+		if len(key) < len(blockHeaderPrefix)+8+32 {
+			continue
+		}
+		height := binary.BigEndian.Uint64(key[len(blockHeaderPrefix) : len(blockHeaderPrefix)+8])
+		if height <= pruned {
+			continue
+		}
+		if height > target {
+			break
+		}
+
+		b32 := [32]byte{}
+		copy(b32[:], key[len(blockHeaderPrefix)+8:])
+		hash := bc.NewHash(b32)
+		batch.Delete(calcBlockKey(&hash))
+		batch.Delete(calcTxStatusKey(&hash))
+		deleted++
+	}
+
+	bytes, err := json.Marshal(pruneState{Height: target})
+	if err != nil {
+		return err
+	}
+
+	batch.Set(pruneStateKey, bytes)
+	batch.Write()
+	atomic.StoreUint64(&s.pruneHeight, target)
+
+	s.compactPruned()
+	log.WithFields(log.Fields{"height": target, "blocks": deleted}).Info("pruned block data")
+	return nil
+}
+
+// compactPruned asks the goleveldb backend to rewrite the tables the pruned
+// entries lived in, deletes alone leave the disk space claimed until a
+// compaction happens to visit them
+func (s *Store) compactPruned() {
+	ldb, ok := s.db.(*dbm.GoLevelDB)
+	if !ok {
+		return
+	}
+
+	ldb.DB().CompactRange(*util.BytesPrefix(blockPrefix))
+	ldb.DB().CompactRange(*util.BytesPrefix(txStatusPrefix))
+}
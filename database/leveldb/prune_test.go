@@ -0,0 +1,83 @@
+package leveldb
+
+import (
+	"os"
+	"testing"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestPruneBlocks(t *testing.T) {
+	testDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+
+	store := NewStore(testDB)
+	store.pruneKeep = 2
+
+	hashes := []bc.Hash{}
+	for height := uint64(1); height <= 5; height++ {
+		block := &types.Block{
+			BlockHeader: types.BlockHeader{
+				Height: height,
+			},
+		}
+		if err := store.SaveBlock(block, bc.NewTransactionStatus()); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, block.Hash())
+	}
+
+	if err := store.pruneBlocks(5); err != nil {
+		t.Fatal(err)
+	}
+
+	if pruneHeight := store.PruneHeight(); pruneHeight != 3 {
+		t.Errorf("got prune height %d, want 3", pruneHeight)
+	}
+
+	for i, hash := range hashes {
+		height := uint64(i + 1)
+		gotBlock := testDB.Get(calcBlockKey(&hash)) != nil
+		if wantBlock := height > 3; gotBlock != wantBlock {
+			t.Errorf("block %d on disk = %v, want %v", height, gotBlock, wantBlock)
+		}
+		if testDB.Get(calcBlockHeaderKey(height, &hash)) == nil {
+			t.Errorf("block header %d discarded by prune", height)
+		}
+	}
+
+	// a second pass with the same tip has nothing left to discard
+	if err := store.pruneBlocks(5); err != nil {
+		t.Fatal(err)
+	}
+	if pruneHeight := store.PruneHeight(); pruneHeight != 3 {
+		t.Errorf("got prune height %d after rerun, want 3", pruneHeight)
+	}
+}
+
+func TestPruneStateReload(t *testing.T) {
+	testDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+
+	store := NewStore(testDB)
+	store.pruneKeep = 1
+
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Height: 1,
+		},
+	}
+	if err := store.SaveBlock(block, bc.NewTransactionStatus()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.pruneBlocks(4); err != nil {
+		t.Fatal(err)
+	}
+
+	if pruneHeight := NewStore(testDB).PruneHeight(); pruneHeight != 3 {
+		t.Errorf("got prune height %d after reload, want 3", pruneHeight)
+	}
+}
@@ -0,0 +1,213 @@
+package leveldb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/database/storage"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// A chain state snapshot is a line-oriented text file: a json meta line
+// pinning the chain state it captures, one "header <hex>" line per block
+// header, one "utxo <hash> <hex>" line per utxo entry, and a closing json
+// commitment line. The commitment carries the sm3 digest over every utxo
+// line, so an importer can verify the set against a published hash before
+// trusting it.
+
+// snapshotVersion is the snapshot file format version this build writes and
+// accepts
+const snapshotVersion = uint64(1)
+
+// snapshotBatchSize bounds how many entries an import accumulates before
+// flushing them to disk
+const snapshotBatchSize = 10000
+
+// pre-define errors for snapshot export and import
+var (
+	// ErrBadSnapshot means the snapshot file can't be decoded
+	ErrBadSnapshot = errors.New("invalid chain state snapshot file")
+	// ErrSnapshotCommitment means the snapshot content doesn't hash to its commitment
+	ErrSnapshotCommitment = errors.New("snapshot doesn't match its utxo commitment")
+)
+
+// snapshotMeta leads the snapshot file and pins the chain state it captures
+type snapshotMeta struct {
+	Version uint64   `json:"version"`
+	Height  uint64   `json:"height"`
+	Hash    *bc.Hash `json:"hash"`
+}
+
+// snapshotCommitment closes the snapshot file. UtxoHash is the sm3 digest
+// over every utxo line in file order.
+type snapshotCommitment struct {
+	HeaderCount uint64 `json:"header_count"`
+	UtxoCount   uint64 `json:"utxo_count"`
+	UtxoHash    string `json:"utxo_hash"`
+}
+
+// ExportSnapshot writes the chain state of the store at its current best
+// height as a verifiable snapshot: the block headers up to the best height,
+// the full utxo set and a commitment over it.
+func (s *Store) ExportSnapshot(w io.Writer) error {
+	state := loadBlockStoreStateJSON(s.db)
+	if state == nil {
+		return errors.New("store has no chain state to snapshot")
+	}
+
+	bw := bufio.NewWriter(w)
+	meta, err := json.Marshal(snapshotMeta{Version: snapshotVersion, Height: state.Height, Hash: state.Hash})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(bw, "%s\n", meta)
+
+	headerCount := uint64(0)
+	bhIter := s.db.IteratorPrefix(blockHeaderPrefix)
+	defer bhIter.Release()
+	for bhIter.Next() {
+		key := bhIter.Key()
+		if len(key) < len(blockHeaderPrefix)+8 {
+			continue
+		}
+		if height := binary.BigEndian.Uint64(key[len(blockHeaderPrefix) : len(blockHeaderPrefix)+8]); height > state.Height {
+			break
+		}
+		fmt.Fprintf(bw, "header %s\n", bhIter.Value())
+		headerCount++
+	}
+
+	digest := sm3.New()
+	utxoCount := uint64(0)
+	utxoIter := s.db.IteratorPrefix([]byte(utxoPreFix))
+	defer utxoIter.Release()
+	for utxoIter.Next() {
+		line := fmt.Sprintf("utxo %s %s", utxoIter.Key()[len(utxoPreFix):], hex.EncodeToString(utxoIter.Value()))
+		digest.Write([]byte(line))
+		fmt.Fprintf(bw, "%s\n", line)
+		utxoCount++
+	}
+
+	commitment, err := json.Marshal(snapshotCommitment{
+		HeaderCount: headerCount,
+		UtxoCount:   utxoCount,
+		UtxoHash:    hex.EncodeToString(digest.Sum(nil)),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(bw, "%s\n", commitment)
+	return bw.Flush()
+}
+
+// ImportSnapshot bootstraps an empty core database from a snapshot: it
+// verifies the content against the snapshot commitment, stores the block
+// headers and the utxo set, and seeds the chain state at the snapshot
+// height so the node syncs forward normally from there.
+func ImportSnapshot(db dbm.DB, r io.Reader) (*protocol.BlockStoreState, error) {
+	if loadBlockStoreStateJSON(db) != nil {
+		return nil, errors.New("can't import a snapshot over an existing chain state")
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	if !scanner.Scan() {
+		return nil, ErrBadSnapshot
+	}
+
+	meta := &snapshotMeta{}
+	if err := json.Unmarshal(scanner.Bytes(), meta); err != nil {
+		return nil, errors.Sub(ErrBadSnapshot, err)
+	}
+	if meta.Version != snapshotVersion || meta.Hash == nil {
+		return nil, ErrBadSnapshot
+	}
+
+	digest := sm3.New()
+	headerCount, utxoCount := uint64(0), uint64(0)
+	var commitment *snapshotCommitment
+	batch := db.NewBatch()
+	pending := 0
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if commitment != nil {
+			return nil, errors.Wrap(ErrBadSnapshot, "content after the snapshot commitment")
+		}
+
+		switch {
+		case strings.HasPrefix(line, "header "):
+			raw := line[len("header "):]
+			header := &types.BlockHeader{}
+			if err := header.UnmarshalText([]byte(raw)); err != nil {
+				return nil, errors.Sub(ErrBadSnapshot, err)
+			}
+
+			headerHash := header.Hash()
+			batch.Set(calcBlockHeaderKey(header.Height, &headerHash), []byte(raw))
+			headerCount++
+
+		case strings.HasPrefix(line, "utxo "):
+			digest.Write([]byte(line))
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				return nil, ErrBadSnapshot
+			}
+
+			data, err := hex.DecodeString(fields[2])
+			if err != nil {
+				return nil, errors.Sub(ErrBadSnapshot, err)
+			}
+			if err := proto.Unmarshal(data, &storage.UtxoEntry{}); err != nil {
+				return nil, errors.Sub(ErrBadSnapshot, err)
+			}
+
+			batch.Set([]byte(utxoPreFix+fields[1]), data)
+			utxoCount++
+
+		default:
+			commitment = &snapshotCommitment{}
+			if err := json.Unmarshal([]byte(line), commitment); err != nil {
+				return nil, errors.Sub(ErrBadSnapshot, err)
+			}
+		}
+
+		if pending++; pending >= snapshotBatchSize {
+			batch.Write()
+			batch = db.NewBatch()
+			pending = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if commitment == nil {
+		return nil, errors.Wrap(ErrBadSnapshot, "missing snapshot commitment")
+	}
+
+	if commitment.HeaderCount != headerCount || commitment.UtxoCount != utxoCount || commitment.UtxoHash != hex.EncodeToString(digest.Sum(nil)) {
+		return nil, ErrSnapshotCommitment
+	}
+
+	state := &protocol.BlockStoreState{Height: meta.Height, Hash: meta.Hash}
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	batch.Set(blockStoreKey, bytes)
+	batch.Write()
+	return state, nil
+}
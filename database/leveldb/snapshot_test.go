@@ -0,0 +1,129 @@
+package leveldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/database/storage"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	sourceDB := dbm.NewDB("sourcedb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+
+	store := NewStore(sourceDB)
+	var bestHash bc.Hash
+	for height := uint64(0); height <= 2; height++ {
+		block := &types.Block{
+			BlockHeader: types.BlockHeader{
+				Height: height,
+			},
+		}
+		if err := store.SaveBlock(block, bc.NewTransactionStatus()); err != nil {
+			t.Fatal(err)
+		}
+		bestHash = block.Hash()
+	}
+
+	utxoHash := bc.NewHash([32]byte{0x01})
+	entry := storage.NewUtxoEntry(false, 1, false)
+	data, err := proto.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceDB.Set(calcUtxoKey(&utxoHash), data)
+
+	stateBytes, err := json.Marshal(protocol.BlockStoreState{Height: 2, Hash: &bestHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceDB.Set(blockStoreKey, stateBytes)
+
+	snapshot := &bytes.Buffer{}
+	if err := store.ExportSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	targetDB := dbm.NewDB("targetdb", "leveldb", "temp2")
+	defer os.RemoveAll("temp2")
+
+	state, err := ImportSnapshot(targetDB, bytes.NewReader(snapshot.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Height != 2 || *state.Hash != bestHash {
+		t.Errorf("got imported state %d:%s, want 2:%s", state.Height, state.Hash.String(), bestHash.String())
+	}
+
+	gotEntry, err := getUtxo(targetDB, &utxoHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotEntry.BlockHeight != entry.BlockHeight || gotEntry.IsCoinBase != entry.IsCoinBase || gotEntry.Spent != entry.Spent {
+		t.Errorf("got imported utxo entry %v, want %v", gotEntry, entry)
+	}
+
+	if targetDB.Get(calcBlockHeaderKey(2, &bestHash)) == nil {
+		t.Error("best block header missing after import")
+	}
+
+	if _, err := ImportSnapshot(targetDB, bytes.NewReader(snapshot.Bytes())); err == nil {
+		t.Error("importing over an existing chain state should fail")
+	}
+}
+
+func TestSnapshotCommitmentMismatch(t *testing.T) {
+	sourceDB := dbm.NewDB("sourcedb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+
+	store := NewStore(sourceDB)
+	block := &types.Block{}
+	if err := store.SaveBlock(block, bc.NewTransactionStatus()); err != nil {
+		t.Fatal(err)
+	}
+
+	utxoHash := bc.NewHash([32]byte{0x02})
+	data, err := proto.Marshal(storage.NewUtxoEntry(false, 1, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceDB.Set(calcUtxoKey(&utxoHash), data)
+
+	blockHash := block.Hash()
+	stateBytes, err := json.Marshal(protocol.BlockStoreState{Height: 0, Hash: &blockHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceDB.Set(blockStoreKey, stateBytes)
+
+	snapshot := &bytes.Buffer{}
+	if err := store.ExportSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// drop the utxo line, the commitment no longer matches
+	lines := []string{}
+	for _, line := range strings.Split(snapshot.String(), "\n") {
+		if !strings.HasPrefix(line, "utxo ") {
+			lines = append(lines, line)
+		}
+	}
+
+	targetDB := dbm.NewDB("targetdb", "leveldb", "temp2")
+	defer os.RemoveAll("temp2")
+
+	_, err = ImportSnapshot(targetDB, strings.NewReader(strings.Join(lines, "\n")))
+	if errors.Root(err) != ErrSnapshotCommitment {
+		t.Errorf("got error %v, want %v", err, ErrSnapshotCommitment)
+	}
+}
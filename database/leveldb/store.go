@@ -40,8 +40,12 @@ func loadBlockStoreStateJSON(db dbm.DB) *protocol.BlockStoreState {
 // It satisfies the interface protocol.Store, and provides additional
 // methods for querying current data.
 type Store struct {
-	db    dbm.DB
-	cache blockCache
+	db        dbm.DB
+	cache     blockCache
+	utxoCache *utxoCache
+
+	pruneKeep   uint64
+	pruneHeight uint64
 }
 
 func calcBlockKey(hash *bc.Hash) []byte {
@@ -76,10 +80,16 @@ func NewStore(db dbm.DB) *Store {
 	cache := newBlockCache(func(hash *bc.Hash) *types.Block {
 		return GetBlock(db, hash)
 	})
-	return &Store{
-		db:    db,
-		cache: cache,
+	store := &Store{
+		db:        db,
+		cache:     cache,
+		utxoCache: newUtxoCache(utxoCacheSize),
+		pruneKeep: pruneKeepBlocks,
+	}
+	if ps := loadPruneStateJSON(db); ps != nil {
+		store.pruneHeight = ps.Height
 	}
+	return store
 }
 
 // GetUtxo will search the utxo in db
@@ -100,7 +110,12 @@ func (s *Store) GetBlock(hash *bc.Hash) (*types.Block, error) {
 
 // GetTransactionsUtxo will return all the utxo that related to the input txs
 func (s *Store) GetTransactionsUtxo(view *state.UtxoViewpoint, txs []*bc.Tx) error {
-	return getTransactionsUtxo(s.db, view, txs)
+	return getTransactionsUtxo(s.db, s.utxoCache, view, txs)
+}
+
+// UtxoCacheStats returns the utxo cache lookup counters since start
+func (s *Store) UtxoCacheStats() (hits, misses uint64) {
+	return s.utxoCache.stats()
 }
 
 // GetTransactionStatus will return the utxo that related to the block hash
@@ -190,10 +205,13 @@ func (s *Store) SaveBlock(block *types.Block, ts *bc.TransactionStatus) error {
 // SaveChainStatus save the core's newest status && delete old status
 func (s *Store) SaveChainStatus(node *state.BlockNode, view *state.UtxoViewpoint) error {
 	batch := s.db.NewBatch()
-	if err := saveUtxoView(batch, view); err != nil {
+	if err := saveUtxoView(batch, s.utxoCache, view); err != nil {
 		return err
 	}
 
+	hits, misses := s.utxoCache.stats()
+	log.WithFields(log.Fields{"hits": hits, "misses": misses}).Debug("utxo cache status")
+
 	bytes, err := json.Marshal(protocol.BlockStoreState{Height: node.Height, Hash: &node.Hash})
 	if err != nil {
 		return err
@@ -201,5 +219,9 @@ func (s *Store) SaveChainStatus(node *state.BlockNode, view *state.UtxoViewpoint
 
 	batch.Set(blockStoreKey, bytes)
 	batch.Write()
+
+	if err := s.pruneBlocks(node.Height); err != nil {
+		log.WithField("error", err).Warning("fail on prune block data")
+	}
 	return nil
 }
@@ -0,0 +1,76 @@
+package leveldb
+
+import (
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+
+	"github.com/bytom-gm/database/storage"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// defaultCachedUtxos bounds the shared utxo cache when no size is configured
+const defaultCachedUtxos = 65536
+
+// utxoCacheSize is the entry bound the next NewStore builds its cache with
+var utxoCacheSize = defaultCachedUtxos
+
+// SetUtxoCacheSize overrides the utxo cache entry bound, zero and below keep
+// the default. Call it before NewStore.
+func SetUtxoCacheSize(size int) {
+	if size > 0 {
+		utxoCacheSize = size
+	}
+}
+
+// utxoCache keeps recently touched utxo entries between blocks so block
+// validation doesn't hit leveldb for every spent output. Every write goes
+// through the cache on block commit, which also covers reorgs: detached
+// outputs come back through the same path.
+type utxoCache struct {
+	mu     sync.Mutex
+	lru    *lru.Cache
+	hits   uint64
+	misses uint64
+}
+
+func newUtxoCache(size int) *utxoCache {
+	return &utxoCache{lru: lru.New(size)}
+}
+
+// get returns a copy of the cached entry, callers mutate their copy freely
+// without poisoning the cache
+func (c *utxoCache) get(hash *bc.Hash) (*storage.UtxoEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.lru.Get(*hash)
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+
+	entry := *cached.(*storage.UtxoEntry)
+	return &entry, true
+}
+
+func (c *utxoCache) add(hash bc.Hash, entry *storage.UtxoEntry) {
+	cached := *entry
+	c.mu.Lock()
+	c.lru.Add(hash, &cached)
+	c.mu.Unlock()
+}
+
+func (c *utxoCache) remove(hash bc.Hash) {
+	c.mu.Lock()
+	c.lru.Remove(hash)
+	c.mu.Unlock()
+}
+
+// stats returns the lookup counters since the store was created
+func (c *utxoCache) stats() (hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
@@ -0,0 +1,72 @@
+package leveldb
+
+import (
+	"os"
+	"testing"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/database/storage"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/state"
+)
+
+func TestUtxoCacheCopySemantics(t *testing.T) {
+	cache := newUtxoCache(16)
+	hash := bc.Hash{V0: 1}
+	cache.add(hash, storage.NewUtxoEntry(false, 7, false))
+
+	entry, ok := cache.get(&hash)
+	if !ok {
+		t.Fatal("cached entry should be found")
+	}
+
+	// mutating the returned copy must not poison the cache
+	entry.SpendOutput()
+	cached, _ := cache.get(&hash)
+	if cached.Spent {
+		t.Error("cache entry got mutated through a returned copy")
+	}
+}
+
+func TestUtxoCacheStats(t *testing.T) {
+	cache := newUtxoCache(16)
+	hash := bc.Hash{V0: 1}
+	missing := bc.Hash{V0: 2}
+	cache.add(hash, storage.NewUtxoEntry(false, 7, false))
+
+	cache.get(&hash)
+	cache.get(&missing)
+
+	hits, misses := cache.stats()
+	if hits != 1 || misses != 1 {
+		t.Errorf("got hits %d misses %d want 1 and 1", hits, misses)
+	}
+}
+
+func TestUtxoCacheWriteThrough(t *testing.T) {
+	testDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+
+	cache := newUtxoCache(16)
+	savedHash := bc.Hash{V0: 1}
+	spentHash := bc.Hash{V0: 2}
+	cache.add(spentHash, storage.NewUtxoEntry(false, 7, false))
+
+	view := state.NewUtxoViewpoint()
+	view.Entries[savedHash] = storage.NewUtxoEntry(false, 7, false)
+	view.Entries[spentHash] = storage.NewUtxoEntry(false, 7, true)
+
+	batch := testDB.NewBatch()
+	if err := saveUtxoView(batch, cache, view); err != nil {
+		t.Fatal(err)
+	}
+	batch.Write()
+
+	if _, ok := cache.get(&savedHash); !ok {
+		t.Error("saved entry should be written through to the cache")
+	}
+	if _, ok := cache.get(&spentHash); ok {
+		t.Error("spent entry should be dropped from the cache")
+	}
+}
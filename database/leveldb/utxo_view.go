@@ -16,13 +16,20 @@ func calcUtxoKey(hash *bc.Hash) []byte {
 	return []byte(utxoPreFix + hash.String())
 }
 
-func getTransactionsUtxo(db dbm.DB, view *state.UtxoViewpoint, txs []*bc.Tx) error {
+func getTransactionsUtxo(db dbm.DB, cache *utxoCache, view *state.UtxoViewpoint, txs []*bc.Tx) error {
 	for _, tx := range txs {
 		for _, prevout := range tx.SpentOutputIDs {
 			if view.HasUtxo(&prevout) {
 				continue
 			}
 
+			if cache != nil {
+				if utxo, ok := cache.get(&prevout); ok {
+					view.Entries[prevout] = utxo
+					continue
+				}
+			}
+
 			data := db.Get(calcUtxoKey(&prevout))
 			if data == nil {
 				continue
@@ -33,6 +40,9 @@ func getTransactionsUtxo(db dbm.DB, view *state.UtxoViewpoint, txs []*bc.Tx) err
 				return errors.Wrap(err, "unmarshaling utxo entry")
 			}
 
+			if cache != nil {
+				cache.add(prevout, &utxo)
+			}
 			view.Entries[prevout] = &utxo
 		}
 	}
@@ -52,10 +62,15 @@ func getUtxo(db dbm.DB, hash *bc.Hash) (*storage.UtxoEntry, error) {
 	return &utxo, nil
 }
 
-func saveUtxoView(batch dbm.Batch, view *state.UtxoViewpoint) error {
+// saveUtxoView persists the view changes and writes them through the cache,
+// so the cache follows every block commit and reorg
+func saveUtxoView(batch dbm.Batch, cache *utxoCache, view *state.UtxoViewpoint) error {
 	for key, entry := range view.Entries {
 		if entry.Spent && !entry.IsCoinBase {
 			batch.Delete(calcUtxoKey(&key))
+			if cache != nil {
+				cache.remove(key)
+			}
 			continue
 		}
 
@@ -64,10 +79,13 @@ func saveUtxoView(batch dbm.Batch, view *state.UtxoViewpoint) error {
 			return errors.Wrap(err, "marshaling utxo entry")
 		}
 		batch.Set(calcUtxoKey(&key), b)
+		if cache != nil {
+			cache.add(key, entry)
+		}
 	}
 	return nil
 }
 
 func SaveUtxoView(batch dbm.Batch, view *state.UtxoViewpoint) error {
-	return saveUtxoView(batch, view)
+	return saveUtxoView(batch, nil, view)
 }
@@ -49,7 +49,7 @@ func TestSaveUtxoView(t *testing.T) {
 		view.Entries[c.hash] = c.utxoEntry
 	}
 
-	saveUtxoView(batch, view)
+	saveUtxoView(batch, nil, view)
 	batch.Write()
 
 	for _, c := range cases {
@@ -77,7 +77,7 @@ func TestGetTransactionsUtxo(t *testing.T) {
 	for i := 0; i <= 2; i++ {
 		inputView.Entries[bc.Hash{V0: uint64(i)}] = storage.NewUtxoEntry(false, uint64(i), false)
 	}
-	saveUtxoView(batch, inputView)
+	saveUtxoView(batch, nil, inputView)
 	batch.Write()
 
 	cases := []struct {
@@ -174,7 +174,7 @@ func TestGetTransactionsUtxo(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		if err := getTransactionsUtxo(testDB, c.inputView, c.txs); c.err != (err != nil) {
+		if err := getTransactionsUtxo(testDB, nil, c.inputView, c.txs); c.err != (err != nil) {
 			t.Errorf("test case %d, want err = %v, get err = %v", i, c.err, err)
 		}
 		if !testutil.DeepEqual(c.inputView, c.fetchView) {
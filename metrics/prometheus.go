@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+)
+
+// PromHistogram is a fixed-bucket cumulative histogram in the Prometheus
+// style, for timings exported through the /metrics endpoint. The zero
+// value is not usable, construct it with NewPromHistogram.
+type PromHistogram struct {
+	mtx    sync.Mutex
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewPromHistogram returns a histogram with the given bucket upper bounds,
+// an implicit +Inf bucket catches everything above the largest bound.
+func NewPromHistogram(bounds ...float64) *PromHistogram {
+	sorted := append([]float64{}, bounds...)
+	sort.Float64s(sorted)
+	return &PromHistogram{
+		bounds: sorted,
+		counts: make([]uint64, len(sorted)+1),
+	}
+}
+
+// Observe records one value.
+func (h *PromHistogram) Observe(v float64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	idx := sort.SearchFloat64s(h.bounds, v)
+	h.counts[idx]++
+	h.sum += v
+	h.count++
+}
+
+// Snapshot returns the bucket bounds, the cumulative count per bucket
+// (the last entry is the +Inf bucket), the sum and the total count.
+func (h *PromHistogram) Snapshot() (bounds []float64, cumulative []uint64, sum float64, count uint64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	bounds = append([]float64{}, h.bounds...)
+	cumulative = make([]uint64, len(h.counts))
+	running := uint64(0)
+	for i, c := range h.counts {
+		running += c
+		cumulative[i] = running
+	}
+	return bounds, cumulative, h.sum, h.count
+}
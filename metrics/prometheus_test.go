@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPromHistogram(t *testing.T) {
+	h := NewPromHistogram(0.1, 1, 10)
+	for _, v := range []float64{0.05, 0.1, 0.5, 2, 50} {
+		h.Observe(v)
+	}
+
+	bounds, cumulative, sum, count := h.Snapshot()
+	if wantBounds := []float64{0.1, 1, 10}; !reflect.DeepEqual(bounds, wantBounds) {
+		t.Errorf("bounds = %v want %v", bounds, wantBounds)
+	}
+	if wantCumulative := []uint64{2, 3, 4, 5}; !reflect.DeepEqual(cumulative, wantCumulative) {
+		t.Errorf("cumulative = %v want %v", cumulative, wantCumulative)
+	}
+	if wantSum := 52.65; sum != wantSum {
+		t.Errorf("sum = %v want %v", sum, wantSum)
+	}
+	if count != 5 {
+		t.Errorf("count = %d want 5", count)
+	}
+}
+
+func TestPromHistogramEmpty(t *testing.T) {
+	h := NewPromHistogram(1)
+	_, cumulative, sum, count := h.Snapshot()
+	if wantCumulative := []uint64{0, 0}; !reflect.DeepEqual(cumulative, wantCumulative) {
+		t.Errorf("cumulative = %v want %v", cumulative, wantCumulative)
+	}
+	if sum != 0 || count != 0 {
+		t.Errorf("sum, count = %v, %d want 0, 0", sum, count)
+	}
+}
@@ -0,0 +1,55 @@
+package mining
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/errors"
+)
+
+// coinbaseHeightReserved is the worst-case size of the mandatory height
+// prefix of the coinbase arbitrary data
+var coinbaseHeightReserved = len(append([]byte{0x00}, []byte(strconv.FormatUint(^uint64(0), 10))...))
+
+// ErrCoinbaseMessageOversize means the operator message plus the reserved
+// extranonce space can't fit the consensus arbitrary data limit
+var ErrCoinbaseMessageOversize = errors.New("coinbase message and extranonce exceed the arbitrary data limit")
+
+var (
+	coinbaseMtx     sync.RWMutex
+	coinbaseMessage []byte
+	extranonceSize  uint64
+)
+
+// SetCoinbaseMessage installs the operator coinbase message and reserves
+// extranonce space, both are appended to the arbitrary data of every new
+// template. The size is validated against the consensus limit up front so a
+// bad setting fails here instead of on every template build
+func SetCoinbaseMessage(message []byte, extranonce uint64) error {
+	if len(message)+int(extranonce)+coinbaseHeightReserved > consensus.CoinbaseArbitrarySizeLimit {
+		return ErrCoinbaseMessageOversize
+	}
+
+	coinbaseMtx.Lock()
+	defer coinbaseMtx.Unlock()
+	coinbaseMessage = message
+	extranonceSize = extranonce
+	return nil
+}
+
+// GetCoinbaseMessage returns the operator coinbase message and the reserved
+// extranonce size
+func GetCoinbaseMessage() ([]byte, uint64) {
+	coinbaseMtx.RLock()
+	defer coinbaseMtx.RUnlock()
+	return coinbaseMessage, extranonceSize
+}
+
+// coinbaseExtra returns the bytes appended to the coinbase arbitrary data:
+// the operator message followed by zeroed extranonce space for pools to roll
+func coinbaseExtra() []byte {
+	message, extranonce := GetCoinbaseMessage()
+	extra := append([]byte{}, message...)
+	return append(extra, make([]byte, extranonce)...)
+}
@@ -0,0 +1,28 @@
+package mining
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bytom-gm/consensus"
+)
+
+func TestSetCoinbaseMessage(t *testing.T) {
+	defer SetCoinbaseMessage(nil, 0)
+
+	if err := SetCoinbaseMessage([]byte("mined by bytom"), 8); err != nil {
+		t.Fatal(err)
+	}
+	extra := coinbaseExtra()
+	if want := append([]byte("mined by bytom"), make([]byte, 8)...); !bytes.Equal(extra, want) {
+		t.Errorf("got coinbase extra %x want %x", extra, want)
+	}
+
+	oversize := make([]byte, consensus.CoinbaseArbitrarySizeLimit)
+	if err := SetCoinbaseMessage(oversize, 0); err != ErrCoinbaseMessageOversize {
+		t.Errorf("got err %v want %v", err, ErrCoinbaseMessageOversize)
+	}
+	if err := SetCoinbaseMessage(nil, uint64(consensus.CoinbaseArbitrarySizeLimit)); err != ErrCoinbaseMessageOversize {
+		t.Errorf("got err %v want %v", err, ErrCoinbaseMessageOversize)
+	}
+}
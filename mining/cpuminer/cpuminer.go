@@ -2,11 +2,13 @@ package cpuminer
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/common"
 	"github.com/bytom-gm/consensus/difficulty"
 	"github.com/bytom-gm/mining"
 	"github.com/bytom-gm/protocol"
@@ -18,8 +20,18 @@ const (
 	maxNonce          = ^uint64(0) // 2^64 - 1
 	defaultNumWorkers = 1
 	hashUpdateSecs    = 1
+	throttleBatch     = 5000 // nonces hashed between throttle pauses and stat flushes
 )
 
+// workerStat tracks the hash throughput of one mining worker goroutine. The
+// worker bumps hashes atomically, Status samples the counter to turn it into
+// a rate.
+type workerStat struct {
+	hashes    uint64 // total hashes, written atomically by the worker
+	sampled   uint64
+	sampledAt time.Time
+}
+
 // CPUMiner provides facilities for solving blocks (mining) using the CPU in
 // a concurrency-safe manner.
 type CPUMiner struct {
@@ -28,24 +40,34 @@ type CPUMiner struct {
 	accountManager   *account.Manager
 	txPool           *protocol.TxPool
 	numWorkers       uint64
+	throttle         uint64 // duty cycle percent, accessed atomically
 	started          bool
 	discreteMining   bool
 	workerWg         sync.WaitGroup
 	updateNumWorkers chan struct{}
 	quit             chan struct{}
 	newBlockCh       chan *bc.Hash
+	workerStats      []*workerStat
+	acceptedBlocks   uint64 // accessed atomically
+	staleBlocks      uint64 // accessed atomically
+	templateAt       time.Time
 }
 
 // solveBlock attempts to find some combination of a nonce, extra nonce, and
 // current timestamp which makes the passed block hash to a value less than the
 // target difficulty.
-func (m *CPUMiner) solveBlock(block *types.Block, ticker *time.Ticker, quit chan struct{}) bool {
+func (m *CPUMiner) solveBlock(block *types.Block, ticker *time.Ticker, quit chan struct{}, stat *workerStat) bool {
 	header := &block.BlockHeader
 	seed, err := m.chain.CalcNextSeed(&header.PreviousBlockHash)
 	if err != nil {
 		return false
 	}
 
+	throttle := atomic.LoadUint64(&m.throttle)
+	batchStart := time.Now()
+	batchHashes := uint64(0)
+	defer func() { atomic.AddUint64(&stat.hashes, batchHashes) }()
+
 	for i := uint64(0); i <= maxNonce; i++ {
 		select {
 		case <-quit:
@@ -59,6 +81,14 @@ func (m *CPUMiner) solveBlock(block *types.Block, ticker *time.Ticker, quit chan
 
 		header.Nonce = i
 		headerHash := header.Hash()
+		if batchHashes++; batchHashes == throttleBatch {
+			atomic.AddUint64(&stat.hashes, batchHashes)
+			batchHashes = 0
+			if throttle > 0 && throttle < 100 {
+				time.Sleep(time.Since(batchStart) * time.Duration(100-throttle) / time.Duration(throttle))
+			}
+			batchStart = time.Now()
+		}
 		if difficulty.CheckProofOfWork(&headerHash, seed, header.Bits) {
 			return true
 		}
@@ -73,7 +103,7 @@ func (m *CPUMiner) solveBlock(block *types.Block, ticker *time.Ticker, quit chan
 // is submitted.
 //
 // It must be run as a goroutine.
-func (m *CPUMiner) generateBlocks(quit chan struct{}) {
+func (m *CPUMiner) generateBlocks(quit chan struct{}, stat *workerStat) {
 	ticker := time.NewTicker(time.Second * hashUpdateSecs)
 	defer ticker.Stop()
 
@@ -85,29 +115,48 @@ out:
 		default:
 		}
 
-		block, err := mining.NewBlockTemplate(m.chain, m.txPool, m.accountManager)
-		if err != nil {
-			log.Errorf("Mining: failed on create NewBlockTemplate: %v", err)
-			continue
-		}
+		m.generateBlock(ticker, quit, stat)
+	}
+
+	m.workerWg.Done()
+}
 
-		if m.solveBlock(block, ticker, quit) {
-			if isOrphan, err := m.chain.ProcessBlock(block); err == nil {
-				log.WithFields(log.Fields{
-					"height":   block.BlockHeader.Height,
-					"isOrphan": isOrphan,
-					"tx":       len(block.Transactions),
-				}).Info("Miner processed block")
+// generateBlock runs one template-solve-submit round, a panic inside the
+// round is converted into an error event so the worker keeps running.
+func (m *CPUMiner) generateBlock(ticker *time.Ticker, quit chan struct{}, stat *workerStat) {
+	defer common.RecoverPanic("cpuminer", nil)
 
-				blockHash := block.Hash()
-				m.newBlockCh <- &blockHash
+	block, err := mining.NewBlockTemplate(m.chain, m.txPool, m.accountManager)
+	if err != nil {
+		log.Errorf("Mining: failed on create NewBlockTemplate: %v", err)
+		return
+	}
+
+	m.Lock()
+	m.templateAt = time.Now()
+	m.Unlock()
+
+	if m.solveBlock(block, ticker, quit, stat) {
+		if isOrphan, err := m.chain.ProcessBlock(block); err == nil {
+			log.WithFields(log.Fields{
+				"height":   block.BlockHeader.Height,
+				"isOrphan": isOrphan,
+				"tx":       len(block.Transactions),
+			}).Info("Miner processed block")
+
+			if isOrphan {
+				atomic.AddUint64(&m.staleBlocks, 1)
 			} else {
-				log.WithField("height", block.BlockHeader.Height).Errorf("Miner fail on ProcessBlock, %v", err)
+				atomic.AddUint64(&m.acceptedBlocks, 1)
 			}
+
+			blockHash := block.Hash()
+			m.newBlockCh <- &blockHash
+		} else {
+			atomic.AddUint64(&m.staleBlocks, 1)
+			log.WithField("height", block.BlockHeader.Height).Errorf("Miner fail on ProcessBlock, %v", err)
 		}
 	}
-
-	m.workerWg.Done()
 }
 
 // miningWorkerController launches the worker goroutines that are used to
@@ -122,10 +171,15 @@ func (m *CPUMiner) miningWorkerController() {
 	launchWorkers := func(numWorkers uint64) {
 		for i := uint64(0); i < numWorkers; i++ {
 			quit := make(chan struct{})
+			stat := &workerStat{sampledAt: time.Now()}
 			runningWorkers = append(runningWorkers, quit)
 
+			m.Lock()
+			m.workerStats = append(m.workerStats, stat)
+			m.Unlock()
+
 			m.workerWg.Add(1)
-			go m.generateBlocks(quit)
+			go m.generateBlocks(quit, stat)
 		}
 	}
 
@@ -156,11 +210,17 @@ out:
 				runningWorkers[i] = nil
 				runningWorkers = runningWorkers[:i]
 			}
+			m.Lock()
+			m.workerStats = m.workerStats[:len(runningWorkers)]
+			m.Unlock()
 
 		case <-m.quit:
 			for _, quit := range runningWorkers {
 				close(quit)
 			}
+			m.Lock()
+			m.workerStats = nil
+			m.Unlock()
 			break out
 		}
 	}
@@ -259,6 +319,64 @@ func (m *CPUMiner) NumWorkers() int32 {
 	return int32(m.numWorkers)
 }
 
+// SetThrottle sets the duty cycle of every worker as the percentage of wall
+// time spent hashing. Zero or anything from 100 up hashes flat out.
+//
+// This function is safe for concurrent access.
+func (m *CPUMiner) SetThrottle(percent uint64) {
+	atomic.StoreUint64(&m.throttle, percent)
+}
+
+// MiningStatus is the point-in-time report returned by Status.
+type MiningStatus struct {
+	IsMining           bool      `json:"is_mining"`
+	NumWorkers         int32     `json:"num_workers"`
+	ThrottlePercent    uint64    `json:"throttle_percent"`
+	WorkerHashRates    []float64 `json:"worker_hash_rates"`
+	HashesPerSecond    float64   `json:"hashes_per_second"`
+	AcceptedBlocks     uint64    `json:"accepted_blocks"`
+	StaleBlocks        uint64    `json:"stale_blocks"`
+	TemplateAgeSeconds float64   `json:"template_age_seconds"`
+}
+
+// Status reports the per-worker hashrate, the block counters and the age of
+// the template being solved. Hashrates cover the window since the previous
+// Status call.
+//
+// This function is safe for concurrent access.
+func (m *CPUMiner) Status() MiningStatus {
+	m.Lock()
+	defer m.Unlock()
+
+	status := MiningStatus{
+		IsMining:        m.started,
+		NumWorkers:      int32(m.numWorkers),
+		ThrottlePercent: atomic.LoadUint64(&m.throttle),
+		WorkerHashRates: []float64{},
+		AcceptedBlocks:  atomic.LoadUint64(&m.acceptedBlocks),
+		StaleBlocks:     atomic.LoadUint64(&m.staleBlocks),
+	}
+
+	now := time.Now()
+	for _, stat := range m.workerStats {
+		total := atomic.LoadUint64(&stat.hashes)
+		rate := float64(0)
+		if window := now.Sub(stat.sampledAt).Seconds(); window > 0 {
+			rate = float64(total-stat.sampled) / window
+		}
+		stat.sampled = total
+		stat.sampledAt = now
+
+		status.WorkerHashRates = append(status.WorkerHashRates, rate)
+		status.HashesPerSecond += rate
+	}
+
+	if m.started && !m.templateAt.IsZero() {
+		status.TemplateAgeSeconds = now.Sub(m.templateAt).Seconds()
+	}
+	return status
+}
+
 // NewCPUMiner returns a new instance of a CPU miner for the provided configuration.
 // Use Start to begin the mining process.  See the documentation for CPUMiner
 // type for more details.
@@ -1,7 +1,6 @@
 package mining
 
 import (
-	"sort"
 	"strconv"
 	"time"
 
@@ -16,7 +15,6 @@ import (
 	"github.com/bytom-gm/protocol/bc/types"
 	"github.com/bytom-gm/protocol/state"
 	"github.com/bytom-gm/protocol/validation"
-	"github.com/bytom-gm/protocol/vm/vmutil"
 )
 
 // createCoinbaseTx returns a coinbase transaction paying an appropriate subsidy
@@ -26,16 +24,14 @@ func createCoinbaseTx(accountManager *account.Manager, amount uint64, blockHeigh
 	amount += consensus.BlockSubsidy(blockHeight)
 	arbitrary := append([]byte{0x00}, []byte(strconv.FormatUint(blockHeight, 10))...)
 
-	var script []byte
-	if accountManager == nil {
-		script, err = vmutil.DefaultCoinbaseProgram()
-	} else {
-		script, err = accountManager.GetCoinbaseControlProgram()
-		arbitrary = append(arbitrary, accountManager.GetCoinbaseArbitrary()...)
-	}
+	script, err := coinbaseScript(accountManager, blockHeight)
 	if err != nil {
 		return nil, err
 	}
+	if accountManager != nil {
+		arbitrary = append(arbitrary, accountManager.GetCoinbaseArbitrary()...)
+	}
+	arbitrary = append(arbitrary, coinbaseExtra()...)
 
 	if len(arbitrary) > consensus.CoinbaseArbitrarySizeLimit {
 		return nil, validation.ErrCoinbaseArbitraryOversize
@@ -99,9 +95,13 @@ func NewBlockTemplate(c *protocol.Chain, txPool *protocol.TxPool, accountManager
 	bcBlock := &bc.Block{BlockHeader: &bc.BlockHeader{Height: nextBlockHeight}}
 	b.Transactions = []*types.Tx{nil}
 
-	txs := txPool.GetTransactions()
-	sort.Sort(byTime(txs))
+	gasBudget := templateGasBudget()
+	txs := prioritizer.Prioritize(txPool.GetTransactions())
 	for _, txDesc := range txs {
+		if excludeByPolicy(txDesc) {
+			continue
+		}
+
 		tx := txDesc.Tx.Tx
 		gasOnlyTx := false
 
@@ -119,7 +119,7 @@ func NewBlockTemplate(c *protocol.Chain, txPool *protocol.TxPool, accountManager
 			gasOnlyTx = true
 		}
 
-		if gasUsed+uint64(gasStatus.GasUsed) > consensus.MaxBlockGas {
+		if gasUsed+uint64(gasStatus.GasUsed) > gasBudget {
 			break
 		}
 
@@ -137,7 +137,7 @@ func NewBlockTemplate(c *protocol.Chain, txPool *protocol.TxPool, accountManager
 		gasUsed += uint64(gasStatus.GasUsed)
 		txFee += txDesc.Fee
 
-		if gasUsed == consensus.MaxBlockGas {
+		if gasUsed == gasBudget {
 			break
 		}
 	}
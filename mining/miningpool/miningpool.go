@@ -3,6 +3,7 @@ package miningpool
 import (
 	"errors"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -15,6 +16,10 @@ import (
 
 const (
 	maxSubmitChSize = 50
+
+	// templateRefreshSecs is how often the mempool is checked for new
+	// transactions worth a fresh block template
+	templateRefreshSecs = 10
 )
 
 type submitBlockMsg struct {
@@ -24,13 +29,16 @@ type submitBlockMsg struct {
 
 // MiningPool is the support struct for p2p mine pool
 type MiningPool struct {
-	mutex    sync.RWMutex
-	block    *types.Block
-	submitCh chan *submitBlockMsg
+	mutex      sync.RWMutex
+	block      *types.Block
+	submitCh   chan *submitBlockMsg
+	workNotify chan struct{}
+	poolTxs    int
 
 	chain          *protocol.Chain
 	accountManager *account.Manager
 	txPool         *protocol.TxPool
+	templates      *mining.TemplateManager
 	newBlockCh     chan *bc.Hash
 }
 
@@ -38,9 +46,11 @@ type MiningPool struct {
 func NewMiningPool(c *protocol.Chain, accountManager *account.Manager, txPool *protocol.TxPool, newBlockCh chan *bc.Hash) *MiningPool {
 	m := &MiningPool{
 		submitCh:       make(chan *submitBlockMsg, maxSubmitChSize),
+		workNotify:     make(chan struct{}),
 		chain:          c,
 		accountManager: accountManager,
 		txPool:         txPool,
+		templates:      mining.NewTemplateManager(c, txPool, accountManager),
 		newBlockCh:     newBlockCh,
 	}
 	m.generateBlock()
@@ -50,11 +60,19 @@ func NewMiningPool(c *protocol.Chain, accountManager *account.Manager, txPool *p
 
 // blockUpdater is the goroutine for keep update mining block
 func (m *MiningPool) blockUpdater() {
+	ticker := time.NewTicker(templateRefreshSecs * time.Second)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-m.chain.BlockWaiter(m.chain.BestBlockHeight() + 1):
 			m.generateBlock()
 
+		case <-ticker.C:
+			if m.txPoolChanged() {
+				m.generateBlock()
+			}
+
 		case submitMsg := <-m.submitCh:
 			err := m.submitWork(submitMsg.blockHeader)
 			if err == nil {
@@ -65,17 +83,38 @@ func (m *MiningPool) blockUpdater() {
 	}
 }
 
+// txPoolChanged reports whether the mempool moved since the current template
+// was built
+func (m *MiningPool) txPoolChanged() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.txPool.GetTransactions()) != m.poolTxs
+}
+
 // generateBlock generates a block template to mine
 func (m *MiningPool) generateBlock() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	block, err := mining.NewBlockTemplate(m.chain, m.txPool, m.accountManager)
+	block, err := m.templates.GetTemplate()
 	if err != nil {
-		log.Errorf("miningpool: failed on create NewBlockTemplate: %v", err)
+		log.Errorf("miningpool: failed on get block template: %v", err)
 		return
 	}
 	m.block = block
+	m.poolTxs = len(m.txPool.GetTransactions())
+
+	// wake every work waiter, long-polling miners pick up the new template
+	close(m.workNotify)
+	m.workNotify = make(chan struct{})
+}
+
+// WorkWaiter returns a channel that is closed the next time the block
+// template is regenerated
+func (m *MiningPool) WorkWaiter() <-chan struct{} {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.workNotify
 }
 
 // GetWork will return a block header for p2p mining
@@ -0,0 +1,82 @@
+package mining
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/vm/vmutil"
+)
+
+// ErrEmptyPayoutProgram means a configured payout program decoded to nothing
+var ErrEmptyPayoutProgram = errors.New("payout program is empty")
+
+var (
+	payoutMtx sync.Mutex
+
+	// control programs the coinbase cycles through, keyed by block height
+	payoutPrograms [][]byte
+
+	// derive a fresh mining account address per block instead of reusing
+	// the mining address
+	rotatePayout bool
+
+	// the program derived for the height being mined, so template rebuilds
+	// at the same height don't burn a key index each
+	derivedPayoutHeight  uint64
+	derivedPayoutProgram []byte
+)
+
+// LoadPayoutRotation installs the payout rotation from the hex encoded config
+// programs. With a program list the coinbase of height h pays programs[h mod
+// len], with rotateAddresses it pays a freshly derived mining account address
+// per block, with neither it keeps paying the fixed mining address.
+func LoadPayoutRotation(programs []string, rotateAddresses bool) error {
+	decoded := [][]byte{}
+	for _, rawProgram := range programs {
+		program, err := hex.DecodeString(rawProgram)
+		if err != nil {
+			return err
+		}
+		if len(program) == 0 {
+			return ErrEmptyPayoutProgram
+		}
+		decoded = append(decoded, program)
+	}
+
+	payoutMtx.Lock()
+	defer payoutMtx.Unlock()
+	payoutPrograms = decoded
+	rotatePayout = rotateAddresses
+	derivedPayoutProgram = nil
+	return nil
+}
+
+// coinbaseScript returns the control program the coinbase of blockHeight pays
+func coinbaseScript(accountManager *account.Manager, blockHeight uint64) ([]byte, error) {
+	payoutMtx.Lock()
+	defer payoutMtx.Unlock()
+
+	if len(payoutPrograms) > 0 {
+		return payoutPrograms[blockHeight%uint64(len(payoutPrograms))], nil
+	}
+
+	if accountManager == nil {
+		return vmutil.DefaultCoinbaseProgram()
+	}
+
+	if !rotatePayout {
+		return accountManager.GetCoinbaseControlProgram()
+	}
+
+	if derivedPayoutProgram == nil || derivedPayoutHeight != blockHeight {
+		program, err := accountManager.NextCoinbaseControlProgram()
+		if err != nil {
+			return nil, err
+		}
+		derivedPayoutHeight = blockHeight
+		derivedPayoutProgram = program
+	}
+	return derivedPayoutProgram, nil
+}
@@ -0,0 +1,96 @@
+package mining
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+
+	"github.com/bytom-gm/consensus"
+	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/protocol"
+)
+
+// SelectionPolicy holds the runtime tunable knobs of the candidate selection
+// loop. Zero values disable the corresponding knob
+type SelectionPolicy struct {
+	// MinFeeRate excludes transactions paying less than this many fee units
+	// per weight unit, they stay in the mempool
+	MinFeeRate uint64 `json:"min_fee_rate"`
+
+	// MaxBlockGasTarget caps the template gas below the consensus maximum
+	MaxBlockGasTarget uint64 `json:"max_block_gas_target"`
+
+	// LocalTxPriority places locally submitted transactions before relayed
+	// ones of the same age
+	LocalTxPriority bool `json:"local_tx_priority"`
+
+	// BannedPrograms excludes transactions paying any of these control programs
+	BannedPrograms []chainjson.HexBytes `json:"banned_programs"`
+}
+
+var (
+	policyMtx       sync.RWMutex
+	selectionPolicy SelectionPolicy
+)
+
+// GetSelectionPolicy returns a copy of the active selection policy
+func GetSelectionPolicy() SelectionPolicy {
+	policyMtx.RLock()
+	defer policyMtx.RUnlock()
+	return selectionPolicy
+}
+
+// SetSelectionPolicy installs a new selection policy, it applies from the
+// next template build
+func SetSelectionPolicy(p SelectionPolicy) {
+	policyMtx.Lock()
+	defer policyMtx.Unlock()
+	selectionPolicy = p
+}
+
+// LoadSelectionPolicy installs the selection policy from the node config,
+// banned programs are hex encoded there
+func LoadSelectionPolicy(minFeeRate, maxBlockGasTarget uint64, localTxPriority bool, bannedPrograms []string) error {
+	policy := SelectionPolicy{
+		MinFeeRate:        minFeeRate,
+		MaxBlockGasTarget: maxBlockGasTarget,
+		LocalTxPriority:   localTxPriority,
+	}
+	for _, rawProgram := range bannedPrograms {
+		program, err := hex.DecodeString(rawProgram)
+		if err != nil {
+			return err
+		}
+		policy.BannedPrograms = append(policy.BannedPrograms, program)
+	}
+
+	SetSelectionPolicy(policy)
+	return nil
+}
+
+// templateGasBudget returns the policy-capped gas budget of one template
+func templateGasBudget() uint64 {
+	policy := GetSelectionPolicy()
+	if policy.MaxBlockGasTarget > 0 && policy.MaxBlockGasTarget < consensus.MaxBlockGas {
+		return policy.MaxBlockGasTarget
+	}
+	return consensus.MaxBlockGas
+}
+
+// excludeByPolicy reports whether the selection policy keeps the transaction
+// out of the template, excluded transactions stay in the mempool
+func excludeByPolicy(txDesc *protocol.TxDesc) bool {
+	policy := GetSelectionPolicy()
+	if policy.MinFeeRate > 0 && txDesc.Fee < policy.MinFeeRate*txDesc.Weight {
+		return true
+	}
+
+	for _, banned := range policy.BannedPrograms {
+		for _, output := range txDesc.Tx.Outputs {
+			if bytes.Equal(banned, output.ControlProgram) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package mining
+
+import (
+	"testing"
+	"time"
+
+	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestExcludeByPolicy(t *testing.T) {
+	defer SetSelectionPolicy(SelectionPolicy{})
+
+	bannedProgram := []byte{0x51}
+	tx := types.NewTx(types.TxData{
+		Outputs: []*types.TxOutput{types.NewTxOutput(bc.AssetID{}, 100, bannedProgram)},
+	})
+
+	cases := []struct {
+		policy  SelectionPolicy
+		txDesc  *protocol.TxDesc
+		exclude bool
+	}{
+		{
+			policy:  SelectionPolicy{},
+			txDesc:  &protocol.TxDesc{Tx: tx, Fee: 0, Weight: 100},
+			exclude: false,
+		},
+		{
+			policy:  SelectionPolicy{MinFeeRate: 2},
+			txDesc:  &protocol.TxDesc{Tx: tx, Fee: 100, Weight: 100},
+			exclude: true,
+		},
+		{
+			policy:  SelectionPolicy{MinFeeRate: 2},
+			txDesc:  &protocol.TxDesc{Tx: tx, Fee: 200, Weight: 100},
+			exclude: false,
+		},
+		{
+			policy:  SelectionPolicy{BannedPrograms: []chainjson.HexBytes{bannedProgram}},
+			txDesc:  &protocol.TxDesc{Tx: tx},
+			exclude: true,
+		},
+		{
+			policy:  SelectionPolicy{BannedPrograms: []chainjson.HexBytes{{0x52}}},
+			txDesc:  &protocol.TxDesc{Tx: tx},
+			exclude: false,
+		},
+	}
+
+	for i, c := range cases {
+		SetSelectionPolicy(c.policy)
+		if got := excludeByPolicy(c.txDesc); got != c.exclude {
+			t.Errorf("case %d: got exclude %v want %v", i, got, c.exclude)
+		}
+	}
+}
+
+func TestLocalTxPriority(t *testing.T) {
+	defer SetSelectionPolicy(SelectionPolicy{})
+	SetSelectionPolicy(SelectionPolicy{LocalTxPriority: true})
+
+	base := time.Now()
+	txs := []*protocol.TxDesc{
+		{Added: base},
+		{Added: base.Add(time.Second), Local: true},
+		{Added: base.Add(2 * time.Second)},
+	}
+
+	ordered := (&defaultPrioritizer{}).Prioritize(txs)
+	if !ordered[0].Local {
+		t.Error("local tx should be ordered first")
+	}
+	if ordered[1].Added.After(ordered[2].Added) {
+		t.Error("relayed txs should keep oldest first order")
+	}
+}
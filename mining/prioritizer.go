@@ -0,0 +1,43 @@
+package mining
+
+import (
+	"sort"
+
+	"github.com/bytom-gm/protocol"
+)
+
+// Prioritizer decides the order and inclusion of mempool transactions in a
+// new block template. Implementations receive the candidate set after it left
+// the mempool and return the transactions to try, in the order to try them;
+// dropping a transaction from the returned slice excludes it from the
+// template without removing it from the mempool.
+type Prioritizer interface {
+	Prioritize(txs []*protocol.TxDesc) []*protocol.TxDesc
+}
+
+// defaultPrioritizer picks the best-paying packages first: candidates are
+// ordered by descendant package fee rate so a child can pull its underpaying
+// parent into the block, ties fall back to oldest accepted first
+type defaultPrioritizer struct{}
+
+func (p *defaultPrioritizer) Prioritize(txs []*protocol.TxDesc) []*protocol.TxDesc {
+	sort.Sort(byTime(txs))
+	txs = packageOrder(txs)
+	if GetSelectionPolicy().LocalTxPriority {
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].Local && !txs[j].Local
+		})
+	}
+	return txs
+}
+
+var prioritizer Prioritizer = &defaultPrioritizer{}
+
+// SetPrioritizer install a custom block template prioritizer, passing nil
+// restores the default ordering
+func SetPrioritizer(p Prioritizer) {
+	if p == nil {
+		p = &defaultPrioritizer{}
+	}
+	prioritizer = p
+}
@@ -0,0 +1,85 @@
+package mining
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestDefaultPrioritizer(t *testing.T) {
+	base := time.Now()
+	txs := []*protocol.TxDesc{
+		{Added: base.Add(2 * time.Second)},
+		{Added: base},
+		{Added: base.Add(time.Second)},
+	}
+
+	ordered := (&defaultPrioritizer{}).Prioritize(txs)
+	for i := 1; i < len(ordered); i++ {
+		if ordered[i].Added.Before(ordered[i-1].Added) {
+			t.Errorf("tx %d added before tx %d, want oldest first on equal fee rates", i, i-1)
+		}
+	}
+}
+
+func prioritizerTestTxDesc(seed byte, fee uint64) *protocol.TxDesc {
+	tx := types.NewTx(types.TxData{
+		SerializedSize: 100,
+		Inputs: []*types.TxInput{
+			types.NewSpendInput(nil, bc.NewHash([32]byte{seed}), *consensus.BTMAssetID, 1, 1, []byte{0x51}),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*consensus.BTMAssetID, 1, []byte{0x6a}),
+		},
+	})
+	return &protocol.TxDesc{Tx: tx, Weight: tx.SerializedSize, Fee: fee}
+}
+
+func TestPackageOrderCPFP(t *testing.T) {
+	parent := prioritizerTestTxDesc(0x01, 1)
+	child := prioritizerTestTxDesc(0x02, 100)
+	// wire the child onto the parent's output, the ordering only inspects
+	// the spend graph so the witness does not have to verify
+	child.Tx.SpentOutputIDs = []bc.Hash{*parent.Tx.Tx.TxHeader.ResultIds[0]}
+	loner := prioritizerTestTxDesc(0x03, 50)
+
+	// parent alone pays 10 milli-neu/byte, its package with the child pays
+	// 505 and must beat the loner's 500 while keeping the parent first
+	ordered := packageOrder([]*protocol.TxDesc{loner, parent, child})
+	want := []*protocol.TxDesc{parent, child, loner}
+	for i := range want {
+		if ordered[i] != want[i] {
+			t.Fatalf("position %d got tx %s, want %s", i, ordered[i].Tx.ID.String(), want[i].Tx.ID.String())
+		}
+	}
+}
+
+type reversePrioritizer struct{}
+
+func (p *reversePrioritizer) Prioritize(txs []*protocol.TxDesc) []*protocol.TxDesc {
+	for i, j := 0, len(txs)-1; i < j; i, j = i+1, j-1 {
+		txs[i], txs[j] = txs[j], txs[i]
+	}
+	return txs
+}
+
+func TestSetPrioritizer(t *testing.T) {
+	defer SetPrioritizer(nil)
+
+	SetPrioritizer(&reversePrioritizer{})
+	base := time.Now()
+	txs := []*protocol.TxDesc{{Added: base}, {Added: base.Add(time.Second)}}
+	ordered := prioritizer.Prioritize(txs)
+	if !ordered[0].Added.After(ordered[1].Added) {
+		t.Error("custom prioritizer was not applied")
+	}
+
+	SetPrioritizer(nil)
+	if _, ok := prioritizer.(*defaultPrioritizer); !ok {
+		t.Error("SetPrioritizer(nil) should restore the default prioritizer")
+	}
+}
@@ -1,9 +1,100 @@
 package mining
 
-import "github.com/bytom-gm/protocol"
+import (
+	"sort"
+
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+)
 
 type byTime []*protocol.TxDesc
 
 func (a byTime) Len() int           { return len(a) }
 func (a byTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byTime) Less(i, j int) bool { return a[i].Added.Before(a[j].Added) }
+
+// packageOrder returns the candidates with the highest descendant package
+// fee rates first, while keeping every transaction behind its in-pool
+// ancestors so the template loop can apply them in spend order. A paying
+// child thereby pulls its underpaying parent into the block (CPFP).
+func packageOrder(txs []*protocol.TxDesc) []*protocol.TxDesc {
+	producedBy := map[bc.Hash]int{}
+	for i, txD := range txs {
+		if txD.Tx == nil {
+			continue
+		}
+		for _, resultID := range txD.Tx.Tx.TxHeader.ResultIds {
+			producedBy[*resultID] = i
+		}
+	}
+
+	parents := make([][]int, len(txs))
+	children := make([][]int, len(txs))
+	for i, txD := range txs {
+		if txD.Tx == nil {
+			continue
+		}
+		for _, spentID := range txD.Tx.Tx.SpentOutputIDs {
+			if parent, ok := producedBy[spentID]; ok && parent != i {
+				parents[i] = append(parents[i], parent)
+				children[parent] = append(children[parent], i)
+			}
+		}
+	}
+
+	rates := make([]uint64, len(txs))
+	for i := range txs {
+		fee, weight := txs[i].Fee, txs[i].Weight
+		for _, descendant := range descendantIndexes(i, children) {
+			fee += txs[descendant].Fee
+			weight += txs[descendant].Weight
+		}
+		if weight > 0 {
+			rates[i] = fee * 1000 / weight
+		}
+	}
+
+	order := make([]int, len(txs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return rates[order[a]] > rates[order[b]] })
+
+	result := make([]*protocol.TxDesc, 0, len(txs))
+	emitted := make([]bool, len(txs))
+	var emit func(int)
+	emit = func(i int) {
+		if emitted[i] {
+			return
+		}
+		emitted[i] = true
+		for _, parent := range parents[i] {
+			emit(parent)
+		}
+		result = append(result, txs[i])
+	}
+	for _, i := range order {
+		emit(i)
+	}
+	return result
+}
+
+// descendantIndexes return the indexes of every candidate transitively
+// spending outputs of candidate i
+func descendantIndexes(i int, children [][]int) []int {
+	visited := map[int]bool{}
+	descendants := []int{}
+	queue := append([]int{}, children[i]...)
+	for len(queue) > 0 {
+		child := queue[0]
+		queue = queue[1:]
+		if visited[child] {
+			continue
+		}
+
+		visited[child] = true
+		descendants = append(descendants, child)
+		queue = append(queue, children[child]...)
+	}
+	return descendants
+}
@@ -0,0 +1,493 @@
+// Package stratum implements a stratum-like mining server distributing block
+// template work to external miners over newline delimited JSON, with share
+// validation, per-worker variable difficulty and statistics.
+package stratum
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/consensus/difficulty"
+	"github.com/bytom-gm/mining/miningpool"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+const (
+	// maxShareMultiplier caps how easy vardiff may make a worker's shares
+	maxShareMultiplier = uint64(1) << 32
+
+	methodSubscribe     = "mining.subscribe"
+	methodSubmit        = "mining.submit"
+	methodNotify        = "mining.notify"
+	methodSetDifficulty = "mining.set_difficulty"
+)
+
+// pre-define stratum errors
+var (
+	ErrNoJob         = errors.New("no mining job is ready")
+	ErrStaleJob      = errors.New("stale job")
+	ErrLowDifficulty = errors.New("low difficulty share")
+	ErrNotSubscribed = errors.New("worker is not subscribed")
+	ErrUnknownMethod = errors.New("unknown stratum method")
+)
+
+// Request is one client message of the line based stratum protocol
+type Request struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response answers one Request
+type Response struct {
+	ID     uint64      `json:"id"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Notification is a server push without a matching request
+type Notification struct {
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+// JobNotify carries one unit of work, the miner rolls nonce and timestamp of
+// the raw header and submits solutions below its share target
+type JobNotify struct {
+	JobID       string `json:"job_id"`
+	Height      uint64 `json:"height"`
+	BlockHeader string `json:"block_header"`
+	Seed        string `json:"seed"`
+	Bits        uint64 `json:"bits"`
+	ShareBits   uint64 `json:"share_bits"`
+}
+
+// WorkerStats is the per-worker counter snapshot of one connection
+type WorkerStats struct {
+	Worker          string `json:"worker"`
+	ConnectedAt     uint64 `json:"connected_at"`
+	LastShareAt     uint64 `json:"last_share_at,omitempty"`
+	Accepted        uint64 `json:"accepted"`
+	Rejected        uint64 `json:"rejected"`
+	Stale           uint64 `json:"stale"`
+	Blocks          uint64 `json:"blocks"`
+	ShareMultiplier uint64 `json:"share_multiplier"`
+}
+
+// job is the work unit currently handed to every worker
+type job struct {
+	id     string
+	header *types.BlockHeader
+	seed   *bc.Hash
+}
+
+// session is one connected worker
+type session struct {
+	mutex           sync.Mutex
+	conn            net.Conn
+	enc             *json.Encoder
+	subscribed      bool
+	shareMultiplier uint64
+	shareTimes      []time.Time
+	stats           WorkerStats
+}
+
+// Server is the stratum mining server, it derives jobs from the mining pool
+// block template and validates the shares coming back
+type Server struct {
+	mutex    sync.RWMutex
+	cfg      *cfg.StratumConfig
+	pool     *miningpool.MiningPool
+	chain    *protocol.Chain
+	listener net.Listener
+	sessions map[*session]bool
+	job      *job
+	jobSeq   uint64
+	started  bool
+	quit     chan struct{}
+}
+
+// NewServer creates a stratum server around the mining pool, use Start to
+// begin listening
+func NewServer(config *cfg.StratumConfig, pool *miningpool.MiningPool, chain *protocol.Chain) *Server {
+	return &Server{
+		cfg:      config,
+		pool:     pool,
+		chain:    chain,
+		sessions: make(map[*session]bool),
+	}
+}
+
+// Start begins listening for miners, calling it on a started server has no
+// effect
+func (s *Server) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.started {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.cfg.ListenAddress)
+	if err != nil {
+		return err
+	}
+
+	s.listener = listener
+	s.quit = make(chan struct{})
+	s.started = true
+	s.refreshJob()
+
+	go s.acceptLoop()
+	go s.jobUpdater()
+	log.WithField("addr", s.cfg.ListenAddress).Info("stratum server started")
+	return nil
+}
+
+// Stop closes the listener and every worker connection, calling it on a
+// stopped server has no effect
+func (s *Server) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.started {
+		return
+	}
+
+	close(s.quit)
+	s.listener.Close()
+	for sess := range s.sessions {
+		sess.conn.Close()
+	}
+	s.started = false
+	log.Info("stratum server stopped")
+}
+
+// WorkerStats returns the counter snapshot of every connected worker
+func (s *Server) WorkerStats() []WorkerStats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stats := []WorkerStats{}
+	for sess := range s.sessions {
+		sess.mutex.Lock()
+		snapshot := sess.stats
+		snapshot.ShareMultiplier = sess.shareMultiplier
+		sess.mutex.Unlock()
+		stats = append(stats, snapshot)
+	}
+	return stats
+}
+
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+				return
+			default:
+				log.WithField("err", err).Warning("stratum fail on accept")
+				continue
+			}
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// jobUpdater rebuilds the job and renotifies every worker when the chain
+// moves to a new best block
+func (s *Server) jobUpdater() {
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-s.chain.BlockWaiter(s.chain.BestBlockHeight() + 1):
+		}
+
+		s.mutex.Lock()
+		s.refreshJob()
+		s.mutex.Unlock()
+		s.broadcastJob()
+	}
+}
+
+// refreshJob derives a new work unit from the mining pool template, the
+// caller must hold the server mutex
+func (s *Server) refreshJob() {
+	bh, err := s.pool.GetWork()
+	if err != nil {
+		log.WithField("err", err).Error("stratum fail on get work")
+		return
+	}
+
+	seed, err := s.chain.CalcNextSeed(&bh.PreviousBlockHash)
+	if err != nil {
+		log.WithField("err", err).Error("stratum fail on calc seed")
+		return
+	}
+
+	s.jobSeq++
+	s.job = &job{
+		id:     strconv.FormatUint(bh.Height, 10) + ":" + strconv.FormatUint(s.jobSeq, 10),
+		header: bh,
+		seed:   seed,
+	}
+}
+
+func (s *Server) broadcastJob() {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for sess := range s.sessions {
+		if err := s.sendJob(sess); err != nil {
+			log.WithField("err", err).Warning("stratum fail on notify worker")
+		}
+	}
+}
+
+// sendJob pushes the current job to one worker with its personal share bits,
+// the caller must hold at least the server read mutex
+func (s *Server) sendJob(sess *session) error {
+	notify, err := s.jobNotify(sess)
+	if err != nil {
+		return err
+	}
+	return sess.send(&Notification{Method: methodNotify, Params: notify})
+}
+
+func (s *Server) jobNotify(sess *session) (*JobNotify, error) {
+	if s.job == nil {
+		return nil, ErrNoJob
+	}
+
+	rawHeader, err := s.job.header.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobNotify{
+		JobID:       s.job.id,
+		Height:      s.job.header.Height,
+		BlockHeader: string(rawHeader),
+		Seed:        s.job.seed.String(),
+		Bits:        s.job.header.Bits,
+		ShareBits:   shareBits(s.job.header.Bits, sess.multiplier()),
+	}, nil
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	sess := &session{
+		conn:            conn,
+		enc:             json.NewEncoder(conn),
+		shareMultiplier: s.cfg.InitialShareMultiplier,
+		stats:           WorkerStats{ConnectedAt: uint64(time.Now().Unix())},
+	}
+	defer func() {
+		conn.Close()
+		s.mutex.Lock()
+		delete(s.sessions, sess)
+		s.mutex.Unlock()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		req := &Request{}
+		if err := json.Unmarshal(scanner.Bytes(), req); err != nil {
+			log.WithField("err", err).Warning("stratum got a malformed request")
+			return
+		}
+		s.handleRequest(sess, req)
+	}
+}
+
+func (s *Server) handleRequest(sess *session, req *Request) {
+	var result interface{}
+	var err error
+
+	switch req.Method {
+	case methodSubscribe:
+		result, err = s.handleSubscribe(sess, req.Params)
+	case methodSubmit:
+		result, err = s.handleSubmit(sess, req.Params)
+	default:
+		err = ErrUnknownMethod
+	}
+
+	resp := &Response{ID: req.ID, Result: result}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	sess.send(resp)
+}
+
+func (s *Server) handleSubscribe(sess *session, rawParams json.RawMessage) (interface{}, error) {
+	params := &struct {
+		Worker string `json:"worker"`
+	}{}
+	if len(rawParams) > 0 {
+		if err := json.Unmarshal(rawParams, params); err != nil {
+			return nil, err
+		}
+	}
+
+	sess.mutex.Lock()
+	sess.subscribed = true
+	sess.stats.Worker = params.Worker
+	sess.mutex.Unlock()
+
+	s.mutex.Lock()
+	s.sessions[sess] = true
+	notify, err := s.jobNotify(sess)
+	s.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return notify, nil
+}
+
+func (s *Server) handleSubmit(sess *session, rawParams json.RawMessage) (interface{}, error) {
+	params := &struct {
+		JobID     string `json:"job_id"`
+		Nonce     uint64 `json:"nonce"`
+		Timestamp uint64 `json:"timestamp"`
+	}{}
+	if err := json.Unmarshal(rawParams, params); err != nil {
+		return nil, err
+	}
+
+	if !sess.isSubscribed() {
+		return nil, ErrNotSubscribed
+	}
+
+	s.mutex.RLock()
+	currentJob := s.job
+	s.mutex.RUnlock()
+	if currentJob == nil {
+		return nil, ErrNoJob
+	}
+	if params.JobID != currentJob.id {
+		sess.countStale()
+		return nil, ErrStaleJob
+	}
+
+	header := *currentJob.header
+	header.Nonce = params.Nonce
+	header.Timestamp = params.Timestamp
+	headerHash := header.Hash()
+
+	if !difficulty.CheckProofOfWork(&headerHash, currentJob.seed, shareBits(header.Bits, sess.multiplier())) {
+		sess.countRejected()
+		return nil, ErrLowDifficulty
+	}
+	sess.countAccepted()
+
+	if difficulty.CheckProofOfWork(&headerHash, currentJob.seed, header.Bits) {
+		if err := s.pool.SubmitWork(&header); err != nil {
+			log.WithFields(log.Fields{"err": err, "worker": sess.stats.Worker}).Warning("stratum block submit failed")
+		} else {
+			sess.countBlock()
+			log.WithFields(log.Fields{"worker": sess.stats.Worker, "height": header.Height}).Info("stratum worker found a block")
+		}
+	}
+
+	if sess.retuneDifficulty(s.cfg) {
+		sess.send(&Notification{
+			Method: methodSetDifficulty,
+			Params: &struct {
+				ShareBits uint64 `json:"share_bits"`
+			}{ShareBits: shareBits(header.Bits, sess.multiplier())},
+		})
+	}
+	return "accepted", nil
+}
+
+// shareBits eases the block target by multiplier so workers can prove rate
+// with sub-block-difficulty shares
+func shareBits(bits, multiplier uint64) uint64 {
+	target := difficulty.CompactToBig(bits)
+	target.Mul(target, new(big.Int).SetUint64(multiplier))
+	return difficulty.BigToCompact(target)
+}
+
+func (sess *session) send(msg interface{}) error {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	return sess.enc.Encode(msg)
+}
+
+func (sess *session) isSubscribed() bool {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	return sess.subscribed
+}
+
+func (sess *session) multiplier() uint64 {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	return sess.shareMultiplier
+}
+
+func (sess *session) countAccepted() {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	sess.stats.Accepted++
+	sess.stats.LastShareAt = uint64(time.Now().Unix())
+}
+
+func (sess *session) countRejected() {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	sess.stats.Rejected++
+}
+
+func (sess *session) countStale() {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	sess.stats.Stale++
+}
+
+func (sess *session) countBlock() {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+	sess.stats.Blocks++
+}
+
+// retuneDifficulty applies vardiff over a window of accepted shares, aiming
+// one share per configured target interval, it reports whether the worker's
+// share target changed
+func (sess *session) retuneDifficulty(config *cfg.StratumConfig) bool {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	sess.shareTimes = append(sess.shareTimes, time.Now())
+	if config.VardiffWindow < 2 || len(sess.shareTimes) < config.VardiffWindow {
+		return false
+	}
+
+	elapsed := sess.shareTimes[len(sess.shareTimes)-1].Sub(sess.shareTimes[0])
+	average := elapsed / time.Duration(len(sess.shareTimes)-1)
+	target := time.Duration(config.VardiffTargetSeconds) * time.Second
+	sess.shareTimes = sess.shareTimes[:0]
+
+	switch {
+	case average < target/2 && sess.shareMultiplier > 1:
+		sess.shareMultiplier /= 2
+	case average > target*2 && sess.shareMultiplier < maxShareMultiplier:
+		sess.shareMultiplier *= 2
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,231 @@
+package mining
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/state"
+	"github.com/bytom-gm/protocol/validation"
+)
+
+// TemplateManager keeps the most recent block template and patches it with
+// mempool deltas instead of rebuilding from scratch on every call. New
+// transactions are validated and appended incrementally, the coinbase and the
+// merkle roots are only recomputed when the candidate set actually changed.
+// A template whose parent is no longer the best block, or whose included
+// transactions left the mempool, is rebuilt in full.
+type TemplateManager struct {
+	mutex          sync.Mutex
+	chain          *protocol.Chain
+	txPool         *protocol.TxPool
+	accountManager *account.Manager
+
+	block    *types.Block
+	bcBlock  *bc.Block
+	view     *state.UtxoViewpoint
+	txStatus *bc.TransactionStatus
+	entries  []*bc.Tx
+	known    map[bc.Hash]bool
+	gasUsed  uint64
+	txFee    uint64
+	full     bool
+	dirty    bool
+}
+
+// NewTemplateManager creates an incremental block template builder
+func NewTemplateManager(c *protocol.Chain, txPool *protocol.TxPool, accountManager *account.Manager) *TemplateManager {
+	return &TemplateManager{
+		chain:          c,
+		txPool:         txPool,
+		accountManager: accountManager,
+	}
+}
+
+// GetTemplate returns a block template for the next height, reusing the
+// cached candidate set whenever the chain tip and the included transactions
+// are unchanged
+func (tm *TemplateManager) GetTemplate() (*types.Block, error) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	preBlockHeader := tm.chain.BestBlockHeader()
+	preBlockHash := preBlockHeader.Hash()
+	if tm.block == nil || tm.block.PreviousBlockHash != preBlockHash {
+		if err := tm.rebuild(preBlockHeader); err != nil {
+			return nil, err
+		}
+	} else if err := tm.applyPoolDelta(preBlockHeader); err != nil {
+		return nil, err
+	}
+
+	if tm.dirty {
+		if err := tm.finalize(); err != nil {
+			return nil, err
+		}
+	}
+	return tm.block, nil
+}
+
+// rebuild recreates the whole candidate set from the current mempool
+func (tm *TemplateManager) rebuild(preBlockHeader *types.BlockHeader) error {
+	preBlockHash := preBlockHeader.Hash()
+	nextBlockHeight := preBlockHeader.Height + 1
+	nextBits, err := tm.chain.CalcNextBits(&preBlockHash)
+	if err != nil {
+		return err
+	}
+
+	txStatus := bc.NewTransactionStatus()
+	if err := txStatus.SetStatus(0, false); err != nil {
+		return err
+	}
+
+	tm.block = &types.Block{
+		BlockHeader: types.BlockHeader{
+			Version:           1,
+			Height:            nextBlockHeight,
+			PreviousBlockHash: preBlockHash,
+			Timestamp:         uint64(time.Now().Unix()),
+			BlockCommitment:   types.BlockCommitment{},
+			Bits:              nextBits,
+		},
+		Transactions: []*types.Tx{nil},
+	}
+	tm.bcBlock = &bc.Block{BlockHeader: &bc.BlockHeader{Height: nextBlockHeight}}
+	tm.view = state.NewUtxoViewpoint()
+	tm.txStatus = txStatus
+	tm.entries = []*bc.Tx{nil}
+	tm.known = map[bc.Hash]bool{}
+	tm.gasUsed = 0
+	tm.txFee = 0
+	tm.full = false
+	tm.dirty = true
+
+	for _, txDesc := range prioritizer.Prioritize(tm.txPool.GetTransactions()) {
+		tm.includeTx(txDesc)
+		if tm.full {
+			break
+		}
+	}
+	return nil
+}
+
+// applyPoolDelta patches the cached candidate set with mempool changes. A
+// removed transaction forces a full rebuild because the utxo view and the
+// gas accounting can't be unwound, added transactions are appended in place
+func (tm *TemplateManager) applyPoolDelta(preBlockHeader *types.BlockHeader) error {
+	snapshot := tm.txPool.GetTransactions()
+	inPool := map[bc.Hash]bool{}
+	for _, txDesc := range snapshot {
+		inPool[txDesc.Tx.ID] = true
+	}
+	for txHash := range tm.known {
+		if !inPool[txHash] {
+			return tm.rebuild(preBlockHeader)
+		}
+	}
+
+	added := []*protocol.TxDesc{}
+	for _, txDesc := range snapshot {
+		if !tm.known[txDesc.Tx.ID] {
+			added = append(added, txDesc)
+		}
+	}
+	if len(added) == 0 || tm.full {
+		return nil
+	}
+
+	for _, txDesc := range prioritizer.Prioritize(added) {
+		tm.includeTx(txDesc)
+		if tm.full {
+			break
+		}
+	}
+	return nil
+}
+
+// includeTx validates one mempool transaction against the cached view and
+// appends it to the candidate set, invalid transactions are dropped from the
+// mempool like the one-shot builder does
+func (tm *TemplateManager) includeTx(txDesc *protocol.TxDesc) {
+	if excludeByPolicy(txDesc) {
+		return
+	}
+
+	tx := txDesc.Tx.Tx
+	gasOnlyTx := false
+
+	if err := tm.chain.GetTransactionsUtxo(tm.view, []*bc.Tx{tx}); err != nil {
+		blkGenSkipTxForErr(tm.txPool, &tx.ID, err)
+		return
+	}
+
+	gasStatus, err := validation.ValidateTx(tx, tm.bcBlock)
+	if err != nil {
+		if !gasStatus.GasValid {
+			blkGenSkipTxForErr(tm.txPool, &tx.ID, err)
+			return
+		}
+		gasOnlyTx = true
+	}
+
+	if tm.gasUsed+uint64(gasStatus.GasUsed) > templateGasBudget() {
+		tm.full = true
+		return
+	}
+
+	if err := tm.view.ApplyTransaction(tm.bcBlock, tx, gasOnlyTx); err != nil {
+		blkGenSkipTxForErr(tm.txPool, &tx.ID, err)
+		return
+	}
+
+	if err := tm.txStatus.SetStatus(len(tm.block.Transactions), gasOnlyTx); err != nil {
+		log.WithField("err", err).Error("template manager fail on set tx status")
+		return
+	}
+
+	tm.block.Transactions = append(tm.block.Transactions, txDesc.Tx)
+	tm.entries = append(tm.entries, tx)
+	tm.known[tx.ID] = true
+	tm.gasUsed += uint64(gasStatus.GasUsed)
+	tm.txFee += txDesc.Fee
+	tm.dirty = true
+
+	if tm.gasUsed == templateGasBudget() {
+		tm.full = true
+	}
+}
+
+// finalize recomputes the coinbase and the header commitments after the
+// candidate set changed
+func (tm *TemplateManager) finalize() error {
+	coinbaseTx, err := createCoinbaseTx(tm.accountManager, tm.txFee, tm.block.Height)
+	if err != nil {
+		return errors.Wrap(err, "fail on createCoinbaseTx")
+	}
+	tm.block.Transactions[0] = coinbaseTx
+	tm.entries[0] = coinbaseTx.Tx
+
+	merkleRoot, err := types.TxMerkleRoot(tm.entries)
+	if err != nil {
+		return err
+	}
+	tm.block.BlockHeader.BlockCommitment.TransactionsMerkleRoot = merkleRoot
+
+	statusHash, err := types.TxStatusMerkleRoot(tm.txStatus.VerifyStatus)
+	if err != nil {
+		return err
+	}
+	tm.block.BlockHeader.BlockCommitment.TransactionStatusHash = statusHash
+
+	tm.block.Timestamp = uint64(time.Now().Unix())
+	tm.dirty = false
+	return nil
+}
@@ -53,6 +53,9 @@ func (a *API) Authenticate(req *http.Request) (*http.Request, error) {
 	if err == nil && token != "" {
 		// if this request was successfully authenticated with a token, pass the token along
 		ctx = newContextWithToken(ctx, token)
+		if permissions, permErr := a.tokens.GetPermissions(token); permErr == nil {
+			ctx = newContextWithPermissions(ctx, permissions)
+		}
 	}
 
 	local := a.localhostAuthn(req)
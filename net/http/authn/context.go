@@ -9,6 +9,7 @@ type key int
 const (
 	tokenKey key = iota
 	localhostKey
+	permissionsKey
 )
 
 // newContextWithToken sets the token in a new context and returns the context.
@@ -25,6 +26,22 @@ func Token(ctx context.Context) string {
 	return t
 }
 
+// newContextWithPermissions sets the permission sets of the authenticated
+// token in a new context and returns the context.
+func newContextWithPermissions(ctx context.Context, permissions []string) context.Context {
+	return context.WithValue(ctx, permissionsKey, permissions)
+}
+
+// Permissions returns the permission sets of the authenticated token, if a
+// token was presented.
+func Permissions(ctx context.Context) []string {
+	permissions, ok := ctx.Value(permissionsKey).([]string)
+	if !ok {
+		return nil
+	}
+	return permissions
+}
+
 // newContextWithLocalhost sets the localhost flag to `true` in a new context
 // and returns that context.
 func newContextWithLocalhost(ctx context.Context) context.Context {
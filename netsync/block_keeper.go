@@ -15,7 +15,6 @@ import (
 
 const (
 	syncCycle            = 5 * time.Second
-	blockProcessChSize   = 1024
 	blocksProcessChSize  = 128
 	headersProcessChSize = 1024
 )
@@ -51,21 +50,21 @@ type blockKeeper struct {
 	peers *peerSet
 
 	syncPeer         *peer
-	blockProcessCh   chan *blockMsg
 	blocksProcessCh  chan *blocksMsg
 	headersProcessCh chan *headersMsg
 
 	headerList *list.List
+	stats      *syncStats
 }
 
 func newBlockKeeper(chain Chain, peers *peerSet) *blockKeeper {
 	bk := &blockKeeper{
 		chain:            chain,
 		peers:            peers,
-		blockProcessCh:   make(chan *blockMsg, blockProcessChSize),
 		blocksProcessCh:  make(chan *blocksMsg, blocksProcessChSize),
 		headersProcessCh: make(chan *headersMsg, headersProcessChSize),
 		headerList:       list.New(),
+		stats:            newSyncStats(),
 	}
 	bk.resetHeaderState()
 	go bk.syncWorker()
@@ -148,6 +147,7 @@ func (bk *blockKeeper) fastBlockSync(checkPoint *consensus.Checkpoint) error {
 			return errors.Wrap(errPeerMisbehave, "requireBlocks return empty list")
 		}
 
+		bk.stats.markDownloaded(uint64(len(blocks)))
 		for _, block := range blocks {
 			if fastHeader = fastHeader.Next(); fastHeader == nil {
 				return errors.New("get block than is higher than checkpoint")
@@ -169,6 +169,7 @@ func (bk *blockKeeper) fastBlockSync(checkPoint *consensus.Checkpoint) error {
 			if err != nil {
 				return errors.Wrap(err, "fail on fastBlockSync process block")
 			}
+			bk.stats.markProcessed(1)
 		}
 	}
 	return nil
@@ -250,10 +251,6 @@ func (bk *blockKeeper) nextCheckpoint() *consensus.Checkpoint {
 	return nextCheckpoint
 }
 
-func (bk *blockKeeper) processBlock(peerID string, block *types.Block) {
-	bk.blockProcessCh <- &blockMsg{block: block, peerID: peerID}
-}
-
 func (bk *blockKeeper) processBlocks(peerID string, blocks []*types.Block) {
 	bk.blocksProcessCh <- &blocksMsg{blocks: blocks, peerID: peerID}
 }
@@ -262,48 +259,156 @@ func (bk *blockKeeper) processHeaders(peerID string, headers []*types.BlockHeade
 	bk.headersProcessCh <- &headersMsg{headers: headers, peerID: peerID}
 }
 
-func (bk *blockKeeper) regularBlockSync(wantHeight uint64) error {
-	i := bk.chain.BestBlockHeight() + 1
-	for i <= wantHeight {
-		block, err := bk.requireBlock(i)
+// headersFirstSync downloads and validates the sync peer's header chain
+// first, then fetches the matching block bodies in parallel from every peer
+// that has them
+func (bk *blockKeeper) headersFirstSync() error {
+	headers, err := bk.requireHeaderChain()
+	if err != nil {
+		return err
+	}
+
+	if len(headers) == 0 {
+		return nil
+	}
+	return bk.fetchBodies(headers)
+}
+
+// requireHeaderChain pulls the header chain up to the sync peer's best hash
+// in batches, checking that it attaches to the local chain and that every
+// header links to the previous one
+func (bk *blockKeeper) requireHeaderChain() ([]*types.BlockHeader, error) {
+	stopHash := bk.syncPeer.bestHash()
+	if stopHash == nil {
+		return nil, errors.Wrap(errPeerMisbehave, "sync peer has no best hash")
+	}
+
+	headers := []*types.BlockHeader{}
+	locator := bk.blockLocator()
+	var prev *types.BlockHeader
+	for {
+		batch, err := bk.requireHeaders(locator, stopHash)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		isOrphan, err := bk.chain.ProcessBlock(block)
-		if err != nil {
-			return err
+		if len(batch) == 0 {
+			break
 		}
 
-		if isOrphan {
-			i--
-			continue
+		for _, header := range batch {
+			if prev == nil {
+				if _, err := bk.chain.GetHeaderByHash(&header.PreviousBlockHash); err != nil {
+					return nil, errors.Wrap(errPeerMisbehave, "header chain doesn't attach to the local chain")
+				}
+			} else if prev.Hash() != header.PreviousBlockHash || prev.Height+1 != header.Height {
+				return nil, errors.Wrap(errPeerMisbehave, "header chain is not continuous")
+			}
+			prev = header
+		}
+
+		if prev.Height > bk.syncPeer.Height() {
+			return nil, errors.Wrap(errPeerMisbehave, "header chain is higher than the peer best height")
+		}
+
+		headers = append(headers, batch...)
+		lastHash := prev.Hash()
+		if lastHash == *stopHash || uint64(len(batch)) < maxBlockHeadersPerMsg {
+			break
 		}
-		i = bk.chain.BestBlockHeight() + 1
+		locator = []*bc.Hash{&lastHash}
 	}
-	return nil
+	return headers, nil
 }
 
-func (bk *blockKeeper) requireBlock(height uint64) (*types.Block, error) {
-	if ok := bk.syncPeer.getBlockByHeight(height); !ok {
-		return nil, errPeerDropped
-	}
+// fetchBodies downloads the bodies of the validated header chain, assigning
+// a range of blocks to every peer that covers it, and processes the blocks
+// in height order as the responses arrive
+func (bk *blockKeeper) fetchBodies(headers []*types.BlockHeader) error {
+	sched := newBodySchedule(headers)
+	nextProcess := headers[0].Height
+	lastHeight := headers[len(headers)-1].Height
+	blocks := map[uint64]*types.Block{}
+
+	progressTimer := time.NewTimer(syncTimeout)
+	defer progressTimer.Stop()
+	wakeTicker := time.NewTicker(time.Second)
+	defer wakeTicker.Stop()
+
+	for nextProcess <= lastHeight {
+		if err := bk.assignBodyRanges(sched); err != nil {
+			return err
+		}
 
-	waitTicker := time.NewTimer(syncTimeout)
-	for {
 		select {
-		case msg := <-bk.blockProcessCh:
-			if msg.peerID != bk.syncPeer.ID() {
+		case msg := <-bk.blocksProcessCh:
+			got, err := sched.deliver(msg.peerID, msg.blocks, time.Now())
+			if err != nil {
+				bk.peers.errorHandler(msg.peerID, err)
 				continue
 			}
-			if msg.block.Height != height {
+
+			if len(got) == 0 {
 				continue
 			}
-			return msg.block, nil
-		case <-waitTicker.C:
-			return nil, errors.Wrap(errRequestTimeout, "requireBlock")
+
+			bk.stats.markDownloaded(uint64(len(got)))
+			for _, block := range got {
+				blocks[block.Height] = block
+			}
+			for block, ok := blocks[nextProcess]; ok; block, ok = blocks[nextProcess] {
+				if _, err := bk.chain.ProcessBlock(block); err != nil {
+					return errors.Wrap(err, "fail on fetchBodies process block")
+				}
+				bk.stats.markProcessed(1)
+				delete(blocks, nextProcess)
+				nextProcess++
+			}
+			progressTimer.Reset(syncTimeout)
+
+		case <-wakeTicker.C:
+			sched.reassignStalled(time.Now())
+
+		case <-progressTimer.C:
+			return errors.Wrap(errRequestTimeout, "fetchBodies")
 		}
 	}
+	return nil
+}
+
+// assignBodyRanges hands the pending download ranges out to idle peers,
+// fastest delivering peer first
+func (bk *blockKeeper) assignBodyRanges(sched *bodySchedule) error {
+	for {
+		r := sched.peekRange()
+		if r == nil {
+			return nil
+		}
+
+		var picked *peer
+		for _, peer := range bk.peers.peersForHeight(r.end) {
+			if sched.isBusy(peer.ID()) {
+				continue
+			}
+			if picked == nil || sched.rate(peer.ID()) > sched.rate(picked.ID()) {
+				picked = peer
+			}
+		}
+
+		if picked == nil {
+			if !sched.hasAssigned() {
+				return errPeerDropped
+			}
+			return nil
+		}
+
+		stopHash := sched.wanted[r.end]
+		if ok := picked.getBlocks([]*bc.Hash{&r.prevHash}, &stopHash); !ok {
+			bk.peers.removePeer(picked.ID())
+			continue
+		}
+		sched.assign(picked.ID(), sched.popRange(), time.Now())
+	}
 }
 
 func (bk *blockKeeper) requireBlocks(locator []*bc.Hash, stopHash *bc.Hash) ([]*types.Block, error) {
@@ -368,16 +473,16 @@ func (bk *blockKeeper) startSync() bool {
 	}
 
 	blockHeight := bk.chain.BestBlockHeight()
-	peer = bk.peers.bestPeer(consensus.SFFullNode)
+	// prefer peers advertising the fast sync service bit, fall back to any
+	// full node
+	peer = bk.peers.bestPeer(consensus.SFFastSync | consensus.SFFullNode)
+	if peer == nil || peer.Height() <= blockHeight {
+		peer = bk.peers.bestPeer(consensus.SFFullNode)
+	}
 	if peer != nil && peer.Height() > blockHeight {
 		bk.syncPeer = peer
-		targetHeight := blockHeight + maxBlockPerMsg
-		if targetHeight > peer.Height() {
-			targetHeight = peer.Height()
-		}
-
-		if err := bk.regularBlockSync(targetHeight); err != nil {
-			log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("fail on regularBlockSync")
+		if err := bk.headersFirstSync(); err != nil {
+			log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("fail on headersFirstSync")
 			bk.peers.errorHandler(peer.ID(), err)
 			return false
 		}
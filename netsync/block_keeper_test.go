@@ -391,7 +391,9 @@ func TestNextCheckpoint(t *testing.T) {
 	}
 }
 
-func TestRegularBlockSync(t *testing.T) {
+func TestHeadersFirstSync(t *testing.T) {
+	maxBlockHeadersPerMsg = 10
+	maxBlockPerMsg = 10
 	baseChain := mockBlocks(nil, 50)
 	chainX := append(baseChain, mockBlocks(baseChain[50], 60)...)
 	chainY := append(baseChain, mockBlocks(baseChain[50], 70)...)
@@ -399,7 +401,7 @@ func TestRegularBlockSync(t *testing.T) {
 		syncTimeout time.Duration
 		aBlocks     []*types.Block
 		bBlocks     []*types.Block
-		syncHeight  uint64
+		peerStatus  *types.BlockHeader
 		want        []*types.Block
 		err         error
 	}{
@@ -407,15 +409,13 @@ func TestRegularBlockSync(t *testing.T) {
 			syncTimeout: 30 * time.Second,
 			aBlocks:     baseChain[:20],
 			bBlocks:     baseChain[:50],
-			syncHeight:  45,
-			want:        baseChain[:46],
+			want:        baseChain[:50],
 			err:         nil,
 		},
 		{
 			syncTimeout: 30 * time.Second,
 			aBlocks:     chainX,
 			bBlocks:     chainY,
-			syncHeight:  70,
 			want:        chainY,
 			err:         nil,
 		},
@@ -423,16 +423,17 @@ func TestRegularBlockSync(t *testing.T) {
 			syncTimeout: 30 * time.Second,
 			aBlocks:     chainX[:52],
 			bBlocks:     chainY[:53],
-			syncHeight:  52,
 			want:        chainY[:53],
 			err:         nil,
 		},
 		{
+			// the peer advertises a best hash it can't serve, so the
+			// header request gets no reply
 			syncTimeout: 1 * time.Millisecond,
-			aBlocks:     baseChain,
-			bBlocks:     baseChain,
-			syncHeight:  52,
-			want:        baseChain,
+			aBlocks:     baseChain[:20],
+			bBlocks:     baseChain[:50],
+			peerStatus:  &types.BlockHeader{Height: 100, Nonce: 1},
+			want:        baseChain[:20],
 			err:         errRequestTimeout,
 		},
 	}
@@ -452,7 +453,12 @@ func TestRegularBlockSync(t *testing.T) {
 		}
 
 		a.blockKeeper.syncPeer = a.peers.getPeer("test node B")
-		if err := a.blockKeeper.regularBlockSync(c.syncHeight); errors.Root(err) != c.err {
+		if c.peerStatus != nil {
+			hash := c.peerStatus.Hash()
+			a.blockKeeper.syncPeer.setStatus(c.peerStatus.Height, &hash)
+		}
+
+		if err := a.blockKeeper.headersFirstSync(); errors.Root(err) != c.err {
 			t.Errorf("case %d: got %v want %v", i, err, c.err)
 		}
 
@@ -471,57 +477,6 @@ func TestRegularBlockSync(t *testing.T) {
 	}
 }
 
-func TestRequireBlock(t *testing.T) {
-	blocks := mockBlocks(nil, 5)
-	a := mockSync(blocks[:1])
-	b := mockSync(blocks[:5])
-	netWork := NewNetWork()
-	netWork.Register(a, "192.168.0.1", "test node A", consensus.SFFullNode)
-	netWork.Register(b, "192.168.0.2", "test node B", consensus.SFFullNode)
-	if B2A, A2B, err := netWork.HandsShake(a, b); err != nil {
-		t.Errorf("fail on peer hands shake %v", err)
-	} else {
-		go B2A.postMan()
-		go A2B.postMan()
-	}
-
-	a.blockKeeper.syncPeer = a.peers.getPeer("test node B")
-	b.blockKeeper.syncPeer = b.peers.getPeer("test node A")
-	cases := []struct {
-		syncTimeout   time.Duration
-		testNode      *SyncManager
-		requireHeight uint64
-		want          *types.Block
-		err           error
-	}{
-		{
-			syncTimeout:   30 * time.Second,
-			testNode:      a,
-			requireHeight: 4,
-			want:          blocks[4],
-			err:           nil,
-		},
-		{
-			syncTimeout:   1 * time.Millisecond,
-			testNode:      b,
-			requireHeight: 4,
-			want:          nil,
-			err:           errRequestTimeout,
-		},
-	}
-
-	for i, c := range cases {
-		syncTimeout = c.syncTimeout
-		got, err := c.testNode.blockKeeper.requireBlock(c.requireHeight)
-		if !testutil.DeepEqual(got, c.want) {
-			t.Errorf("case %d: got %v want %v", i, got, c.want)
-		}
-		if errors.Root(err) != c.err {
-			t.Errorf("case %d: got %v want %v", i, err, c.err)
-		}
-	}
-}
-
 func TestSendMerkleBlock(t *testing.T) {
 	cases := []struct {
 		txCount        int
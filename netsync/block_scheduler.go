@@ -0,0 +1,168 @@
+package netsync
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// bodyRange is a contiguous run of block heights whose bodies still have to
+// be downloaded. prevHash is the header hash right before start, used as the
+// block locator of the range request.
+type bodyRange struct {
+	start    uint64
+	end      uint64
+	prevHash bc.Hash
+}
+
+// bodyAssignment is a range that has been handed to a peer and is waiting
+// for its blocks response.
+type bodyAssignment struct {
+	bodyRange *bodyRange
+	sentAt    time.Time
+	deadline  time.Time
+}
+
+// bodySchedule splits a validated header chain into download ranges, hands
+// them out to peers one range per peer, tracks how fast every peer delivers
+// and takes back the ranges that stall.
+type bodySchedule struct {
+	wanted   map[uint64]bc.Hash
+	pending  []*bodyRange
+	assigned map[string]*bodyAssignment
+	rates    map[string]float64
+}
+
+func newBodySchedule(headers []*types.BlockHeader) *bodySchedule {
+	s := &bodySchedule{
+		wanted:   map[uint64]bc.Hash{},
+		assigned: map[string]*bodyAssignment{},
+		rates:    map[string]float64{},
+	}
+	for _, header := range headers {
+		s.wanted[header.Height] = header.Hash()
+	}
+
+	rangeSize := maxBlockPerMsg
+	prevHash := headers[0].PreviousBlockHash
+	for start := headers[0].Height; start <= headers[len(headers)-1].Height; start += rangeSize {
+		end := start + rangeSize - 1
+		if lastHeight := headers[len(headers)-1].Height; end > lastHeight {
+			end = lastHeight
+		}
+
+		s.pending = append(s.pending, &bodyRange{start: start, end: end, prevHash: prevHash})
+		prevHash = s.wanted[end]
+	}
+	return s
+}
+
+// assign marks the schedule's front range as outstanding on the given peer
+func (s *bodySchedule) assign(peerID string, r *bodyRange, now time.Time) {
+	s.assigned[peerID] = &bodyAssignment{bodyRange: r, sentAt: now, deadline: now.Add(syncTimeout / 2)}
+}
+
+// deliver consumes a blocks response for the peer's outstanding range. It
+// returns the prefix that matches the wanted header chain, requeues whatever
+// part of the range is still missing, and reports errPeerMisbehave when
+// nothing in the response lines up.
+func (s *bodySchedule) deliver(peerID string, blocks []*types.Block, now time.Time) ([]*types.Block, error) {
+	a := s.assigned[peerID]
+	if a == nil {
+		return nil, nil
+	}
+	delete(s.assigned, peerID)
+
+	r := a.bodyRange
+	matched := []*types.Block{}
+	next := r.start
+	for _, block := range blocks {
+		if block.Height != next || block.Hash() != s.wanted[block.Height] {
+			break
+		}
+		matched = append(matched, block)
+		next++
+	}
+
+	if len(matched) == 0 {
+		s.requeue(r)
+		s.punish(peerID)
+		return nil, errPeerMisbehave
+	}
+
+	s.updateRate(peerID, len(matched), now.Sub(a.sentAt))
+	if next <= r.end {
+		s.requeue(&bodyRange{start: next, end: r.end, prevHash: s.wanted[next-1]})
+	}
+	return matched, nil
+}
+
+func (s *bodySchedule) hasAssigned() bool {
+	return len(s.assigned) > 0
+}
+
+func (s *bodySchedule) isBusy(peerID string) bool {
+	return s.assigned[peerID] != nil
+}
+
+func (s *bodySchedule) peekRange() *bodyRange {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	return s.pending[0]
+}
+
+func (s *bodySchedule) popRange() *bodyRange {
+	r := s.pending[0]
+	s.pending = s.pending[1:]
+	return r
+}
+
+// punish halves the throughput estimate of a peer that stalled or delivered
+// garbage, so pending ranges prefer other peers
+func (s *bodySchedule) punish(peerID string) {
+	s.rates[peerID] /= 2
+}
+
+func (s *bodySchedule) rate(peerID string) float64 {
+	return s.rates[peerID]
+}
+
+// reassignStalled takes the ranges whose deadline has passed away from their
+// peers and puts them back in the pending queue
+func (s *bodySchedule) reassignStalled(now time.Time) {
+	for peerID, a := range s.assigned {
+		if now.Before(a.deadline) {
+			continue
+		}
+
+		delete(s.assigned, peerID)
+		s.requeue(a.bodyRange)
+		s.punish(peerID)
+	}
+}
+
+// requeue inserts the range back into the pending queue ordered by start
+// height, so the lowest missing heights always go out first
+func (s *bodySchedule) requeue(r *bodyRange) {
+	i := sort.Search(len(s.pending), func(i int) bool { return s.pending[i].start > r.start })
+	s.pending = append(s.pending, nil)
+	copy(s.pending[i+1:], s.pending[i:])
+	s.pending[i] = r
+}
+
+// updateRate folds a finished range into the peer's blocks-per-second
+// estimate, weighting history over the latest sample
+func (s *bodySchedule) updateRate(peerID string, blocks int, elapsed time.Duration) {
+	if elapsed <= 0 {
+		elapsed = time.Millisecond
+	}
+
+	rate := float64(blocks) / elapsed.Seconds()
+	if old, ok := s.rates[peerID]; ok {
+		rate = 0.7*old + 0.3*rate
+	}
+	s.rates[peerID] = rate
+}
@@ -0,0 +1,68 @@
+package netsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestBodySchedule(t *testing.T) {
+	maxBlockPerMsg = 10
+	blocks := mockBlocks(nil, 25)
+	headers := []*types.BlockHeader{}
+	for _, block := range blocks[1:] {
+		headers = append(headers, &block.BlockHeader)
+	}
+
+	sched := newBodySchedule(headers)
+	if len(sched.pending) != 3 {
+		t.Fatalf("got %d pending ranges want 3", len(sched.pending))
+	}
+	if r := sched.peekRange(); r.start != 1 || r.end != 10 || r.prevHash != blocks[0].Hash() {
+		t.Errorf("got first range [%d %d] want [1 10]", r.start, r.end)
+	}
+
+	now := time.Now()
+	sched.assign("peer 1", sched.popRange(), now)
+	sched.assign("peer 2", sched.popRange(), now)
+	if !sched.isBusy("peer 1") || sched.isBusy("peer 3") {
+		t.Error("busy state doesn't match the assignments")
+	}
+
+	// a partial delivery keeps the tail of the range pending
+	got, err := sched.deliver("peer 1", blocks[1:6], now.Add(time.Second))
+	if err != nil || len(got) != 5 {
+		t.Errorf("got %d blocks, err %v, want 5 blocks", len(got), err)
+	}
+	if r := sched.peekRange(); r.start != 6 || r.end != 10 || r.prevHash != blocks[5].Hash() {
+		t.Errorf("got requeued range [%d %d] want [6 10]", r.start, r.end)
+	}
+	if sched.rate("peer 1") <= 0 {
+		t.Error("delivery didn't update the peer rate")
+	}
+
+	// blocks that don't match the wanted chain are a misbehave
+	if _, err := sched.deliver("peer 2", blocks[1:6], now); err != errPeerMisbehave {
+		t.Errorf("got err %v want %v", err, errPeerMisbehave)
+	}
+
+	// an unsolicited response is ignored
+	if got, err := sched.deliver("peer 3", blocks[1:6], now); got != nil || err != nil {
+		t.Errorf("got (%v, %v) for unsolicited delivery, want (nil, nil)", got, err)
+	}
+
+	// a stalled range goes back to the pending queue and the peer pays for it
+	sched.assign("peer 1", sched.popRange(), now.Add(-syncTimeout))
+	rate := sched.rate("peer 1")
+	sched.reassignStalled(now)
+	if sched.isBusy("peer 1") {
+		t.Error("stalled assignment wasn't taken back")
+	}
+	if sched.rate("peer 1") >= rate {
+		t.Error("stalled peer wasn't punished")
+	}
+	if r := sched.peekRange(); r.start != 6 {
+		t.Errorf("got front range start %d want 6", r.start)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/tendermint/go-crypto"
 	cmn "github.com/tendermint/tmlibs/common"
 
+	"github.com/bytom-gm/common"
 	cfg "github.com/bytom-gm/config"
 	"github.com/bytom-gm/consensus"
 	"github.com/bytom-gm/p2p"
@@ -43,6 +44,7 @@ type Chain interface {
 	InMainChain(bc.Hash) bool
 	ProcessBlock(*types.Block) (bool, error)
 	ValidateTx(*types.Tx) (bool, error)
+	ValidateTxFromPeer(*types.Tx, string) (bool, error)
 }
 
 //SyncManager Sync Manager is responsible for the business layer information synchronization
@@ -97,7 +99,7 @@ func NewSyncManager(config *cfg.Config, chain Chain, txPool *core.TxPool, newBlo
 	var l p2p.Listener
 	if !config.VaultMode {
 		p, address := protocolAndAddress(manager.config.P2P.ListenAddress)
-		l, listenerStatus = p2p.NewDefaultListener(p, address, manager.config.P2P.SkipUPNP)
+		l, listenerStatus = p2p.NewDefaultListener(p, address, manager.config.P2P)
 		manager.sw.AddListener(l)
 
 		discv, err := initDiscover(config, &manager.privKey, l.ExternalAddress().Port)
@@ -156,17 +158,21 @@ func (sm *SyncManager) Switch() *p2p.Switch {
 }
 
 func (sm *SyncManager) handleBlockMsg(peer *peer, msg *BlockMessage) {
-	block, err := msg.GetBlock()
-	if err != nil {
+	if _, err := msg.GetBlock(); err != nil {
+		sm.peers.addBanScore(peer.ID(), 0, banScoreMalformedMsg, "fail on get block from message")
 		return
 	}
-	sm.blockKeeper.processBlock(peer.ID(), block)
+
+	// single blocks are never requested since the headers-first sync, so
+	// getting one is unrequested data
+	sm.peers.addBanScore(peer.ID(), 0, banScoreUnrequested, "unrequested block message")
 }
 
 func (sm *SyncManager) handleBlocksMsg(peer *peer, msg *BlocksMessage) {
 	blocks, err := msg.GetBlocks()
 	if err != nil {
 		log.WithFields(log.Fields{"module": logModule, "err": err}).Debug("fail on handleBlocksMsg GetBlocks")
+		sm.peers.addBanScore(peer.ID(), 0, banScoreMalformedMsg, "fail on get blocks from message")
 		return
 	}
 
@@ -289,6 +295,7 @@ func (sm *SyncManager) handleHeadersMsg(peer *peer, msg *HeadersMessage) {
 	headers, err := msg.GetHeaders()
 	if err != nil {
 		log.WithFields(log.Fields{"module": logModule, "err": err}).Debug("fail on handleHeadersMsg GetHeaders")
+		sm.peers.addBanScore(peer.ID(), 0, banScoreMalformedMsg, "fail on get headers from message")
 		return
 	}
 
@@ -299,6 +306,7 @@ func (sm *SyncManager) handleMineBlockMsg(peer *peer, msg *MineBlockMessage) {
 	block, err := msg.GetMineBlock()
 	if err != nil {
 		log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("fail on handleMineBlockMsg GetMineBlock")
+		sm.peers.addBanScore(peer.ID(), 0, banScoreMalformedMsg, "fail on get mine block from message")
 		return
 	}
 
@@ -341,18 +349,30 @@ func (sm *SyncManager) handleStatusResponseMsg(basePeer BasePeer, msg *StatusRes
 }
 
 func (sm *SyncManager) handleTransactionMsg(peer *peer, msg *TransactionMessage) {
+	if sm.config.P2P.BlocksOnly {
+		log.WithFields(log.Fields{"module": logModule, "peer": peer.Addr()}).Debug("ignore transaction message in blocks-only mode")
+		return
+	}
+
 	tx, err := msg.GetTransaction()
 	if err != nil {
-		sm.peers.addBanScore(peer.ID(), 0, 10, "fail on get tx from message")
+		sm.peers.addBanScore(peer.ID(), 0, banScoreMalformedMsg, "fail on get tx from message")
 		return
 	}
 
-	if isOrphan, err := sm.chain.ValidateTx(tx); err != nil && isOrphan == false {
+	// the announcing peer clearly knows the tx, never echo it back
+	peer.markTransaction(&tx.ID)
+	if isOrphan, err := sm.chain.ValidateTxFromPeer(tx, peer.ID()); err != nil && isOrphan == false {
 		sm.peers.addBanScore(peer.ID(), 10, 0, "fail on validate tx transaction")
 	}
 }
 
 func (sm *SyncManager) processMsg(basePeer BasePeer, msgType byte, msg BlockchainMessage) {
+	// a malformed message must not take down the whole node, ban the sender instead
+	defer common.RecoverPanic(logModule, func(err error) {
+		sm.peers.addBanScore(basePeer.ID(), 20, 0, "panic on process message: "+err.Error())
+	})
+
 	peer := sm.peers.getPeer(basePeer.ID())
 	if peer == nil && msgType != StatusResponseByte && msgType != StatusRequestByte {
 		return
@@ -428,12 +448,26 @@ func protocolAndAddress(listenAddr string) (string, string) {
 }
 
 func (sm *SyncManager) makeNodeInfo(listenerStatus bool) *p2p.NodeInfo {
+	services := consensus.DefaultServices
+	if sm.config.P2P.BlocksOnly {
+		services |= consensus.SFBlocksOnly
+	}
+
 	nodeInfo := &p2p.NodeInfo{
 		PubKey:  sm.privKey.PubKey().Unwrap().(crypto.PubKeyEd25519),
 		Moniker: sm.config.Moniker,
 		Network: sm.config.ChainID,
 		Version: version.Version,
-		Other:   []string{strconv.FormatUint(uint64(consensus.DefaultServices), 10)},
+		// Other carries the bits for peers that predate ServiceFlags
+		Other:        []string{strconv.FormatUint(uint64(services), 10)},
+		ServiceFlags: uint64(services),
+	}
+
+	// operators running behind Tor advertise their hidden service address
+	// instead of the clearnet listener
+	if sm.config.P2P.OnionAddress != "" {
+		nodeInfo.ListenAddr = sm.config.P2P.OnionAddress
+		return nodeInfo
 	}
 
 	if !sm.sw.IsListening() {
@@ -458,10 +492,12 @@ func (sm *SyncManager) Start() {
 	if _, err := sm.sw.Start(); err != nil {
 		cmn.Exit(cmn.Fmt("fail on start SyncManager: %v", err))
 	}
-	// broadcast transactions
-	go sm.txBroadcastLoop()
+	// broadcast transactions unless the node runs blocks-only
+	if !sm.config.P2P.BlocksOnly {
+		go sm.txBroadcastLoop()
+		go sm.txSyncLoop()
+	}
 	go sm.minedBroadcastLoop()
-	go sm.txSyncLoop()
 }
 
 //Stop stop sync manager
@@ -488,11 +524,12 @@ func initDiscover(config *cfg.Config, priv *crypto.PrivKeyEd25519, port uint16)
 	}
 
 	// add the seeds node to the discover table
-	if config.P2P.Seeds == "" {
+	seeds := collectSeeds(config, &consensus.ActiveNetParams)
+	if len(seeds) == 0 {
 		return ntab, nil
 	}
 	nodes := []*discover.Node{}
-	for _, seed := range strings.Split(config.P2P.Seeds, ",") {
+	for _, seed := range seeds {
 		version.Status.AddSeed(seed)
 		url := "enode://" + hex.EncodeToString(crypto.Sha256([]byte(seed))) + "@" + seed
 		nodes = append(nodes, discover.MustParseNode(url))
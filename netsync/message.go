@@ -60,6 +60,44 @@ var _ = wire.RegisterInterface(
 	wire.ConcreteType{&MerkleBlockMessage{}, MerkleResponseByte},
 )
 
+//msgTypeName returns the readable name of a protocol msg byte, it keys the
+//per-peer traffic accounting
+func msgTypeName(msgType byte) string {
+	switch msgType {
+	case BlockRequestByte:
+		return "get_block"
+	case BlockResponseByte:
+		return "block"
+	case HeadersRequestByte:
+		return "get_headers"
+	case HeadersResponseByte:
+		return "headers"
+	case BlocksRequestByte:
+		return "get_blocks"
+	case BlocksResponseByte:
+		return "blocks"
+	case StatusRequestByte:
+		return "status_request"
+	case StatusResponseByte:
+		return "status_response"
+	case NewTransactionByte:
+		return "transaction"
+	case NewMineBlockByte:
+		return "mine_block"
+	case FilterLoadByte:
+		return "filter_load"
+	case FilterAddByte:
+		return "filter_add"
+	case FilterClearByte:
+		return "filter_clear"
+	case MerkleRequestByte:
+		return "get_merkle_block"
+	case MerkleResponseByte:
+		return "merkle_block"
+	}
+	return "unknown"
+}
+
 //DecodeMessage decode msg
 func DecodeMessage(bz []byte) (msgType byte, msg BlockchainMessage, err error) {
 	msgType = bz[0]
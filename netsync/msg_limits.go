@@ -0,0 +1,66 @@
+package netsync
+
+import (
+	"time"
+)
+
+const (
+	// msgRateWindow is the sliding window the per-peer message rate caps
+	// are counted over
+	msgRateWindow = 10 * time.Second
+	// maxRequestMsgSize bounds the request style messages, a locator never
+	// legitimately grows near this
+	maxRequestMsgSize = 8192
+	// maxTxMsgSize bounds a single transaction announcement
+	maxTxMsgSize = 4194304
+)
+
+// maxMsgSize returns the maximum wire size accepted for a protocol msg byte,
+// it is enforced before the message is decoded
+func maxMsgSize(msgType byte) int {
+	switch msgType {
+	case BlockResponseByte, BlocksResponseByte, HeadersResponseByte, NewMineBlockByte, MerkleResponseByte:
+		return maxBlockchainResponseSize
+	case NewTransactionByte:
+		return maxTxMsgSize
+	}
+	return maxRequestMsgSize
+}
+
+// maxMsgRate returns how many messages of the type a peer may send within
+// msgRateWindow before it collects misbehavior points
+func maxMsgRate(msgType byte) int {
+	switch msgType {
+	case NewTransactionByte:
+		return 1000
+	case FilterLoadByte, FilterAddByte, FilterClearByte:
+		return 50
+	}
+	return 100
+}
+
+// checkMsgLimits enforces the per-type size and rate caps on a raw inbound
+// message, handing out misbehavior points on violation. It reports whether
+// the message may be decoded.
+func (ps *peerSet) checkMsgLimits(peerID string, msgBytes []byte) bool {
+	if len(msgBytes) == 0 {
+		return false
+	}
+
+	msgType := msgBytes[0]
+	if len(msgBytes) > maxMsgSize(msgType) {
+		ps.addBanScore(peerID, 0, banScoreOversizedMsg, "oversized "+msgTypeName(msgType)+" message")
+		return false
+	}
+
+	peer := ps.getPeer(peerID)
+	if peer == nil {
+		return true
+	}
+
+	if peer.exceedsMsgRate(msgType) {
+		ps.addBanScore(peerID, 0, banScoreMsgFlood, msgTypeName(msgType)+" message rate cap exceeded")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package netsync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxMsgSize(t *testing.T) {
+	cases := []struct {
+		msgType byte
+		want    int
+	}{
+		{BlocksResponseByte, maxBlockchainResponseSize},
+		{NewMineBlockByte, maxBlockchainResponseSize},
+		{NewTransactionByte, maxTxMsgSize},
+		{BlockRequestByte, maxRequestMsgSize},
+		{StatusRequestByte, maxRequestMsgSize},
+		{byte(0xff), maxRequestMsgSize},
+	}
+
+	for _, c := range cases {
+		if got := maxMsgSize(c.msgType); got != c.want {
+			t.Errorf("maxMsgSize(%#x): got %d, want %d", c.msgType, got, c.want)
+		}
+	}
+}
+
+func TestExceedsMsgRate(t *testing.T) {
+	p := &peer{msgCounts: make(map[byte]int), msgWindowStart: time.Now()}
+
+	cap := maxMsgRate(StatusRequestByte)
+	for i := 0; i < cap; i++ {
+		if p.exceedsMsgRate(StatusRequestByte) {
+			t.Fatalf("message %d flagged below the rate cap %d", i+1, cap)
+		}
+	}
+	if !p.exceedsMsgRate(StatusRequestByte) {
+		t.Fatal("message above the rate cap was not flagged")
+	}
+
+	// other types count against their own cap
+	if p.exceedsMsgRate(NewTransactionByte) {
+		t.Fatal("first message of another type flagged")
+	}
+
+	// an expired window resets the counters
+	p.msgWindowStart = time.Now().Add(-msgRateWindow - time.Second)
+	if p.exceedsMsgRate(StatusRequestByte) {
+		t.Fatal("message flagged after the window expired")
+	}
+}
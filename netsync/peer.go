@@ -4,8 +4,10 @@ import (
 	"encoding/hex"
 	"net"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	wire "github.com/tendermint/go-wire"
 	"gopkg.in/fatih/set.v0"
 
 	"github.com/bytom-gm/consensus"
@@ -19,6 +21,15 @@ const (
 	maxKnownTxs         = 32768 // Maximum transactions hashes to keep in the known list (prevent DOS)
 	maxKnownBlocks      = 1024  // Maximum block hashes to keep in the known list (prevent DOS)
 	defaultBanThreshold = uint64(100)
+
+	// penalty points handed out by the misbehavior scoring, a peer whose
+	// score passes defaultBanThreshold gets disconnected and banned
+	banScoreInvalidData  = uint64(20) // persistent: invalid blocks or headers
+	banScoreMalformedMsg = uint64(10) // transient: message that doesn't decode
+	banScoreUnrequested  = uint64(10) // transient: data we never asked for
+	banScoreStall        = uint64(20) // transient: sync request timeout
+	banScoreOversizedMsg = uint64(10) // transient: message above the per-type size cap
+	banScoreMsgFlood     = uint64(10) // transient: message above the per-type rate cap
 )
 
 //BasePeer is the interface for connection level peer
@@ -37,10 +48,13 @@ type BasePeerSet interface {
 
 // PeerInfo indicate peer status snap
 type PeerInfo struct {
-	ID         string `json:"peer_id"`
-	RemoteAddr string `json:"remote_addr"`
-	Height     uint64 `json:"height"`
-	Delay      uint32 `json:"delay"`
+	ID         string            `json:"peer_id"`
+	RemoteAddr string            `json:"remote_addr"`
+	Height     uint64            `json:"height"`
+	Delay      uint32            `json:"delay"`
+	BanScore   uint64            `json:"ban_score"`
+	BytesSent  map[string]uint64 `json:"bytes_sent"`
+	BytesRecv  map[string]uint64 `json:"bytes_received"`
 }
 
 type peer struct {
@@ -53,6 +67,14 @@ type peer struct {
 	knownTxs    *set.Set // Set of transaction hashes known to be known by this peer
 	knownBlocks *set.Set // Set of block hashes known to be known by this peer
 	filterAdds  *set.Set // Set of addresses that the spv node cares about.
+
+	trafficMtx sync.Mutex
+	bytesSent  map[string]uint64 // accumulated sent bytes keyed by message type
+	bytesRecv  map[string]uint64 // accumulated received bytes keyed by message type
+
+	limiterMtx     sync.Mutex
+	msgCounts      map[byte]int // messages received per type in the current window
+	msgWindowStart time.Time
 }
 
 func newPeer(height uint64, hash *bc.Hash, basePeer BasePeer) *peer {
@@ -64,6 +86,9 @@ func newPeer(height uint64, hash *bc.Hash, basePeer BasePeer) *peer {
 		knownTxs:    set.New(),
 		knownBlocks: set.New(),
 		filterAdds:  set.New(),
+		bytesSent:   make(map[string]uint64),
+		bytesRecv:   make(map[string]uint64),
+		msgCounts:   make(map[byte]int),
 	}
 }
 
@@ -73,6 +98,12 @@ func (p *peer) Height() uint64 {
 	return p.height
 }
 
+func (p *peer) bestHash() *bc.Hash {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return p.hash
+}
+
 func (p *peer) addBanScore(persistent, transient uint64, reason string) bool {
 	score := p.banScore.Increase(persistent, transient)
 	if score > defaultBanThreshold {
@@ -97,6 +128,22 @@ func (p *peer) addBanScore(persistent, transient uint64, reason string) bool {
 	return false
 }
 
+// exceedsMsgRate counts a received message of the type against the current
+// window and reports whether the peer went over its rate cap
+func (p *peer) exceedsMsgRate(msgType byte) bool {
+	p.limiterMtx.Lock()
+	defer p.limiterMtx.Unlock()
+
+	now := time.Now()
+	if now.Sub(p.msgWindowStart) > msgRateWindow {
+		p.msgWindowStart = now
+		p.msgCounts = make(map[byte]int)
+	}
+
+	p.msgCounts[msgType]++
+	return p.msgCounts[msgType] > maxMsgRate(msgType)
+}
+
 func (p *peer) addFilterAddress(address []byte) {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
@@ -121,28 +168,63 @@ func (p *peer) addFilterAddresses(addresses [][]byte) {
 	}
 }
 
-func (p *peer) getBlockByHeight(height uint64) bool {
-	msg := struct{ BlockchainMessage }{&GetBlockMessage{Height: height}}
-	return p.TrySend(BlockchainChannel, msg)
+// sendMsg pushes a protocol message to the peer and accounts its wire size
+// under the message type
+func (p *peer) sendMsg(msg BlockchainMessage) bool {
+	raw := wire.BinaryBytes(struct{ BlockchainMessage }{msg})
+	ok := p.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg})
+	if ok {
+		p.accountSent(msgTypeName(raw[0]), uint64(len(raw)))
+	}
+	return ok
+}
+
+func (p *peer) accountSent(msgType string, size uint64) {
+	p.trafficMtx.Lock()
+	defer p.trafficMtx.Unlock()
+	p.bytesSent[msgType] += size
+}
+
+func (p *peer) accountRecv(msgType string, size uint64) {
+	p.trafficMtx.Lock()
+	defer p.trafficMtx.Unlock()
+	p.bytesRecv[msgType] += size
+}
+
+func (p *peer) trafficStatus() (map[string]uint64, map[string]uint64) {
+	p.trafficMtx.Lock()
+	defer p.trafficMtx.Unlock()
+
+	sent := make(map[string]uint64, len(p.bytesSent))
+	for msgType, size := range p.bytesSent {
+		sent[msgType] = size
+	}
+	recv := make(map[string]uint64, len(p.bytesRecv))
+	for msgType, size := range p.bytesRecv {
+		recv[msgType] = size
+	}
+	return sent, recv
 }
 
 func (p *peer) getBlocks(locator []*bc.Hash, stopHash *bc.Hash) bool {
-	msg := struct{ BlockchainMessage }{NewGetBlocksMessage(locator, stopHash)}
-	return p.TrySend(BlockchainChannel, msg)
+	return p.sendMsg(NewGetBlocksMessage(locator, stopHash))
 }
 
 func (p *peer) getHeaders(locator []*bc.Hash, stopHash *bc.Hash) bool {
-	msg := struct{ BlockchainMessage }{NewGetHeadersMessage(locator, stopHash)}
-	return p.TrySend(BlockchainChannel, msg)
+	return p.sendMsg(NewGetHeadersMessage(locator, stopHash))
 }
 
 func (p *peer) getPeerInfo() *PeerInfo {
+	sent, recv := p.trafficStatus()
 	p.mtx.RLock()
 	defer p.mtx.RUnlock()
 	return &PeerInfo{
 		ID:         p.ID(),
 		RemoteAddr: p.Addr().String(),
 		Height:     p.height,
+		BanScore:   p.banScore.Int(),
+		BytesSent:  sent,
+		BytesRecv:  recv,
 	}
 }
 
@@ -179,6 +261,10 @@ func (p *peer) isSPVNode() bool {
 	return !p.services.IsEnable(consensus.SFFullNode)
 }
 
+func (p *peer) isBlocksOnlyNode() bool {
+	return p.services.IsEnable(consensus.SFBlocksOnly)
+}
+
 func (p *peer) markBlock(hash *bc.Hash) {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
@@ -205,7 +291,7 @@ func (p *peer) sendBlock(block *types.Block) (bool, error) {
 		return false, errors.Wrap(err, "fail on NewBlockMessage")
 	}
 
-	ok := p.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg})
+	ok := p.sendMsg(msg)
 	if ok {
 		blcokHash := block.Hash()
 		p.knownBlocks.Add(blcokHash.String())
@@ -219,7 +305,7 @@ func (p *peer) sendBlocks(blocks []*types.Block) (bool, error) {
 		return false, errors.Wrap(err, "fail on NewBlocksMessage")
 	}
 
-	if ok := p.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg}); !ok {
+	if ok := p.sendMsg(msg); !ok {
 		return ok, nil
 	}
 
@@ -236,7 +322,7 @@ func (p *peer) sendHeaders(headers []*types.BlockHeader) (bool, error) {
 		return false, errors.New("fail on NewHeadersMessage")
 	}
 
-	ok := p.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg})
+	ok := p.sendMsg(msg)
 	return ok, nil
 }
 
@@ -258,11 +344,15 @@ func (p *peer) sendMerkleBlock(block *types.Block, txStatuses *bc.TransactionSta
 		return false, nil
 	}
 
-	ok := p.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg})
+	ok := p.sendMsg(msg)
 	return ok, nil
 }
 
 func (p *peer) sendTransactions(txs []*types.Tx) (bool, error) {
+	if p.isBlocksOnlyNode() {
+		return true, nil
+	}
+
 	for _, tx := range txs {
 		if p.isSPVNode() && !p.isRelatedTx(tx) {
 			continue
@@ -275,7 +365,7 @@ func (p *peer) sendTransactions(txs []*types.Tx) (bool, error) {
 		if p.knownTxs.Has(tx.ID.String()) {
 			continue
 		}
-		if ok := p.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg}); !ok {
+		if ok := p.sendMsg(msg); !ok {
 			return ok, nil
 		}
 		p.knownTxs.Add(tx.ID.String())
@@ -321,6 +411,12 @@ func (ps *peerSet) addBanScore(peerID string, persistent, transient uint64, reas
 	ps.removePeer(peerID)
 }
 
+func (ps *peerSet) accountRecv(peerID string, msgType byte, size int) {
+	if peer := ps.getPeer(peerID); peer != nil {
+		peer.accountRecv(msgTypeName(msgType), uint64(size))
+	}
+}
+
 func (ps *peerSet) addPeer(peer BasePeer, height uint64, hash *bc.Hash) {
 	ps.mtx.Lock()
 	defer ps.mtx.Unlock()
@@ -360,7 +456,7 @@ func (ps *peerSet) broadcastMinedBlock(block *types.Block) error {
 		if peer.isSPVNode() {
 			continue
 		}
-		if ok := peer.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg}); !ok {
+		if ok := peer.sendMsg(msg); !ok {
 			ps.removePeer(peer.ID())
 			continue
 		}
@@ -376,7 +472,7 @@ func (ps *peerSet) broadcastNewStatus(bestBlock, genesisBlock *types.Block) erro
 	genesisHash := genesisBlock.Hash()
 	msg := NewStatusResponseMessage(&bestBlock.BlockHeader, &genesisHash)
 	for _, peer := range peers {
-		if ok := peer.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg}); !ok {
+		if ok := peer.sendMsg(msg); !ok {
 			ps.removePeer(peer.ID())
 			continue
 		}
@@ -384,30 +480,36 @@ func (ps *peerSet) broadcastNewStatus(bestBlock, genesisBlock *types.Block) erro
 	return nil
 }
 
-func (ps *peerSet) broadcastTx(tx *types.Tx) error {
-	msg, err := NewTransactionMessage(tx)
-	if err != nil {
-		return errors.Wrap(err, "fail on broadcast tx")
+// broadcastTxs announces a batch of transactions, skipping for every peer the
+// ones it already sent us or got from us before
+func (ps *peerSet) broadcastTxs(txs []*types.Tx) error {
+	ps.mtx.RLock()
+	peers := []*peer{}
+	for _, peer := range ps.peers {
+		peers = append(peers, peer)
 	}
+	ps.mtx.RUnlock()
 
-	peers := ps.peersWithoutTx(&tx.ID)
 	for _, peer := range peers {
-		if peer.isSPVNode() && !peer.isRelatedTx(tx) {
-			continue
+		ok, err := peer.sendTransactions(txs)
+		if err != nil {
+			return errors.Wrap(err, "fail on broadcast txs")
 		}
-		if ok := peer.TrySend(BlockchainChannel, struct{ BlockchainMessage }{msg}); !ok {
+		if !ok {
 			ps.removePeer(peer.ID())
-			continue
 		}
-		peer.markTransaction(&tx.ID)
 	}
 	return nil
 }
 
 func (ps *peerSet) errorHandler(peerID string, err error) {
-	if errors.Root(err) == errPeerMisbehave {
-		ps.addBanScore(peerID, 20, 0, err.Error())
-	} else {
+	switch errors.Root(err) {
+	case errPeerMisbehave:
+		ps.addBanScore(peerID, banScoreInvalidData, 0, err.Error())
+	case errRequestTimeout:
+		ps.addBanScore(peerID, 0, banScoreStall, err.Error())
+		ps.removePeer(peerID)
+	default:
 		ps.removePeer(peerID)
 	}
 }
@@ -430,26 +532,28 @@ func (ps *peerSet) getPeerInfos() []*PeerInfo {
 	return result
 }
 
-func (ps *peerSet) peersWithoutBlock(hash *bc.Hash) []*peer {
+// peersForHeight return the full node peers whose best chain reaches the
+// given height
+func (ps *peerSet) peersForHeight(height uint64) []*peer {
 	ps.mtx.RLock()
 	defer ps.mtx.RUnlock()
 
 	peers := []*peer{}
 	for _, peer := range ps.peers {
-		if !peer.knownBlocks.Has(hash.String()) {
+		if peer.services.IsEnable(consensus.SFFullNode) && peer.Height() >= height {
 			peers = append(peers, peer)
 		}
 	}
 	return peers
 }
 
-func (ps *peerSet) peersWithoutTx(hash *bc.Hash) []*peer {
+func (ps *peerSet) peersWithoutBlock(hash *bc.Hash) []*peer {
 	ps.mtx.RLock()
 	defer ps.mtx.RUnlock()
 
 	peers := []*peer{}
 	for _, peer := range ps.peers {
-		if !peer.knownTxs.Has(hash.String()) {
+		if !peer.knownBlocks.Has(hash.String()) {
 			peers = append(peers, peer)
 		}
 	}
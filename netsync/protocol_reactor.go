@@ -89,11 +89,16 @@ func (pr *ProtocolReactor) RemovePeer(peer *p2p.Peer, reason interface{}) {
 
 // Receive implements Reactor by handling 4 types of messages (look below).
 func (pr *ProtocolReactor) Receive(chID byte, src *p2p.Peer, msgBytes []byte) {
+	if ok := pr.peers.checkMsgLimits(src.Key, msgBytes); !ok {
+		return
+	}
+
 	msgType, msg, err := DecodeMessage(msgBytes)
 	if err != nil {
 		log.WithFields(log.Fields{"module": logModule, "err": err}).Error("fail on reactor decoding message")
 		return
 	}
 
+	pr.sm.peers.accountRecv(src.Key, msgType, len(msgBytes))
 	pr.sm.processMsg(src, msgType, msg)
 }
@@ -0,0 +1,118 @@
+package netsync
+
+import (
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/consensus"
+)
+
+const (
+	// maxDNSSeedPeers caps how many addresses the DNS seeds contribute
+	maxDNSSeedPeers = 8
+	// seedProbeTimeout bounds the health probe of one seed candidate
+	seedProbeTimeout = 3 * time.Second
+)
+
+// collectSeeds merges the operator-configured seeds with the hardcoded seed
+// nodes and DNS seeds of the network
+func collectSeeds(config *cfg.Config, params *consensus.Params) []string {
+	candidates := []string{}
+	if config.P2P.Seeds != "" {
+		candidates = append(candidates, strings.Split(config.P2P.Seeds, ",")...)
+	}
+	candidates = append(candidates, params.SeedNodes...)
+	candidates = append(candidates, resolveDNSSeeds(params.DNSSeeds, params.SeedPort, config.P2P.PreferredFamily)...)
+
+	seeds := []string{}
+	seen := map[string]bool{}
+	for _, seed := range candidates {
+		if seen[seed] {
+			continue
+		}
+		seen[seed] = true
+		seeds = append(seeds, seed)
+	}
+	return seeds
+}
+
+// resolveDNSSeeds looks up the seed hostnames and returns a rotated subset
+// of the addresses that pass the health probe, the preferred IP family
+// sorted first
+func resolveDNSSeeds(hosts []string, port uint16, preferredFamily string) []string {
+	candidates := []string{}
+	for _, host := range hosts {
+		ips, err := net.LookupIP(host)
+		if err != nil {
+			log.WithFields(log.Fields{"module": logModule, "seed": host, "err": err}).Warning("fail on resolve DNS seed")
+			continue
+		}
+		for _, ip := range ips {
+			candidates = append(candidates, net.JoinHostPort(ip.String(), strconv.Itoa(int(port))))
+		}
+	}
+
+	healthy := probeSeeds(candidates)
+	// rotate so restarting nodes don't all lean on the same seeds
+	rand.Shuffle(len(healthy), func(i, j int) { healthy[i], healthy[j] = healthy[j], healthy[i] })
+	if preferredFamily != "" {
+		sort.SliceStable(healthy, func(i, j int) bool {
+			return isPreferredFamily(healthy[i], preferredFamily) && !isPreferredFamily(healthy[j], preferredFamily)
+		})
+	}
+	if len(healthy) > maxDNSSeedPeers {
+		healthy = healthy[:maxDNSSeedPeers]
+	}
+	return healthy
+}
+
+// isPreferredFamily reports whether the host:port address belongs to the
+// preferred IP family
+func isPreferredFamily(addr, preferredFamily string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if preferredFamily == "ipv6" {
+		return ip.To4() == nil
+	}
+	return ip.To4() != nil
+}
+
+// probeSeeds keeps the candidates that accept a TCP connection
+func probeSeeds(candidates []string) []string {
+	var wg sync.WaitGroup
+	healthyCh := make(chan string, len(candidates))
+	for _, addr := range candidates {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			conn, err := net.DialTimeout("tcp", addr, seedProbeTimeout)
+			if err != nil {
+				return
+			}
+			conn.Close()
+			healthyCh <- addr
+		}(addr)
+	}
+	wg.Wait()
+	close(healthyCh)
+
+	healthy := []string{}
+	for addr := range healthyCh {
+		healthy = append(healthy, addr)
+	}
+	return healthy
+}
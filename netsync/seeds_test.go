@@ -0,0 +1,55 @@
+package netsync
+
+import (
+	"net"
+	"testing"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/consensus"
+)
+
+func TestCollectSeeds(t *testing.T) {
+	config := &cfg.Config{P2P: &cfg.P2PConfig{Seeds: "1.2.3.4:46656,5.6.7.8:46656"}}
+	params := &consensus.Params{SeedNodes: []string{"5.6.7.8:46656", "9.10.11.12:46656"}}
+
+	seeds := collectSeeds(config, params)
+	want := []string{"1.2.3.4:46656", "5.6.7.8:46656", "9.10.11.12:46656"}
+	if len(seeds) != len(want) {
+		t.Fatalf("got %d seeds %v, want %d", len(seeds), seeds, len(want))
+	}
+	for i, seed := range want {
+		if seeds[i] != seed {
+			t.Errorf("got seed %s at %d, want %s", seeds[i], i, seed)
+		}
+	}
+}
+
+func TestProbeSeeds(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// a closed listener gives a dead candidate with a fast refusal
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadAddr := dead.Addr().String()
+	dead.Close()
+
+	healthy := probeSeeds([]string{listener.Addr().String(), deadAddr})
+	if len(healthy) != 1 || healthy[0] != listener.Addr().String() {
+		t.Errorf("got healthy seeds %v, want only %s", healthy, listener.Addr().String())
+	}
+}
@@ -0,0 +1,113 @@
+package netsync
+
+import (
+	"sync"
+	"time"
+)
+
+// syncStatsWindow is the sliding window the block rates are averaged over
+const syncStatsWindow = 60 * time.Second
+
+type statsSample struct {
+	when  time.Time
+	count uint64
+}
+
+// syncStats tracks how fast blocks are downloaded from peers and how fast
+// the chain verifies them, over a sliding window
+type syncStats struct {
+	mtx        sync.Mutex
+	downloaded []statsSample
+	processed  []statsSample
+}
+
+func newSyncStats() *syncStats {
+	return &syncStats{}
+}
+
+// markDownloaded records count blocks delivered by peers
+func (s *syncStats) markDownloaded(count uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.downloaded = append(s.downloaded, statsSample{when: time.Now(), count: count})
+}
+
+// markProcessed records count blocks accepted by the chain
+func (s *syncStats) markProcessed(count uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.processed = append(s.processed, statsSample{when: time.Now(), count: count})
+}
+
+// rates returns the download and verification rates in blocks per second
+func (s *syncStats) rates() (float64, float64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	s.downloaded = pruneSamples(s.downloaded, now)
+	s.processed = pruneSamples(s.processed, now)
+	return sampleRate(s.downloaded, now), sampleRate(s.processed, now)
+}
+
+// pruneSamples drops the samples that fell out of the window
+func pruneSamples(samples []statsSample, now time.Time) []statsSample {
+	cutoff := now.Add(-syncStatsWindow)
+	for len(samples) > 0 && samples[0].when.Before(cutoff) {
+		samples = samples[1:]
+	}
+	return samples
+}
+
+// sampleRate averages the samples over the elapsed part of the window
+func sampleRate(samples []statsSample, now time.Time) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	total := uint64(0)
+	for _, sample := range samples {
+		total += sample.count
+	}
+
+	elapsed := now.Sub(samples[0].when)
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+	return float64(total) / elapsed.Seconds()
+}
+
+// SyncStatus is the sync progress snapshot served by the API
+type SyncStatus struct {
+	Syncing          bool    `json:"syncing"`
+	CurrentHeight    uint64  `json:"current_height"`
+	HighestHeight    uint64  `json:"highest_height"`
+	DownloadRate     float64 `json:"download_rate"`
+	VerificationRate float64 `json:"verification_rate"`
+	ETASeconds       uint64  `json:"eta_seconds"`
+}
+
+//SyncStatus return the current height against the best-known peer height
+//together with the block rates and the remaining sync time estimate
+func (sm *SyncManager) SyncStatus() *SyncStatus {
+	downloadRate, verificationRate := sm.blockKeeper.stats.rates()
+	status := &SyncStatus{
+		Syncing:          !sm.IsCaughtUp(),
+		CurrentHeight:    sm.chain.BestBlockHeight(),
+		DownloadRate:     downloadRate,
+		VerificationRate: verificationRate,
+	}
+
+	if bestPeer := sm.BestPeer(); bestPeer != nil {
+		status.HighestHeight = bestPeer.Height
+	}
+	if status.CurrentHeight > status.HighestHeight {
+		status.HighestHeight = status.CurrentHeight
+	}
+
+	if status.Syncing && verificationRate > 0 {
+		remaining := status.HighestHeight - status.CurrentHeight
+		status.ETASeconds = uint64(float64(remaining) / verificationRate)
+	}
+	return status
+}
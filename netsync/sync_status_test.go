@@ -0,0 +1,65 @@
+package netsync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampleRate(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		samples []statsSample
+		want    float64
+	}{
+		{samples: nil, want: 0},
+		{
+			samples: []statsSample{
+				{when: now.Add(-10 * time.Second), count: 50},
+				{when: now.Add(-5 * time.Second), count: 50},
+			},
+			want: 10,
+		},
+		{
+			// samples newer than a second are averaged over a full second
+			samples: []statsSample{{when: now, count: 7}},
+			want:    7,
+		},
+	}
+
+	for i, c := range cases {
+		if got := sampleRate(c.samples, now); got != c.want {
+			t.Errorf("case %d: got rate %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestPruneSamples(t *testing.T) {
+	now := time.Now()
+	samples := []statsSample{
+		{when: now.Add(-2 * syncStatsWindow), count: 1},
+		{when: now.Add(-syncStatsWindow - time.Second), count: 2},
+		{when: now.Add(-time.Second), count: 3},
+	}
+
+	pruned := pruneSamples(samples, now)
+	if len(pruned) != 1 || pruned[0].count != 3 {
+		t.Errorf("got %v, want the sample inside the window only", pruned)
+	}
+}
+
+func TestSyncStatsRates(t *testing.T) {
+	stats := newSyncStats()
+	if download, verification := stats.rates(); download != 0 || verification != 0 {
+		t.Errorf("got rates %v/%v on empty stats, want 0/0", download, verification)
+	}
+
+	stats.markDownloaded(128)
+	stats.markProcessed(64)
+	download, verification := stats.rates()
+	if download <= 0 || verification <= 0 {
+		t.Errorf("got rates %v/%v after marking, want positive", download, verification)
+	}
+	if download <= verification {
+		t.Errorf("got download rate %v <= verification rate %v, want higher", download, verification)
+	}
+}
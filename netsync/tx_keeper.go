@@ -2,6 +2,7 @@ package netsync
 
 import (
 	"math/rand"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -12,6 +13,11 @@ const (
 	// This is the target size for the packs of transactions sent by txSyncLoop.
 	// A pack can get larger than this if a single transactions exceeds this size.
 	txSyncPackSize = 100 * 1024
+
+	// txBroadcastDelay is the base wait before the queued transactions are
+	// announced to the peers. A random slice of it is added on every flush so
+	// the nodes of the network don't announce in lockstep.
+	txBroadcastDelay = 100 * time.Millisecond
 )
 
 type txSyncMsg struct {
@@ -20,6 +26,10 @@ type txSyncMsg struct {
 }
 
 func (sm *SyncManager) syncTransactions(peerID string) {
+	if sm.config.P2P.BlocksOnly {
+		return
+	}
+
 	pending := sm.txPool.GetTransactions()
 	if len(pending) == 0 {
 		return
@@ -32,14 +42,28 @@ func (sm *SyncManager) syncTransactions(peerID string) {
 	sm.txSyncCh <- &txSyncMsg{peerID, txs}
 }
 
+// txBroadcastLoop collects the new transactions for a short randomized delay
+// and announces them to the peers in one batch, so a busy mempool doesn't
+// turn into a message per transaction per peer.
 func (sm *SyncManager) txBroadcastLoop() {
+	queue := []*types.Tx{}
+	var flushCh <-chan time.Time
 	for {
 		select {
 		case newTx := <-sm.newTxCh:
-			if err := sm.peers.broadcastTx(newTx); err != nil {
-				log.WithFields(log.Fields{"module": logModule, "err": err}).Error("fail on broadcast new tx.")
+			queue = append(queue, newTx)
+			if flushCh == nil {
+				flushCh = time.After(txBroadcastDelay + time.Duration(rand.Int63n(int64(txBroadcastDelay))))
+			}
+
+		case <-flushCh:
+			flushCh = nil
+			if err := sm.peers.broadcastTxs(queue); err != nil {
+				log.WithFields(log.Fields{"module": logModule, "err": err}).Error("fail on broadcast new txs.")
 				return
 			}
+			queue = []*types.Tx{}
+
 		case <-sm.quitSync:
 			return
 		}
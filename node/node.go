@@ -8,6 +8,8 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/prometheus/prometheus/util/flock"
 	log "github.com/sirupsen/logrus"
@@ -17,20 +19,27 @@ import (
 
 	"github.com/bytom-gm/accesstoken"
 	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/addrindex"
 	"github.com/bytom-gm/api"
+	apigrpc "github.com/bytom-gm/api/grpc"
 	"github.com/bytom-gm/asset"
 	"github.com/bytom-gm/blockchain/pseudohsm"
+	"github.com/bytom-gm/blockchain/txbuilder"
 	"github.com/bytom-gm/blockchain/txfeed"
 	cfg "github.com/bytom-gm/config"
 	"github.com/bytom-gm/consensus"
 	"github.com/bytom-gm/database/leveldb"
 	"github.com/bytom-gm/env"
+	"github.com/bytom-gm/mining"
 	"github.com/bytom-gm/mining/cpuminer"
 	"github.com/bytom-gm/mining/miningpool"
+	"github.com/bytom-gm/mining/stratum"
 	"github.com/bytom-gm/mining/tensority"
 	"github.com/bytom-gm/netsync"
 	"github.com/bytom-gm/protocol"
 	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/txindex"
+	"github.com/bytom-gm/updater"
 	w "github.com/bytom-gm/wallet"
 )
 
@@ -48,14 +57,18 @@ type Node struct {
 	syncManager *netsync.SyncManager
 
 	//bcReactor    *bc.BlockchainReactor
-	wallet       *w.Wallet
-	accessTokens *accesstoken.CredentialStore
-	api          *api.API
-	chain        *protocol.Chain
-	txfeed       *txfeed.Tracker
-	cpuMiner     *cpuminer.CPUMiner
-	miningPool   *miningpool.MiningPool
-	miningEnable bool
+	wallet        *w.Wallet
+	addrIndex     *addrindex.AddrIndex
+	txIndex       *txindex.TxIndex
+	accessTokens  *accesstoken.CredentialStore
+	api           *api.API
+	chain         *protocol.Chain
+	txfeed        *txfeed.Tracker
+	cpuMiner      *cpuminer.CPUMiner
+	miningPool    *miningpool.MiningPool
+	stratumServer *stratum.Server
+	miningEnable  bool
+	updater       *updater.Updater
 
 	newBlockCh chan *bc.Hash
 }
@@ -74,6 +87,8 @@ func NewNode(config *cfg.Config) *Node {
 		cmn.Exit(cmn.Fmt("Param db_backend [%v] is invalid, use leveldb or memdb", config.DBBackend))
 	}
 	coreDB := dbm.NewDB("core", config.DBBackend, config.DBDir())
+	leveldb.SetUtxoCacheSize(config.UtxoCacheSize)
+	leveldb.SetPruneMode(config.Prune, config.PruneKeepBlocks)
 	store := leveldb.NewStore(coreDB)
 
 	tokenDB := dbm.NewDB("accesstoken", config.DBBackend, config.DBDir())
@@ -84,6 +99,25 @@ func NewNode(config *cfg.Config) *Node {
 	if err != nil {
 		cmn.Exit(cmn.Fmt("Failed to create chain structure: %v", err))
 	}
+	txbuilder.SetFeeEstimater(txPool)
+
+	// bring back the unconfirmed transactions of the previous run, stale
+	// ones get dropped by revalidation
+	if count, err := chain.LoadMempool(); err != nil {
+		log.WithField("error", err).Warning("load persisted mempool")
+	} else if count > 0 {
+		log.WithField("count", count).Info("reloaded persisted mempool transactions")
+	}
+
+	// load the out-of-band headers file so initial sync can verify blocks
+	// against a locally validated header chain
+	if config.HeadersFile != "" {
+		if headers, err := protocol.LoadHeadersFile(config.HeadersFile); err != nil {
+			log.WithField("error", err).Error("load headers file")
+		} else if err := chain.ImportHeaders(headers); err != nil {
+			log.WithField("error", err).Error("import bootstrap headers")
+		}
+	}
 
 	var accounts *account.Manager = nil
 	var assets *asset.Registry = nil
@@ -117,10 +151,36 @@ func NewNode(config *cfg.Config) *Node {
 			wallet.RescanBlocks()
 		}
 	}
+
+	var addrIndex *addrindex.AddrIndex
+	if config.AddressIndex {
+		addrIndexDB := dbm.NewDB("addrindex", config.DBBackend, config.DBDir())
+		if addrIndex, err = addrindex.NewAddrIndex(addrIndexDB, chain); err != nil {
+			cmn.Exit(cmn.Fmt("initialize address index failed: %v", err))
+		}
+	}
+
+	var txIndex *txindex.TxIndex
+	if config.TxIndex {
+		txIndexDB := dbm.NewDB("txindex", config.DBBackend, config.DBDir())
+		if txIndex, err = txindex.NewTxIndex(txIndexDB, chain); err != nil {
+			cmn.Exit(cmn.Fmt("initialize transaction index failed: %v", err))
+		}
+	}
 	newBlockCh := make(chan *bc.Hash, maxNewBlockChSize)
 
 	syncManager, _ := netsync.NewSyncManager(config, chain, txPool, newBlockCh)
 
+	var upd *updater.Updater
+	if config.Update.Enable && config.Update.ManifestURL != "" {
+		stageDir := config.Update.StagePath
+		if !filepath.IsAbs(stageDir) {
+			stageDir = filepath.Join(config.RootDir, stageDir)
+		}
+		upd = updater.NewUpdater(config.Update, stageDir)
+		go upd.Run()
+	}
+
 	// get transaction from txPool and send it to syncManager and wallet
 	go newPoolTxListener(txPool, syncManager, wallet)
 
@@ -141,15 +201,39 @@ func NewNode(config *cfg.Config) *Node {
 		syncManager:  syncManager,
 		accessTokens: accessTokens,
 		wallet:       wallet,
+		addrIndex:    addrIndex,
+		txIndex:      txIndex,
 		chain:        chain,
 		txfeed:       txFeed,
 		miningEnable: config.Mining,
+		updater:      upd,
 
 		newBlockCh: newBlockCh,
 	}
 
+	if config.Miner != nil {
+		if err := mining.LoadSelectionPolicy(config.Miner.MinFeeRate, config.Miner.MaxBlockGasTarget, config.Miner.LocalTxPriority, config.Miner.BannedPrograms); err != nil {
+			cmn.Exit(cmn.Fmt("Param miner.banned_programs is invalid: %v", err))
+		}
+		if err := mining.SetCoinbaseMessage([]byte(config.Miner.CoinbaseMessage), config.Miner.ExtranonceSize); err != nil {
+			cmn.Exit(cmn.Fmt("Param miner.coinbase_message is invalid: %v", err))
+		}
+		if err := mining.LoadPayoutRotation(config.Miner.PayoutPrograms, config.Miner.RotatePayoutAddresses); err != nil {
+			cmn.Exit(cmn.Fmt("Param miner.payout_programs is invalid: %v", err))
+		}
+	}
+
 	node.cpuMiner = cpuminer.NewCPUMiner(chain, accounts, txPool, newBlockCh)
+	if config.Miner != nil {
+		if config.Miner.CPUWorkers > 0 {
+			node.cpuMiner.SetNumWorkers(int32(config.Miner.CPUWorkers))
+		}
+		node.cpuMiner.SetThrottle(config.Miner.CPUThrottle)
+	}
 	node.miningPool = miningpool.NewMiningPool(chain, accounts, txPool, newBlockCh)
+	if config.Stratum != nil && config.Stratum.Enable {
+		node.stratumServer = stratum.NewServer(config.Stratum, node.miningPool, chain)
+	}
 
 	node.BaseService = *cmn.NewBaseService(nil, "Node", node)
 
@@ -177,6 +261,10 @@ func newPoolTxListener(txPool *protocol.TxPool, syncManager *netsync.SyncManager
 			if wallet != nil {
 				wallet.RemoveUnconfirmedTx(msg.TxDesc)
 			}
+		case protocol.MsgConflictTx:
+			if wallet != nil {
+				wallet.NotifyConflictTx(msg.TxDesc, msg.Conflicts)
+			}
 		default:
 			log.Warn("got unknow message type from the txPool channel")
 		}
@@ -198,6 +286,33 @@ func initActiveNetParams(config *cfg.Config) {
 	if !exist {
 		cmn.Exit(cmn.Fmt("chain_id[%v] don't exist", config.ChainID))
 	}
+
+	if config.AssumeValid != "" {
+		assumeValid, err := parseAssumeValid(config.AssumeValid)
+		if err != nil {
+			cmn.Exit(cmn.Fmt("Param assume_valid is invalid: %v", err))
+		}
+		consensus.ActiveNetParams.AssumeValid = assumeValid
+	}
+}
+
+// parseAssumeValid parses the "<height>:<block hash>" assume-valid override
+func parseAssumeValid(raw string) (*consensus.Checkpoint, error) {
+	parts := strings.SplitN(raw, ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("want <height>:<block hash>")
+	}
+
+	height, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := bc.Hash{}
+	if err := hash.UnmarshalText([]byte(parts[1])); err != nil {
+		return nil, err
+	}
+	return &consensus.Checkpoint{Height: height, Hash: hash}, nil
 }
 
 func initLogFile(config *cfg.Config) {
@@ -229,13 +344,26 @@ func launchWebBrowser(port string) {
 }
 
 func (n *Node) initAndstartApiServer() {
-	n.api = api.NewAPI(n.syncManager, n.wallet, n.txfeed, n.cpuMiner, n.miningPool, n.chain, n.config, n.accessTokens, n.newBlockCh)
+	n.api = api.NewAPI(n.syncManager, n.wallet, n.addrIndex, n.txIndex, n.txfeed, n.cpuMiner, n.miningPool, n.stratumServer, n.chain, n.config, n.accessTokens, n.newBlockCh, n.updater)
 
 	listenAddr := env.String("LISTEN", n.config.ApiAddress)
 	env.Parse()
 	n.api.StartServer(*listenAddr)
 }
 
+func (n *Node) startGRPCServer() {
+	if n.config.GRPCAddress == "" {
+		return
+	}
+
+	grpcServer := apigrpc.NewServer(n.chain, n.miningPool, n.wallet, n.syncManager)
+	go func() {
+		if err := grpcServer.Run(n.config.GRPCAddress); err != nil {
+			log.WithField("err", err).Error("grpc api server exit")
+		}
+	}()
+}
+
 func (n *Node) OnStart() error {
 	if n.miningEnable {
 		if _, err := n.wallet.AccountMgr.GetMiningAddress(); err != nil {
@@ -249,6 +377,12 @@ func (n *Node) OnStart() error {
 		n.syncManager.Start()
 	}
 	n.initAndstartApiServer()
+	n.startGRPCServer()
+	if n.stratumServer != nil {
+		if err := n.stratumServer.Start(); err != nil {
+			log.WithField("err", err).Error("start stratum server")
+		}
+	}
 	if !n.config.Web.Closed {
 		_, port, err := net.SplitHostPort(n.config.ApiAddress)
 		if err != nil {
@@ -268,6 +402,14 @@ func (n *Node) OnStop() {
 	if !n.config.VaultMode {
 		n.syncManager.Stop()
 	}
+	if n.stratumServer != nil {
+		n.stratumServer.Stop()
+	}
+	if count, err := n.chain.SaveMempool(); err != nil {
+		log.WithField("error", err).Error("persist mempool")
+	} else {
+		log.WithField("count", count).Info("persisted mempool transactions")
+	}
 }
 
 func (n *Node) RunForever() {
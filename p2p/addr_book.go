@@ -0,0 +1,275 @@
+package p2p
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+const (
+	addrBookKey = "AddrBook"
+
+	// maxAddrBookEntries bounds the whole book, the lowest scored entries
+	// are dropped first when it overflows
+	maxAddrBookEntries = 1024
+	// maxEntriesPerSourceBucket caps how many addresses one source netgroup
+	// may contribute, so a single gossiping subnet can't fill the book
+	maxEntriesPerSourceBucket = 64
+	// addrExpireAfter is how long an entry survives without a successful
+	// connection or a fresh gossip announcement
+	addrExpireAfter = 30 * 24 * time.Hour
+	// addrDecayInterval is how often the connection history counters are
+	// halved, old history should weigh less than recent behavior
+	addrDecayInterval = 7 * 24 * time.Hour
+)
+
+// addrEntry is one gossiped address together with its connection history
+type addrEntry struct {
+	Addr        string    `json:"addr"`
+	SourceGroup string    `json:"source_group"`
+	Attempts    uint32    `json:"attempts"` // dial attempts since the last success
+	Successes   uint32    `json:"successes"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastSuccess time.Time `json:"last_success"`
+	LastSeen    time.Time `json:"last_seen"` // last gossip announcement
+	LastDecay   time.Time `json:"last_decay"`
+}
+
+// score rates the entry for outbound selection, fresh and reliable
+// addresses come out on top
+func (e *addrEntry) score(now time.Time) float64 {
+	score := float64(e.Successes+1) / float64(e.Attempts+e.Successes+1)
+	if !e.LastSuccess.IsZero() && now.Sub(e.LastSuccess) < addrDecayInterval {
+		score *= 2
+	}
+	// back off addresses that were tried very recently and failed
+	if e.Attempts > 0 && now.Sub(e.LastAttempt) < 10*time.Minute {
+		score /= 2
+	}
+	return score
+}
+
+// decay halves the history counters once per elapsed decay interval, so an
+// address can outlive a bad streak
+func (e *addrEntry) decay(now time.Time) {
+	if e.LastDecay.IsZero() {
+		e.LastDecay = now
+		return
+	}
+	for now.Sub(e.LastDecay) >= addrDecayInterval {
+		e.Attempts /= 2
+		e.Successes /= 2
+		e.LastDecay = e.LastDecay.Add(addrDecayInterval)
+	}
+}
+
+// addrBook buckets the gossiped addresses by the netgroup of their source,
+// tracks the connection history of each one and persists the whole book in
+// the switch database across restarts
+type addrBook struct {
+	mtx       sync.Mutex
+	db        dbm.DB
+	netGroups *netGroupKeeper
+	entries   map[string]*addrEntry
+}
+
+func newAddrBook(db dbm.DB, netGroups *netGroupKeeper) *addrBook {
+	book := &addrBook{
+		db:        db,
+		netGroups: netGroups,
+		entries:   map[string]*addrEntry{},
+	}
+
+	if datajson := db.Get([]byte(addrBookKey)); datajson != nil {
+		json.Unmarshal(datajson, &book.entries)
+	}
+
+	now := time.Now()
+	for addr, entry := range book.entries {
+		entry.decay(now)
+		if book.isExpired(entry, now) {
+			delete(book.entries, addr)
+		}
+	}
+	book.save()
+	return book
+}
+
+// addAddress records a gossiped address, source is the IP of whoever told us
+// about it
+func (b *addrBook) addAddress(addr *NetAddress, source net.IP) {
+	if addr == nil || !addr.Valid() {
+		return
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := time.Now()
+	if entry, ok := b.entries[addr.String()]; ok {
+		entry.LastSeen = now
+		b.save()
+		return
+	}
+
+	sourceGroup := b.netGroups.GroupKey(source)
+	if b.countSourceBucket(sourceGroup) >= maxEntriesPerSourceBucket {
+		return
+	}
+
+	b.entries[addr.String()] = &addrEntry{
+		Addr:        addr.String(),
+		SourceGroup: sourceGroup,
+		LastSeen:    now,
+		LastDecay:   now,
+	}
+	b.evictOverflow(now)
+	b.save()
+}
+
+// markAttempt records a dial attempt against the address
+func (b *addrBook) markAttempt(addr string) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	entry, ok := b.entries[addr]
+	if !ok {
+		return
+	}
+	entry.Attempts++
+	entry.LastAttempt = time.Now()
+	b.save()
+}
+
+// markSuccess records a completed handshake, it clears the failure streak.
+// Addresses outside the book (seeds, anchors) earn an entry on success.
+func (b *addrBook) markSuccess(addr string) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	entry, ok := b.entries[addr]
+	if !ok {
+		netAddr, err := NewNetAddressString(addr)
+		if err != nil {
+			return
+		}
+		entry = &addrEntry{
+			Addr:        addr,
+			SourceGroup: b.netGroups.GroupKey(netAddr.IP),
+			LastDecay:   time.Now(),
+		}
+		b.entries[addr] = entry
+	}
+	entry.Successes++
+	entry.Attempts = 0
+	entry.LastSuccess = time.Now()
+	entry.LastSeen = entry.LastSuccess
+	b.save()
+}
+
+// pickAddresses returns up to count addresses for outbound dialing, highest
+// scored first while spreading the picks over distinct netgroups
+func (b *addrBook) pickAddresses(count int, exclude map[string]bool) []*NetAddress {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := time.Now()
+	candidates := []*addrEntry{}
+	for _, entry := range b.entries {
+		if b.isExpired(entry, now) {
+			continue
+		}
+		candidates = append(candidates, entry)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].score(now) > candidates[j].score(now)
+	})
+
+	picked := []*NetAddress{}
+	usedGroups := map[string]bool{}
+	for relaxed := 0; relaxed < 2 && len(picked) < count; relaxed++ {
+		for _, entry := range candidates {
+			if len(picked) >= count {
+				break
+			}
+
+			addr, err := NewNetAddressString(entry.Addr)
+			if err != nil {
+				continue
+			}
+			if exclude[addr.IP.String()] || exclude[entry.Addr] {
+				continue
+			}
+
+			group := b.netGroups.GroupKey(addr.IP)
+			// the first pass takes one address per netgroup, the second
+			// relaxes the diversity constraint if the book is too small
+			if relaxed == 0 && usedGroups[group] {
+				continue
+			}
+
+			usedGroups[group] = true
+			exclude[addr.IP.String()] = true
+			picked = append(picked, addr)
+		}
+	}
+	return picked
+}
+
+// size returns how many addresses the book holds
+func (b *addrBook) size() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return len(b.entries)
+}
+
+// countSourceBucket must be called with the book lock held
+func (b *addrBook) countSourceBucket(sourceGroup string) int {
+	count := 0
+	for _, entry := range b.entries {
+		if entry.SourceGroup == sourceGroup {
+			count++
+		}
+	}
+	return count
+}
+
+// evictOverflow drops the lowest scored entries over the book capacity, it
+// must be called with the book lock held
+func (b *addrBook) evictOverflow(now time.Time) {
+	if len(b.entries) <= maxAddrBookEntries {
+		return
+	}
+
+	entries := []*addrEntry{}
+	for _, entry := range b.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].score(now) < entries[j].score(now)
+	})
+
+	for _, entry := range entries[:len(entries)-maxAddrBookEntries] {
+		delete(b.entries, entry.Addr)
+	}
+}
+
+// isExpired must be called with the book lock held
+func (b *addrBook) isExpired(entry *addrEntry, now time.Time) bool {
+	last := entry.LastSeen
+	if entry.LastSuccess.After(last) {
+		last = entry.LastSuccess
+	}
+	return now.Sub(last) > addrExpireAfter
+}
+
+// save must be called with the book lock held
+func (b *addrBook) save() {
+	if datajson, err := json.Marshal(b.entries); err == nil {
+		b.db.Set([]byte(addrBookKey), datajson)
+	}
+}
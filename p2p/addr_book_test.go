@@ -0,0 +1,97 @@
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	cfg "github.com/bytom-gm/config"
+)
+
+func testAddrBook() *addrBook {
+	return newAddrBook(dbm.NewMemDB(), newNetGroupKeeper(&cfg.P2PConfig{}))
+}
+
+func TestAddrBookSelection(t *testing.T) {
+	book := testAddrBook()
+	source := net.ParseIP("203.0.113.1")
+
+	// two addresses in the same /16, one in another
+	for _, addr := range []string{"198.51.100.1:46656", "198.51.100.2:46656", "192.0.2.1:46656"} {
+		netAddr, err := NewNetAddressString(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		book.addAddress(netAddr, source)
+	}
+
+	picked := book.pickAddresses(2, map[string]bool{})
+	if len(picked) != 2 {
+		t.Fatalf("got %d addresses, want 2", len(picked))
+	}
+	if g0, g1 := book.netGroups.GroupKey(picked[0].IP), book.netGroups.GroupKey(picked[1].IP); g0 == g1 {
+		t.Errorf("both picks are in netgroup %s, want diverse groups", g0)
+	}
+
+	// with the diversity constraint unsatisfiable the second pass relaxes it
+	picked = book.pickAddresses(3, map[string]bool{})
+	if len(picked) != 3 {
+		t.Errorf("got %d addresses, want all 3 on the relaxed pass", len(picked))
+	}
+}
+
+func TestAddrBookScoring(t *testing.T) {
+	book := testAddrBook()
+	source := net.ParseIP("203.0.113.1")
+
+	good, _ := NewNetAddressString("198.51.100.1:46656")
+	bad, _ := NewNetAddressString("198.51.100.2:46656")
+	book.addAddress(good, source)
+	book.addAddress(bad, source)
+
+	book.markSuccess(good.String())
+	for i := 0; i < 5; i++ {
+		book.markAttempt(bad.String())
+	}
+	// age the attempts out of the recent-failure backoff
+	book.entries[bad.String()].LastAttempt = time.Now().Add(-time.Hour)
+
+	picked := book.pickAddresses(2, map[string]bool{})
+	if len(picked) == 0 || !picked[0].Equals(good) {
+		t.Errorf("got %v first, want the address with the success history", picked)
+	}
+}
+
+func TestAddrBookPersistence(t *testing.T) {
+	db := dbm.NewMemDB()
+	netGroups := newNetGroupKeeper(&cfg.P2PConfig{})
+	book := newAddrBook(db, netGroups)
+
+	addr, _ := NewNetAddressString("198.51.100.1:46656")
+	book.addAddress(addr, net.ParseIP("203.0.113.1"))
+	book.markSuccess(addr.String())
+
+	reloaded := newAddrBook(db, netGroups)
+	if reloaded.size() != 1 {
+		t.Fatalf("got %d entries after reload, want 1", reloaded.size())
+	}
+	if entry := reloaded.entries[addr.String()]; entry.Successes != 1 {
+		t.Errorf("got %d successes after reload, want 1", entry.Successes)
+	}
+}
+
+func TestAddrEntryDecay(t *testing.T) {
+	now := time.Now()
+	entry := &addrEntry{
+		Attempts:  8,
+		Successes: 4,
+		LastDecay: now.Add(-2*addrDecayInterval - time.Minute),
+	}
+
+	entry.decay(now)
+	if entry.Attempts != 2 || entry.Successes != 1 {
+		t.Errorf("got %d/%d after two decay intervals, want 2/1", entry.Attempts, entry.Successes)
+	}
+}
@@ -0,0 +1,249 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/errors"
+)
+
+const (
+	banStoreKey  = "BanStore"
+	whitelistKey = "BanWhitelist"
+)
+
+// pre-define errors for ban store operations
+var (
+	ErrInvalidBanAddress = errors.New("invalid ip address or subnet")
+	ErrBanWhitelisted    = errors.New("address is whitelisted")
+)
+
+// BanRecord is one banned IP or subnet together with its expiry
+type BanRecord struct {
+	CIDR   string    `json:"cidr"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// banStore keeps the banned subnets and the whitelist that is exempt from
+// them, persisted in the switch database across restarts
+type banStore struct {
+	mtx       sync.Mutex
+	db        dbm.DB
+	banned    map[string]*BanRecord
+	whitelist map[string]bool
+}
+
+func newBanStore(db dbm.DB) *banStore {
+	s := &banStore{
+		db:        db,
+		banned:    map[string]*BanRecord{},
+		whitelist: map[string]bool{},
+	}
+
+	if datajson := db.Get([]byte(banStoreKey)); datajson != nil {
+		json.Unmarshal(datajson, &s.banned)
+	}
+	if datajson := db.Get([]byte(whitelistKey)); datajson != nil {
+		json.Unmarshal(datajson, &s.whitelist)
+	}
+
+	// migrate the flat ip -> expiry map written by older versions
+	if datajson := db.Get([]byte(bannedPeerKey)); datajson != nil {
+		oldBans := map[string]time.Time{}
+		if err := json.Unmarshal(datajson, &oldBans); err == nil {
+			for ip, until := range oldBans {
+				if cidr, _, err := normalizeCIDR(ip); err == nil {
+					s.banned[cidr] = &BanRecord{CIDR: cidr, Until: until}
+				}
+			}
+			s.save()
+		}
+		db.Delete([]byte(bannedPeerKey))
+	}
+	return s
+}
+
+// normalizeCIDR canonicalizes an IP or subnet string, turning a bare IP into
+// a single host subnet
+func normalizeCIDR(s string) (string, *net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return "", nil, ErrInvalidBanAddress
+		}
+
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		s = fmt.Sprintf("%s/%d", ip.String(), bits)
+	}
+
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return "", nil, ErrInvalidBanAddress
+	}
+	return ipNet.String(), ipNet, nil
+}
+
+func (s *banStore) addWhitelist(cidr string) (string, error) {
+	cidr, _, err := normalizeCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.whitelist[cidr] = true
+	s.save()
+	return cidr, nil
+}
+
+func (s *banStore) ban(cidr string, duration time.Duration, reason string) (*BanRecord, error) {
+	cidr, ipNet, err := normalizeCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.matchWhitelist(ipNet.IP) {
+		return nil, ErrBanWhitelisted
+	}
+
+	record := &BanRecord{CIDR: cidr, Until: time.Now().Add(duration), Reason: reason}
+	s.banned[cidr] = record
+	s.save()
+	return record, nil
+}
+
+func (s *banStore) bannedList() []*BanRecord {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.pruneExpired()
+	records := []*BanRecord{}
+	for _, record := range s.banned {
+		records = append(records, record)
+	}
+	return records
+}
+
+func (s *banStore) isBanned(ip string) bool {
+	netIP := net.ParseIP(ip)
+	if netIP == nil {
+		return false
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.matchWhitelist(netIP) {
+		return false
+	}
+
+	s.pruneExpired()
+	for cidr := range s.banned {
+		if _, ipNet, err := normalizeCIDR(cidr); err == nil && ipNet.Contains(netIP) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *banStore) isWhitelisted(ip string) bool {
+	netIP := net.ParseIP(ip)
+	if netIP == nil {
+		return false
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.matchWhitelist(netIP)
+}
+
+// matchWhitelist must be called with the store lock held
+func (s *banStore) matchWhitelist(ip net.IP) bool {
+	for cidr := range s.whitelist {
+		if _, ipNet, err := normalizeCIDR(cidr); err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneExpired must be called with the store lock held
+func (s *banStore) pruneExpired() {
+	now := time.Now()
+	dirty := false
+	for cidr, record := range s.banned {
+		if now.After(record.Until) {
+			delete(s.banned, cidr)
+			dirty = true
+		}
+	}
+	if dirty {
+		s.save()
+	}
+}
+
+func (s *banStore) removeWhitelist(cidr string) bool {
+	cidr, _, err := normalizeCIDR(cidr)
+	if err != nil {
+		return false
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.whitelist[cidr] {
+		return false
+	}
+
+	delete(s.whitelist, cidr)
+	s.save()
+	return true
+}
+
+// save must be called with the store lock held
+func (s *banStore) save() {
+	if datajson, err := json.Marshal(s.banned); err == nil {
+		s.db.Set([]byte(banStoreKey), datajson)
+	}
+	if datajson, err := json.Marshal(s.whitelist); err == nil {
+		s.db.Set([]byte(whitelistKey), datajson)
+	}
+}
+
+func (s *banStore) unban(cidr string) bool {
+	cidr, _, err := normalizeCIDR(cidr)
+	if err != nil {
+		return false
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.banned[cidr]; !ok {
+		return false
+	}
+
+	delete(s.banned, cidr)
+	s.save()
+	return true
+}
+
+func (s *banStore) whitelistList() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	list := []string{}
+	for cidr := range s.whitelist {
+		list = append(list, cidr)
+	}
+	return list
+}
@@ -0,0 +1,122 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func TestBanStore(t *testing.T) {
+	db := dbm.NewMemDB()
+	store := newBanStore(db)
+
+	// a bare IP bans just that host
+	if _, err := store.ban("192.168.0.1", time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if !store.isBanned("192.168.0.1") {
+		t.Error("banned ip is not reported as banned")
+	}
+	if store.isBanned("192.168.0.2") {
+		t.Error("unrelated ip is reported as banned")
+	}
+
+	// a subnet ban covers every host inside it
+	if _, err := store.ban("10.0.0.0/24", time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if !store.isBanned("10.0.0.77") {
+		t.Error("ip inside the banned subnet is not reported as banned")
+	}
+	if store.isBanned("10.0.1.1") {
+		t.Error("ip outside the banned subnet is reported as banned")
+	}
+
+	// whitelisted addresses are exempt and can't be banned
+	if _, err := store.addWhitelist("10.0.0.77"); err != nil {
+		t.Fatal(err)
+	}
+	if store.isBanned("10.0.0.77") {
+		t.Error("whitelisted ip is reported as banned")
+	}
+	if _, err := store.ban("10.0.0.77", time.Hour, "test"); err != ErrBanWhitelisted {
+		t.Errorf("got err %v banning a whitelisted ip, want %v", err, ErrBanWhitelisted)
+	}
+	if !store.removeWhitelist("10.0.0.77") {
+		t.Error("fail to remove the whitelisted ip")
+	}
+	if !store.isBanned("10.0.0.77") {
+		t.Error("ip is not banned again after leaving the whitelist")
+	}
+
+	// expired records are pruned on lookup
+	if _, err := store.ban("172.16.0.1", -time.Second, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if store.isBanned("172.16.0.1") {
+		t.Error("expired ban is still effective")
+	}
+
+	if !store.unban("192.168.0.1") {
+		t.Error("fail to unban the banned ip")
+	}
+	if store.isBanned("192.168.0.1") {
+		t.Error("unbanned ip is still reported as banned")
+	}
+
+	// the records survive a reload from the same database
+	reloaded := newBanStore(db)
+	if !reloaded.isBanned("10.0.0.99") {
+		t.Error("subnet ban didn't survive the reload")
+	}
+	if len(reloaded.bannedList()) != 1 {
+		t.Errorf("got %d ban records after reload, want 1", len(reloaded.bannedList()))
+	}
+}
+
+func TestBanStoreMigration(t *testing.T) {
+	db := dbm.NewMemDB()
+	db.Set([]byte(bannedPeerKey), []byte(`{"192.168.0.1":"2999-01-01T00:00:00Z"}`))
+
+	store := newBanStore(db)
+	if !store.isBanned("192.168.0.1") {
+		t.Error("legacy ban entry wasn't migrated")
+	}
+	if db.Get([]byte(bannedPeerKey)) != nil {
+		t.Error("legacy ban key wasn't removed")
+	}
+}
+
+func TestBanStoreIPv6(t *testing.T) {
+	db := dbm.NewMemDB()
+	store := newBanStore(db)
+
+	// a bare IPv6 address bans just that host
+	if _, err := store.ban("2001:db8::1", time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if !store.isBanned("2001:db8::1") {
+		t.Error("banned ipv6 host is not reported as banned")
+	}
+	if store.isBanned("2001:db8::2") {
+		t.Error("unrelated ipv6 host is reported as banned")
+	}
+
+	// an IPv6 subnet ban covers every host inside it
+	if _, err := store.ban("2001:db8:1::/48", time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if !store.isBanned("2001:db8:1::5") {
+		t.Error("ipv6 host inside the banned subnet is not reported as banned")
+	}
+	if store.isBanned("2001:db8:2::5") {
+		t.Error("ipv6 host outside the banned subnet is reported as banned")
+	}
+
+	// the entries survive a reload from the same database
+	reloaded := newBanStore(db)
+	if !reloaded.isBanned("2001:db8::1") || !reloaded.isBanned("2001:db8:1::5") {
+		t.Error("ipv6 bans were not persisted")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"math"
 	"net"
 	"runtime/debug"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -36,6 +38,9 @@ const (
 	defaultRecvMessageCapacity = 22020096      // 21MB
 	defaultRecvRate            = int64(512000) // 500KB/s
 	defaultSendTimeout         = 10 * time.Second
+
+	// maxLatencySamples bounds the ping round trips kept for the median
+	maxLatencySamples = 11
 )
 
 type receiveCbFunc func(chID byte, msgBytes []byte)
@@ -93,6 +98,10 @@ type MConnection struct {
 	flushTimer   *cmn.ThrottleTimer // flush writes as necessary but throttled.
 	pingTimer    *time.Ticker       // send pings periodically
 	chStatsTimer *time.Ticker       // update channel stats periodically
+
+	latencyMtx     sync.Mutex
+	pingSentAt     time.Time       // when the outstanding ping left, zero when none
+	latencySamples []time.Duration // recent ping round trips, the median is the latency
 }
 
 // MConnConfig is a MConnection configuration.
@@ -247,8 +256,9 @@ func (c *MConnection) recvRoutine() {
 	defer close(c.pong)
 
 	for {
-		// Block until .recvMonitor says we can read.
+		// Block until .recvMonitor and the global bucket say we can read.
 		c.recvMonitor.Limit(maxMsgPacketTotalSize, atomic.LoadInt64(&c.config.RecvRate), true)
+		globalRecvLimit(maxMsgPacketTotalSize)
 
 		// Read packet type
 		var n int
@@ -275,6 +285,7 @@ func (c *MConnection) recvRoutine() {
 
 		case packetTypePong:
 			log.Debug("receive Pong")
+			c.recordPong(time.Now())
 
 		case packetTypeMsg:
 			pkt, n, err := msgPacket{}, int(0), error(nil)
@@ -312,6 +323,45 @@ func (c *MConnection) recvRoutine() {
 	}
 }
 
+// recordPing marks the departure of a ping, a pong closes the measurement
+func (c *MConnection) recordPing(now time.Time) {
+	c.latencyMtx.Lock()
+	defer c.latencyMtx.Unlock()
+	c.pingSentAt = now
+}
+
+// recordPong turns the outstanding ping into a latency sample
+func (c *MConnection) recordPong(now time.Time) {
+	c.latencyMtx.Lock()
+	defer c.latencyMtx.Unlock()
+
+	if c.pingSentAt.IsZero() {
+		return
+	}
+
+	c.latencySamples = append(c.latencySamples, now.Sub(c.pingSentAt))
+	if len(c.latencySamples) > maxLatencySamples {
+		c.latencySamples = c.latencySamples[1:]
+	}
+	c.pingSentAt = time.Time{}
+}
+
+// Latency returns the median of the recent ping round trips, zero when no
+// pong came back yet
+func (c *MConnection) Latency() time.Duration {
+	c.latencyMtx.Lock()
+	defer c.latencyMtx.Unlock()
+
+	if len(c.latencySamples) == 0 {
+		return 0
+	}
+
+	samples := make([]time.Duration, len(c.latencySamples))
+	copy(samples, c.latencySamples)
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)/2]
+}
+
 // Returns true if messages from channels were exhausted.
 func (c *MConnection) sendMsgPacket() bool {
 	var leastRatio float32 = math.MaxFloat32
@@ -356,6 +406,7 @@ func (c *MConnection) sendRoutine() {
 			}
 		case <-c.pingTimer.C:
 			log.Debug("send Ping")
+			c.recordPing(time.Now())
 			wire.WriteByte(packetTypePing, c.bufWriter, &n, &err)
 			c.sendMonitor.Update(int(n))
 			c.flush()
@@ -388,10 +439,11 @@ func (c *MConnection) sendRoutine() {
 
 // Returns true if messages from channels were exhausted.
 func (c *MConnection) sendSomeMsgPackets() bool {
-	// Block until .sendMonitor says we can write.
+	// Block until .sendMonitor and the global bucket say we can write.
 	// Once we're ready we send more than we asked for,
 	// but amortized it should even out.
 	c.sendMonitor.Limit(maxMsgPacketTotalSize, atomic.LoadInt64(&c.config.SendRate), true)
+	globalSendLimit(maxMsgPacketTotalSize)
 	for i := 0; i < numBatchMsgPackets; i++ {
 		if c.sendMsgPacket() {
 			return true
@@ -124,3 +124,25 @@ func TestMConnectionStopsAndReturnsError(t *testing.T) {
 		t.Fatal("Did not receive error in 500ms")
 	}
 }
+
+func TestMConnectionLatency(t *testing.T) {
+	assert := assert.New(t)
+
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	mconn := createMConnection(client)
+	assert.Equal(time.Duration(0), mconn.Latency())
+
+	// a pong without an outstanding ping is ignored
+	mconn.recordPong(time.Now())
+	assert.Equal(time.Duration(0), mconn.Latency())
+
+	now := time.Now()
+	for _, rtt := range []time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 20 * time.Millisecond} {
+		mconn.recordPing(now)
+		mconn.recordPong(now.Add(rtt))
+	}
+	assert.Equal(20*time.Millisecond, mconn.Latency())
+}
@@ -0,0 +1,79 @@
+package connection
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket is a blocking byte-rate limiter. Tokens refill at rate bytes
+// per second up to one second of burst, a take that overdraws sleeps until
+// the bucket catches up.
+type tokenBucket struct {
+	mtx      sync.Mutex
+	rate     int64 // bytes per second, 0 means unlimited
+	capacity int64
+	tokens   int64
+	lastFill time.Time
+}
+
+func newTokenBucket(rate int64) *tokenBucket {
+	return &tokenBucket{
+		rate:     rate,
+		capacity: rate,
+		tokens:   rate,
+		lastFill: time.Now(),
+	}
+}
+
+// take consumes n tokens, blocking until the bucket holds them
+func (b *tokenBucket) take(n int64) {
+	if b == nil || b.rate <= 0 {
+		return
+	}
+
+	b.mtx.Lock()
+	now := time.Now()
+	b.tokens += int64(now.Sub(b.lastFill).Seconds() * float64(b.rate))
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.lastFill = now
+	b.tokens -= n
+	missing := -b.tokens
+	b.mtx.Unlock()
+
+	if missing > 0 {
+		time.Sleep(time.Duration(missing) * time.Second / time.Duration(b.rate))
+	}
+}
+
+// the global buckets cap the bandwidth shared by every connection, on top
+// of the per-connection send/recv rates
+var (
+	globalMtx        sync.RWMutex
+	globalSendBucket *tokenBucket
+	globalRecvBucket *tokenBucket
+)
+
+// SetGlobalRateLimits caps the total send/receive bandwidth across all
+// connections in bytes per second, 0 leaves a direction unlimited
+func SetGlobalRateLimits(sendRate, recvRate int64) {
+	globalMtx.Lock()
+	defer globalMtx.Unlock()
+	globalSendBucket = newTokenBucket(sendRate)
+	globalRecvBucket = newTokenBucket(recvRate)
+}
+
+func globalSendLimit(n int) {
+	globalMtx.RLock()
+	bucket := globalSendBucket
+	globalMtx.RUnlock()
+	bucket.take(int64(n))
+}
+
+func globalRecvLimit(n int) {
+	globalMtx.RLock()
+	bucket := globalRecvBucket
+	globalMtx.RUnlock()
+	bucket.take(int64(n))
+}
@@ -9,7 +9,9 @@ import (
 	log "github.com/sirupsen/logrus"
 	cmn "github.com/tendermint/tmlibs/common"
 
+	cfg "github.com/bytom-gm/config"
 	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/p2p/natpmp"
 	"github.com/bytom-gm/p2p/upnp"
 )
 
@@ -17,6 +19,9 @@ const (
 	numBufferedConnections = 10
 	defaultExternalPort    = 8770
 	tryListenTimes         = 5
+	// natpmpMappingLifetime is the lease asked from the gateway in seconds,
+	// the mapping is renewed at half of it
+	natpmpMappingLifetime = 7200
 )
 
 //Listener subset of the methods of DefaultListener
@@ -54,25 +59,84 @@ func getUPNPExternalAddress(externalPort, internalPort int) (*NetAddress, error)
 	return NewNetAddressIPPort(ext, uint16(externalPort)), nil
 }
 
-func getNaiveExternalAddress(port int, settleForLocal bool) *NetAddress {
+//getNATPMPExternalAddress NAT-PMP external address discovery & port mapping
+func getNATPMPExternalAddress(gateway string, externalPort, internalPort int) (*NetAddress, error) {
+	gatewayIP := net.ParseIP(gateway)
+	if gatewayIP == nil {
+		return nil, errors.New("invalid NAT-PMP gateway address")
+	}
+
+	client := natpmp.NewClient(gatewayIP)
+	ext, err := client.GetExternalAddress()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not perform NAT-PMP external address")
+	}
+
+	if externalPort == 0 {
+		externalPort = defaultExternalPort
+	}
+	externalPort, err = client.AddPortMapping("tcp", externalPort, internalPort, natpmpMappingLifetime)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not add tcp NAT-PMP port mapping")
+	}
+	if _, err = client.AddPortMapping("udp", externalPort, internalPort, natpmpMappingLifetime); err != nil {
+		return nil, errors.Wrap(err, "could not add udp NAT-PMP port mapping")
+	}
+
+	go natpmpRenewRoutine(client, externalPort, internalPort)
+	return NewNetAddressIPPort(ext, uint16(externalPort)), nil
+}
+
+// natpmpRenewRoutine keeps the NAT-PMP lease alive, the gateway drops the
+// mapping when it expires
+func natpmpRenewRoutine(client *natpmp.Client, externalPort, internalPort int) {
+	ticker := time.NewTicker(natpmpMappingLifetime / 2 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := client.AddPortMapping("tcp", externalPort, internalPort, natpmpMappingLifetime); err != nil {
+			log.WithField("err", err).Warning("fail on renew tcp NAT-PMP port mapping")
+		}
+		if _, err := client.AddPortMapping("udp", externalPort, internalPort, natpmpMappingLifetime); err != nil {
+			log.WithField("err", err).Warning("fail on renew udp NAT-PMP port mapping")
+		}
+	}
+}
+
+func getNaiveExternalAddress(port int, settleForLocal bool, preferredFamily string) *NetAddress {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
 		cmn.PanicCrisis(cmn.Fmt("Could not fetch interface addresses: %v", err))
 	}
 
+	var v4Addr, v6Addr *NetAddress
 	for _, a := range addrs {
 		ipnet, ok := a.(*net.IPNet)
-		if !ok {
+		if !ok || (!settleForLocal && ipnet.IP.IsLoopback()) {
 			continue
 		}
-		if v4 := ipnet.IP.To4(); v4 == nil || (!settleForLocal && v4[0] == 127) {
-			continue
+		if ipnet.IP.To4() != nil {
+			if v4Addr == nil {
+				v4Addr = NewNetAddressIPPort(ipnet.IP, uint16(port))
+			}
+		} else if settleForLocal || ipnet.IP.IsGlobalUnicast() {
+			if v6Addr == nil {
+				v6Addr = NewNetAddressIPPort(ipnet.IP, uint16(port))
+			}
 		}
-		return NewNetAddressIPPort(ipnet.IP, uint16(port))
+	}
+
+	switch {
+	case preferredFamily == "ipv6" && v6Addr != nil:
+		return v6Addr
+	case v4Addr != nil:
+		return v4Addr
+	case v6Addr != nil:
+		return v6Addr
 	}
 
 	log.Info("Node may not be connected to internet. Settling for local address")
-	return getNaiveExternalAddress(port, true)
+	return getNaiveExternalAddress(port, true, preferredFamily)
 }
 
 func splitHostPort(addr string) (host string, port int) {
@@ -98,7 +162,7 @@ type DefaultListener struct {
 }
 
 //NewDefaultListener create a default listener
-func NewDefaultListener(protocol string, lAddr string, skipUPNP bool) (Listener, bool) {
+func NewDefaultListener(protocol string, lAddr string, config *cfg.P2PConfig) (Listener, bool) {
 	// Local listen IP & port
 	lAddrIP, lAddrPort := splitHostPort(lAddr)
 
@@ -122,10 +186,16 @@ func NewDefaultListener(protocol string, lAddr string, skipUPNP bool) (Listener,
 
 	// Determine external address...
 	var extAddr *NetAddress
-	var upnpMap bool
-	if !skipUPNP && (lAddrIP == "" || lAddrIP == "0.0.0.0") {
+	var portMapped bool
+	if config.NATPMPGateway != "" {
+		extAddr, err = getNATPMPExternalAddress(config.NATPMPGateway, lAddrPort, listenerPort)
+		portMapped = err == nil
+		log.WithField("err", err).Info("get NAT-PMP external address")
+	}
+
+	if extAddr == nil && !config.SkipUPNP && (lAddrIP == "" || lAddrIP == "0.0.0.0" || lAddrIP == "::") {
 		extAddr, err = getUPNPExternalAddress(lAddrPort, listenerPort)
-		upnpMap = err == nil
+		portMapped = err == nil
 		log.WithField("err", err).Info("get UPNP external address")
 	}
 
@@ -135,7 +205,7 @@ func NewDefaultListener(protocol string, lAddr string, skipUPNP bool) (Listener,
 		}
 	}
 	if extAddr == nil {
-		extAddr = getNaiveExternalAddress(listenerPort, false)
+		extAddr = getNaiveExternalAddress(listenerPort, false, config.PreferredFamily)
 	}
 	if extAddr == nil {
 		cmn.PanicCrisis("could not determine external address!")
@@ -149,7 +219,7 @@ func NewDefaultListener(protocol string, lAddr string, skipUPNP bool) (Listener,
 	}
 	dl.BaseService = *cmn.NewBaseService(nil, "DefaultListener", dl)
 	dl.Start() // Started upon construction
-	if upnpMap {
+	if portMapped {
 		return dl, true
 	}
 
@@ -5,11 +5,13 @@ package p2p
 import (
 	"bytes"
 	"testing"
+
+	cfg "github.com/bytom-gm/config"
 )
 
 func TestListener(t *testing.T) {
 	// Create a listener
-	l, _ := NewDefaultListener("tcp", ":8001", true)
+	l, _ := NewDefaultListener("tcp", ":8001", &cfg.P2PConfig{SkipUPNP: true})
 
 	// Dial the listener
 	lAddr := l.ExternalAddress()
@@ -0,0 +1,115 @@
+// Package natpmp implements the client side of the NAT Port Mapping
+// Protocol (RFC 6886), the lightweight alternative to UPnP spoken by many
+// home routers.
+package natpmp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/bytom-gm/errors"
+)
+
+const (
+	natpmpPort = 5351
+	version    = 0
+
+	opExternalAddress = 0
+	opMapUDP          = 1
+	opMapTCP          = 2
+
+	responseBit  = 128
+	retryTimes   = 3
+	retryTimeout = 250 * time.Millisecond
+)
+
+// pre-define errors for NAT-PMP negotiation fail
+var (
+	ErrNoResponse      = errors.New("no response from NAT-PMP gateway")
+	ErrBadResponse     = errors.New("malformed response from NAT-PMP gateway")
+	ErrUnknownProtocol = errors.New("unknown mapping protocol")
+)
+
+// Client talks NAT-PMP to a single gateway
+type Client struct {
+	gateway net.IP
+	port    int
+}
+
+// NewClient returns a client for the given gateway
+func NewClient(gateway net.IP) *Client {
+	return &Client{gateway: gateway, port: natpmpPort}
+}
+
+// GetExternalAddress asks the gateway for its public IP
+func (c *Client) GetExternalAddress() (net.IP, error) {
+	resp, err := c.rpc([]byte{version, opExternalAddress}, 12)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+// AddPortMapping maps an external port to the local port for the given
+// lifetime in seconds, returning the external port the gateway picked
+func (c *Client) AddPortMapping(protocol string, externalPort, internalPort, lifetime int) (int, error) {
+	var op byte
+	switch protocol {
+	case "tcp":
+		op = opMapTCP
+	case "udp":
+		op = opMapUDP
+	default:
+		return 0, ErrUnknownProtocol
+	}
+
+	req := make([]byte, 12)
+	req[0] = version
+	req[1] = op
+	binary.BigEndian.PutUint16(req[4:6], uint16(internalPort))
+	binary.BigEndian.PutUint16(req[6:8], uint16(externalPort))
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime))
+
+	resp, err := c.rpc(req, 16)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint16(resp[10:12])), nil
+}
+
+// rpc sends the request with the retransmissions the RFC asks for and
+// checks the response envelope
+func (c *Client) rpc(req []byte, respSize int) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: c.gateway, Port: c.port})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	timeout := retryTimeout
+	for i := 0; i < retryTimes; i++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		buf := make([]byte, 16)
+		n, err := conn.Read(buf)
+		if err != nil {
+			timeout *= 2
+			continue
+		}
+
+		resp := buf[:n]
+		if len(resp) < respSize || resp[0] != version || resp[1] != req[1]|responseBit {
+			return nil, ErrBadResponse
+		}
+		if result := binary.BigEndian.Uint16(resp[2:4]); result != 0 {
+			return nil, fmt.Errorf("NAT-PMP gateway refused the request with result code %d", result)
+		}
+		return resp, nil
+	}
+	return nil, ErrNoResponse
+}
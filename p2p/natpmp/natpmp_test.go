@@ -0,0 +1,109 @@
+package natpmp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// fakeGateway answers NAT-PMP requests on a loopback UDP socket
+func fakeGateway(t *testing.T) (*net.UDPConn, int) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestGetExternalAddress(t *testing.T) {
+	gw, port := fakeGateway(t)
+	defer gw.Close()
+
+	go func() {
+		buf := make([]byte, 16)
+		n, addr, err := gw.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if n != 2 || buf[0] != version || buf[1] != opExternalAddress {
+			t.Errorf("got request %x, want external address request", buf[:n])
+		}
+
+		resp := make([]byte, 12)
+		resp[1] = opExternalAddress | responseBit
+		copy(resp[8:12], net.IPv4(1, 2, 3, 4).To4())
+		gw.WriteToUDP(resp, addr)
+	}()
+
+	client := &Client{gateway: net.IPv4(127, 0, 0, 1), port: port}
+	ext, err := client.GetExternalAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ext.Equal(net.IPv4(1, 2, 3, 4)) {
+		t.Errorf("got external address %s, want 1.2.3.4", ext)
+	}
+}
+
+func TestAddPortMapping(t *testing.T) {
+	gw, port := fakeGateway(t)
+	defer gw.Close()
+
+	go func() {
+		buf := make([]byte, 16)
+		n, addr, err := gw.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if n != 12 || buf[1] != opMapTCP {
+			t.Errorf("got request %x, want tcp mapping request", buf[:n])
+		}
+		if internal := binary.BigEndian.Uint16(buf[4:6]); internal != 46656 {
+			t.Errorf("got internal port %d, want 46656", internal)
+		}
+
+		resp := make([]byte, 16)
+		resp[1] = opMapTCP | responseBit
+		copy(resp[8:10], buf[4:6])
+		// the gateway may pick a different external port
+		binary.BigEndian.PutUint16(resp[10:12], 48770)
+		gw.WriteToUDP(resp, addr)
+	}()
+
+	client := &Client{gateway: net.IPv4(127, 0, 0, 1), port: port}
+	mapped, err := client.AddPortMapping("tcp", 8770, 46656, 7200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapped != 48770 {
+		t.Errorf("got mapped port %d, want 48770", mapped)
+	}
+
+	if _, err := client.AddPortMapping("sctp", 8770, 46656, 7200); err != ErrUnknownProtocol {
+		t.Errorf("got err %v for unknown protocol, want %v", err, ErrUnknownProtocol)
+	}
+}
+
+func TestRefusedRequest(t *testing.T) {
+	gw, port := fakeGateway(t)
+	defer gw.Close()
+
+	go func() {
+		buf := make([]byte, 16)
+		_, addr, err := gw.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		resp := make([]byte, 16)
+		resp[1] = opMapUDP | responseBit
+		// result code 2: not authorized
+		binary.BigEndian.PutUint16(resp[2:4], 2)
+		gw.WriteToUDP(resp, addr)
+	}()
+
+	client := &Client{gateway: net.IPv4(127, 0, 0, 1), port: port}
+	if _, err := client.AddPortMapping("udp", 8770, 46656, 7200); err == nil {
+		t.Error("got nil err for refused request, want an error")
+	}
+}
@@ -38,6 +38,9 @@ func TestNewNetAddressString(t *testing.T) {
 		{"a:8080", false},
 		{"8082", false},
 		{"127.0.0:8080000", false},
+		{"[::1]:8080", true},
+		{"[2001:db8::1]:8080", true},
+		{"2001:db8::1:8080", false},
 	}
 
 	for _, t := range tests {
@@ -79,6 +82,9 @@ func TestNetAddressProperties(t *testing.T) {
 	}{
 		{"127.0.0.1:8080", true, true, false},
 		{"ya.ru:80", true, false, true},
+		{"[::1]:8080", true, true, false},
+		{"[fe80::1]:8080", true, false, false},
+		{"[2606:4700:4700::1111]:8080", true, false, true},
 	}
 
 	for _, t := range tests {
@@ -91,6 +97,20 @@ func TestNetAddressProperties(t *testing.T) {
 	}
 }
 
+// TestNetAddressIPv6RoundTrip covers the string round trip the persisted
+// address entries (anchor peers, configured seeds) rely on.
+func TestNetAddressIPv6RoundTrip(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	addr := NewNetAddressIPPort(net.ParseIP("2001:db8::68"), 46656)
+	assert.Equal("[2001:db8::68]:46656", addr.String())
+
+	parsed, err := NewNetAddressString(addr.String())
+	require.Nil(err)
+	assert.True(addr.Equals(parsed))
+	assert.Equal(addr.DialString(), parsed.DialString())
+}
+
 func TestNetAddressReachabilityTo(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
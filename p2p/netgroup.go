@@ -0,0 +1,98 @@
+package p2p
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	cfg "github.com/bytom-gm/config"
+)
+
+// netGroupKeeper maps peer IPs to a network group and enforces a cap of
+// outbound connections per group, which keeps a node from ending up peered
+// mostly within one hosting provider and improves eclipse resistance. The
+// group is the ASN when an IP-to-ASN mapping file is configured, otherwise it
+// falls back to the /16 prefix for IPv4 and the /32 prefix for IPv6.
+type netGroupKeeper struct {
+	mtx         sync.RWMutex
+	asnByNet    []asnEntry
+	maxPerGroup int
+}
+
+type asnEntry struct {
+	ipNet *net.IPNet
+	asn   string
+}
+
+func newNetGroupKeeper(config *cfg.P2PConfig) *netGroupKeeper {
+	keeper := &netGroupKeeper{maxPerGroup: config.MaxPeersPerGroup}
+	if config.ASNFile == "" {
+		return keeper
+	}
+
+	if err := keeper.loadASNFile(config.ASNFile); err != nil {
+		log.WithFields(log.Fields{"file": config.ASNFile, "err": err}).Error("load ASN mapping file")
+	}
+	return keeper
+}
+
+// loadASNFile parse a static GeoLite-style mapping file, the format is one
+// "CIDR ASN" pair per line, '#' starts a comment.
+func (k *netGroupKeeper) loadASNFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries := []asnEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(fields[0])
+		if err != nil {
+			log.WithFields(log.Fields{"cidr": fields[0], "err": err}).Warn("skip invalid ASN mapping entry")
+			continue
+		}
+		entries = append(entries, asnEntry{ipNet: ipNet, asn: fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	k.mtx.Lock()
+	k.asnByNet = entries
+	k.mtx.Unlock()
+	log.WithField("count", len(entries)).Info("loaded ASN mapping entries")
+	return nil
+}
+
+// GroupKey return the network group of the given IP.
+func (k *netGroupKeeper) GroupKey(ip net.IP) string {
+	k.mtx.RLock()
+	for _, entry := range k.asnByNet {
+		if entry.ipNet.Contains(ip) {
+			k.mtx.RUnlock()
+			return "asn:" + entry.asn
+		}
+	}
+	k.mtx.RUnlock()
+
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4.Mask(net.CIDRMask(16, 32)).String() + "/16"
+	}
+	return ip.Mask(net.CIDRMask(32, 128)).String() + "/32"
+}
@@ -21,6 +21,15 @@ type NodeInfo struct {
 	ListenAddr string               `json:"listen_addr"`
 	Version    string               `json:"version"` // major.minor.revision
 	Other      []string             `json:"other"`   // other application specific data
+
+	// ServiceFlags is the service bits advertised in the handshake, see
+	// the consensus.SF* constants. Legacy peers leave it zero and encode
+	// the bits as a string inside Other instead.
+	ServiceFlags uint64 `json:"service_flags"`
+
+	// Timestamp is the sender's unix time when the handshake was sent, it
+	// feeds the clock skew tracking. Legacy peers leave it zero.
+	Timestamp int64 `json:"timestamp"`
 }
 
 // CompatibleWith checks if two NodeInfo are compatible with eachother.
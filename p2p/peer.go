@@ -29,14 +29,30 @@ type PeerConfig struct {
 	HandshakeTimeout time.Duration           `mapstructure:"handshake_timeout"` // times are in seconds
 	DialTimeout      time.Duration           `mapstructure:"dial_timeout"`
 	MConfig          *connection.MConnConfig `mapstructure:"connection"`
+	ProxyAddress     string                  `mapstructure:"proxy_address"`
+	ProxyUsername    string                  `mapstructure:"proxy_username"`
+	ProxyPassword    string                  `mapstructure:"proxy_password"`
+	ProxyIsolation   bool                    `mapstructure:"proxy_isolation"`
 }
 
 // DefaultPeerConfig returns the default config.
 func DefaultPeerConfig(config *cfg.P2PConfig) *PeerConfig {
+	mConfig := connection.DefaultMConnConfig()
+	if config.SendRate > 0 {
+		mConfig.SendRate = config.SendRate
+	}
+	if config.RecvRate > 0 {
+		mConfig.RecvRate = config.RecvRate
+	}
+
 	return &PeerConfig{
 		HandshakeTimeout: time.Duration(config.HandshakeTimeout) * time.Second, // * time.Second,
 		DialTimeout:      time.Duration(config.DialTimeout) * time.Second,      // * time.Second,
-		MConfig:          connection.DefaultMConnConfig(),
+		MConfig:          mConfig,
+		ProxyAddress:     config.ProxyAddress,
+		ProxyUsername:    config.ProxyUsername,
+		ProxyPassword:    config.ProxyPassword,
+		ProxyIsolation:   config.ProxyIsolation,
 	}
 }
 
@@ -47,6 +63,11 @@ type Peer struct {
 	*peerConn
 	mconn *connection.MConnection // multiplex connection
 	Key   string
+
+	connectedTime    time.Time
+	handshakeLatency time.Duration
+	clockSkew        time.Duration // peer clock minus ours, zero when unknown
+	clockSkewKnown   bool
 }
 
 // OnStart implements BaseService.
@@ -65,9 +86,10 @@ func (p *Peer) OnStop() {
 func newPeer(pc *peerConn, nodeInfo *NodeInfo, reactorsByCh map[byte]Reactor, chDescs []*connection.ChannelDescriptor, onPeerError func(*Peer, interface{})) *Peer {
 	// Key and NodeInfo are set after Handshake
 	p := &Peer{
-		peerConn: pc,
-		NodeInfo: nodeInfo,
-		Key:      nodeInfo.PubKey.KeyString(),
+		peerConn:      pc,
+		NodeInfo:      nodeInfo,
+		Key:           nodeInfo.PubKey.KeyString(),
+		connectedTime: time.Now(),
 	}
 	p.mconn = createMConnection(pc.conn, p, reactorsByCh, chDescs, onPeerError, pc.config.MConfig)
 	p.BaseService = *cmn.NewBaseService(nil, "Peer", p)
@@ -188,6 +210,11 @@ func (p *Peer) Send(chID byte, msg interface{}) bool {
 }
 
 func (p *Peer) ServiceFlag() consensus.ServiceFlag {
+	if p.ServiceFlags != 0 {
+		return consensus.ServiceFlag(p.ServiceFlags)
+	}
+
+	// legacy peers advertise the bits as a string inside Other
 	services := consensus.SFFullNode
 	if len(p.Other) == 0 {
 		return services
@@ -199,6 +226,17 @@ func (p *Peer) ServiceFlag() consensus.ServiceFlag {
 	return services
 }
 
+// Latency returns the median of the recent ping round trips to the peer
+func (p *Peer) Latency() time.Duration {
+	return p.mconn.Latency()
+}
+
+// ClockSkew returns the peer clock offset measured at handshake, the second
+// return reports whether the peer advertised its time at all
+func (p *Peer) ClockSkew() (time.Duration, bool) {
+	return p.clockSkew, p.clockSkewKnown
+}
+
 // String representation.
 func (p *Peer) String() string {
 	if p.outbound {
@@ -232,6 +270,10 @@ func createMConnection(conn net.Conn, p *Peer, reactorsByCh map[byte]Reactor, ch
 }
 
 func dial(addr *NetAddress, config *PeerConfig) (net.Conn, error) {
+	if config.ProxyAddress != "" {
+		return dialThroughProxy(addr, config)
+	}
+
 	conn, err := addr.DialTimeout(config.DialTimeout)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,29 @@
+package p2p
+
+import (
+	"net"
+
+	"golang.org/x/net/proxy"
+)
+
+// proxyAuth picks the SOCKS5 credentials for dialing the given address. With
+// stream isolation every peer gets credentials derived from its address,
+// which a Tor daemon maps to separate circuits.
+func proxyAuth(addr *NetAddress, config *PeerConfig) *proxy.Auth {
+	if config.ProxyIsolation {
+		return &proxy.Auth{User: "bytom-" + addr.DialString(), Password: "0"}
+	}
+	if config.ProxyUsername != "" {
+		return &proxy.Auth{User: config.ProxyUsername, Password: config.ProxyPassword}
+	}
+	return nil
+}
+
+// dialThroughProxy connects to the address over the configured SOCKS5 proxy
+func dialThroughProxy(addr *NetAddress, config *PeerConfig) (net.Conn, error) {
+	dialer, err := proxy.SOCKS5("tcp", config.ProxyAddress, proxyAuth(addr, config), &net.Dialer{Timeout: config.DialTimeout})
+	if err != nil {
+		return nil, err
+	}
+	return dialer.Dial("tcp", addr.DialString())
+}
@@ -0,0 +1,35 @@
+package p2p
+
+import (
+	"net"
+	"testing"
+)
+
+func TestProxyAuth(t *testing.T) {
+	addrA := NewNetAddressIPPort(net.ParseIP("10.0.0.1"), 46656)
+	addrB := NewNetAddressIPPort(net.ParseIP("10.0.0.2"), 46656)
+
+	// no credentials configured means anonymous proxy access
+	config := &PeerConfig{ProxyAddress: "127.0.0.1:9050"}
+	if auth := proxyAuth(addrA, config); auth != nil {
+		t.Errorf("got auth %v without configured credentials, want nil", auth)
+	}
+
+	// static credentials are passed through
+	config.ProxyUsername = "user"
+	config.ProxyPassword = "pass"
+	auth := proxyAuth(addrA, config)
+	if auth == nil || auth.User != "user" || auth.Password != "pass" {
+		t.Errorf("got auth %v, want the configured credentials", auth)
+	}
+
+	// stream isolation derives distinct credentials per peer
+	config.ProxyIsolation = true
+	authA, authB := proxyAuth(addrA, config), proxyAuth(addrB, config)
+	if authA == nil || authB == nil || authA.User == authB.User {
+		t.Errorf("got same isolation credentials %v and %v for different peers", authA, authB)
+	}
+	if authA.User != proxyAuth(addrA, config).User {
+		t.Error("isolation credentials are not stable for the same peer")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
@@ -23,8 +24,15 @@ import (
 
 const (
 	bannedPeerKey       = "BannedPeer"
+	anchorPeersKey      = "AnchorPeers"
 	defaultBanDuration  = time.Hour * 1
 	minNumOutboundPeers = 5
+	numAnchorPeers      = 2
+
+	// the clock skew warning fires when enough peers report a median this
+	// far from the local clock, well inside consensus.MaxTimeOffsetSeconds
+	minClockSkewSamples    = 5
+	clockSkewWarnThreshold = 5 * time.Minute
 )
 
 //pre-define errors for connecting fail
@@ -33,6 +41,9 @@ var (
 	ErrConnectSelf       = errors.New("Connect self")
 	ErrConnectBannedPeer = errors.New("Connect banned peer")
 	ErrConnectSpvPeer    = errors.New("Outbound connect spv peer")
+	ErrPeerGroupLimit    = errors.New("Outbound connection limit for network group reached")
+	ErrMaxOutboundPeers  = errors.New("Outbound connection slots are full")
+	ErrInboundEviction   = errors.New("Evicted to free an inbound slot")
 )
 
 // Switch handles peer connections and exposes an API to receive incoming messages
@@ -53,7 +64,9 @@ type Switch struct {
 	nodeInfo     *NodeInfo             // our node info
 	nodePrivKey  crypto.PrivKeyEd25519 // our node privkey
 	discv        *discover.Network
-	bannedPeer   map[string]time.Time
+	netGroups    *netGroupKeeper
+	banStore     *banStore
+	addrBook     *addrBook
 	db           dbm.DB
 	mtx          sync.Mutex
 }
@@ -69,15 +82,13 @@ func NewSwitch(config *cfg.Config) *Switch {
 		peers:        NewPeerSet(),
 		dialing:      cmn.NewCMap(),
 		nodeInfo:     nil,
+		netGroups:    newNetGroupKeeper(config.P2P),
 		db:           dbm.NewDB("trusthistory", config.DBBackend, config.DBDir()),
 	}
 	sw.BaseService = *cmn.NewBaseService(nil, "P2P Switch", sw)
-	sw.bannedPeer = make(map[string]time.Time)
-	if datajson := sw.db.Get([]byte(bannedPeerKey)); datajson != nil {
-		if err := json.Unmarshal(datajson, &sw.bannedPeer); err != nil {
-			return nil
-		}
-	}
+	sw.banStore = newBanStore(sw.db)
+	sw.addrBook = newAddrBook(sw.db, sw.netGroups)
+	connection.SetGlobalRateLimits(config.P2P.GlobalSendRate, config.P2P.GlobalRecvRate)
 	trust.Init()
 	return sw
 }
@@ -115,17 +126,66 @@ func (sw *Switch) OnStop() {
 
 //AddBannedPeer add peer to blacklist
 func (sw *Switch) AddBannedPeer(ip string) error {
-	sw.mtx.Lock()
-	defer sw.mtx.Unlock()
+	if sw.banStore.isWhitelisted(ip) {
+		log.WithFields(log.Fields{"ip": ip}).Debug("skip banning whitelisted peer")
+		return nil
+	}
+
+	_, err := sw.banStore.ban(ip, defaultBanDuration, "misbehavior")
+	return err
+}
+
+// BanPeer bans an IP or subnet for the given duration and disconnects every
+// connected peer inside it
+func (sw *Switch) BanPeer(cidr string, duration time.Duration, reason string) (*BanRecord, error) {
+	if duration <= 0 {
+		duration = defaultBanDuration
+	}
 
-	sw.bannedPeer[ip] = time.Now().Add(defaultBanDuration)
-	datajson, err := json.Marshal(sw.bannedPeer)
+	record, err := sw.banStore.ban(cidr, duration, reason)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	sw.db.Set([]byte(bannedPeerKey), datajson)
-	return nil
+	_, ipNet, _ := normalizeCIDR(record.CIDR)
+	for _, peer := range sw.peers.List() {
+		if ip := net.ParseIP(peer.RemoteAddrHost()); ip != nil && ipNet.Contains(ip) {
+			sw.StopPeerForError(peer, ErrConnectBannedPeer)
+		}
+	}
+	return record, nil
+}
+
+// UnbanPeer lifts the ban of an IP or subnet, reporting whether there was one
+func (sw *Switch) UnbanPeer(cidr string) bool {
+	return sw.banStore.unban(cidr)
+}
+
+// BannedPeers returns the active ban records
+func (sw *Switch) BannedPeers() []*BanRecord {
+	return sw.banStore.bannedList()
+}
+
+// AddWhitelist exempts an IP or subnet from bans, returning the normalized
+// subnet that was stored
+func (sw *Switch) AddWhitelist(cidr string) (string, error) {
+	return sw.banStore.addWhitelist(cidr)
+}
+
+// RemoveWhitelist drops an IP or subnet from the whitelist, reporting whether
+// it was present
+func (sw *Switch) RemoveWhitelist(cidr string) bool {
+	return sw.banStore.removeWhitelist(cidr)
+}
+
+// Whitelist returns the subnets exempt from bans
+func (sw *Switch) Whitelist() []string {
+	return sw.banStore.whitelistList()
+}
+
+// IsWhitelisted reports whether an IP belongs to a whitelisted subnet
+func (sw *Switch) IsWhitelisted(ip string) bool {
+	return sw.banStore.isWhitelisted(ip)
 }
 
 // AddPeer performs the P2P handshake with a peer
@@ -134,10 +194,15 @@ func (sw *Switch) AddBannedPeer(ip string) error {
 // NOTE: This performs a blocking handshake before the peer is added.
 // CONTRACT: If error is returned, peer is nil, and conn is immediately closed.
 func (sw *Switch) AddPeer(pc *peerConn) error {
-	peerNodeInfo, err := pc.HandshakeTimeout(sw.nodeInfo, time.Duration(sw.peerConfig.HandshakeTimeout))
+	ourNodeInfo := *sw.nodeInfo
+	ourNodeInfo.Timestamp = time.Now().Unix()
+
+	handshakeStart := time.Now()
+	peerNodeInfo, err := pc.HandshakeTimeout(&ourNodeInfo, time.Duration(sw.peerConfig.HandshakeTimeout))
 	if err != nil {
 		return err
 	}
+	handshakeLatency := time.Since(handshakeStart)
 
 	if err := version.Status.CheckUpdate(sw.nodeInfo.Version, peerNodeInfo.Version, peerNodeInfo.RemoteAddr); err != nil {
 		return err
@@ -147,6 +212,11 @@ func (sw *Switch) AddPeer(pc *peerConn) error {
 	}
 
 	peer := newPeer(pc, peerNodeInfo, sw.reactorsByCh, sw.chDescs, sw.StopPeerForError)
+	peer.handshakeLatency = handshakeLatency
+	if peerNodeInfo.Timestamp != 0 {
+		peer.clockSkew = time.Unix(peerNodeInfo.Timestamp, 0).Sub(time.Now())
+		peer.clockSkewKnown = true
+	}
 	if err := sw.filterConnByPeer(peer); err != nil {
 		return err
 	}
@@ -161,7 +231,45 @@ func (sw *Switch) AddPeer(pc *peerConn) error {
 			return err
 		}
 	}
-	return sw.peers.Add(peer)
+
+	if err := sw.peers.Add(peer); err != nil {
+		return err
+	}
+	sw.checkClockSkew()
+	return nil
+}
+
+// NetworkClockSkew returns the median of the peer clock offsets together
+// with the number of peers that reported their time
+func (sw *Switch) NetworkClockSkew() (time.Duration, int) {
+	skews := []time.Duration{}
+	for _, peer := range sw.peers.List() {
+		if skew, ok := peer.ClockSkew(); ok {
+			skews = append(skews, skew)
+		}
+	}
+	if len(skews) == 0 {
+		return 0, 0
+	}
+
+	sort.Slice(skews, func(i, j int) bool { return skews[i] < skews[j] })
+	return skews[len(skews)/2], len(skews)
+}
+
+// checkClockSkew warns when the local clock deviates from the network
+// median, a skewed clock breaks block timestamp validation
+func (sw *Switch) checkClockSkew() {
+	median, samples := sw.NetworkClockSkew()
+	if samples < minClockSkewSamples {
+		return
+	}
+
+	if median > clockSkewWarnThreshold || median < -clockSkewWarnThreshold {
+		log.WithFields(log.Fields{
+			"median_skew": median.String(),
+			"samples":     samples,
+		}).Warning("local clock deviates from the network median, please check the system time")
+	}
 }
 
 // AddReactor adds the given reactor to the switch.
@@ -196,7 +304,14 @@ func (sw *Switch) DialPeerWithAddress(addr *NetAddress) error {
 	if err := sw.filterConnByIP(addr.IP.String()); err != nil {
 		return err
 	}
+	if err := sw.checkOutboundLimit(); err != nil {
+		return err
+	}
+	if err := sw.checkOutboundGroupLimit(addr.IP); err != nil {
+		return err
+	}
 
+	sw.addrBook.markAttempt(addr.String())
 	pc, err := newOutboundPeerConn(addr, sw.nodePrivKey, sw.peerConfig)
 	if err != nil {
 		log.WithFields(log.Fields{"address": addr, " err": err}).Debug("DialPeer fail on newOutboundPeerConn")
@@ -208,6 +323,7 @@ func (sw *Switch) DialPeerWithAddress(addr *NetAddress) error {
 		pc.CloseConn()
 		return err
 	}
+	sw.addrBook.markSuccess(addr.String())
 	log.Debug("DialPeer added peer:", addr)
 	return nil
 }
@@ -297,37 +413,55 @@ func (sw *Switch) addPeerWithConnection(conn net.Conn) error {
 }
 
 func (sw *Switch) checkBannedPeer(peer string) error {
-	sw.mtx.Lock()
-	defer sw.mtx.Unlock()
-
-	if banEnd, ok := sw.bannedPeer[peer]; ok {
-		if time.Now().Before(banEnd) {
-			return ErrConnectBannedPeer
-		}
-		sw.delBannedPeer(peer)
+	if sw.banStore.isBanned(peer) {
+		return ErrConnectBannedPeer
 	}
 	return nil
 }
 
-func (sw *Switch) delBannedPeer(addr string) error {
-	sw.mtx.Lock()
-	defer sw.mtx.Unlock()
+func (sw *Switch) filterConnByIP(ip string) error {
+	if ip == sw.nodeInfo.ListenHost() {
+		return ErrConnectSelf
+	}
+	return sw.checkBannedPeer(ip)
+}
 
-	delete(sw.bannedPeer, addr)
-	datajson, err := json.Marshal(sw.bannedPeer)
-	if err != nil {
-		return err
+// checkOutboundLimit refuse an outbound dial when every outbound slot is
+// taken already
+func (sw *Switch) checkOutboundLimit() error {
+	if sw.Config.P2P.MaxNumOutboundPeers <= 0 {
+		return nil
 	}
 
-	sw.db.Set([]byte(bannedPeerKey), datajson)
+	if numOutbound, _, _ := sw.NumPeers(); numOutbound >= sw.Config.P2P.MaxNumOutboundPeers {
+		return ErrMaxOutboundPeers
+	}
 	return nil
 }
 
-func (sw *Switch) filterConnByIP(ip string) error {
-	if ip == sw.nodeInfo.ListenHost() {
-		return ErrConnectSelf
+// checkOutboundGroupLimit refuse an outbound dial when we already have the
+// configured maximum of outbound peers inside the target's network group.
+func (sw *Switch) checkOutboundGroupLimit(ip net.IP) error {
+	if sw.netGroups.maxPerGroup <= 0 {
+		return nil
 	}
-	return sw.checkBannedPeer(ip)
+
+	group := sw.netGroups.GroupKey(ip)
+	count := 0
+	for _, peer := range sw.peers.List() {
+		if !peer.outbound {
+			continue
+		}
+		peerIP := net.ParseIP(peer.RemoteAddrHost())
+		if peerIP != nil && sw.netGroups.GroupKey(peerIP) == group {
+			count++
+		}
+	}
+
+	if count >= sw.netGroups.maxPerGroup {
+		return ErrPeerGroupLimit
+	}
+	return nil
 }
 
 func (sw *Switch) filterConnByPeer(peer *Peer) error {
@@ -359,6 +493,16 @@ func (sw *Switch) listenerRoutine(l Listener) {
 			continue
 		}
 
+		// a full inbound table evicts the most disposable inbound peer
+		// before giving up the connection
+		if _, numInbound, _ := sw.NumPeers(); numInbound >= sw.Config.P2P.MaxNumInboundPeers && sw.Config.P2P.MaxNumInboundPeers > 0 {
+			if !sw.evictInboundPeer() {
+				inConn.Close()
+				log.Info("Ignoring inbound connection: inbound slots are full.")
+				continue
+			}
+		}
+
 		// New inbound connection!
 		if err := sw.addPeerWithConnection(inConn); err != nil {
 			log.Info("Ignoring inbound connection: error while adding peer.", " address:", inConn.RemoteAddr().String(), " error:", err)
@@ -367,6 +511,51 @@ func (sw *Switch) listenerRoutine(l Listener) {
 	}
 }
 
+// evictInboundPeer frees an inbound slot by disconnecting the most
+// disposable inbound peer. The quarter with the lowest handshake latency and
+// the longest connected quarter are protected since they are the hardest for
+// an attacker to replace, from the remainder the youngest peer of the most
+// crowded network group goes.
+func (sw *Switch) evictInboundPeer() bool {
+	candidates := []*Peer{}
+	for _, peer := range sw.peers.List() {
+		if peer.outbound || sw.IsWhitelisted(peer.RemoteAddrHost()) {
+			continue
+		}
+		candidates = append(candidates, peer)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].handshakeLatency < candidates[j].handshakeLatency
+	})
+	candidates = candidates[len(candidates)/4:]
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].connectedTime.After(candidates[j].connectedTime)
+	})
+	candidates = candidates[:len(candidates)-len(candidates)/4]
+	if len(candidates) == 0 {
+		return false
+	}
+
+	groups := map[string][]*Peer{}
+	for _, peer := range candidates {
+		key := sw.netGroups.GroupKey(net.ParseIP(peer.RemoteAddrHost()))
+		groups[key] = append(groups[key], peer)
+	}
+
+	var crowded []*Peer
+	for _, group := range groups {
+		if len(group) > len(crowded) {
+			crowded = group
+		}
+	}
+
+	// candidates are sorted youngest first, so is every group
+	sw.StopPeerForError(crowded[0], ErrInboundEviction)
+	return true
+}
+
 func (sw *Switch) SetDiscv(discv *discover.Network) {
 	sw.discv = discv
 }
@@ -386,25 +575,28 @@ func (sw *Switch) ensureOutboundPeers() {
 		return
 	}
 
-	connectedPeers := make(map[string]struct{})
+	exclude := make(map[string]bool)
 	for _, peer := range sw.Peers().List() {
-		connectedPeers[peer.RemoteAddrHost()] = struct{}{}
+		exclude[peer.RemoteAddrHost()] = true
 	}
 
-	var wg sync.WaitGroup
-	nodes := make([]*discover.Node, numToDial)
+	// feed the freshly discovered nodes into the address book, it scores
+	// and diversifies them before they are dialed
+	nodes := make([]*discover.Node, numToDial*2)
 	n := sw.discv.ReadRandomNodes(nodes)
 	for i := 0; i < n; i++ {
 		try := NewNetAddressIPPort(nodes[i].IP, nodes[i].TCP)
 		if sw.NodeInfo().ListenAddr == try.String() {
 			continue
 		}
+		sw.addrBook.addAddress(try, nodes[i].IP)
+	}
+
+	var wg sync.WaitGroup
+	for _, try := range sw.addrBook.pickAddresses(numToDial, exclude) {
 		if dialling := sw.IsDialing(try); dialling {
 			continue
 		}
-		if _, ok := connectedPeers[try.IP.String()]; ok {
-			continue
-		}
 
 		wg.Add(1)
 		go sw.dialPeerWorker(try, &wg)
@@ -412,7 +604,68 @@ func (sw *Switch) ensureOutboundPeers() {
 	wg.Wait()
 }
 
+// saveAnchorPeers persists the addresses of our longest connected outbound
+// peers, so a restart reconnects them first instead of starting from a
+// blank slate an eclipse attacker could fill
+func (sw *Switch) saveAnchorPeers() {
+	outbound := []*Peer{}
+	for _, peer := range sw.peers.List() {
+		if peer.outbound {
+			outbound = append(outbound, peer)
+		}
+	}
+	if len(outbound) == 0 {
+		return
+	}
+
+	sort.Slice(outbound, func(i, j int) bool {
+		return outbound[i].connectedTime.Before(outbound[j].connectedTime)
+	})
+	if len(outbound) > numAnchorPeers {
+		outbound = outbound[:numAnchorPeers]
+	}
+
+	anchors := []string{}
+	for _, peer := range outbound {
+		anchors = append(anchors, peer.RemoteAddr)
+	}
+
+	bytes, err := json.Marshal(anchors)
+	if err != nil {
+		log.WithField("err", err).Warning("fail on marshal anchor peers")
+		return
+	}
+	sw.db.Set([]byte(anchorPeersKey), bytes)
+}
+
+// dialAnchorPeers re-establishes the anchor connections persisted by the
+// previous run
+func (sw *Switch) dialAnchorPeers() {
+	bytes := sw.db.Get([]byte(anchorPeersKey))
+	if bytes == nil {
+		return
+	}
+
+	anchors := []string{}
+	if err := json.Unmarshal(bytes, &anchors); err != nil {
+		log.WithField("err", err).Warning("fail on unmarshal anchor peers")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, anchor := range anchors {
+		addr, err := NewNetAddressString(anchor)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go sw.dialPeerWorker(addr, &wg)
+	}
+	wg.Wait()
+}
+
 func (sw *Switch) ensureOutboundPeersRoutine() {
+	sw.dialAnchorPeers()
 	sw.ensureOutboundPeers()
 
 	ticker := time.NewTicker(10 * time.Second)
@@ -421,6 +674,7 @@ func (sw *Switch) ensureOutboundPeersRoutine() {
 	for {
 		select {
 		case <-ticker.C:
+			sw.saveAnchorPeers()
 			sw.ensureOutboundPeers()
 		case <-sw.Quit:
 			return
@@ -1,6 +1,8 @@
 package protocol
 
 import (
+	"time"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/bytom-gm/errors"
@@ -93,6 +95,7 @@ func (c *Chain) connectBlock(block *types.Block) (err error) {
 	}
 
 	for _, tx := range block.Transactions {
+		c.txPool.ConfirmTransaction(&tx.Tx.ID, block.Height)
 		c.txPool.RemoveTransaction(&tx.Tx.ID)
 	}
 	return nil
@@ -214,9 +217,16 @@ func (c *Chain) ProcessBlock(block *types.Block) (bool, error) {
 }
 
 func (c *Chain) blockProcesser() {
-	for msg := range c.processBlockCh {
-		isOrphan, err := c.processBlock(msg.block)
-		msg.reply <- processBlockResponse{isOrphan: isOrphan, err: err}
+	for {
+		select {
+		case msg := <-c.processBlockCh:
+			start := time.Now()
+			isOrphan, err := c.processBlock(msg.block)
+			c.blockProcessTimes.Observe(time.Since(start).Seconds())
+			msg.reply <- processBlockResponse{isOrphan: isOrphan, err: err}
+		case msg := <-c.rollbackCh:
+			msg.reply <- c.rollback(msg.height)
+		}
 	}
 }
 
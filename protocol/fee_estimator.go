@@ -0,0 +1,121 @@
+package protocol
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/bytom-gm/protocol/bc"
+)
+
+const (
+	// maxConfirmTarget is the deepest confirmation target tracked by the estimator
+	maxConfirmTarget = 25
+	// maxSamplesPerBucket limit the samples kept per confirmation bucket
+	maxSamplesPerBucket = 100
+	// minFeeSamples is the minimum number of samples required for an estimate
+	minFeeSamples = 10
+)
+
+// ErrNoFeeSamples indicates the estimator hasn't observed enough confirmations yet
+var ErrNoFeeSamples = errors.New("not enough fee samples for estimate")
+
+type watchedTx struct {
+	feeRate     uint64
+	addedHeight uint64
+}
+
+// FeeEstimator tracks mempool acceptance and block confirmation by fee rate,
+// so the node can recommend fee rates from recent history instead of constants
+type FeeEstimator struct {
+	mtx     sync.RWMutex
+	watched map[bc.Hash]*watchedTx
+	buckets [maxConfirmTarget][]uint64
+}
+
+// NewFeeEstimator init a new FeeEstimator
+func NewFeeEstimator() *FeeEstimator {
+	return &FeeEstimator{watched: make(map[bc.Hash]*watchedTx)}
+}
+
+// AddTx record the fee rate of a transaction accepted by the mempool
+func (fe *FeeEstimator) AddTx(txD *TxDesc) {
+	if fe == nil || txD.Tx.SerializedSize == 0 {
+		return
+	}
+
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	fe.watched[txD.Tx.ID] = &watchedTx{
+		feeRate:     txD.Fee / txD.Tx.SerializedSize,
+		addedHeight: txD.Height,
+	}
+}
+
+// ConfirmTx record the confirmation of a watched transaction at the given height
+func (fe *FeeEstimator) ConfirmTx(txHash *bc.Hash, height uint64) {
+	if fe == nil {
+		return
+	}
+
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	watched, ok := fe.watched[*txHash]
+	if !ok {
+		return
+	}
+	delete(fe.watched, *txHash)
+
+	blocks := uint64(1)
+	if height > watched.addedHeight {
+		blocks = height - watched.addedHeight
+	}
+	if blocks > maxConfirmTarget {
+		blocks = maxConfirmTarget
+	}
+
+	bucket := append(fe.buckets[blocks-1], watched.feeRate)
+	if len(bucket) > maxSamplesPerBucket {
+		bucket = bucket[len(bucket)-maxSamplesPerBucket:]
+	}
+	fe.buckets[blocks-1] = bucket
+}
+
+// RemoveTx drop a watched transaction that left the mempool unconfirmed
+func (fe *FeeEstimator) RemoveTx(txHash *bc.Hash) {
+	if fe == nil {
+		return
+	}
+
+	fe.mtx.Lock()
+	defer fe.mtx.Unlock()
+
+	delete(fe.watched, *txHash)
+}
+
+// EstimateFeeRate return the median fee rate of transactions that confirmed
+// within targetBlocks blocks of entering the mempool
+func (fe *FeeEstimator) EstimateFeeRate(targetBlocks uint64) (uint64, error) {
+	if targetBlocks == 0 || targetBlocks > maxConfirmTarget {
+		return 0, errors.New("confirm target out of range")
+	}
+	if fe == nil {
+		return 0, ErrNoFeeSamples
+	}
+
+	fe.mtx.RLock()
+	defer fe.mtx.RUnlock()
+
+	samples := []uint64{}
+	for i := uint64(0); i < targetBlocks; i++ {
+		samples = append(samples, fe.buckets[i]...)
+	}
+	if len(samples) < minFeeSamples {
+		return 0, ErrNoFeeSamples
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)/2], nil
+}
@@ -0,0 +1,39 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestFeeEstimator(t *testing.T) {
+	fe := NewFeeEstimator()
+	if _, err := fe.EstimateFeeRate(1); err != ErrNoFeeSamples {
+		t.Errorf("got error %v, want %v", err, ErrNoFeeSamples)
+	}
+
+	for i := 0; i < minFeeSamples; i++ {
+		tx := types.NewTx(types.TxData{
+			SerializedSize: 100,
+			Inputs:         []*types.TxInput{types.NewSpendInput(nil, bc.NewHash([32]byte{byte(i)}), bc.AssetID{V0: 1}, 1, uint64(i), nil)},
+		})
+		fe.AddTx(&TxDesc{Tx: tx, Fee: uint64(100000 + i*100), Height: 10})
+		fe.ConfirmTx(&tx.ID, 11)
+	}
+
+	feeRate, err := fe.EstimateFeeRate(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feeRate < 1000 || feeRate > 1010 {
+		t.Errorf("got fee rate %d, want median near 1005", feeRate)
+	}
+
+	if _, err := fe.EstimateFeeRate(0); err == nil {
+		t.Error("expect error on zero confirm target")
+	}
+	if _, err := fe.EstimateFeeRate(maxConfirmTarget + 1); err == nil {
+		t.Error("expect error on out of range confirm target")
+	}
+}
@@ -0,0 +1,126 @@
+package protocol
+
+import (
+	"bufio"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/state"
+	"github.com/bytom-gm/protocol/validation"
+)
+
+// pre-define errors for header bootstrap
+var (
+	// ErrBadHeadersFile means the headers file can't be decoded
+	ErrBadHeadersFile = errors.New("invalid bootstrap headers file")
+	// ErrDisjointHeader means a bootstrap header doesn't link to the known chain
+	ErrDisjointHeader = errors.New("bootstrap header doesn't connect to the chain")
+)
+
+// bootstrapHeaders holds a pre-validated header chain loaded out-of-band, it
+// lets initial sync know the expected header at every height before the block
+// bodies have been downloaded.
+type bootstrapHeaders struct {
+	mtx     sync.RWMutex
+	headers map[uint64]*types.BlockHeader
+	height  uint64
+}
+
+func newBootstrapHeaders() *bootstrapHeaders {
+	return &bootstrapHeaders{headers: make(map[uint64]*types.BlockHeader)}
+}
+
+// LoadHeadersFile decode a pre-packaged headers file, the format is one
+// hex-encoded serialized block header per line.
+func LoadHeadersFile(path string) ([]*types.BlockHeader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	headers := []*types.BlockHeader{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		header := &types.BlockHeader{}
+		if err := header.UnmarshalText(line); err != nil {
+			return nil, errors.Sub(ErrBadHeadersFile, err)
+		}
+		headers = append(headers, header)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// ImportHeaders validates a chain of pre-packaged block headers against the
+// consensus header rules and remembers them, so a fresh node can verify
+// incoming blocks by height without downloading every header from peers
+// first. The first header must connect to a block already in the index.
+func (c *Chain) ImportHeaders(headers []*types.BlockHeader) error {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	parent := c.index.GetNode(&headers[0].PreviousBlockHash)
+	if parent == nil {
+		return ErrDisjointHeader
+	}
+
+	imported := 0
+	for _, header := range headers {
+		headerHash := header.Hash()
+		if parent.Hash != header.PreviousBlockHash {
+			return errors.Wrapf(ErrDisjointHeader, "header %s doesn't link to previous", headerHash.String())
+		}
+
+		bcBlock := types.MapBlock(&types.Block{BlockHeader: *header})
+		if err := validation.ValidateBlockHeader(bcBlock, parent); err != nil {
+			return err
+		}
+
+		node, err := state.NewBlockNode(header, parent)
+		if err != nil {
+			return err
+		}
+
+		c.bootstrap.mtx.Lock()
+		c.bootstrap.headers[header.Height] = header
+		if header.Height > c.bootstrap.height {
+			c.bootstrap.height = header.Height
+		}
+		c.bootstrap.mtx.Unlock()
+
+		parent = node
+		imported++
+	}
+
+	log.WithFields(log.Fields{"count": imported, "height": c.BootstrapHeight()}).Info("imported bootstrap headers")
+	return nil
+}
+
+// GetBootstrapHeader return the imported header of the given height, nil when
+// the height is beyond the imported chain.
+func (c *Chain) GetBootstrapHeader(height uint64) *types.BlockHeader {
+	c.bootstrap.mtx.RLock()
+	defer c.bootstrap.mtx.RUnlock()
+	return c.bootstrap.headers[height]
+}
+
+// BootstrapHeight return the height of the highest imported bootstrap header.
+func (c *Chain) BootstrapHeight() uint64 {
+	c.bootstrap.mtx.RLock()
+	defer c.bootstrap.mtx.RUnlock()
+	return c.bootstrap.height
+}
@@ -0,0 +1,113 @@
+// Package policy implements the node-local standardness rules applied to
+// transactions before they enter the mempool or get relayed. Unlike the rules
+// in protocol/validation, these are not consensus rules: every node may tune
+// them independently without risking a chain split.
+package policy
+
+import (
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/consensus/segwit"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// pre-define errors for supporting bytom errorFormatter
+var (
+	// ErrNonStandardProgram means the control program template is not accepted by the node policy
+	ErrNonStandardProgram = errors.New("control program is not standard")
+	// ErrDustOutput means an output carries less BTM than the configured dust threshold
+	ErrDustOutput = errors.New("transaction output is below the dust threshold")
+	// ErrExceedWitnessLimit means an input carries more witness items than the node policy allows
+	ErrExceedWitnessLimit = errors.New("transaction input exceeds the witness item limit")
+)
+
+// Policy holds the standardness parameters of a node, the zero value of a
+// field disables the corresponding check.
+type Policy struct {
+	// DustThreshold is the minimum amount of BTM a standard output may carry
+	DustThreshold uint64
+	// MaxWitnessItems is the maximum number of witness arguments per input
+	MaxWitnessItems int
+	// AllowNonStandardProgram accepts BTM inputs/outputs whose control
+	// program is not a recognized P2W template
+	AllowNonStandardProgram bool
+}
+
+// DefaultPolicy return the standardness rules applied when the node has no
+// explicit policy configuration.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		DustThreshold:   0,
+		MaxWitnessItems: 100,
+	}
+}
+
+// NewPolicy build the standardness rules from the node configuration, missing
+// configuration falls back to DefaultPolicy.
+func NewPolicy(config *cfg.Config) *Policy {
+	p := DefaultPolicy()
+	if config == nil || config.Policy == nil {
+		return p
+	}
+
+	p.DustThreshold = config.Policy.DustThreshold
+	p.AllowNonStandardProgram = config.Policy.AllowNonStandardProgram
+	if config.Policy.MaxWitnessItems != 0 {
+		p.MaxWitnessItems = config.Policy.MaxWitnessItems
+	}
+	return p
+}
+
+// CheckStandardTx enforce the node standardness rules on a mempool candidate
+// transaction.
+func (p *Policy) CheckStandardTx(tx *bc.Tx) error {
+	for _, id := range tx.GasInputIDs {
+		spend, err := tx.Spend(id)
+		if err != nil {
+			return err
+		}
+		spentOutput, err := tx.Output(*spend.SpentOutputId)
+		if err != nil {
+			return err
+		}
+
+		if !p.AllowNonStandardProgram && !segwit.IsP2WScript(spentOutput.ControlProgram.Code) {
+			return ErrNonStandardProgram
+		}
+	}
+
+	if p.MaxWitnessItems > 0 {
+		for _, id := range tx.InputIDs {
+			var args [][]byte
+			if spend, err := tx.Spend(id); err == nil {
+				args = spend.WitnessArguments
+			} else if issuance, err := tx.Issuance(id); err == nil {
+				args = issuance.WitnessArguments
+			}
+			if len(args) > p.MaxWitnessItems {
+				return ErrExceedWitnessLimit
+			}
+		}
+	}
+
+	for _, id := range tx.ResultIds {
+		e, ok := tx.Entries[*id]
+		if !ok {
+			return errors.Wrapf(bc.ErrMissingEntry, "id %x", id.Bytes())
+		}
+
+		output, ok := e.(*bc.Output)
+		if !ok || *output.Source.Value.AssetId != *consensus.BTMAssetID {
+			continue
+		}
+
+		if !p.AllowNonStandardProgram && !segwit.IsP2WScript(output.ControlProgram.Code) {
+			return ErrNonStandardProgram
+		}
+		if output.Source.Value.Amount < p.DustThreshold {
+			return ErrDustOutput
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/vm"
+	"github.com/bytom-gm/protocol/vm/vmutil"
+)
+
+func mockP2WPKHProgram(t *testing.T) []byte {
+	prog, err := vmutil.P2WPKHProgram(make([]byte, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prog
+}
+
+func mockTx(t *testing.T, witnessArgs [][]byte, inputProg, outputProg []byte, outputAmount uint64) *bc.Tx {
+	return types.MapTx(&types.TxData{
+		SerializedSize: 1,
+		Inputs: []*types.TxInput{
+			types.NewSpendInput(witnessArgs, bc.Hash{V0: 1}, *consensus.BTMAssetID, outputAmount+100000, 0, inputProg),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*consensus.BTMAssetID, outputAmount, outputProg),
+		},
+	})
+}
+
+func TestCheckStandardTx(t *testing.T) {
+	p2wpkh := mockP2WPKHProgram(t)
+	// a single data push is parseable but matches no standard template
+	nonStd := []byte{byte(vm.OP_DATA_1), 0x01}
+
+	cases := []struct {
+		desc   string
+		policy *Policy
+		tx     *bc.Tx
+		err    error
+	}{
+		{
+			desc:   "standard spend and output",
+			policy: DefaultPolicy(),
+			tx:     mockTx(t, nil, p2wpkh, p2wpkh, 100000),
+			err:    nil,
+		},
+		{
+			desc:   "non standard gas input program",
+			policy: DefaultPolicy(),
+			tx:     mockTx(t, nil, nonStd, p2wpkh, 100000),
+			err:    ErrNonStandardProgram,
+		},
+		{
+			desc:   "non standard output program",
+			policy: DefaultPolicy(),
+			tx:     mockTx(t, nil, p2wpkh, nonStd, 100000),
+			err:    ErrNonStandardProgram,
+		},
+		{
+			desc:   "non standard program explicitly allowed",
+			policy: &Policy{AllowNonStandardProgram: true},
+			tx:     mockTx(t, nil, nonStd, nonStd, 100000),
+			err:    nil,
+		},
+		{
+			desc:   "output below the dust threshold",
+			policy: &Policy{DustThreshold: 100},
+			tx:     mockTx(t, nil, p2wpkh, p2wpkh, 99),
+			err:    ErrDustOutput,
+		},
+		{
+			desc:   "too many witness items",
+			policy: &Policy{MaxWitnessItems: 1},
+			tx:     mockTx(t, [][]byte{{0x01}, {0x02}}, p2wpkh, p2wpkh, 100000),
+			err:    ErrExceedWitnessLimit,
+		},
+	}
+
+	for _, c := range cases {
+		if err := c.policy.CheckStandardTx(c.tx); err != c.err {
+			t.Errorf("%s: got error %v, want %v", c.desc, err, c.err)
+		}
+	}
+}
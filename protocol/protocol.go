@@ -7,8 +7,10 @@ import (
 
 	"github.com/bytom-gm/config"
 	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/metrics"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/policy"
 	"github.com/bytom-gm/protocol/state"
 )
 
@@ -19,11 +21,18 @@ type Chain struct {
 	index          *state.BlockIndex
 	orphanManage   *OrphanManage
 	txPool         *TxPool
+	txPolicy       *policy.Policy
 	store          Store
+	bootstrap      *bootstrapHeaders
 	processBlockCh chan *processBlockMsg
+	rollbackCh     chan *rollbackMsg
 
 	cond     sync.Cond
 	bestNode *state.BlockNode
+
+	// blockProcessTimes records how long block validation and insertion
+	// take, exported through the metrics endpoint
+	blockProcessTimes *metrics.PromHistogram
 }
 
 // NewChain returns a new Chain using store as the underlying storage.
@@ -31,8 +40,13 @@ func NewChain(store Store, txPool *TxPool) (*Chain, error) {
 	c := &Chain{
 		orphanManage:   NewOrphanManage(),
 		txPool:         txPool,
+		txPolicy:       policy.NewPolicy(config.CommonConfig),
 		store:          store,
+		bootstrap:      newBootstrapHeaders(),
 		processBlockCh: make(chan *processBlockMsg, maxProcessBlockChSize),
+		rollbackCh:     make(chan *rollbackMsg),
+
+		blockProcessTimes: metrics.NewPromHistogram(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10),
 	}
 	c.cond.L = new(sync.Mutex)
 
@@ -81,6 +95,12 @@ func (c *Chain) initChainStatus() error {
 	return c.store.SaveChainStatus(node, utxoView)
 }
 
+// PruneHeight returns the height up to which the store has discarded raw
+// block data, zero when prune mode is off or nothing has been pruned yet.
+func (c *Chain) PruneHeight() uint64 {
+	return c.store.PruneHeight()
+}
+
 // BestBlockHeight returns the current height of the blockchain.
 func (c *Chain) BestBlockHeight() uint64 {
 	c.cond.L.Lock()
@@ -106,6 +126,35 @@ func (c *Chain) InMainChain(hash bc.Hash) bool {
 	return c.index.InMainchain(hash)
 }
 
+// TipDesc describes one known branch tip. Only validated blocks enter the
+// block index, so a tip off the main chain is always a valid fork.
+type TipDesc struct {
+	Height    uint64  `json:"height"`
+	Hash      bc.Hash `json:"hash"`
+	BranchLen uint64  `json:"branch_len"`
+	Status    string  `json:"status"`
+}
+
+// ChainTips returns every known branch tip with its distance from the main
+// chain, for monitoring reorg behavior.
+func (c *Chain) ChainTips() []*TipDesc {
+	bestHash := *c.BestBlockHash()
+
+	tips := []*TipDesc{}
+	for _, node := range c.index.Tips() {
+		desc := &TipDesc{Height: node.Height, Hash: node.Hash, Status: "valid-fork"}
+		if node.Hash == bestHash {
+			desc.Status = "active"
+		}
+
+		for fork := node; fork != nil && !c.index.InMainchain(fork.Hash); fork = fork.Parent {
+			desc.BranchLen++
+		}
+		tips = append(tips, desc)
+	}
+	return tips
+}
+
 // CalcNextSeed return the seed for the given block
 func (c *Chain) CalcNextSeed(preBlock *bc.Hash) (*bc.Hash, error) {
 	node := c.index.GetNode(preBlock)
@@ -160,3 +209,9 @@ func (c *Chain) BlockWaiter(height uint64) <-chan struct{} {
 func (c *Chain) GetTxPool() *TxPool {
 	return c.txPool
 }
+
+// BlockProcessTimes returns the histogram of block validation and
+// insertion timings.
+func (c *Chain) BlockProcessTimes() *metrics.PromHistogram {
+	return c.blockProcessTimes
+}
@@ -0,0 +1,63 @@
+package protocol
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/state"
+)
+
+// pre-define errors for the administrative rollback
+var (
+	// ErrRollbackHeight means the requested height isn't below the best height
+	ErrRollbackHeight = errors.New("rollback height is not below the current best height")
+)
+
+type rollbackMsg struct {
+	height uint64
+	reply  chan error
+}
+
+// Rollback detaches blocks from the best chain down to the given height,
+// rewinding the utxo state with them. The detached blocks stay on disk so
+// the wallet can rewind its own indexes, but they leave the block index and
+// get re-validated if a peer sends them again. The request goes through the
+// block processor goroutine, so it can't race an incoming block.
+func (c *Chain) Rollback(height uint64) error {
+	reply := make(chan error, 1)
+	c.rollbackCh <- &rollbackMsg{height: height, reply: reply}
+	return <-reply
+}
+
+func (c *Chain) rollback(height uint64) error {
+	if height >= c.bestNode.Height {
+		return ErrRollbackHeight
+	}
+
+	node := c.index.NodeByHeight(height)
+	if node == nil {
+		return errors.New("can't find block in given height")
+	}
+
+	detachNodes := []*state.BlockNode{}
+	for n := c.bestNode; n != node; n = n.Parent {
+		detachNodes = append(detachNodes, n)
+	}
+
+	if err := c.reorganizeChain(node); err != nil {
+		return err
+	}
+
+	for _, detachNode := range detachNodes {
+		c.index.RemoveNode(detachNode)
+	}
+
+	// transactions in the pool may spend outputs that just got rewound,
+	// drop them all instead of guessing which ones survived
+	for _, txDesc := range c.txPool.GetTransactions() {
+		c.txPool.RemoveTransaction(&txDesc.Tx.ID)
+	}
+
+	log.WithFields(log.Fields{"height": height, "detached": len(detachNodes)}).Info("chain rolled back")
+	return nil
+}
@@ -95,6 +95,14 @@ func (node *BlockNode) CalcPastMedianTime() uint64 {
 
 // CalcNextBits calculate the bits for next block
 func (node *BlockNode) CalcNextBits() uint64 {
+	if algorithm := difficulty.ActiveRetarget(node.Height + 1); algorithm != nil {
+		headers := []*types.BlockHeader{}
+		for iterNode := node; len(headers) < algorithm.HeadersNeeded() && iterNode != nil; iterNode = iterNode.Parent {
+			headers = append(headers, iterNode.BlockHeader())
+		}
+		return algorithm.NextRequiredDifficulty(headers)
+	}
+
 	if node.Height%consensus.BlocksPerRetarget != 0 || node.Height == 0 {
 		return node.Bits
 	}
@@ -140,6 +148,14 @@ func (bi *BlockIndex) AddNode(node *BlockNode) {
 	bi.Unlock()
 }
 
+// RemoveNode drops the node from the index map, the caller makes sure the
+// main chain no longer points at it
+func (bi *BlockIndex) RemoveNode(node *BlockNode) {
+	bi.Lock()
+	delete(bi.index, node.Hash)
+	bi.Unlock()
+}
+
 // GetNode will search node from the index map
 func (bi *BlockIndex) GetNode(hash *bc.Hash) *BlockNode {
 	bi.RLock()
@@ -161,6 +177,28 @@ func (bi *BlockIndex) BlockExist(hash *bc.Hash) bool {
 	return ok
 }
 
+// Tips returns the leaf nodes of the block index, the head of every known
+// branch including the main chain one
+func (bi *BlockIndex) Tips() []*BlockNode {
+	bi.RLock()
+	defer bi.RUnlock()
+
+	hasChild := make(map[bc.Hash]bool)
+	for _, node := range bi.index {
+		if node.Parent != nil {
+			hasChild[node.Parent.Hash] = true
+		}
+	}
+
+	tips := []*BlockNode{}
+	for _, node := range bi.index {
+		if !hasChild[node.Hash] {
+			tips = append(tips, node)
+		}
+	}
+	return tips
+}
+
 // TODO: THIS FUNCTION MIGHT BE DELETED
 func (bi *BlockIndex) InMainchain(hash bc.Hash) bool {
 	bi.RLock()
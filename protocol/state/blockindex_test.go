@@ -210,3 +210,44 @@ func TestSetMainChain(t *testing.T) {
 		tailNode = tailNode.Parent
 	}
 }
+
+func TestTips(t *testing.T) {
+	blockIndex := NewBlockIndex()
+	var lastNode *BlockNode
+	for i := uint64(0); i < 4; i++ {
+		node := &BlockNode{
+			Height: i,
+			Hash:   bc.Hash{V0: i},
+			Parent: lastNode,
+		}
+		blockIndex.AddNode(node)
+		lastNode = node
+	}
+	blockIndex.SetMainChain(lastNode)
+	mainTip := lastNode
+
+	// a two block fork off height 1
+	forkNode := blockIndex.nodeByHeight(1)
+	for i := uint64(1); i <= 2; i++ {
+		node := &BlockNode{
+			Height: forkNode.Height + 1,
+			Hash:   bc.Hash{V1: i},
+			Parent: forkNode,
+		}
+		blockIndex.AddNode(node)
+		forkNode = node
+	}
+
+	tips := blockIndex.Tips()
+	if len(tips) != 2 {
+		t.Fatalf("got %d tips, want 2", len(tips))
+	}
+
+	found := map[bc.Hash]bool{}
+	for _, tip := range tips {
+		found[tip.Hash] = true
+	}
+	if !found[mainTip.Hash] || !found[forkNode.Hash] {
+		t.Errorf("tips = %v, want main tip %v and fork tip %v", found, mainTip.Hash, forkNode.Hash)
+	}
+}
@@ -0,0 +1,101 @@
+package state
+
+import (
+	"github.com/bytom-gm/consensus"
+)
+
+// SoftForkState is the versionbits state a deployment window is in
+type SoftForkState int
+
+// the versionbits state machine: a deployment is defined until its start
+// height, collects signals while started, locks in for one full window once
+// the threshold is met and stays active afterwards. Reaching the timeout
+// height before lock-in fails the deployment for good.
+const (
+	SoftForkDefined SoftForkState = iota
+	SoftForkStarted
+	SoftForkLockedIn
+	SoftForkActive
+	SoftForkFailed
+)
+
+// String implement fmt.Stringer
+func (s SoftForkState) String() string {
+	switch s {
+	case SoftForkDefined:
+		return "defined"
+	case SoftForkStarted:
+		return "started"
+	case SoftForkLockedIn:
+		return "locked_in"
+	case SoftForkActive:
+		return "active"
+	case SoftForkFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// SoftForkSignals reports whether a block of the given header version
+// signals readiness for the deployment
+func SoftForkSignals(version uint64, d *consensus.Deployment) bool {
+	return version&(uint64(1)<<d.Bit) != 0
+}
+
+func countSignals(nodes []*BlockNode, d *consensus.Deployment) uint64 {
+	count := uint64(0)
+	for _, node := range nodes {
+		if SoftForkSignals(node.Version, d) {
+			count++
+		}
+	}
+	return count
+}
+
+// SoftForkStatus runs the versionbits state machine for the deployment over
+// the chain ending at node and returns the state the next block falls in.
+// States only change on window boundaries, a boundary checks the signals of
+// the window that just closed.
+func SoftForkStatus(node *BlockNode, d *consensus.Deployment) SoftForkState {
+	window := consensus.VersionBitsWindow
+	chain := make([]*BlockNode, node.Height+1)
+	for n := node; n != nil; n = n.Parent {
+		chain[n.Height] = n
+	}
+
+	status := SoftForkDefined
+	for boundary := window; boundary <= node.Height+1; boundary += window {
+		switch status {
+		case SoftForkDefined:
+			if boundary >= d.TimeoutHeight {
+				status = SoftForkFailed
+			} else if boundary >= d.StartHeight {
+				status = SoftForkStarted
+			}
+		case SoftForkStarted:
+			if countSignals(chain[boundary-window:boundary], d) >= consensus.VersionBitsThreshold {
+				status = SoftForkLockedIn
+			} else if boundary >= d.TimeoutHeight {
+				status = SoftForkFailed
+			}
+		case SoftForkLockedIn:
+			status = SoftForkActive
+		case SoftForkActive, SoftForkFailed:
+			return status
+		}
+	}
+	return status
+}
+
+// SoftForkWindowSignals counts the signaling blocks of the still open window
+// the node sits in, so operators can watch a started deployment approach
+// the threshold
+func SoftForkWindowSignals(node *BlockNode, d *consensus.Deployment) (count uint64, windowStart uint64) {
+	windowStart = node.Height + 1 - (node.Height+1)%consensus.VersionBitsWindow
+	for n := node; n != nil && n.Height >= windowStart; n = n.Parent {
+		if SoftForkSignals(n.Version, d) {
+			count++
+		}
+	}
+	return count, windowStart
+}
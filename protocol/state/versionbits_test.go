@@ -0,0 +1,100 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/consensus"
+)
+
+func buildVersionChain(height uint64, version func(height uint64) uint64) *BlockNode {
+	var parent *BlockNode
+	for h := uint64(0); h <= height; h++ {
+		parent = &BlockNode{Parent: parent, Height: h, Version: version(h)}
+	}
+	return parent
+}
+
+func TestSoftForkStatus(t *testing.T) {
+	window := consensus.VersionBitsWindow
+	signal := func(uint64) uint64 { return uint64(1) << 1 }
+	noSignal := func(uint64) uint64 { return 1 }
+
+	cases := []struct {
+		desc       string
+		deployment *consensus.Deployment
+		tipHeight  uint64
+		version    func(uint64) uint64
+		want       SoftForkState
+	}{
+		{
+			desc:       "before the start window",
+			deployment: &consensus.Deployment{Bit: 1, StartHeight: window, TimeoutHeight: 10 * window},
+			tipHeight:  window - 2,
+			version:    signal,
+			want:       SoftForkDefined,
+		},
+		{
+			desc:       "started while the signaling window is open",
+			deployment: &consensus.Deployment{Bit: 1, StartHeight: window, TimeoutHeight: 10 * window},
+			tipHeight:  2*window - 2,
+			version:    signal,
+			want:       SoftForkStarted,
+		},
+		{
+			desc:       "locked in when the signaling window closes",
+			deployment: &consensus.Deployment{Bit: 1, StartHeight: window, TimeoutHeight: 10 * window},
+			tipHeight:  2*window - 1,
+			version:    signal,
+			want:       SoftForkLockedIn,
+		},
+		{
+			desc:       "active one window after lock-in",
+			deployment: &consensus.Deployment{Bit: 1, StartHeight: window, TimeoutHeight: 10 * window},
+			tipHeight:  3*window - 1,
+			version:    signal,
+			want:       SoftForkActive,
+		},
+		{
+			desc:       "failed at the timeout height without signals",
+			deployment: &consensus.Deployment{Bit: 1, StartHeight: window, TimeoutHeight: 2 * window},
+			tipHeight:  3*window - 1,
+			version:    noSignal,
+			want:       SoftForkFailed,
+		},
+		{
+			desc:       "signaling the wrong bit never locks in",
+			deployment: &consensus.Deployment{Bit: 2, StartHeight: window, TimeoutHeight: 10 * window},
+			tipHeight:  3*window - 1,
+			version:    signal,
+			want:       SoftForkStarted,
+		},
+	}
+
+	for _, c := range cases {
+		tip := buildVersionChain(c.tipHeight, c.version)
+		if got := SoftForkStatus(tip, c.deployment); got != c.want {
+			t.Errorf("%s: got state %s, want %s", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestSoftForkWindowSignals(t *testing.T) {
+	window := consensus.VersionBitsWindow
+	deployment := &consensus.Deployment{Bit: 1, StartHeight: 0, TimeoutHeight: 10 * window}
+
+	tip := buildVersionChain(window+5, func(uint64) uint64 { return uint64(1) << 1 })
+	count, windowStart := SoftForkWindowSignals(tip, deployment)
+	if windowStart != window {
+		t.Errorf("got window start %d, want %d", windowStart, window)
+	}
+	if count != 6 {
+		t.Errorf("got %d signals in the open window, want 6", count)
+	}
+
+	// a tip closing its window leaves the next window empty
+	tip = buildVersionChain(window-1, func(uint64) uint64 { return uint64(1) << 1 })
+	count, windowStart = SoftForkWindowSignals(tip, deployment)
+	if windowStart != window || count != 0 {
+		t.Errorf("got %d signals from window start %d, want 0 from %d", count, windowStart, window)
+	}
+}
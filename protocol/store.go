@@ -18,6 +18,7 @@ type Store interface {
 	GetUtxo(*bc.Hash) (*storage.UtxoEntry, error)
 
 	LoadBlockIndex(uint64) (*state.BlockIndex, error)
+	PruneHeight() uint64
 	SaveBlock(*types.Block, *bc.TransactionStatus) error
 	SaveChainStatus(*state.BlockNode, *state.UtxoViewpoint) error
 }
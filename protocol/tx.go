@@ -27,12 +27,55 @@ func (c *Chain) GetTransactionsUtxo(view *state.UtxoViewpoint, txs []*bc.Tx) err
 // per-transaction validation results and is consulted before
 // performing full validation.
 func (c *Chain) ValidateTx(tx *types.Tx) (bool, error) {
+	return c.validateTx(tx, "")
+}
+
+// ValidateTxFromPeer is ValidateTx for relayed transactions, the peer ID
+// counts a resulting orphan against that peer's quota
+func (c *Chain) ValidateTxFromPeer(tx *types.Tx, peerID string) (bool, error) {
+	return c.validateTx(tx, peerID)
+}
+
+// DryRunTx runs full consensus validation of tx against the current tip,
+// including VM execution and utxo existence checks, without touching the
+// mempool or the validation caches. The gas accounting is returned even
+// when validation fails.
+func (c *Chain) DryRunTx(tx *types.Tx) (*validation.GasState, error) {
+	nextHeader := *c.BestBlockHeader()
+	nextHeader.Height++
+	block := types.MapBlock(&types.Block{BlockHeader: nextHeader})
+	gasStatus, err := validation.ValidateTx(tx.Tx, block)
+	if err != nil {
+		return gasStatus, err
+	}
+
+	view := state.NewUtxoViewpoint()
+	if err := c.GetTransactionsUtxo(view, []*bc.Tx{tx.Tx}); err != nil {
+		return gasStatus, err
+	}
+	for _, spentOutputID := range tx.Tx.SpentOutputIDs {
+		if !view.CanSpend(&spentOutputID) {
+			return gasStatus, errors.WithDetailf(ErrBadTx, "utxo %s is missing or already spent", spentOutputID.String())
+		}
+	}
+	return gasStatus, nil
+}
+
+func (c *Chain) validateTx(tx *types.Tx, peerID string) (bool, error) {
 	if ok := c.txPool.HaveTransaction(&tx.ID); ok {
 		return false, c.txPool.GetErrCache(&tx.ID)
 	}
 
-	bh := c.BestBlockHeader()
-	block := types.MapBlock(&types.Block{BlockHeader: *bh})
+	if err := c.txPolicy.CheckStandardTx(tx.Tx); err != nil {
+		c.txPool.AddErrCache(&tx.ID, err)
+		return false, err
+	}
+
+	// admit against the context of the block the transaction can first appear
+	// in, which also lets block validation reuse the cached result
+	nextHeader := *c.BestBlockHeader()
+	nextHeader.Height++
+	block := types.MapBlock(&types.Block{BlockHeader: nextHeader})
 	gasStatus, err := validation.ValidateTx(tx.Tx, block)
 	if gasStatus.GasValid == false {
 		c.txPool.AddErrCache(&tx.ID, err)
@@ -43,5 +86,5 @@ func (c *Chain) ValidateTx(tx *types.Tx) (bool, error) {
 		log.WithFields(log.Fields{"tx_id": tx.Tx.ID.String(), "error": err}).Info("transaction status fail")
 	}
 
-	return c.txPool.ProcessTransaction(tx, err != nil, block.BlockHeader.Height, gasStatus.BTMValue)
+	return c.txPool.ProcessTransaction(tx, err != nil, block.BlockHeader.Height, gasStatus.BTMValue, peerID)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/golang/groupcache/lru"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/bytom-gm/config"
 	"github.com/bytom-gm/consensus"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
@@ -19,6 +20,7 @@ import (
 const (
 	MsgNewTx = iota
 	MsgRemoveTx
+	MsgConflictTx
 )
 
 var (
@@ -27,13 +29,30 @@ var (
 	maxNewTxNum     = 10000
 	maxOrphanNum    = 2000
 
+	// maxOrphanNumPerPeer bounds how much of the orphan pool a single peer
+	// may occupy, so one flooder can't starve chained spends from others
+	maxOrphanNumPerPeer = 50
+
+	// defaultMaxPoolBytes bounds the summed serialized size of the pool
+	defaultMaxPoolBytes = uint64(100 * 1024 * 1024)
+
 	orphanTTL                = 10 * time.Minute
 	orphanExpireScanInterval = 3 * time.Minute
 
+	// minFeeRateHalfLife is how fast the dynamic minimum relay fee decays
+	// once eviction pressure stops, it halves every interval
+	minFeeRateHalfLife = 10 * time.Minute
+
 	// ErrTransactionNotExist is the pre-defined error message
 	ErrTransactionNotExist = errors.New("transaction are not existed in the mempool")
 	// ErrPoolIsFull indicates the pool is full
 	ErrPoolIsFull = errors.New("transaction pool reach the max number")
+	// ErrTxChainTooDeep indicates the unconfirmed ancestor/descendant limit is hit
+	ErrTxChainTooDeep = errors.New("transaction exceeds the mempool chain depth limit")
+	// ErrBelowRelayFee indicates the fee rate is under the dynamic relay minimum
+	ErrBelowRelayFee = errors.New("transaction fee rate is below the relay minimum")
+	// ErrPeerOrphanLimit indicates one peer reach its orphan transaction quota
+	ErrPeerOrphanLimit = errors.New("peer reach the max orphan transaction number")
 )
 
 // TxDesc store tx and related info for mining strategy
@@ -44,30 +63,70 @@ type TxDesc struct {
 	Height     uint64
 	Weight     uint64
 	Fee        uint64
+
+	// Local means the transaction came in through the local API instead of
+	// being relayed from a peer
+	Local bool
+}
+
+// SpendConflict pairs a double spent outpoint with the pool transaction
+// already spending it
+type SpendConflict struct {
+	OutputID bc.Hash
+	TxID     bc.Hash
 }
 
 // TxPoolMsg is use for notify pool changes
 type TxPoolMsg struct {
 	*TxDesc
 	MsgType int
+
+	// Conflicts carries the double spent outpoints for MsgConflictTx
+	Conflicts []*SpendConflict
 }
 
 type orphanTx struct {
 	*TxDesc
 	expiration time.Time
+
+	// peerID is the relaying peer the orphan is counted against, empty for
+	// local submissions
+	peerID string
 }
 
 // TxPool is use for store the unconfirmed transaction
 type TxPool struct {
-	lastUpdated   int64
-	mtx           sync.RWMutex
-	store         Store
-	pool          map[bc.Hash]*TxDesc
-	utxo          map[bc.Hash]*types.Tx
-	orphans       map[bc.Hash]*orphanTx
-	orphansByPrev map[bc.Hash]map[bc.Hash]*orphanTx
-	errCache      *lru.Cache
-	msgCh         chan *TxPoolMsg
+	lastUpdated    int64
+	mtx            sync.RWMutex
+	store          Store
+	pool           map[bc.Hash]*TxDesc
+	utxo           map[bc.Hash]*types.Tx
+	orphans        map[bc.Hash]*orphanTx
+	orphansByPrev  map[bc.Hash]map[bc.Hash]*orphanTx
+	orphansByPeer  map[string]int
+	errCache       *lru.Cache
+	msgCh          chan *TxPoolMsg
+	estimator      *FeeEstimator
+	children       map[bc.Hash]map[bc.Hash]bool
+	spentOutputs   map[bc.Hash]bc.Hash
+	maxChainDepth  int
+	maxAncestors   int
+	maxDescendants int
+
+	maxPoolTxs   int
+	maxPoolBytes uint64
+	poolBytes    uint64
+
+	// dynamicMinFeeRate is the relay minimum in milli-neu per byte, it
+	// rises when eviction kicks out paying transactions and decays back
+	// towards zero afterwards
+	dynamicMinFeeRate uint64
+	minFeeRateUpdated time.Time
+
+	// newTxSubs fans the accepted transactions out to extra listeners
+	// (e.g. the websocket API) besides the single msgCh consumer
+	subsMtx   sync.Mutex
+	newTxSubs map[chan *TxDesc]struct{}
 }
 
 // NewTxPool init a new TxPool
@@ -79,8 +138,24 @@ func NewTxPool(store Store) *TxPool {
 		utxo:          make(map[bc.Hash]*types.Tx),
 		orphans:       make(map[bc.Hash]*orphanTx),
 		orphansByPrev: make(map[bc.Hash]map[bc.Hash]*orphanTx),
+		orphansByPeer: make(map[string]int),
 		errCache:      lru.New(maxCachedErrTxs),
 		msgCh:         make(chan *TxPoolMsg, maxMsgChSize),
+		estimator:     NewFeeEstimator(),
+		children:      make(map[bc.Hash]map[bc.Hash]bool),
+		spentOutputs:  make(map[bc.Hash]bc.Hash),
+		newTxSubs:     make(map[chan *TxDesc]struct{}),
+	}
+	if config.CommonConfig != nil && config.CommonConfig.Policy != nil {
+		tp.maxChainDepth = config.CommonConfig.Policy.MaxChainDepth
+		tp.maxAncestors = config.CommonConfig.Policy.MaxAncestors
+		tp.maxDescendants = config.CommonConfig.Policy.MaxDescendants
+		if config.CommonConfig.Policy.MaxMempoolTxs > 0 {
+			tp.maxPoolTxs = config.CommonConfig.Policy.MaxMempoolTxs
+		}
+		if config.CommonConfig.Policy.MaxMempoolBytes > 0 {
+			tp.maxPoolBytes = config.CommonConfig.Policy.MaxMempoolBytes
+		}
 	}
 	go tp.orphanExpireWorker()
 	return tp
@@ -123,26 +198,108 @@ func (tp *TxPool) GetMsgCh() <-chan *TxPoolMsg {
 	return tp.msgCh
 }
 
+// SubscribeNewTx register a listener for accepted mempool transactions, the
+// returned channel is closed by UnsubscribeNewTx. A slow listener loses
+// transactions rather than stalling the pool.
+func (tp *TxPool) SubscribeNewTx() chan *TxDesc {
+	ch := make(chan *TxDesc, maxMsgChSize)
+
+	tp.subsMtx.Lock()
+	tp.newTxSubs[ch] = struct{}{}
+	tp.subsMtx.Unlock()
+	return ch
+}
+
+// UnsubscribeNewTx drop the listener and close its channel
+func (tp *TxPool) UnsubscribeNewTx(ch chan *TxDesc) {
+	tp.subsMtx.Lock()
+	defer tp.subsMtx.Unlock()
+
+	if _, ok := tp.newTxSubs[ch]; ok {
+		delete(tp.newTxSubs, ch)
+		close(ch)
+	}
+}
+
+// notifyNewTxSubs deliver the accepted transaction to every listener without
+// blocking
+func (tp *TxPool) notifyNewTxSubs(txD *TxDesc) {
+	tp.subsMtx.Lock()
+	defer tp.subsMtx.Unlock()
+	for ch := range tp.newTxSubs {
+		select {
+		case ch <- txD:
+		default:
+		}
+	}
+}
+
+// ConfirmTransaction notify the fee estimator that a transaction is confirmed
+func (tp *TxPool) ConfirmTransaction(txHash *bc.Hash, height uint64) {
+	tp.estimator.ConfirmTx(txHash, height)
+}
+
+// EstimateFeeRate return the estimated fee rate for the given confirm target
+func (tp *TxPool) EstimateFeeRate(targetBlocks uint64) (uint64, error) {
+	return tp.estimator.EstimateFeeRate(targetBlocks)
+}
+
 // RemoveTransaction remove a transaction from the pool
 func (tp *TxPool) RemoveTransaction(txHash *bc.Hash) {
 	tp.mtx.Lock()
 	defer tp.mtx.Unlock()
+	tp.removeTransaction(txHash)
+}
 
+// removeTransaction is RemoveTransaction with the pool lock already held
+func (tp *TxPool) removeTransaction(txHash *bc.Hash) {
 	txD, ok := tp.pool[*txHash]
 	if !ok {
 		return
 	}
 
+	for _, spend := range txD.Tx.SpentOutputIDs {
+		parent, ok := tp.utxo[spend]
+		if !ok {
+			continue
+		}
+
+		if children, ok := tp.children[parent.ID]; ok {
+			if delete(children, *txHash); len(children) == 0 {
+				delete(tp.children, parent.ID)
+			}
+		}
+	}
+	delete(tp.children, *txHash)
+
+	for _, spend := range txD.Tx.SpentOutputIDs {
+		if spender, ok := tp.spentOutputs[spend]; ok && spender == *txHash {
+			delete(tp.spentOutputs, spend)
+		}
+	}
 	for _, output := range txD.Tx.ResultIds {
 		delete(tp.utxo, *output)
 	}
 	delete(tp.pool, *txHash)
+	tp.poolBytes -= txD.Weight
+	tp.estimator.RemoveTx(txHash)
 
 	atomic.StoreInt64(&tp.lastUpdated, time.Now().Unix())
 	tp.msgCh <- &TxPoolMsg{TxDesc: txD, MsgType: MsgRemoveTx}
 	log.WithField("tx_id", txHash).Debug("remove tx from mempool")
 }
 
+// MarkLocal flag a pooled transaction as locally submitted, the mining
+// selection policy may prioritize it
+func (tp *TxPool) MarkLocal(txHash *bc.Hash) {
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+
+	if txD, ok := tp.pool[*txHash]; ok {
+		txD.Local = true
+	}
+}
+
 // GetTransaction return the TxDesc by hash
 func (tp *TxPool) GetTransaction(txHash *bc.Hash) (*TxDesc, error) {
 	tp.mtx.RLock()
@@ -191,8 +348,9 @@ func (tp *TxPool) HaveTransaction(txHash *bc.Hash) bool {
 	return tp.IsTransactionInPool(txHash) || tp.IsTransactionInErrCache(txHash)
 }
 
-// ProcessTransaction is the main entry for txpool handle new tx
-func (tp *TxPool) ProcessTransaction(tx *types.Tx, statusFail bool, height, fee uint64) (bool, error) {
+// ProcessTransaction is the main entry for txpool handle new tx, peerID tags
+// an orphan against the relaying peer's quota and is empty for local txs
+func (tp *TxPool) ProcessTransaction(tx *types.Tx, statusFail bool, height, fee uint64, peerID string) (bool, error) {
 	tp.mtx.Lock()
 	defer tp.mtx.Unlock()
 
@@ -209,7 +367,12 @@ func (tp *TxPool) ProcessTransaction(tx *types.Tx, statusFail bool, height, fee
 	}
 
 	if len(requireParents) > 0 {
-		return true, tp.addOrphan(txD, requireParents)
+		return true, tp.addOrphan(txD, requireParents, peerID)
+	}
+
+	if conflicts := tp.conflictingSpends(tx); len(conflicts) > 0 {
+		log.WithFields(log.Fields{"tx_id": tx.ID.String(), "conflicts": len(conflicts)}).Warning("transaction double spends mempool outpoints")
+		tp.msgCh <- &TxPoolMsg{TxDesc: txD, MsgType: MsgConflictTx, Conflicts: conflicts}
 	}
 
 	if err := tp.addTransaction(txD); err != nil {
@@ -220,13 +383,34 @@ func (tp *TxPool) ProcessTransaction(tx *types.Tx, statusFail bool, height, fee
 	return false, nil
 }
 
-func (tp *TxPool) addOrphan(txD *TxDesc, requireParents []*bc.Hash) error {
+// TxChainStats return the unconfirmed ancestor/descendant counts of a pool transaction
+func (tp *TxPool) TxChainStats(txHash *bc.Hash) (int, int, error) {
+	tp.mtx.RLock()
+	defer tp.mtx.RUnlock()
+
+	txD, ok := tp.pool[*txHash]
+	if !ok {
+		return 0, 0, ErrTransactionNotExist
+	}
+	return len(tp.ancestors(txD.Tx)), tp.countDescendants(&txD.Tx.ID), nil
+}
+
+func (tp *TxPool) addOrphan(txD *TxDesc, requireParents []*bc.Hash, peerID string) error {
 	if len(tp.orphans) >= maxOrphanNum {
 		return ErrPoolIsFull
 	}
+	if peerID != "" && tp.orphansByPeer[peerID] >= maxOrphanNumPerPeer {
+		return ErrPeerOrphanLimit
+	}
 
-	orphan := &orphanTx{txD, time.Now().Add(orphanTTL)}
+	orphan := &orphanTx{TxDesc: txD, expiration: time.Now().Add(orphanTTL), peerID: peerID}
 	tp.orphans[txD.Tx.ID] = orphan
+	if peerID != "" {
+		if tp.orphansByPeer == nil {
+			tp.orphansByPeer = make(map[string]int)
+		}
+		tp.orphansByPeer[peerID]++
+	}
 	for _, hash := range requireParents {
 		if _, ok := tp.orphansByPrev[*hash]; !ok {
 			tp.orphansByPrev[*hash] = make(map[bc.Hash]*orphanTx)
@@ -237,13 +421,40 @@ func (tp *TxPool) addOrphan(txD *TxDesc, requireParents []*bc.Hash) error {
 }
 
 func (tp *TxPool) addTransaction(txD *TxDesc) error {
-	if len(tp.pool) >= maxNewTxNum {
-		return ErrPoolIsFull
+	if minRate := tp.currentMinFeeRate(); minRate > 0 && milliFeeRate(txD) < minRate {
+		return ErrBelowRelayFee
+	}
+
+	if err := tp.makeRoom(txD); err != nil {
+		return err
+	}
+
+	if err := tp.checkChainDepth(txD.Tx); err != nil {
+		return err
 	}
 
 	tx := txD.Tx
 	txD.Added = time.Now()
 	tp.pool[tx.ID] = txD
+	tp.poolBytes += txD.Weight
+	for _, parent := range tp.parentHashes(tx) {
+		if tp.children == nil {
+			tp.children = make(map[bc.Hash]map[bc.Hash]bool)
+		}
+		if _, ok := tp.children[parent]; !ok {
+			tp.children[parent] = make(map[bc.Hash]bool)
+		}
+		tp.children[parent][tx.ID] = true
+	}
+	for _, spend := range tx.SpentOutputIDs {
+		if tp.spentOutputs == nil {
+			tp.spentOutputs = make(map[bc.Hash]bc.Hash)
+		}
+		// the first spender keeps the claim, later double spends only report
+		if _, ok := tp.spentOutputs[spend]; !ok {
+			tp.spentOutputs[spend] = tx.ID
+		}
+	}
 	for _, id := range tx.ResultIds {
 		output, err := tx.Output(*id)
 		if err != nil {
@@ -256,11 +467,75 @@ func (tp *TxPool) addTransaction(txD *TxDesc) error {
 	}
 
 	atomic.StoreInt64(&tp.lastUpdated, time.Now().Unix())
+	tp.estimator.AddTx(txD)
 	tp.msgCh <- &TxPoolMsg{TxDesc: txD, MsgType: MsgNewTx}
+	tp.notifyNewTxSubs(txD)
 	log.WithField("tx_id", tx.ID.String()).Debug("Add tx to mempool")
 	return nil
 }
 
+// ancestors return every in-pool transaction the given one transitively spends
+func (tp *TxPool) ancestors(tx *types.Tx) map[bc.Hash]bool {
+	visited := map[bc.Hash]bool{}
+	queue := tp.parentHashes(tx)
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if visited[hash] {
+			continue
+		}
+
+		visited[hash] = true
+		if parentD, ok := tp.pool[hash]; ok {
+			queue = append(queue, tp.parentHashes(parentD.Tx)...)
+		}
+	}
+	return visited
+}
+
+// checkChainDepth enforce the configured unconfirmed chain limits, the
+// dedicated ancestor/descendant bounds fall back to max_chain_depth when unset
+func (tp *TxPool) checkChainDepth(tx *types.Tx) error {
+	maxAncestors, maxDescendants := tp.maxAncestors, tp.maxDescendants
+	if maxAncestors <= 0 {
+		maxAncestors = tp.maxChainDepth
+	}
+	if maxDescendants <= 0 {
+		maxDescendants = tp.maxChainDepth
+	}
+	if maxAncestors <= 0 && maxDescendants <= 0 {
+		return nil
+	}
+
+	ancestors := tp.ancestors(tx)
+	if maxAncestors > 0 && len(ancestors) >= maxAncestors {
+		return ErrTxChainTooDeep
+	}
+	if maxDescendants <= 0 {
+		return nil
+	}
+
+	for hash := range ancestors {
+		hash := hash
+		if tp.countDescendants(&hash)+1 >= maxDescendants {
+			return ErrTxChainTooDeep
+		}
+	}
+	return nil
+}
+
+// conflictingSpends return the outpoints the given transaction double spends
+// against the pool, paired with the transactions already spending them
+func (tp *TxPool) conflictingSpends(tx *types.Tx) []*SpendConflict {
+	conflicts := []*SpendConflict{}
+	for _, spend := range tx.SpentOutputIDs {
+		if spender, ok := tp.spentOutputs[spend]; ok && spender != tx.ID {
+			conflicts = append(conflicts, &SpendConflict{OutputID: spend, TxID: spender})
+		}
+	}
+	return conflicts
+}
+
 func (tp *TxPool) checkOrphanUtxos(tx *types.Tx) ([]*bc.Hash, error) {
 	view := state.NewUtxoViewpoint()
 	if err := tp.store.GetTransactionsUtxo(view, []*bc.Tx{tx.Tx}); err != nil {
@@ -276,6 +551,139 @@ func (tp *TxPool) checkOrphanUtxos(tx *types.Tx) ([]*bc.Hash, error) {
 	return hashes, nil
 }
 
+// countDescendants return the number of in-pool transactions that transitively
+// spend outputs of the given one
+func (tp *TxPool) countDescendants(txHash *bc.Hash) int {
+	return len(tp.descendants(txHash))
+}
+
+// descendants return every in-pool transaction that transitively spends
+// outputs of the given one
+func (tp *TxPool) descendants(txHash *bc.Hash) map[bc.Hash]bool {
+	visited := map[bc.Hash]bool{}
+	queue := []bc.Hash{}
+	for child := range tp.children[*txHash] {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if visited[hash] {
+			continue
+		}
+
+		visited[hash] = true
+		for child := range tp.children[hash] {
+			queue = append(queue, child)
+		}
+	}
+	return visited
+}
+
+// milliFeeRate return the fee rate of a pool transaction in milli-neu per
+// byte, the extra precision keeps small transactions comparable
+func milliFeeRate(txD *TxDesc) uint64 {
+	if txD.Weight == 0 {
+		return 0
+	}
+	return txD.Fee * 1000 / txD.Weight
+}
+
+// packageMilliFeeRate return the combined fee rate of a transaction and its
+// in-pool descendants, so a cheap parent can't hide behind a paying child
+func (tp *TxPool) packageMilliFeeRate(txD *TxDesc) uint64 {
+	fee, weight := txD.Fee, txD.Weight
+	for hash := range tp.descendants(&txD.Tx.ID) {
+		if child, ok := tp.pool[hash]; ok {
+			fee += child.Fee
+			weight += child.Weight
+		}
+	}
+	if weight == 0 {
+		return 0
+	}
+	return fee * 1000 / weight
+}
+
+func (tp *TxPool) lowestFeeRatePackage() (*TxDesc, uint64) {
+	var victim *TxDesc
+	victimRate := uint64(0)
+	for _, txD := range tp.pool {
+		if rate := tp.packageMilliFeeRate(txD); victim == nil || rate < victimRate {
+			victim, victimRate = txD, rate
+		}
+	}
+	return victim, victimRate
+}
+
+// makeRoom evicts the lowest fee-rate packages until the incoming
+// transaction fits in the pool bounds. The incomer has to outbid what it
+// displaces, otherwise it is the one rejected, and every round of pressure
+// pushes the dynamic relay minimum up to the displaced rate.
+func (tp *TxPool) makeRoom(txD *TxDesc) error {
+	maxTxs, maxBytes := tp.maxPoolTxs, tp.maxPoolBytes
+	if maxTxs <= 0 {
+		maxTxs = maxNewTxNum
+	}
+	if maxBytes == 0 {
+		maxBytes = defaultMaxPoolBytes
+	}
+
+	for len(tp.pool) >= maxTxs || tp.poolBytes+txD.Weight > maxBytes {
+		victim, victimRate := tp.lowestFeeRatePackage()
+		if victim == nil {
+			return ErrPoolIsFull
+		}
+
+		tp.bumpMinFeeRate(victimRate)
+		if milliFeeRate(txD) <= victimRate {
+			return ErrPoolIsFull
+		}
+
+		for hash := range tp.descendants(&victim.Tx.ID) {
+			hash := hash
+			tp.removeTransaction(&hash)
+		}
+		tp.removeTransaction(&victim.Tx.ID)
+		log.WithFields(log.Fields{"tx_id": victim.Tx.ID.String(), "fee_rate": victimRate}).Debug("evicted lowest fee-rate mempool package")
+	}
+	return nil
+}
+
+// bumpMinFeeRate raises the dynamic relay minimum a notch above an evicted
+// rate, so the next wave of underpaying transactions bounces off early
+func (tp *TxPool) bumpMinFeeRate(evictedRate uint64) {
+	rate := evictedRate + evictedRate/4 + 1
+	if current := tp.currentMinFeeRate(); rate < current {
+		rate = current
+	}
+	tp.dynamicMinFeeRate = rate
+	tp.minFeeRateUpdated = time.Now()
+}
+
+// currentMinFeeRate return the dynamic relay minimum in milli-neu per byte,
+// halving for every half life that passed since the last eviction
+func (tp *TxPool) currentMinFeeRate() uint64 {
+	if tp.dynamicMinFeeRate == 0 {
+		return 0
+	}
+
+	halvings := time.Since(tp.minFeeRateUpdated) / minFeeRateHalfLife
+	if halvings >= 64 {
+		return 0
+	}
+	return tp.dynamicMinFeeRate >> uint(halvings)
+}
+
+// MinRelayFeeRate return the dynamic minimum relay fee rate in neu per
+// byte, zero while the pool is under no eviction pressure
+func (tp *TxPool) MinRelayFeeRate() uint64 {
+	tp.mtx.RLock()
+	defer tp.mtx.RUnlock()
+	return (tp.currentMinFeeRate() + 999) / 1000
+}
+
 func (tp *TxPool) orphanExpireWorker() {
 	ticker := time.NewTicker(orphanExpireScanInterval)
 	for now := range ticker.C {
@@ -316,6 +724,19 @@ func (tp *TxPool) processOrphans(txD *TxDesc) {
 	}
 }
 
+// parentHashes return the in-pool parents directly spent by a transaction
+func (tp *TxPool) parentHashes(tx *types.Tx) []bc.Hash {
+	seen := map[bc.Hash]bool{}
+	parents := []bc.Hash{}
+	for _, spend := range tx.SpentOutputIDs {
+		if parent, ok := tp.utxo[spend]; ok && !seen[parent.ID] {
+			seen[parent.ID] = true
+			parents = append(parents, parent.ID)
+		}
+	}
+	return parents
+}
+
 func (tp *TxPool) removeOrphan(hash *bc.Hash) {
 	orphan, ok := tp.orphans[*hash]
 	if !ok {
@@ -332,5 +753,10 @@ func (tp *TxPool) removeOrphan(hash *bc.Hash) {
 			delete(tp.orphansByPrev, spend)
 		}
 	}
+	if orphan.peerID != "" {
+		if tp.orphansByPeer[orphan.peerID]--; tp.orphansByPeer[orphan.peerID] <= 0 {
+			delete(tp.orphansByPeer, orphan.peerID)
+		}
+	}
 	delete(tp.orphans, *hash)
 }
@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func evictTestPool(maxTxs int, maxBytes uint64) *TxPool {
+	tp := NewTxPool(&mockStore{})
+	tp.maxPoolTxs = maxTxs
+	tp.maxPoolBytes = maxBytes
+	return tp
+}
+
+func evictTestTxDesc(seed byte, fee uint64) *TxDesc {
+	tx := types.NewTx(types.TxData{
+		SerializedSize: 100,
+		Inputs: []*types.TxInput{
+			types.NewSpendInput(nil, bc.NewHash([32]byte{seed}), *mockAssetID(seed), 1, 1, []byte{0x51}),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*mockAssetID(seed), 1, []byte{0x6a}),
+		},
+	})
+	return &TxDesc{Tx: tx, Weight: tx.SerializedSize, Fee: fee}
+}
+
+func mockAssetID(seed byte) *bc.AssetID {
+	assetID := bc.NewAssetID([32]byte{0xa0, seed})
+	return &assetID
+}
+
+func TestMempoolEviction(t *testing.T) {
+	tp := evictTestPool(2, defaultMaxPoolBytes)
+
+	cheap := evictTestTxDesc(0x01, 1)
+	paying := evictTestTxDesc(0x02, 100)
+	if err := tp.addTransaction(cheap); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.addTransaction(paying); err != nil {
+		t.Fatal(err)
+	}
+
+	// the incomer outbids the cheapest package and displaces it
+	incoming := evictTestTxDesc(0x03, 50)
+	if err := tp.addTransaction(incoming); err != nil {
+		t.Fatal(err)
+	}
+	if tp.IsTransactionInPool(&cheap.Tx.ID) {
+		t.Error("lowest fee-rate transaction survived eviction")
+	}
+	if !tp.IsTransactionInPool(&paying.Tx.ID) || !tp.IsTransactionInPool(&incoming.Tx.ID) {
+		t.Error("paying transactions evicted instead of the cheapest one")
+	}
+
+	// eviction pressure raises the dynamic relay minimum
+	if tp.MinRelayFeeRate() == 0 {
+		t.Error("eviction left the dynamic relay minimum at zero")
+	}
+	if err := tp.addTransaction(evictTestTxDesc(0x04, 1)); err != ErrBelowRelayFee {
+		t.Errorf("got error %v for an underpaying transaction, want %v", err, ErrBelowRelayFee)
+	}
+}
+
+func TestMempoolByteBound(t *testing.T) {
+	tp := evictTestPool(maxNewTxNum, 250)
+
+	if err := tp.addTransaction(evictTestTxDesc(0x01, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.addTransaction(evictTestTxDesc(0x02, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if tp.poolBytes != 200 {
+		t.Fatalf("got pool bytes %d, want 200", tp.poolBytes)
+	}
+
+	// an incomer cheaper than the lowest package bounces off a full pool
+	if err := tp.addTransaction(evictTestTxDesc(0x03, 5)); err != ErrPoolIsFull {
+		t.Errorf("got error %v for an underbidding transaction, want %v", err, ErrPoolIsFull)
+	}
+	tp.dynamicMinFeeRate = 0
+
+	if err := tp.addTransaction(evictTestTxDesc(0x04, 30)); err != nil {
+		t.Fatal(err)
+	}
+	if tp.poolBytes != 200 {
+		t.Errorf("got pool bytes %d after eviction, want 200", tp.poolBytes)
+	}
+}
+
+func TestMinRelayFeeRateDecay(t *testing.T) {
+	tp := evictTestPool(maxNewTxNum, defaultMaxPoolBytes)
+	tp.bumpMinFeeRate(8000)
+
+	full := tp.MinRelayFeeRate()
+	tp.minFeeRateUpdated = time.Now().Add(-2 * minFeeRateHalfLife)
+	if decayed := tp.MinRelayFeeRate(); decayed >= full || decayed == 0 {
+		t.Errorf("got decayed rate %d, want between 1 and %d", decayed, full)
+	}
+
+	tp.minFeeRateUpdated = time.Now().Add(-100 * minFeeRateHalfLife)
+	if rate := tp.MinRelayFeeRate(); rate != 0 {
+		t.Errorf("got rate %d long after the pressure stopped, want 0", rate)
+	}
+}
@@ -0,0 +1,143 @@
+package protocol
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bytom-gm/config"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+// mempoolFileName is where the unconfirmed transactions go between restarts,
+// one hex-encoded raw transaction per line under the node root directory
+const mempoolFileName = "mempool.dat"
+
+func mempoolFilePath() string {
+	if config.CommonConfig == nil {
+		return ""
+	}
+	return filepath.Join(config.CommonConfig.RootDir, mempoolFileName)
+}
+
+// SaveMempool writes every transaction currently in the pool to the mempool
+// file, so a restart doesn't silently drop unconfirmed transactions. It
+// returns how many transactions got persisted.
+func (c *Chain) SaveMempool() (int, error) {
+	path := mempoolFilePath()
+	if path == "" {
+		return 0, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	saved := 0
+	bw := bufio.NewWriter(file)
+	for _, txDesc := range c.txPool.GetTransactions() {
+		rawTx, err := txDesc.Tx.MarshalText()
+		if err != nil {
+			log.WithFields(log.Fields{"tx_id": txDesc.Tx.ID.String(), "error": err}).Warning("fail on marshal mempool transaction")
+			continue
+		}
+
+		fmt.Fprintf(bw, "%s\n", rawTx)
+		saved++
+	}
+
+	if err := bw.Flush(); err != nil {
+		return saved, err
+	}
+	return saved, nil
+}
+
+// LoadMempool reloads the persisted mempool file and revalidates every
+// transaction against the current chain state, transactions that no longer
+// validate are dropped. It returns how many transactions made it back into
+// the pool.
+func (c *Chain) LoadMempool() (int, error) {
+	path := mempoolFilePath()
+	if path == "" {
+		return 0, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	loaded := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 8*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		tx := &types.Tx{}
+		if err := tx.UnmarshalText(line); err != nil {
+			log.WithField("error", err).Warning("fail on unmarshal mempool transaction")
+			continue
+		}
+
+		if _, err := c.ValidateTx(tx); err != nil {
+			log.WithFields(log.Fields{"tx_id": tx.ID.String(), "error": err}).Info("drop stale mempool transaction")
+			continue
+		}
+		loaded++
+	}
+	if err := scanner.Err(); err != nil {
+		return loaded, err
+	}
+	return loaded, nil
+}
+
+// SavedMempool lists the ids of the transactions sitting in the persisted
+// mempool file without touching the live pool
+func (c *Chain) SavedMempool() ([]bc.Hash, error) {
+	txIDs := []bc.Hash{}
+	path := mempoolFilePath()
+	if path == "" {
+		return txIDs, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return txIDs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 8*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		tx := &types.Tx{}
+		if err := tx.UnmarshalText(line); err != nil {
+			continue
+		}
+		txIDs = append(txIDs, tx.ID)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return txIDs, nil
+}
@@ -74,6 +74,7 @@ func (s *mockStore) GetTransactionStatus(*bc.Hash) (*bc.TransactionStatus, error
 func (s *mockStore) GetTransactionsUtxo(*state.UtxoViewpoint, []*bc.Tx) error     { return nil }
 func (s *mockStore) GetUtxo(*bc.Hash) (*storage.UtxoEntry, error)                 { return nil, nil }
 func (s *mockStore) LoadBlockIndex(uint64) (*state.BlockIndex, error)             { return nil, nil }
+func (s *mockStore) PruneHeight() uint64                                          { return 0 }
 func (s *mockStore) SaveBlock(*types.Block, *bc.TransactionStatus) error          { return nil }
 func (s *mockStore) SaveChainStatus(*state.BlockNode, *state.UtxoViewpoint) error { return nil }
 
@@ -189,7 +190,7 @@ func TestAddOrphan(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		c.before.addOrphan(c.addOrphan, c.requireParents)
+		c.before.addOrphan(c.addOrphan, c.requireParents, "")
 		for _, orphan := range c.before.orphans {
 			orphan.expiration = time.Time{}
 		}
@@ -204,6 +205,62 @@ func TestAddOrphan(t *testing.T) {
 	}
 }
 
+func TestOrphanPeerLimit(t *testing.T) {
+	defer func(old int) { maxOrphanNumPerPeer = old }(maxOrphanNumPerPeer)
+	maxOrphanNumPerPeer = 2
+
+	tp := &TxPool{
+		orphans:       map[bc.Hash]*orphanTx{},
+		orphansByPrev: map[bc.Hash]map[bc.Hash]*orphanTx{},
+		orphansByPeer: map[string]int{},
+	}
+	for i := 0; i < maxOrphanNumPerPeer; i++ {
+		if err := tp.addOrphan(&TxDesc{Tx: testTxs[i]}, []*bc.Hash{&testTxs[i].SpentOutputIDs[0]}, "peer1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tp.addOrphan(&TxDesc{Tx: testTxs[2]}, []*bc.Hash{&testTxs[2].SpentOutputIDs[0]}, "peer1"); err != ErrPeerOrphanLimit {
+		t.Errorf("got error %v for the peer over its orphan quota, want %v", err, ErrPeerOrphanLimit)
+	}
+	if err := tp.addOrphan(&TxDesc{Tx: testTxs[2]}, []*bc.Hash{&testTxs[2].SpentOutputIDs[0]}, "peer2"); err != nil {
+		t.Errorf("got error %v for another peer, want nil", err)
+	}
+	if err := tp.addOrphan(&TxDesc{Tx: testTxs[3]}, []*bc.Hash{&testTxs[3].SpentOutputIDs[0]}, ""); err != nil {
+		t.Errorf("got error %v for a local orphan, want nil", err)
+	}
+
+	// resolving an orphan releases its slot back to the peer
+	tp.removeOrphan(&testTxs[0].ID)
+	if err := tp.addOrphan(&TxDesc{Tx: testTxs[4]}, []*bc.Hash{&testTxs[4].SpentOutputIDs[0]}, "peer1"); err != nil {
+		t.Errorf("got error %v after a slot was released, want nil", err)
+	}
+}
+
+func TestConflictingSpends(t *testing.T) {
+	tp := &TxPool{
+		pool:  map[bc.Hash]*TxDesc{},
+		utxo:  map[bc.Hash]*types.Tx{},
+		msgCh: make(chan *TxPoolMsg, 2),
+	}
+	tp.addTransaction(&TxDesc{Tx: testTxs[0]})
+
+	// testTxs[1] spends the same outpoint as testTxs[0]
+	conflicts := tp.conflictingSpends(testTxs[1])
+	if len(conflicts) != 1 {
+		t.Fatalf("got %d conflicts, want 1", len(conflicts))
+	}
+	if conflicts[0].TxID != testTxs[0].ID || conflicts[0].OutputID != testTxs[0].SpentOutputIDs[0] {
+		t.Errorf("got conflict %v, want tx %v on outpoint %v", conflicts[0], testTxs[0].ID, testTxs[0].SpentOutputIDs[0])
+	}
+
+	// removing the first spender releases the outpoint
+	tp.removeTransaction(&testTxs[0].ID)
+	if conflicts := tp.conflictingSpends(testTxs[1]); len(conflicts) != 0 {
+		t.Errorf("got %d conflicts after removal, want 0", len(conflicts))
+	}
+}
+
 func TestAddTransaction(t *testing.T) {
 	cases := []struct {
 		before *TxPool
@@ -435,3 +492,46 @@ func TestRemoveOrphan(t *testing.T) {
 		}
 	}
 }
+
+func TestChainDepthLimit(t *testing.T) {
+	tp := &TxPool{
+		pool: map[bc.Hash]*TxDesc{
+			testTxs[2].ID: {Tx: testTxs[2]},
+			testTxs[3].ID: {Tx: testTxs[3]},
+		},
+		utxo: map[bc.Hash]*types.Tx{
+			testTxs[3].SpentOutputIDs[0]: testTxs[2],
+			testTxs[4].SpentOutputIDs[0]: testTxs[3],
+		},
+		children: map[bc.Hash]map[bc.Hash]bool{
+			testTxs[2].ID: {testTxs[3].ID: true},
+		},
+		msgCh:         make(chan *TxPoolMsg, 1),
+		maxChainDepth: 2,
+	}
+
+	if err := tp.addTransaction(&TxDesc{Tx: testTxs[4]}); err != ErrTxChainTooDeep {
+		t.Errorf("got error %v, want %v", err, ErrTxChainTooDeep)
+	}
+
+	tp.maxChainDepth = 3
+	if err := tp.addTransaction(&TxDesc{Tx: testTxs[4]}); err != nil {
+		t.Errorf("got error %v, want nil", err)
+	}
+
+	ancestors, descendants, err := tp.TxChainStats(&testTxs[2].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ancestors != 0 || descendants != 2 {
+		t.Errorf("got ancestors %d descendants %d, want 0 2", ancestors, descendants)
+	}
+
+	ancestors, descendants, err = tp.TxChainStats(&testTxs[4].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ancestors != 2 || descendants != 0 {
+		t.Errorf("got ancestors %d descendants %d, want 2 0", ancestors, descendants)
+	}
+}
@@ -188,3 +188,21 @@ func TestValidateBlockHeader(t *testing.T) {
 		}
 	}
 }
+
+func TestScriptsAssumedValid(t *testing.T) {
+	originAssumeValid := consensus.ActiveNetParams.AssumeValid
+	defer func() { consensus.ActiveNetParams.AssumeValid = originAssumeValid }()
+
+	consensus.ActiveNetParams.AssumeValid = nil
+	if scriptsAssumedValid(&bc.Block{BlockHeader: &bc.BlockHeader{Height: 1}}) {
+		t.Error("no checkpoint should keep scripts enforced")
+	}
+
+	consensus.ActiveNetParams.AssumeValid = &consensus.Checkpoint{Height: 100, Hash: bc.Hash{V0: 1}}
+	if !scriptsAssumedValid(&bc.Block{BlockHeader: &bc.BlockHeader{Height: 100}}) {
+		t.Error("blocks under the checkpoint should skip scripts")
+	}
+	if scriptsAssumedValid(&bc.Block{BlockHeader: &bc.BlockHeader{Height: 101}}) {
+		t.Error("blocks over the checkpoint should run scripts")
+	}
+}
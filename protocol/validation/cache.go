@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// maxCachedValidations bounds the validation cache, at roughly 100 bytes per
+// entry the cache tops out around a few megabytes
+var maxCachedValidations = 50000
+
+// txCacheKey identifies one validation outcome. The witness hash covers the
+// signature arguments the transaction ID leaves out, and the block height
+// pins the only context the VM can observe, so a cached result is never
+// replayed under a context where the scripts would run differently.
+type txCacheKey struct {
+	witnessHash bc.Hash
+	blockHeight uint64
+}
+
+type txCacheEntry struct {
+	gasStatus GasState
+	err       error
+}
+
+// txValidationCache is an LRU of full transaction validation results shared
+// between mempool admission and block validation
+type txValidationCache struct {
+	mtx    sync.Mutex
+	lru    *lru.Cache
+	hits   uint64
+	misses uint64
+}
+
+var txCache = &txValidationCache{lru: lru.New(maxCachedValidations)}
+
+// WitnessHash return the witness-inclusive hash of a transaction: the ID plus
+// every input witness argument in input order. Two transactions with the same
+// ID but different signatures hash differently.
+func WitnessHash(tx *bc.Tx) bc.Hash {
+	hasher := sm3.New()
+	tx.ID.WriteTo(hasher)
+	for _, inputID := range tx.InputIDs {
+		inputID.WriteTo(hasher)
+		var args [][]byte
+		switch e := tx.Entries[inputID].(type) {
+		case *bc.Spend:
+			args = e.WitnessArguments
+		case *bc.Issuance:
+			args = e.WitnessArguments
+		}
+		for _, arg := range args {
+			hasher.Write(arg)
+		}
+	}
+	return bc.NewHash(sm3.Sum256(hasher.Sum(nil)))
+}
+
+func (c *txValidationCache) lookup(key txCacheKey) (*GasState, error, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	v, ok := c.lru.Get(key)
+	if !ok {
+		c.misses++
+		return nil, nil, false
+	}
+
+	c.hits++
+	gasStatus := v.(*txCacheEntry).gasStatus
+	return &gasStatus, v.(*txCacheEntry).err, true
+}
+
+func (c *txValidationCache) store(key txCacheKey, gasStatus *GasState, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.lru.Add(key, &txCacheEntry{gasStatus: *gasStatus, err: err})
+}
+
+// TxCacheStats return the validation cache hit/miss counters and its current
+// and maximum entry counts
+func TxCacheStats() (hits, misses uint64, entries, maxEntries int) {
+	txCache.mtx.Lock()
+	defer txCache.mtx.Unlock()
+	return txCache.hits, txCache.misses, txCache.lru.Len(), maxCachedValidations
+}
@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestTxValidationCache(t *testing.T) {
+	tx := types.MapTx(&types.TxData{
+		SerializedSize: 1,
+		Inputs: []*types.TxInput{
+			mockGasTxInput(),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*consensus.BTMAssetID, 1, []byte{0x6a}),
+		},
+	})
+	block := &bc.Block{BlockHeader: &bc.BlockHeader{Height: 333}}
+
+	firstStatus, firstErr := ValidateTx(tx, block)
+	hits, _, _, _ := TxCacheStats()
+
+	cachedStatus, cachedErr := ValidateTx(tx, block)
+	if newHits, _, _, _ := TxCacheStats(); newHits != hits+1 {
+		t.Errorf("got %d hits after revalidation, want %d", newHits, hits+1)
+	}
+	if (firstErr == nil) != (cachedErr == nil) || *firstStatus != *cachedStatus {
+		t.Errorf("cached result (%v, %v) differs from first validation (%v, %v)", cachedStatus, cachedErr, firstStatus, firstErr)
+	}
+
+	// a different block height is a different VM context, not a hit
+	otherBlock := &bc.Block{BlockHeader: &bc.BlockHeader{Height: 334}}
+	hits, _, _, _ = TxCacheStats()
+	ValidateTx(tx, otherBlock)
+	if newHits, _, _, _ := TxCacheStats(); newHits != hits {
+		t.Errorf("got a cache hit across block heights")
+	}
+}
+
+func TestWitnessHashCoversArguments(t *testing.T) {
+	newTx := func(arg byte) *bc.Tx {
+		return types.MapTx(&types.TxData{
+			SerializedSize: 1,
+			Inputs: []*types.TxInput{
+				types.NewSpendInput([][]byte{{arg}}, bc.NewHash([32]byte{0x01}), *consensus.BTMAssetID, 1, 1, []byte{0x51}),
+			},
+			Outputs: []*types.TxOutput{
+				types.NewTxOutput(*consensus.BTMAssetID, 1, []byte{0x6a}),
+			},
+		})
+	}
+
+	tx1, tx2 := newTx(0x01), newTx(0x02)
+	if tx1.ID != tx2.ID {
+		t.Fatal("witness arguments unexpectedly changed the transaction ID")
+	}
+	if WitnessHash(tx1) == WitnessHash(tx2) {
+		t.Error("transactions with different witnesses share a witness hash")
+	}
+}
@@ -0,0 +1,75 @@
+package validation
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// validateTxWorkers is the number of goroutines block validation spreads
+// transaction validation over
+var validateTxWorkers = runtime.NumCPU()
+
+// txValidateResult carries the outcome of validating one block transaction
+type txValidateResult struct {
+	gasStatus *GasState
+	err       error
+}
+
+// txValidateStages assigns every transaction a stage such that a transaction
+// spending an output created earlier in the same block lands in a later
+// stage than its parent. Transactions inside one stage are independent and
+// can be validated concurrently.
+func txValidateStages(txs []*bc.Tx) ([]int, int) {
+	stages := make([]int, len(txs))
+	producedBy := map[bc.Hash]int{}
+	maxStage := 0
+
+	for i, tx := range txs {
+		for _, spentID := range tx.SpentOutputIDs {
+			if parent, ok := producedBy[spentID]; ok && stages[parent]+1 > stages[i] {
+				stages[i] = stages[parent] + 1
+			}
+		}
+		for _, resultID := range tx.TxHeader.ResultIds {
+			producedBy[*resultID] = i
+		}
+		if stages[i] > maxStage {
+			maxStage = stages[i]
+		}
+	}
+	return stages, maxStage
+}
+
+// validateTxs validates the block transactions over a worker pool, stage by
+// stage so intra-block spend dependencies hold, and returns the results in
+// block order so error reporting stays deterministic
+func validateTxs(txs []*bc.Tx, b *bc.Block, skipScripts bool) []*txValidateResult {
+	results := make([]*txValidateResult, len(txs))
+	stages, maxStage := txValidateStages(txs)
+
+	for stage := 0; stage <= maxStage; stage++ {
+		jobs := make(chan int, len(txs))
+		for i := range txs {
+			if stages[i] == stage {
+				jobs <- i
+			}
+		}
+		close(jobs)
+
+		var wg sync.WaitGroup
+		for worker := 0; worker < validateTxWorkers; worker++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					gasStatus, err := validateTx(txs[i], b, skipScripts)
+					results[i] = &txValidateResult{gasStatus: gasStatus, err: err}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	return results
+}
@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/bytom-gm/protocol/bc"
+)
+
+func TestTxValidateStages(t *testing.T) {
+	outputA := bc.NewHash([32]byte{0x0a})
+	outputB := bc.NewHash([32]byte{0x0b})
+	external := bc.NewHash([32]byte{0xff})
+
+	cases := []struct {
+		desc         string
+		txs          []*bc.Tx
+		wantStages   []int
+		wantMaxStage int
+	}{
+		{
+			desc: "independent txs share stage zero",
+			txs: []*bc.Tx{
+				{TxHeader: &bc.TxHeader{}, SpentOutputIDs: []bc.Hash{external}},
+				{TxHeader: &bc.TxHeader{}},
+			},
+			wantStages:   []int{0, 0},
+			wantMaxStage: 0,
+		},
+		{
+			desc: "tx spending an in-block output moves one stage later",
+			txs: []*bc.Tx{
+				{TxHeader: &bc.TxHeader{ResultIds: []*bc.Hash{&outputA}}},
+				{TxHeader: &bc.TxHeader{}, SpentOutputIDs: []bc.Hash{outputA}},
+			},
+			wantStages:   []int{0, 1},
+			wantMaxStage: 1,
+		},
+		{
+			desc: "spend chains keep stacking stages",
+			txs: []*bc.Tx{
+				{TxHeader: &bc.TxHeader{ResultIds: []*bc.Hash{&outputA}}},
+				{TxHeader: &bc.TxHeader{ResultIds: []*bc.Hash{&outputB}}, SpentOutputIDs: []bc.Hash{outputA}},
+				{TxHeader: &bc.TxHeader{}, SpentOutputIDs: []bc.Hash{outputB, external}},
+				{TxHeader: &bc.TxHeader{}},
+			},
+			wantStages:   []int{0, 1, 2, 0},
+			wantMaxStage: 2,
+		},
+	}
+
+	for _, c := range cases {
+		stages, maxStage := txValidateStages(c.txs)
+		if maxStage != c.wantMaxStage {
+			t.Errorf("%s: got max stage %d want %d", c.desc, maxStage, c.wantMaxStage)
+		}
+		for i, stage := range stages {
+			if stage != c.wantStages[i] {
+				t.Errorf("%s: tx %d got stage %d want %d", c.desc, i, stage, c.wantStages[i])
+			}
+		}
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"math"
 
 	"github.com/bytom-gm/consensus"
-	"github.com/bytom-gm/consensus/segwit"
 	"github.com/bytom-gm/errors"
 	"github.com/bytom-gm/math/checked"
 	"github.com/bytom-gm/protocol/bc"
@@ -17,7 +16,6 @@ var (
 	ErrTxVersion                 = errors.New("invalid transaction version")
 	ErrWrongTransactionSize      = errors.New("invalid transaction size")
 	ErrBadTimeRange              = errors.New("invalid transaction time range")
-	ErrNotStandardTx             = errors.New("not standard transaction")
 	ErrWrongCoinbaseTransaction  = errors.New("wrong coinbase transaction")
 	ErrWrongCoinbaseAsset        = errors.New("wrong coinbase assetID")
 	ErrCoinbaseArbitraryOversize = errors.New("coinbase arbitrary size is larger than limit")
@@ -108,6 +106,10 @@ type validationState struct {
 	sourcePos uint64            // The source position, for validate ValueSources
 	destPos   uint64            // The destination position, for validate ValueDestinations
 	cache     map[bc.Hash]error // Memoized per-entry validation results
+
+	// skipScripts trusts programs under the assume-valid checkpoint
+	// instead of running them
+	skipScripts bool
 }
 
 func checkValid(vs *validationState, e bc.Entry) (err error) {
@@ -229,12 +231,14 @@ func checkValid(vs *validationState, e bc.Entry) (err error) {
 			return errors.WithDetailf(ErrMismatchedAssetID, "asset ID is %x, issuance wants %x", computedAssetID.Bytes(), e.Value.AssetId.Bytes())
 		}
 
-		gasLeft, err := vm.Verify(NewTxVMContext(vs, e, e.WitnessAssetDefinition.IssuanceProgram, e.WitnessArguments), vs.gasStatus.GasLeft)
-		if err != nil {
-			return errors.Wrap(err, "checking issuance program")
-		}
-		if err = vs.gasStatus.updateUsage(gasLeft); err != nil {
-			return err
+		if !vs.skipScripts {
+			gasLeft, err := vm.Verify(NewTxVMContext(vs, e, e.WitnessAssetDefinition.IssuanceProgram, e.WitnessArguments), vs.gasStatus.GasLeft)
+			if err != nil {
+				return errors.Wrap(err, "checking issuance program")
+			}
+			if err = vs.gasStatus.updateUsage(gasLeft); err != nil {
+				return err
+			}
 		}
 
 		destVS := *vs
@@ -252,12 +256,14 @@ func checkValid(vs *validationState, e bc.Entry) (err error) {
 			return errors.Wrap(err, "getting spend prevout")
 		}
 
-		gasLeft, err := vm.Verify(NewTxVMContext(vs, e, spentOutput.ControlProgram, e.WitnessArguments), vs.gasStatus.GasLeft)
-		if err != nil {
-			return errors.Wrap(err, "checking control program")
-		}
-		if err = vs.gasStatus.updateUsage(gasLeft); err != nil {
-			return err
+		if !vs.skipScripts {
+			gasLeft, err := vm.Verify(NewTxVMContext(vs, e, spentOutput.ControlProgram, e.WitnessArguments), vs.gasStatus.GasLeft)
+			if err != nil {
+				return errors.Wrap(err, "checking control program")
+			}
+			if err = vs.gasStatus.updateUsage(gasLeft); err != nil {
+				return err
+			}
 		}
 
 		eq, err := spentOutput.Source.Value.Equal(e.WitnessDestination.Value)
@@ -440,40 +446,6 @@ func checkValidDest(vs *validationState, vd *bc.ValueDestination) error {
 	return nil
 }
 
-func checkStandardTx(tx *bc.Tx) error {
-	for _, id := range tx.GasInputIDs {
-		spend, err := tx.Spend(id)
-		if err != nil {
-			return err
-		}
-		spentOutput, err := tx.Output(*spend.SpentOutputId)
-		if err != nil {
-			return err
-		}
-
-		if !segwit.IsP2WScript(spentOutput.ControlProgram.Code) {
-			return ErrNotStandardTx
-		}
-	}
-
-	for _, id := range tx.ResultIds {
-		e, ok := tx.Entries[*id]
-		if !ok {
-			return errors.Wrapf(bc.ErrMissingEntry, "id %x", id.Bytes())
-		}
-
-		output, ok := e.(*bc.Output)
-		if !ok || *output.Source.Value.AssetId != *consensus.BTMAssetID {
-			continue
-		}
-
-		if !segwit.IsP2WScript(output.ControlProgram.Code) {
-			return ErrNotStandardTx
-		}
-	}
-	return nil
-}
-
 func checkTimeRange(tx *bc.Tx, block *bc.Block) error {
 	if tx.TimeRange == 0 {
 		return nil
@@ -487,6 +459,12 @@ func checkTimeRange(tx *bc.Tx, block *bc.Block) error {
 
 // ValidateTx validates a transaction.
 func ValidateTx(tx *bc.Tx, block *bc.Block) (*GasState, error) {
+	return validateTx(tx, block, false)
+}
+
+// validateTx carries the skipScripts flag of the assume-valid sync mode,
+// everything but the program runs stays enforced
+func validateTx(tx *bc.Tx, block *bc.Block, skipScripts bool) (*GasState, error) {
 	gasStatus := &GasState{GasValid: false}
 	if block.Version == 1 && tx.Version != 1 {
 		return gasStatus, errors.WithDetailf(ErrTxVersion, "block version %d, transaction version %d", block.Version, tx.Version)
@@ -497,16 +475,38 @@ func ValidateTx(tx *bc.Tx, block *bc.Block) (*GasState, error) {
 	if err := checkTimeRange(tx, block); err != nil {
 		return gasStatus, err
 	}
-	if err := checkStandardTx(tx); err != nil {
-		return gasStatus, err
+
+	// the coinbase rule depends on the tx position in the block, and assume
+	// valid results must not poison full validation, so neither is cached
+	cacheable := !skipScripts && !hasCoinbaseInput(tx)
+	cacheKey := txCacheKey{witnessHash: WitnessHash(tx), blockHeight: block.Height}
+	if cacheable {
+		if cachedStatus, err, ok := txCache.lookup(cacheKey); ok {
+			return cachedStatus, err
+		}
 	}
 
 	vs := &validationState{
-		block:     block,
-		tx:        tx,
-		entryID:   tx.ID,
-		gasStatus: gasStatus,
-		cache:     make(map[bc.Hash]error),
+		block:       block,
+		tx:          tx,
+		entryID:     tx.ID,
+		gasStatus:   gasStatus,
+		cache:       make(map[bc.Hash]error),
+		skipScripts: skipScripts,
+	}
+	err := checkValid(vs, tx.TxHeader)
+	if cacheable {
+		txCache.store(cacheKey, gasStatus, err)
+	}
+	return gasStatus, err
+}
+
+// hasCoinbaseInput report whether the transaction carries a coinbase entry
+func hasCoinbaseInput(tx *bc.Tx) bool {
+	for _, inputID := range tx.InputIDs {
+		if _, ok := tx.Entries[inputID].(*bc.Coinbase); ok {
+			return true
+		}
 	}
-	return vs.gasStatus, checkValid(vs, tx.TxHeader)
+	return false
 }
@@ -0,0 +1,58 @@
+package protocol
+
+import (
+	"sort"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/state"
+)
+
+// SoftFork summarizes one versionbits deployment at the current best height
+type SoftFork struct {
+	Name          string
+	Bit           uint8
+	StartHeight   uint64
+	TimeoutHeight uint64
+	State         state.SoftForkState
+	SignalCount   uint64
+	WindowStart   uint64
+}
+
+// SoftForks runs the versionbits state machine over the main chain for
+// every deployment of the active network, sorted by rule name
+func (c *Chain) SoftForks() []*SoftFork {
+	c.cond.L.Lock()
+	best := c.bestNode
+	c.cond.L.Unlock()
+
+	forks := []*SoftFork{}
+	for name, d := range consensus.ActiveNetParams.Deployments {
+		count, windowStart := state.SoftForkWindowSignals(best, d)
+		forks = append(forks, &SoftFork{
+			Name:          name,
+			Bit:           d.Bit,
+			StartHeight:   d.StartHeight,
+			TimeoutHeight: d.TimeoutHeight,
+			State:         state.SoftForkStatus(best, d),
+			SignalCount:   count,
+			WindowStart:   windowStart,
+		})
+	}
+
+	sort.Slice(forks, func(i, j int) bool { return forks[i].Name < forks[j].Name })
+	return forks
+}
+
+// SoftForkActive reports whether the named deployment is active for the
+// next block, consensus features gate on it for a safe activation path
+func (c *Chain) SoftForkActive(name string) bool {
+	d := consensus.ActiveNetParams.Deployments[name]
+	if d == nil {
+		return false
+	}
+
+	c.cond.L.Lock()
+	best := c.bestNode
+	c.cond.L.Unlock()
+	return state.SoftForkStatus(best, d) == state.SoftForkActive
+}
@@ -0,0 +1,91 @@
+package vm
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/bytom-gm/errors"
+)
+
+// oracleVerify is an independently written execution loop kept as a
+// differential oracle for Verify. It must stay semantically identical while
+// the production path is free to be rewritten for performance; the fuzz
+// harness below asserts both agree on verdict and gas for random programs.
+func oracleVerify(context *Context, gasLimit int64) (gasLeft int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rErr, ok := r.(error); ok {
+				err = errors.Sub(ErrUnexpected, rErr)
+			} else {
+				err = errors.Wrap(ErrUnexpected, r)
+			}
+		}
+	}()
+
+	if context.VMVersion != 1 {
+		return gasLimit, ErrUnsupportedVM
+	}
+
+	vm := &virtualMachine{
+		expansionReserved: context.TxVersion != nil && *context.TxVersion == 1,
+		program:           context.Code,
+		runLimit:          gasLimit,
+		context:           context,
+	}
+	for i, arg := range context.Arguments {
+		if err := vm.push(arg, false); err != nil {
+			return vm.runLimit, errors.Wrapf(err, "pushing initial argument %d", i)
+		}
+	}
+
+	// drive execution one instruction at a time instead of delegating to run
+	for vm.pc = 0; vm.pc < uint32(len(vm.program)); {
+		if err := vm.step(); err != nil {
+			return vm.runLimit, err
+		}
+	}
+	if vm.falseResult() {
+		return vm.runLimit, ErrFalseVMResult
+	}
+	return vm.runLimit, nil
+}
+
+func TestVerifyDifferentialQuickCheck(t *testing.T) {
+	const gasLimit = int64(10000)
+
+	f := func(program []byte, witnesses [][]byte) (ok bool) {
+		defer func() {
+			if err := recover(); err != nil {
+				t.Log(decompile(program))
+				for i := range witnesses {
+					t.Logf("witness %d: %x\n", i, witnesses[i])
+				}
+				t.Log(err)
+				ok = false
+			}
+		}()
+
+		gotGas, gotErr := Verify(&Context{VMVersion: 1, Code: program, Arguments: witnesses}, gasLimit)
+		wantGas, wantErr := oracleVerify(&Context{VMVersion: 1, Code: program, Arguments: witnesses}, gasLimit)
+		if gotGas != wantGas || errors.Root(gotErr) != errors.Root(wantErr) {
+			t.Errorf("divergence on %s:\n\tgot  gas %d err %v\n\twant gas %d err %v",
+				decompile(program), gotGas, gotErr, wantGas, wantErr)
+			return false
+		}
+
+		// a program that fits in the budget must consume the same gas under a
+		// larger budget, gas cost can't depend on the remaining allowance
+		if gotErr == nil {
+			bigGas, bigErr := Verify(&Context{VMVersion: 1, Code: program, Arguments: witnesses}, 2*gasLimit)
+			if bigErr != nil || 2*gasLimit-bigGas != gasLimit-gotGas {
+				t.Errorf("budget dependent gas on %s:\n\tused %d at limit %d, used %d at limit %d (err %v)",
+					decompile(program), gasLimit-gotGas, gasLimit, 2*gasLimit-bigGas, 2*gasLimit, bigErr)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,235 @@
+package vmutil
+
+import (
+	"sort"
+
+	"github.com/bytom-gm/crypto/sm2"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/vm"
+)
+
+// pre-define template errors
+var (
+	ErrUnknownTemplate  = errors.New("unknown script template")
+	ErrBadTemplateParam = errors.New("bad script template parameter")
+)
+
+// TemplateParam documents one parameter of a script template
+type TemplateParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TemplateArgs carries the parameters used to instantiate a script template
+type TemplateArgs struct {
+	Pubkeys     []sm2.PubKey
+	Quorum      int
+	Hash        []byte
+	BlockHeight int64
+}
+
+// ScriptTemplate is a named, versioned standard script. Callers reference it
+// by ID with typed parameters instead of submitting raw program bytes, the
+// version is part of the ID so the bytecode of a published template never
+// changes under the callers
+type ScriptTemplate struct {
+	ID          string          `json:"id"`
+	Description string          `json:"description"`
+	Params      []TemplateParam `json:"params"`
+	build       func(args *TemplateArgs) ([]byte, error)
+}
+
+var scriptTemplates = map[string]*ScriptTemplate{
+	"multisig-v1": {
+		ID:          "multisig-v1",
+		Description: "m-of-n signature program",
+		Params: []TemplateParam{
+			{Name: "pubkeys", Type: "list(pubkey)"},
+			{Name: "quorum", Type: "integer"},
+		},
+		build: func(args *TemplateArgs) ([]byte, error) {
+			return P2SPMultiSigProgram(args.Pubkeys, args.Quorum)
+		},
+	},
+	"escrow-v1": {
+		ID:          "escrow-v1",
+		Description: "2-of-3 escrow between buyer, seller and arbiter",
+		Params: []TemplateParam{
+			{Name: "pubkeys", Type: "list(pubkey), buyer seller arbiter"},
+		},
+		build: func(args *TemplateArgs) ([]byte, error) {
+			if len(args.Pubkeys) != 3 {
+				return nil, errors.WithDetail(ErrBadTemplateParam, "escrow needs buyer, seller and arbiter pubkeys")
+			}
+			return P2SPMultiSigProgram(args.Pubkeys, 2)
+		},
+	},
+	"htlc-v1": {
+		ID:          "htlc-v1",
+		Description: "hash time locked contract, recipient claims with the hash preimage, sender refunds after the block height",
+		Params: []TemplateParam{
+			{Name: "pubkeys", Type: "list(pubkey), sender recipient"},
+			{Name: "hash", Type: "hash, sm3 of the preimage"},
+			{Name: "block_height", Type: "integer"},
+		},
+		build: func(args *TemplateArgs) ([]byte, error) {
+			if len(args.Pubkeys) != 2 {
+				return nil, errors.WithDetail(ErrBadTemplateParam, "htlc needs sender and recipient pubkeys")
+			}
+			return HTLCProgram(args.Pubkeys[0], args.Pubkeys[1], args.Hash, args.BlockHeight)
+		},
+	},
+	"regulated-v1": {
+		ID:          "regulated-v1",
+		Description: "regulated asset holding, the owner spends normally and the issuer admin key can seize the output",
+		Params: []TemplateParam{
+			{Name: "pubkeys", Type: "list(pubkey), owner admin"},
+		},
+		build: func(args *TemplateArgs) ([]byte, error) {
+			if len(args.Pubkeys) != 2 {
+				return nil, errors.WithDetail(ErrBadTemplateParam, "regulated holding needs owner and admin pubkeys")
+			}
+			return RegulatedProgram(args.Pubkeys[0], args.Pubkeys[1])
+		},
+	},
+	"vault-v1": {
+		ID:          "vault-v1",
+		Description: "time locked vault, owner spends after the block height, recovery key spends any time",
+		Params: []TemplateParam{
+			{Name: "pubkeys", Type: "list(pubkey), owner recovery"},
+			{Name: "block_height", Type: "integer"},
+		},
+		build: func(args *TemplateArgs) ([]byte, error) {
+			if len(args.Pubkeys) != 2 {
+				return nil, errors.WithDetail(ErrBadTemplateParam, "vault needs owner and recovery pubkeys")
+			}
+			return VaultProgram(args.Pubkeys[0], args.Pubkeys[1], args.BlockHeight)
+		},
+	},
+}
+
+// GetScriptTemplate look up a registered template by ID
+func GetScriptTemplate(id string) (*ScriptTemplate, error) {
+	template, ok := scriptTemplates[id]
+	if !ok {
+		return nil, errors.WithDetailf(ErrUnknownTemplate, "template: %s", id)
+	}
+	return template, nil
+}
+
+// ListScriptTemplates return every registered template sorted by ID
+func ListScriptTemplates() []*ScriptTemplate {
+	templates := make([]*ScriptTemplate, 0, len(scriptTemplates))
+	for _, template := range scriptTemplates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates
+}
+
+// BuildTemplate instantiate a registered template with the given parameters
+func BuildTemplate(id string, args *TemplateArgs) ([]byte, error) {
+	template, err := GetScriptTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	return template.build(args)
+}
+
+// HTLCProgram generates the hash time locked contract script. The last
+// witness argument selects the clause: true for the recipient claim with
+// [preimage, sig], false for the sender refund with [sig] after blockHeight
+func HTLCProgram(sender, recipient sm2.PubKey, hash []byte, blockHeight int64) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, errors.WithDetail(ErrBadTemplateParam, "htlc hash must be 32 bytes")
+	}
+	if blockHeight <= 0 {
+		return nil, errors.WithDetail(ErrBadTemplateParam, "htlc block height must be positive")
+	}
+
+	builder := NewBuilder()
+	endTarget := builder.NewJumpTarget()
+	claimTarget := builder.NewJumpTarget()
+
+	builder.AddJumpIf(claimTarget)
+	// refund clause, stack is [... sig]
+	builder.AddInt64(blockHeight)
+	builder.AddOp(vm.OP_BLOCKHEIGHT)
+	builder.AddOp(vm.OP_LESSTHANOREQUAL)
+	builder.AddOp(vm.OP_VERIFY)
+	builder.AddOp(vm.OP_TXSIGHASH)
+	builder.AddData(sender)
+	builder.AddOp(vm.OP_CHECKSIG)
+	builder.AddJump(endTarget)
+
+	builder.SetJumpTarget(claimTarget)
+	// claim clause, stack is [... preimage sig]
+	builder.AddOp(vm.OP_SWAP)
+	builder.AddOp(vm.OP_SM3)
+	builder.AddData(hash)
+	builder.AddOp(vm.OP_EQUALVERIFY)
+	builder.AddOp(vm.OP_TXSIGHASH)
+	builder.AddData(recipient)
+	builder.AddOp(vm.OP_CHECKSIG)
+
+	builder.SetJumpTarget(endTarget)
+	return builder.Build()
+}
+
+// RegulatedProgram generates the holding script for regulated assets. The
+// last witness argument selects the clause: true for the admin seizure with
+// [sig], false for the regular owner spend with [sig]
+func RegulatedProgram(owner, admin sm2.PubKey) ([]byte, error) {
+	builder := NewBuilder()
+	endTarget := builder.NewJumpTarget()
+	adminTarget := builder.NewJumpTarget()
+
+	builder.AddJumpIf(adminTarget)
+	// owner clause, stack is [... sig]
+	builder.AddOp(vm.OP_TXSIGHASH)
+	builder.AddData(owner)
+	builder.AddOp(vm.OP_CHECKSIG)
+	builder.AddJump(endTarget)
+
+	builder.SetJumpTarget(adminTarget)
+	// admin seizure clause, stack is [... sig]
+	builder.AddOp(vm.OP_TXSIGHASH)
+	builder.AddData(admin)
+	builder.AddOp(vm.OP_CHECKSIG)
+
+	builder.SetJumpTarget(endTarget)
+	return builder.Build()
+}
+
+// VaultProgram generates the time locked vault script. The last witness
+// argument selects the clause: true for the owner spend with [sig] after
+// lockHeight, false for the recovery sweep with [sig] at any time
+func VaultProgram(owner, recovery sm2.PubKey, lockHeight int64) ([]byte, error) {
+	if lockHeight <= 0 {
+		return nil, errors.WithDetail(ErrBadTemplateParam, "vault lock height must be positive")
+	}
+
+	builder := NewBuilder()
+	endTarget := builder.NewJumpTarget()
+	ownerTarget := builder.NewJumpTarget()
+
+	builder.AddJumpIf(ownerTarget)
+	// recovery clause, stack is [... sig]
+	builder.AddOp(vm.OP_TXSIGHASH)
+	builder.AddData(recovery)
+	builder.AddOp(vm.OP_CHECKSIG)
+	builder.AddJump(endTarget)
+
+	builder.SetJumpTarget(ownerTarget)
+	// owner clause, stack is [... sig]
+	builder.AddInt64(lockHeight)
+	builder.AddOp(vm.OP_BLOCKHEIGHT)
+	builder.AddOp(vm.OP_LESSTHANOREQUAL)
+	builder.AddOp(vm.OP_VERIFY)
+	builder.AddOp(vm.OP_TXSIGHASH)
+	builder.AddData(owner)
+	builder.AddOp(vm.OP_CHECKSIG)
+
+	builder.SetJumpTarget(endTarget)
+	return builder.Build()
+}
@@ -0,0 +1,107 @@
+package vmutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bytom-gm/crypto/sm2"
+	"github.com/bytom-gm/errors"
+)
+
+func TestBuildTemplate(t *testing.T) {
+	pubkey := make(sm2.PubKey, sm2.PubKeySize)
+	hash := make([]byte, 32)
+
+	cases := []struct {
+		id      string
+		args    *TemplateArgs
+		wantErr error
+	}{
+		{
+			id:   "multisig-v1",
+			args: &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey, pubkey}, Quorum: 2},
+		},
+		{
+			id:   "escrow-v1",
+			args: &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey, pubkey, pubkey}},
+		},
+		{
+			id:      "escrow-v1",
+			args:    &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey}},
+			wantErr: ErrBadTemplateParam,
+		},
+		{
+			id:   "htlc-v1",
+			args: &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey, pubkey}, Hash: hash, BlockHeight: 1000},
+		},
+		{
+			id:      "htlc-v1",
+			args:    &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey, pubkey}, Hash: hash[1:], BlockHeight: 1000},
+			wantErr: ErrBadTemplateParam,
+		},
+		{
+			id:   "regulated-v1",
+			args: &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey, pubkey}},
+		},
+		{
+			id:      "regulated-v1",
+			args:    &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey}},
+			wantErr: ErrBadTemplateParam,
+		},
+		{
+			id:   "vault-v1",
+			args: &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey, pubkey}, BlockHeight: 1000},
+		},
+		{
+			id:      "vault-v1",
+			args:    &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey, pubkey}},
+			wantErr: ErrBadTemplateParam,
+		},
+		{
+			id:      "no-such-template",
+			args:    &TemplateArgs{},
+			wantErr: ErrUnknownTemplate,
+		},
+	}
+
+	for i, c := range cases {
+		program, err := BuildTemplate(c.id, c.args)
+		if errors.Root(err) != c.wantErr {
+			t.Errorf("case %d: got error %v want %v", i, err, c.wantErr)
+			continue
+		}
+		if c.wantErr == nil && len(program) == 0 {
+			t.Errorf("case %d: got empty program", i)
+		}
+	}
+}
+
+func TestTemplateDeterministic(t *testing.T) {
+	pubkey := make(sm2.PubKey, sm2.PubKeySize)
+	hash := make([]byte, 32)
+	args := &TemplateArgs{Pubkeys: []sm2.PubKey{pubkey, pubkey}, Hash: hash, BlockHeight: 1000}
+
+	first, err := BuildTemplate("htlc-v1", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := BuildTemplate("htlc-v1", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("same template and parameters should build identical bytecode")
+	}
+}
+
+func TestListScriptTemplates(t *testing.T) {
+	templates := ListScriptTemplates()
+	if len(templates) != len(scriptTemplates) {
+		t.Errorf("got %d templates, want %d", len(templates), len(scriptTemplates))
+	}
+	for i := 1; i < len(templates); i++ {
+		if templates[i-1].ID >= templates[i].ID {
+			t.Error("templates should be sorted by ID")
+		}
+	}
+}
@@ -192,7 +192,7 @@ func (ctx *walletTestContext) update(block *types.Block) error {
 }
 
 func (ctx *walletTestContext) getBalance(accountAlias string, assetAlias string) (uint64, error) {
-	balances, _ := ctx.Wallet.GetAccountBalances("", "")
+	balances, _ := ctx.Wallet.GetAccountBalances("", "", false)
 	for _, balance := range balances {
 		if balance.Alias == accountAlias && balance.AssetAlias == assetAlias {
 			return balance.Amount, nil
@@ -203,7 +203,7 @@ func (ctx *walletTestContext) getBalance(accountAlias string, assetAlias string)
 
 func (ctx *walletTestContext) getAccBalances() map[string]map[string]uint64 {
 	accBalances := make(map[string]map[string]uint64)
-	balances, _ := ctx.Wallet.GetAccountBalances("", "")
+	balances, _ := ctx.Wallet.GetAccountBalances("", "", false)
 	for _, balance := range balances {
 		if accBalance, ok := accBalances[balance.Alias]; ok {
 			if _, ok := accBalance[balance.AssetAlias]; ok {
@@ -0,0 +1,180 @@
+package txindex
+
+import (
+	"encoding/json"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+var (
+	statusKey = []byte("txIndexStatus")
+
+	// ErrNotFound means the transaction id is not in the index
+	ErrNotFound = errors.New("transaction not found in index")
+)
+
+func entryKey(txID *bc.Hash) []byte {
+	return append([]byte("TI:"), []byte(txID.String())...)
+}
+
+// Entry locates one confirmed transaction on the main chain.
+type Entry struct {
+	BlockHash   bc.Hash `json:"block_hash"`
+	BlockHeight uint64  `json:"block_height"`
+	Position    uint32  `json:"position"`
+}
+
+// Status is the chain position the index has processed up to, persisted so
+// the follower resumes, backfills an existing datadir from genesis when the
+// index is first enabled, and detaches orphaned blocks after a restart.
+type Status struct {
+	Height uint64  `json:"height"`
+	Hash   bc.Hash `json:"hash"`
+}
+
+// TxIndex maps every confirmed transaction id to its block and position,
+// enabling lookups beyond wallet-tracked transactions. It follows the main
+// chain the same way the wallet does and is entirely optional; enabling it
+// on an existing datadir backfills in the background starting at genesis.
+type TxIndex struct {
+	db    dbm.DB
+	chain *protocol.Chain
+
+	rw     sync.RWMutex
+	status Status
+}
+
+// NewTxIndex opens the index over db and starts the chain follower.
+func NewTxIndex(db dbm.DB, chain *protocol.Chain) (*TxIndex, error) {
+	ti := &TxIndex{
+		db:    db,
+		chain: chain,
+	}
+
+	if rawStatus := db.Get(statusKey); rawStatus != nil {
+		if err := json.Unmarshal(rawStatus, &ti.status); err != nil {
+			return nil, err
+		}
+	} else {
+		block, err := chain.GetBlockByHeight(0)
+		if err != nil {
+			return nil, err
+		}
+		if err := ti.AttachBlock(block); err != nil {
+			return nil, err
+		}
+	}
+
+	go ti.indexUpdater()
+	return ti, nil
+}
+
+// BestHeight returns the height the index has processed up to.
+func (ti *TxIndex) BestHeight() uint64 {
+	ti.rw.RLock()
+	defer ti.rw.RUnlock()
+	return ti.status.Height
+}
+
+// indexUpdater keeps the index in step with the main chain, detaching
+// orphaned blocks first like the wallet updater does. The same loop is the
+// background backfill: a fresh index starts at genesis and walks forward.
+func (ti *TxIndex) indexUpdater() {
+	for {
+		for ti.status.Height > 0 && !ti.chain.InMainChain(ti.status.Hash) {
+			block, err := ti.chain.GetBlockByHash(&ti.status.Hash)
+			if err != nil {
+				log.WithField("err", err).Error("txindex updater GetBlockByHash")
+				return
+			}
+
+			if err := ti.DetachBlock(block); err != nil {
+				log.WithField("err", err).Error("txindex updater DetachBlock stop")
+				return
+			}
+		}
+
+		block, _ := ti.chain.GetBlockByHeight(ti.BestHeight() + 1)
+		if block == nil {
+			<-ti.chain.BlockWaiter(ti.BestHeight() + 1)
+			continue
+		}
+
+		if err := ti.AttachBlock(block); err != nil {
+			log.WithField("err", err).Error("txindex updater AttachBlock stop")
+			return
+		}
+	}
+}
+
+// AttachBlock indexes the transactions of a new main chain block.
+func (ti *TxIndex) AttachBlock(block *types.Block) error {
+	ti.rw.Lock()
+	defer ti.rw.Unlock()
+
+	if block.Height > 0 && block.PreviousBlockHash != ti.status.Hash {
+		log.Warn("txindex skip AttachBlock due to status hash not equal to previous hash")
+		return nil
+	}
+
+	blockHash := block.Hash()
+	batch := ti.db.NewBatch()
+	for i, tx := range block.Transactions {
+		rawEntry, err := json.Marshal(&Entry{
+			BlockHash:   blockHash,
+			BlockHeight: block.Height,
+			Position:    uint32(i),
+		})
+		if err != nil {
+			return err
+		}
+		batch.Set(entryKey(&tx.ID), rawEntry)
+	}
+	return ti.commitStatus(batch, Status{Height: block.Height, Hash: blockHash})
+}
+
+// DetachBlock drops the entries of an orphaned block from the index.
+func (ti *TxIndex) DetachBlock(block *types.Block) error {
+	ti.rw.Lock()
+	defer ti.rw.Unlock()
+
+	batch := ti.db.NewBatch()
+	for _, tx := range block.Transactions {
+		batch.Delete(entryKey(&tx.ID))
+	}
+	return ti.commitStatus(batch, Status{Height: block.Height - 1, Hash: block.PreviousBlockHash})
+}
+
+func (ti *TxIndex) commitStatus(batch dbm.Batch, status Status) error {
+	rawStatus, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	batch.Set(statusKey, rawStatus)
+	batch.Write()
+	ti.status = status
+	return nil
+}
+
+// Get returns the chain location of a confirmed transaction, ErrNotFound
+// when the id is unknown or not yet backfilled.
+func (ti *TxIndex) Get(txID *bc.Hash) (*Entry, error) {
+	rawEntry := ti.db.Get(entryKey(txID))
+	if rawEntry == nil {
+		return nil, ErrNotFound
+	}
+
+	entry := &Entry{}
+	if err := json.Unmarshal(rawEntry, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,44 @@
+package txindex
+
+import (
+	"testing"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+func TestAttachDetachBlock(t *testing.T) {
+	ti := &TxIndex{db: dbm.NewMemDB()}
+
+	tx := types.NewTx(types.TxData{
+		Version: 1,
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*consensus.BTMAssetID, 100, []byte{0x51}),
+		},
+	})
+	block := &types.Block{
+		BlockHeader:  types.BlockHeader{Height: 0},
+		Transactions: []*types.Tx{tx},
+	}
+
+	if err := ti.AttachBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := ti.Get(&tx.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.BlockHash != block.Hash() || entry.BlockHeight != 0 || entry.Position != 0 {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	if err := ti.DetachBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ti.Get(&tx.ID); err != ErrNotFound {
+		t.Errorf("after detach err = %v want ErrNotFound", err)
+	}
+}
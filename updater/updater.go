@@ -0,0 +1,295 @@
+// Package updater implements the optional signed release auto-update check.
+// It periodically fetches a release manifest from a configured URL, verifies
+// the maintainer SM2 signature, reports when a newer compatible version
+// exists and can stage the release binary for an operator-confirmed restart.
+package updater
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	gover "github.com/hashicorp/go-version"
+	log "github.com/sirupsen/logrus"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/crypto/sm2"
+	"github.com/bytom-gm/crypto/sm3"
+	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/version"
+)
+
+const (
+	logModule           = "updater"
+	defaultCheckMinutes = 60
+	httpTimeout         = 30 * time.Second
+	maxManifestSize     = 1 << 20
+)
+
+var (
+	// ErrBadManifest means the release manifest could not be parsed
+	ErrBadManifest = errors.New("malformed release manifest")
+	// ErrBadSignature means the manifest signature does not verify against
+	// any configured maintainer key
+	ErrBadSignature = errors.New("invalid release manifest signature")
+	// ErrNoUpdate means there is no staged or stageable release
+	ErrNoUpdate = errors.New("no update available")
+	// ErrBadChecksum means the downloaded binary does not match the manifest
+	ErrBadChecksum = errors.New("staged binary checksum mismatch")
+)
+
+// Manifest describes one published release
+type Manifest struct {
+	Version     string `json:"version"`
+	URL         string `json:"url"`
+	Checksum    string `json:"checksum"`
+	PublishedAt uint64 `json:"published_at"`
+}
+
+// SignedManifest wraps the raw manifest with a maintainer SM2 signature over
+// the manifest bytes
+type SignedManifest struct {
+	Manifest  json.RawMessage    `json:"manifest"`
+	Pubkey    chainjson.HexBytes `json:"pubkey"`
+	Signature chainjson.HexBytes `json:"signature"`
+}
+
+// Status is the externally visible updater state
+type Status struct {
+	CurrentVersion  string    `json:"current_version"`
+	LatestVersion   string    `json:"latest_version"`
+	UpdateAvailable bool      `json:"update_available"`
+	StagedPath      string    `json:"staged_path,omitempty"`
+	LastChecked     uint64    `json:"last_checked"`
+	LastError       string    `json:"last_error,omitempty"`
+	Manifest        *Manifest `json:"manifest,omitempty"`
+}
+
+// Updater periodically checks the configured manifest URL for signed releases
+type Updater struct {
+	mtx        sync.RWMutex
+	config     *cfg.UpdateConfig
+	stageDir   string
+	client     *http.Client
+	manifest   *Manifest
+	stagedPath string
+	lastCheck  uint64
+	lastError  string
+}
+
+// NewUpdater return an updater over the given config, stageDir is where
+// confirmed downloads are placed
+func NewUpdater(config *cfg.UpdateConfig, stageDir string) *Updater {
+	return &Updater{
+		config:   config,
+		stageDir: stageDir,
+		client:   &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Run periodically checks for updates, it must be run as a goroutine
+func (u *Updater) Run() {
+	checkMinutes := u.config.CheckMinutes
+	if checkMinutes <= 0 {
+		checkMinutes = defaultCheckMinutes
+	}
+
+	ticker := time.NewTicker(time.Duration(checkMinutes) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if err := u.Check(); err != nil {
+			log.WithFields(log.Fields{"module": logModule, "err": err}).Warning("release update check")
+		}
+		<-ticker.C
+	}
+}
+
+// Check fetch and verify the release manifest once, recording the result
+func (u *Updater) Check() error {
+	manifest, err := u.fetchManifest()
+
+	u.mtx.Lock()
+	u.lastCheck = uint64(time.Now().Unix())
+	if err != nil {
+		u.lastError = err.Error()
+		u.mtx.Unlock()
+		return err
+	}
+	u.lastError = ""
+	u.manifest = manifest
+	u.mtx.Unlock()
+
+	newer, err := isNewerCompatible(manifest.Version)
+	if err != nil {
+		return err
+	}
+	if newer {
+		log.WithFields(log.Fields{
+			"module":          logModule,
+			"current version": version.Version,
+			"newer version":   manifest.Version,
+			"url":             manifest.URL,
+		}).Warn("a newer compatible release is available")
+	}
+	return nil
+}
+
+func (u *Updater) fetchManifest() (*Manifest, error) {
+	resp, err := u.client.Get(u.config.ManifestURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	rawSigned, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, err
+	}
+
+	signed := &SignedManifest{}
+	if err := json.Unmarshal(rawSigned, signed); err != nil {
+		return nil, errors.Sub(ErrBadManifest, err)
+	}
+
+	if !u.verifySignature(signed) {
+		return nil, ErrBadSignature
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(signed.Manifest, manifest); err != nil {
+		return nil, errors.Sub(ErrBadManifest, err)
+	}
+	if manifest.Version == "" || manifest.URL == "" {
+		return nil, ErrBadManifest
+	}
+	return manifest, nil
+}
+
+// verifySignature check the manifest signature against the configured
+// maintainer keys, the pubkey must both verify and be in the trusted set
+func (u *Updater) verifySignature(signed *SignedManifest) bool {
+	trusted := false
+	pubkeyHex := hex.EncodeToString(signed.Pubkey)
+	for _, key := range u.config.Pubkeys {
+		if key == pubkeyHex {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return false
+	}
+
+	if len(signed.Pubkey) != 33 || len(signed.Signature) != 64 {
+		return false
+	}
+
+	var msgHash [32]byte
+	sm3.Sum(msgHash[:], signed.Manifest)
+	return sm2.VerifyCompressedPubkey(signed.Pubkey, msgHash[:], signed.Signature)
+}
+
+// Stage download the release binary named by the verified manifest into the
+// stage directory, checking its checksum, and leave the restart to the operator
+func (u *Updater) Stage() (string, error) {
+	u.mtx.RLock()
+	manifest := u.manifest
+	u.mtx.RUnlock()
+
+	if manifest == nil {
+		return "", ErrNoUpdate
+	}
+
+	newer, err := isNewerCompatible(manifest.Version)
+	if err != nil {
+		return "", err
+	}
+	if !newer {
+		return "", ErrNoUpdate
+	}
+
+	resp, err := u.client.Get(manifest.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	binary, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var checksum [32]byte
+	sm3.Sum(checksum[:], binary)
+	if hex.EncodeToString(checksum[:]) != manifest.Checksum {
+		return "", ErrBadChecksum
+	}
+
+	if err := os.MkdirAll(u.stageDir, 0700); err != nil {
+		return "", err
+	}
+
+	stagedPath := filepath.Join(u.stageDir, "bytomd-"+manifest.Version)
+	if err := ioutil.WriteFile(stagedPath, binary, 0700); err != nil {
+		return "", err
+	}
+
+	u.mtx.Lock()
+	u.stagedPath = stagedPath
+	u.mtx.Unlock()
+
+	log.WithFields(log.Fields{
+		"module":  logModule,
+		"version": manifest.Version,
+		"path":    stagedPath,
+	}).Info("staged release binary, restart to apply")
+	return stagedPath, nil
+}
+
+// GetStatus return a snapshot of the updater state
+func (u *Updater) GetStatus() *Status {
+	u.mtx.RLock()
+	defer u.mtx.RUnlock()
+
+	status := &Status{
+		CurrentVersion: version.Version,
+		LatestVersion:  version.Version,
+		StagedPath:     u.stagedPath,
+		LastChecked:    u.lastCheck,
+		LastError:      u.lastError,
+		Manifest:       u.manifest,
+	}
+	if u.manifest != nil {
+		status.LatestVersion = u.manifest.Version
+		if newer, err := isNewerCompatible(u.manifest.Version); err == nil {
+			status.UpdateAvailable = newer
+		}
+	}
+	return status
+}
+
+// isNewerCompatible reports whether verStr is newer than the running version
+// while staying within the same major version
+func isNewerCompatible(verStr string) (bool, error) {
+	localVersion, err := gover.NewVersion(version.Version)
+	if err != nil {
+		return false, err
+	}
+	remoteVersion, err := gover.NewVersion(verStr)
+	if err != nil {
+		return false, err
+	}
+
+	if remoteVersion.Segments()[0] != localVersion.Segments()[0] {
+		return false, nil
+	}
+	return remoteVersion.GreaterThan(localVersion), nil
+}
@@ -0,0 +1,243 @@
+package updater
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gover "github.com/hashicorp/go-version"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/crypto/sm2"
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/version"
+)
+
+// signManifest signs the raw manifest bytes the way a maintainer release
+// tool would, returning the wrapped signed manifest
+func signManifest(t *testing.T, priv *sm2.PrivateKey, rawManifest []byte) *SignedManifest {
+	var msgHash [32]byte
+	sm3.Sum(msgHash[:], rawManifest)
+
+	r, s, err := sm2.Sign(priv, msgHash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+	copy(sig[32-len(rBytes):32], rBytes)
+	copy(sig[64-len(sBytes):], sBytes)
+
+	pubKey, _ := priv.Public().(*sm2.PublicKey)
+	return &SignedManifest{
+		Manifest:  rawManifest,
+		Pubkey:    sm2.Compress(pubKey),
+		Signature: sig,
+	}
+}
+
+// serveSigned serves the signed manifest as the manifest URL would and
+// returns an updater trusting the given pubkeys
+func serveSigned(t *testing.T, signed *SignedManifest, pubkeys []string) (*Updater, func()) {
+	rawSigned, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write(rawSigned)
+	}))
+
+	updater := NewUpdater(&cfg.UpdateConfig{
+		Enable:      true,
+		ManifestURL: server.URL,
+		Pubkeys:     pubkeys,
+	}, "")
+	return updater, server.Close
+}
+
+// newerCompatibleVersion returns the running version with the patch bumped,
+// so isNewerCompatible accepts it
+func newerCompatibleVersion(t *testing.T) string {
+	localVersion, err := gover.NewVersion(version.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments := localVersion.Segments()
+	return fmt.Sprintf("%d.%d.%d", segments[0], segments[1], segments[2]+1)
+}
+
+func TestFetchManifestValid(t *testing.T) {
+	priv, err := sm2.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawManifest, err := json.Marshal(&Manifest{Version: "1.1.0", URL: "https://release.example.com/bytomd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := signManifest(t, priv, rawManifest)
+
+	updater, closeServer := serveSigned(t, signed, []string{hex.EncodeToString(signed.Pubkey)})
+	defer closeServer()
+
+	manifest, err := updater.fetchManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Version != "1.1.0" || manifest.URL != "https://release.example.com/bytomd" {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+}
+
+func TestFetchManifestUntrustedKey(t *testing.T) {
+	priv, err := sm2.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trustedPriv, err := sm2.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawManifest, err := json.Marshal(&Manifest{Version: "1.1.0", URL: "https://release.example.com/bytomd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := signManifest(t, priv, rawManifest)
+
+	// the signature verifies, but the key is not in the configured set
+	trustedPubKey, _ := trustedPriv.Public().(*sm2.PublicKey)
+	updater, closeServer := serveSigned(t, signed, []string{hex.EncodeToString(sm2.Compress(trustedPubKey))})
+	defer closeServer()
+
+	if _, err := updater.fetchManifest(); err != ErrBadSignature {
+		t.Errorf("manifest signed by an untrusted key: err = %v want ErrBadSignature", err)
+	}
+}
+
+func TestFetchManifestTampered(t *testing.T) {
+	priv, err := sm2.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawManifest, err := json.Marshal(&Manifest{Version: "1.1.0", URL: "https://release.example.com/bytomd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := signManifest(t, priv, rawManifest)
+
+	// mutate the manifest bytes after signing
+	tampered, err := json.Marshal(&Manifest{Version: "9.9.9", URL: "https://attacker.example.com/bytomd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed.Manifest = tampered
+
+	updater, closeServer := serveSigned(t, signed, []string{hex.EncodeToString(signed.Pubkey)})
+	defer closeServer()
+
+	if _, err := updater.fetchManifest(); err != ErrBadSignature {
+		t.Errorf("tampered manifest bytes: err = %v want ErrBadSignature", err)
+	}
+}
+
+func TestFetchManifestBadLengths(t *testing.T) {
+	priv, err := sm2.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawManifest, err := json.Marshal(&Manifest{Version: "1.1.0", URL: "https://release.example.com/bytomd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a truncated pubkey must be rejected even when configured as trusted
+	truncated := signManifest(t, priv, rawManifest)
+	truncated.Pubkey = truncated.Pubkey[:32]
+	updater, closeServer := serveSigned(t, truncated, []string{hex.EncodeToString(truncated.Pubkey)})
+	if _, err := updater.fetchManifest(); err != ErrBadSignature {
+		t.Errorf("wrong-length pubkey: err = %v want ErrBadSignature", err)
+	}
+	closeServer()
+
+	// same for a truncated signature
+	shortSig := signManifest(t, priv, rawManifest)
+	shortSig.Signature = shortSig.Signature[:63]
+	updater, closeServer = serveSigned(t, shortSig, []string{hex.EncodeToString(shortSig.Pubkey)})
+	defer closeServer()
+	if _, err := updater.fetchManifest(); err != ErrBadSignature {
+		t.Errorf("wrong-length signature: err = %v want ErrBadSignature", err)
+	}
+}
+
+func TestFetchManifestMalformed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("not a signed manifest"))
+	}))
+	defer server.Close()
+
+	updater := NewUpdater(&cfg.UpdateConfig{Enable: true, ManifestURL: server.URL}, "")
+	if _, err := updater.fetchManifest(); errors.Root(err) != ErrBadManifest {
+		t.Errorf("malformed manifest body: err = %v want ErrBadManifest", err)
+	}
+}
+
+func TestStageChecksum(t *testing.T) {
+	stageDir, err := ioutil.TempDir("", "updater")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stageDir)
+
+	binary := []byte("release binary bytes")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write(binary)
+	}))
+	defer server.Close()
+
+	var checksum [32]byte
+	sm3.Sum(checksum[:], binary)
+	newVersion := newerCompatibleVersion(t)
+
+	updater := NewUpdater(&cfg.UpdateConfig{Enable: true}, stageDir)
+	updater.manifest = &Manifest{
+		Version:  newVersion,
+		URL:      server.URL,
+		Checksum: hex.EncodeToString(checksum[:]),
+	}
+
+	stagedPath, err := updater.Stage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	staged, err := ioutil.ReadFile(stagedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(staged) != string(binary) {
+		t.Errorf("staged binary mismatch: got %q want %q", staged, binary)
+	}
+	if filepath.Dir(stagedPath) != stageDir {
+		t.Errorf("staged outside the stage dir: %s", stagedPath)
+	}
+
+	// a body whose SM3 digest differs from the manifest checksum is refused
+	updater.manifest = &Manifest{
+		Version:  newVersion,
+		URL:      server.URL,
+		Checksum: hex.EncodeToString(checksum[:31]) + "00",
+	}
+	if _, err := updater.Stage(); err != ErrBadChecksum {
+		t.Errorf("checksum mismatch: err = %v want ErrBadChecksum", err)
+	}
+}
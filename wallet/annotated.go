@@ -60,6 +60,20 @@ func (w *Wallet) getExternalDefinition(assetID *bc.AssetID) json.RawMessage {
 	return definitionByte
 }
 
+// GetAliasDefinition returns the alias and raw definition of an asset when
+// the wallet knows it, for callers annotating transactions outside the
+// wallet index
+func (w *Wallet) GetAliasDefinition(assetID bc.AssetID) (string, json.RawMessage) {
+	return w.getAliasDefinition(assetID)
+}
+
+// BuildAnnotatedTransaction annotates a confirmed transaction at the given
+// block position, for callers resolving transactions outside the wallet
+// index such as the optional txindex
+func (w *Wallet) BuildAnnotatedTransaction(orig *types.Tx, b *types.Block, statusFail bool, indexInBlock int) *query.AnnotatedTx {
+	return w.buildAnnotatedTransaction(orig, b, statusFail, indexInBlock)
+}
+
 func (w *Wallet) getAliasDefinition(assetID bc.AssetID) (string, json.RawMessage) {
 	//btm
 	if assetID.String() == consensus.BTMAssetID.String() {
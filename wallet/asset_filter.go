@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"github.com/bytom-gm/blockchain/query"
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/consensus"
+)
+
+// assetFilter holds the operator configured asset allowlist/denylist. Denied
+// assets are still indexed internally so the wallet stays consistent, they are
+// only hidden from balances, histories and notifications unless explicitly
+// requested.
+type assetFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// newAssetFilter build the filter from the wallet section of the node config
+func newAssetFilter() *assetFilter {
+	filter := &assetFilter{
+		allow: make(map[string]bool),
+		deny:  make(map[string]bool),
+	}
+	if cfg.CommonConfig == nil || cfg.CommonConfig.Wallet == nil {
+		return filter
+	}
+
+	for _, assetID := range cfg.CommonConfig.Wallet.AssetAllowlist {
+		filter.allow[assetID] = true
+	}
+	for _, assetID := range cfg.CommonConfig.Wallet.AssetDenylist {
+		filter.deny[assetID] = true
+	}
+	return filter
+}
+
+// isDenied reports whether an asset should be hidden by default. BTM is never
+// hidden, it pays every transaction's gas
+func (f *assetFilter) isDenied(assetID string) bool {
+	if f == nil || assetID == consensus.BTMAssetID.String() {
+		return false
+	}
+	if f.deny[assetID] {
+		return true
+	}
+	return len(f.allow) > 0 && !f.allow[assetID]
+}
+
+// isAssetDenied reports whether the wallet hides the asset by default
+func (w *Wallet) isAssetDenied(assetID string) bool {
+	return w.assetFilter.isDenied(assetID)
+}
+
+// txOnlyDeniedAssets reports whether every account related input and output of
+// the annotated transaction carries a denied asset, such transactions are
+// dropped from default history listings
+func (w *Wallet) txOnlyDeniedAssets(annotatedTx *query.AnnotatedTx) bool {
+	related := false
+	for _, input := range annotatedTx.Inputs {
+		if input.AccountID == "" {
+			continue
+		}
+		related = true
+		if !w.isAssetDenied(input.AssetID.String()) {
+			return false
+		}
+	}
+	for _, output := range annotatedTx.Outputs {
+		if output.AccountID == "" {
+			continue
+		}
+		related = true
+		if !w.isAssetDenied(output.AssetID.String()) {
+			return false
+		}
+	}
+	return related
+}
@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"encoding/json"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// audit discrepancy types
+const (
+	AuditMissingFromWallet  = "missing_from_wallet"
+	AuditUnexpectedInWallet = "unexpected_in_wallet"
+	AuditMismatch           = "mismatch"
+)
+
+// AuditDiscrepancy describe one disagreement between the chain derived UTXO
+// set and the wallet index
+type AuditDiscrepancy struct {
+	Type      string     `json:"type"`
+	OutputID  bc.Hash    `json:"output_id"`
+	AccountID string     `json:"account_id,omitempty"`
+	AssetID   bc.AssetID `json:"asset_id"`
+	Amount    uint64     `json:"amount"`
+}
+
+// AuditReport is the result of a double entry wallet audit
+type AuditReport struct {
+	AuditedHeight   uint64              `json:"audited_height"`
+	ChainUtxoCount  int                 `json:"chain_utxo_count"`
+	WalletUtxoCount int                 `json:"wallet_utxo_count"`
+	Consistent      bool                `json:"consistent"`
+	Discrepancies   []*AuditDiscrepancy `json:"discrepancies"`
+}
+
+// AuditAccountUtxos re-derive the tracked UTXO set by replaying every block
+// from the chain and diff it against the wallet index, bypassing the stored
+// per-block bookkeeping. The wallet should be synced and quiet while the
+// audit runs, a concurrent attach can show up as a spurious discrepancy
+func (w *Wallet) AuditAccountUtxos() (*AuditReport, error) {
+	auditedHeight := w.status.WorkHeight
+	expected, err := w.deriveChainUtxos(auditedHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AuditReport{
+		AuditedHeight:  auditedHeight,
+		ChainUtxoCount: len(expected),
+		Discrepancies:  []*AuditDiscrepancy{},
+	}
+
+	indexed := map[bc.Hash]bool{}
+	for _, prefix := range []string{account.UTXOPreFix, account.SUTXOPrefix} {
+		utxoIter := w.DB.IteratorPrefix([]byte(prefix))
+		defer utxoIter.Release()
+
+		for utxoIter.Next() {
+			got := &account.UTXO{}
+			if err := json.Unmarshal(utxoIter.Value(), got); err != nil {
+				return nil, err
+			}
+
+			report.WalletUtxoCount++
+			indexed[got.OutputID] = true
+			want, ok := expected[got.OutputID]
+			if !ok {
+				report.Discrepancies = append(report.Discrepancies, &AuditDiscrepancy{
+					Type:      AuditUnexpectedInWallet,
+					OutputID:  got.OutputID,
+					AccountID: got.AccountID,
+					AssetID:   got.AssetID,
+					Amount:    got.Amount,
+				})
+				continue
+			}
+
+			if want.Amount != got.Amount || want.AssetID != got.AssetID || want.AccountID != got.AccountID {
+				report.Discrepancies = append(report.Discrepancies, &AuditDiscrepancy{
+					Type:      AuditMismatch,
+					OutputID:  got.OutputID,
+					AccountID: want.AccountID,
+					AssetID:   want.AssetID,
+					Amount:    want.Amount,
+				})
+			}
+		}
+	}
+
+	for outputID, want := range expected {
+		if indexed[outputID] {
+			continue
+		}
+
+		report.Discrepancies = append(report.Discrepancies, &AuditDiscrepancy{
+			Type:      AuditMissingFromWallet,
+			OutputID:  outputID,
+			AccountID: want.AccountID,
+			AssetID:   want.AssetID,
+			Amount:    want.Amount,
+		})
+	}
+
+	report.Consistent = len(report.Discrepancies) == 0
+	return report, nil
+}
+
+// deriveChainUtxos replay blocks 0..height and rebuild the tracked UTXO set
+// in memory, using only the control program registry to decide ownership
+func (w *Wallet) deriveChainUtxos(height uint64) (map[bc.Hash]*account.UTXO, error) {
+	utxos := map[bc.Hash]*account.UTXO{}
+	for h := uint64(0); h <= height; h++ {
+		block, err := w.chain.GetBlockByHeight(h)
+		if err != nil {
+			return nil, err
+		}
+
+		blockHash := block.Hash()
+		txStatus, err := w.chain.GetTransactionStatus(&blockHash)
+		if err != nil {
+			return nil, err
+		}
+
+		for txIndex, tx := range block.Transactions {
+			statusFail, err := txStatus.GetStatus(txIndex)
+			if err != nil {
+				log.WithField("err", err).Error("deriveChainUtxos fail on get tx status")
+				continue
+			}
+
+			for _, inputUtxo := range txInToUtxos(tx, statusFail) {
+				delete(utxos, inputUtxo.OutputID)
+			}
+
+			validHeight := uint64(0)
+			if txIndex == 0 {
+				validHeight = block.Height + consensus.CoinbasePendingBlockNumber
+			}
+			for _, utxo := range w.filterAccountUtxo(txOutToUtxos(tx, statusFail, validHeight)) {
+				utxos[utxo.OutputID] = utxo
+			}
+		}
+	}
+	return utxos, nil
+}
@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/asset"
+	"github.com/bytom-gm/blockchain/pseudohsm"
+)
+
+var backupWatermarkKey = []byte("BackupWatermark")
+
+// BackupWatermark records what the last snapshot already captured, so the
+// next incremental backup only carries wallet records changed since then
+type BackupWatermark struct {
+	Height        uint64            `json:"height"`
+	Timestamp     uint64            `json:"timestamp"`
+	ContractIndex map[string]uint64 `json:"contract_index"`
+	AssetIDs      map[string]bool   `json:"asset_ids"`
+	KeyCount      int               `json:"key_count"`
+}
+
+// DiffImage is an incremental wallet snapshot, it holds only the records
+// changed since the base watermark and the new watermark height
+type DiffImage struct {
+	BaseHeight   uint64              `json:"base_height"`
+	Height       uint64              `json:"height"`
+	Timestamp    uint64              `json:"timestamp"`
+	AccountImage *account.Image      `json:"account_image"`
+	AssetImage   *asset.Image        `json:"asset_image"`
+	KeyImages    *pseudohsm.KeyImage `json:"key_images"`
+}
+
+func (w *Wallet) getBackupWatermark() *BackupWatermark {
+	watermark := &BackupWatermark{
+		ContractIndex: map[string]uint64{},
+		AssetIDs:      map[string]bool{},
+	}
+
+	rawWatermark := w.DB.Get(backupWatermarkKey)
+	if rawWatermark == nil {
+		return watermark
+	}
+
+	if err := json.Unmarshal(rawWatermark, watermark); err != nil {
+		return &BackupWatermark{
+			ContractIndex: map[string]uint64{},
+			AssetIDs:      map[string]bool{},
+		}
+	}
+	return watermark
+}
+
+// BackupDiff export only the wallet records changed since the previous
+// incremental snapshot and advance the stored watermark, a wallet without a
+// watermark yields a full image
+func (w *Wallet) BackupDiff() (*DiffImage, error) {
+	watermark := w.getBackupWatermark()
+
+	accountImage, err := w.AccountMgr.Backup()
+	if err != nil {
+		return nil, err
+	}
+	assetImage, err := w.AssetReg.Backup()
+	if err != nil {
+		return nil, err
+	}
+	keyImages, err := w.Hsm.Backup()
+	if err != nil {
+		return nil, err
+	}
+
+	nextWatermark := &BackupWatermark{
+		Height:        w.chain.BestBlockHeight(),
+		Timestamp:     uint64(time.Now().Unix()),
+		ContractIndex: map[string]uint64{},
+		AssetIDs:      map[string]bool{},
+		KeyCount:      len(keyImages.XKeys),
+	}
+
+	diffAccounts := &account.Image{Slice: []*account.ImageSlice{}}
+	for _, slice := range accountImage.Slice {
+		nextWatermark.ContractIndex[slice.Account.ID] = slice.ContractIndex
+		index, known := watermark.ContractIndex[slice.Account.ID]
+		if !known || slice.ContractIndex > index {
+			diffAccounts.Slice = append(diffAccounts.Slice, slice)
+		}
+	}
+
+	diffAssets := &asset.Image{Assets: []*asset.Asset{}}
+	for _, a := range assetImage.Assets {
+		nextWatermark.AssetIDs[a.AssetID.String()] = true
+		if !watermark.AssetIDs[a.AssetID.String()] {
+			diffAssets.Assets = append(diffAssets.Assets, a)
+		}
+	}
+
+	// key files change rarely, ship them all whenever the key set grew
+	diffKeys := &pseudohsm.KeyImage{}
+	if len(keyImages.XKeys) != watermark.KeyCount {
+		diffKeys = keyImages
+	}
+
+	rawWatermark, err := json.Marshal(nextWatermark)
+	if err != nil {
+		return nil, err
+	}
+	w.DB.Set(backupWatermarkKey, rawWatermark)
+
+	return &DiffImage{
+		BaseHeight:   watermark.Height,
+		Height:       nextWatermark.Height,
+		Timestamp:    nextWatermark.Timestamp,
+		AccountImage: diffAccounts,
+		AssetImage:   diffAssets,
+		KeyImages:    diffKeys,
+	}, nil
+}
+
+// RestoreDiff apply an incremental snapshot, records already present locally
+// are skipped by the underlying restore routines
+func (w *Wallet) RestoreDiff(image *DiffImage) error {
+	if image.KeyImages != nil && len(image.KeyImages.XKeys) != 0 {
+		if err := w.Hsm.Restore(image.KeyImages); err != nil {
+			return err
+		}
+	}
+	if image.AssetImage != nil {
+		if err := w.AssetReg.Restore(image.AssetImage); err != nil {
+			return err
+		}
+	}
+	if image.AccountImage != nil {
+		if err := w.AccountMgr.Restore(image.AccountImage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
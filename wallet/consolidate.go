@@ -0,0 +1,164 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/blockchain/txbuilder"
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+const (
+	// defaultDustThreshold marks BTM outputs below 1 BTM as dust when neither
+	// the request nor the node policy sets a threshold
+	defaultDustThreshold = uint64(100000000)
+	// defaultConsolidateMaxInputs caps the inputs of one consolidation round
+	defaultConsolidateMaxInputs = 250
+	// consolidateTxTTL bounds how long the reserved utxos stay locked while
+	// the template waits for signing
+	consolidateTxTTL = 10 * time.Minute
+
+	// rough per-part serialized sizes used for the consolidation fee estimate
+	consolidateTxOverhead = uint64(70)
+	consolidateInputSize  = uint64(450)
+	consolidateOutputSize = uint64(90)
+)
+
+var (
+	// ErrNoDustUTXOs means there is nothing worth consolidating
+	ErrNoDustUTXOs = errors.New("not enough dust utxos to consolidate")
+	// ErrConsolidateFeeBudget means the fee budget can't cover a minimal consolidation
+	ErrConsolidateFeeBudget = errors.New("fee budget too small for consolidation")
+)
+
+// DustThreshold resolve the effective threshold: request value first, then the
+// node dust policy, then the built-in default
+func DustThreshold(threshold uint64) uint64 {
+	if threshold != 0 {
+		return threshold
+	}
+	if cfg.CommonConfig != nil && cfg.CommonConfig.Policy != nil && cfg.CommonConfig.Policy.DustThreshold != 0 {
+		return cfg.CommonConfig.Policy.DustThreshold
+	}
+	return defaultDustThreshold
+}
+
+// ListDustUTXOs return the spendable BTM utxos of the account whose amount is
+// below the threshold, smallest first. Only BTM outputs are reported, other
+// assets can't pay for their own consolidation
+func (w *Wallet) ListDustUTXOs(accountID string, threshold uint64) ([]*account.UTXO, error) {
+	threshold = DustThreshold(threshold)
+	bestHeight := w.chain.BestBlockHeight()
+
+	dustUTXOs := []*account.UTXO{}
+	for _, utxo := range w.GetAccountUtxos(accountID, "", false, false) {
+		if utxo.AssetID != *consensus.BTMAssetID || utxo.Amount >= threshold {
+			continue
+		}
+		// skip outputs whose timelock has not expired yet
+		if utxo.ValidHeight > bestHeight {
+			continue
+		}
+		dustUTXOs = append(dustUTXOs, utxo)
+	}
+
+	sort.Slice(dustUTXOs, func(i, j int) bool { return dustUTXOs[i].Amount < dustUTXOs[j].Amount })
+	return dustUTXOs, nil
+}
+
+// estimateConsolidateFee project the fee of a consolidation transaction with
+// the given input count at the current mempool fee rate
+func estimateConsolidateFee(inputs int) uint64 {
+	size := consolidateTxOverhead + uint64(inputs)*consolidateInputSize + consolidateOutputSize
+	return txbuilder.EstimateFee(size, 1)
+}
+
+// BuildConsolidationTx build a transaction merging the account's dust BTM
+// utxos into a single fresh change address, trimming inputs until the
+// projected fee fits the budget. The returned template goes through the
+// regular sign-transaction flow and can be handed to schedule-transaction to
+// submit during a low fee period
+func (w *Wallet) BuildConsolidationTx(ctx context.Context, accountID string, threshold, feeBudget uint64, maxInputs int) (*txbuilder.Template, error) {
+	if accountID == "" {
+		return nil, account.ErrFindAccount
+	}
+
+	utxos, err := w.ListDustUTXOs(accountID, threshold)
+	if err != nil {
+		return nil, err
+	}
+	if maxInputs <= 0 || maxInputs > defaultConsolidateMaxInputs {
+		maxInputs = defaultConsolidateMaxInputs
+	}
+	if len(utxos) > maxInputs {
+		utxos = utxos[:maxInputs]
+	}
+	if len(utxos) < 2 {
+		return nil, ErrNoDustUTXOs
+	}
+
+	// trim the smallest gain first: dropping the largest utxo keeps the most
+	// dust cleaned per fee spent
+	fee := estimateConsolidateFee(len(utxos))
+	for feeBudget != 0 && fee > feeBudget && len(utxos) > 2 {
+		utxos = utxos[:len(utxos)-1]
+		fee = estimateConsolidateFee(len(utxos))
+	}
+	if feeBudget != 0 && fee > feeBudget {
+		return nil, ErrConsolidateFeeBudget
+	}
+
+	total := uint64(0)
+	for _, utxo := range utxos {
+		total += utxo.Amount
+	}
+	if total <= fee {
+		return nil, ErrNoDustUTXOs
+	}
+
+	actions := []txbuilder.Action{}
+	for _, utxo := range utxos {
+		data, err := json.Marshal(&struct {
+			OutputID *bc.Hash `json:"output_id"`
+		}{OutputID: &utxo.OutputID})
+		if err != nil {
+			return nil, err
+		}
+
+		action, err := w.AccountMgr.DecodeSpendUTXOAction(data)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	cp, err := w.AccountMgr.CreateAddress(accountID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(&struct {
+		bc.AssetAmount
+		Address string `json:"address"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: consensus.BTMAssetID, Amount: total - fee},
+		Address:     cp.Address,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := txbuilder.DecodeControlAddressAction(data)
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, action)
+
+	return txbuilder.Build(ctx, nil, actions, time.Now().Add(consolidateTxTTL), 0)
+}
@@ -0,0 +1,275 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto/sm2"
+	"github.com/bytom-gm/crypto/sm3"
+	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/vm/vmutil"
+)
+
+// EscrowPrefix is the leveldb key prefix of tracked escrow instances
+const EscrowPrefix = "ESCROW:"
+
+// escrowTxTTL bounds how long reserved utxos stay locked while an escrow
+// template waits for the party signatures
+const escrowTxTTL = 10 * time.Minute
+
+// escrow settlement paths
+const (
+	EscrowPathRelease = "release"
+	EscrowPathRefund  = "refund"
+	EscrowPathDispute = "dispute"
+)
+
+// pre-define escrow errors
+var (
+	ErrBadEscrowPubkey   = errors.New("escrow pubkeys must be 33 bytes")
+	ErrEscrowExist       = errors.New("escrow with the same parties and alias already exists")
+	ErrFindEscrow        = errors.New("escrow not found")
+	ErrEscrowNotFunded   = errors.New("escrow has no unspent output on chain")
+	ErrBadEscrowPath     = errors.New("unknown escrow settlement path")
+	ErrEscrowFeeTooLarge = errors.New("fee exceeds the escrow amount")
+)
+
+// Escrow is a tracked 2-of-3 escrow contract instance between a buyer, a
+// seller and an arbiter
+type Escrow struct {
+	ID      string             `json:"id"`
+	Alias   string             `json:"alias"`
+	Program chainjson.HexBytes `json:"program"`
+	Buyer   chainjson.HexBytes `json:"buyer_pubkey"`
+	Seller  chainjson.HexBytes `json:"seller_pubkey"`
+	Arbiter chainjson.HexBytes `json:"arbiter_pubkey"`
+	AssetID bc.AssetID         `json:"asset_id"`
+	Amount  uint64             `json:"amount"`
+}
+
+// EscrowStatus bundle a tracked escrow with its current on-chain state
+type EscrowStatus struct {
+	*Escrow
+	State    string   `json:"state"`
+	OutputID *bc.Hash `json:"output_id,omitempty"`
+	Balance  uint64   `json:"balance"`
+}
+
+func escrowKey(id string) []byte {
+	return []byte(EscrowPrefix + id)
+}
+
+// CreateEscrow derive the 2-of-3 escrow program, register the instance for
+// tracking and build the funding transaction from the given account. The
+// escrow output is a raw script, the wallet indexes it as a contract utxo
+func (w *Wallet) CreateEscrow(ctx context.Context, accountID, alias string, buyer, seller, arbiter []byte, assetID bc.AssetID, amount uint64) (*Escrow, *txbuilder.Template, error) {
+	for _, pubkey := range [][]byte{buyer, seller, arbiter} {
+		if len(pubkey) != sm2.PubKeySize {
+			return nil, nil, ErrBadEscrowPubkey
+		}
+	}
+
+	program, err := vmutil.BuildTemplate("escrow-v1", &vmutil.TemplateArgs{
+		Pubkeys: []sm2.PubKey{sm2.PubKey(buyer), sm2.PubKey(seller), sm2.PubKey(arbiter)},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	programHash := sm3.Sum256(program)
+	escrow := &Escrow{
+		ID:      hex.EncodeToString(programHash[:]),
+		Alias:   alias,
+		Program: program,
+		Buyer:   buyer,
+		Seller:  seller,
+		Arbiter: arbiter,
+		AssetID: assetID,
+		Amount:  amount,
+	}
+	if w.DB.Get(escrowKey(escrow.ID)) != nil {
+		return nil, nil, ErrEscrowExist
+	}
+
+	rawEscrow, err := json.Marshal(escrow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	spendData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		AccountID string `json:"account_id"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: &assetID, Amount: amount},
+		AccountID:   accountID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	spendAction, err := w.AccountMgr.DecodeSpendAction(spendData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	controlData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		Program chainjson.HexBytes `json:"control_program"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: &assetID, Amount: amount},
+		Program:     program,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	controlAction, err := txbuilder.DecodeControlProgramAction(controlData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tpl, err := txbuilder.Build(ctx, nil, []txbuilder.Action{spendAction, controlAction}, time.Now().Add(escrowTxTTL), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w.DB.Set(escrowKey(escrow.ID), rawEscrow)
+	return escrow, tpl, nil
+}
+
+// GetEscrow return a tracked escrow with its current state
+func (w *Wallet) GetEscrow(id string) (*EscrowStatus, error) {
+	rawEscrow := w.DB.Get(escrowKey(id))
+	if rawEscrow == nil {
+		return nil, ErrFindEscrow
+	}
+
+	escrow := &Escrow{}
+	if err := json.Unmarshal(rawEscrow, escrow); err != nil {
+		return nil, err
+	}
+	return w.escrowStatus(escrow), nil
+}
+
+// ListEscrows return every tracked escrow with its current state
+func (w *Wallet) ListEscrows() ([]*EscrowStatus, error) {
+	escrows := []*EscrowStatus{}
+
+	escrowIter := w.DB.IteratorPrefix([]byte(EscrowPrefix))
+	defer escrowIter.Release()
+	for escrowIter.Next() {
+		escrow := &Escrow{}
+		if err := json.Unmarshal(escrowIter.Value(), escrow); err != nil {
+			return nil, err
+		}
+		escrows = append(escrows, w.escrowStatus(escrow))
+	}
+	return escrows, nil
+}
+
+// escrowStatus derive the current state of an escrow from the contract utxo
+// index: funded while its output is unspent, settled once it is gone
+func (w *Wallet) escrowStatus(escrow *Escrow) *EscrowStatus {
+	status := &EscrowStatus{Escrow: escrow, State: "unfunded"}
+	if utxo := w.findEscrowUtxo(escrow); utxo != nil {
+		status.State = "funded"
+		status.OutputID = &utxo.OutputID
+		status.Balance = utxo.Amount
+	}
+	return status
+}
+
+func (w *Wallet) findEscrowUtxo(escrow *Escrow) *account.UTXO {
+	for _, utxo := range w.GetAccountUtxos("", "", false, true) {
+		if bytes.Equal(utxo.ControlProgram, escrow.Program) {
+			return utxo
+		}
+	}
+	return nil
+}
+
+// escrowSigners return which parties must sign the witness for a settlement
+// path, in witness order
+func escrowSigners(path string) ([]string, error) {
+	switch path {
+	case EscrowPathRelease, EscrowPathRefund:
+		return []string{"buyer", "seller"}, nil
+	case EscrowPathDispute:
+		return []string{"arbiter", "buyer or seller"}, nil
+	}
+	return nil, ErrBadEscrowPath
+}
+
+// BuildEscrowSettlement build a transaction spending the escrow output to the
+// given address. The release and refund paths expect buyer and seller
+// signatures, the dispute path expects the arbiter plus one party, any two of
+// the three keys satisfy the program. The witness takes the two raw
+// signatures over the tx sig hash, collected offline by the parties
+func (w *Wallet) BuildEscrowSettlement(ctx context.Context, id, path, address string, fee uint64) (*txbuilder.Template, []string, error) {
+	signers, err := escrowSigners(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawEscrow := w.DB.Get(escrowKey(id))
+	if rawEscrow == nil {
+		return nil, nil, ErrFindEscrow
+	}
+
+	escrow := &Escrow{}
+	if err := json.Unmarshal(rawEscrow, escrow); err != nil {
+		return nil, nil, err
+	}
+
+	utxo := w.findEscrowUtxo(escrow)
+	if utxo == nil {
+		return nil, nil, ErrEscrowNotFunded
+	}
+
+	if fee == 0 && utxo.AssetID == *consensus.BTMAssetID {
+		fee = estimateConsolidateFee(1)
+	}
+	if fee >= utxo.Amount {
+		return nil, nil, ErrEscrowFeeTooLarge
+	}
+
+	controlData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		Address string `json:"address"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: &utxo.AssetID, Amount: utxo.Amount - fee},
+		Address:     address,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	controlAction, err := txbuilder.DecodeControlAddressAction(controlData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	builder := txbuilder.NewBuilder(time.Now().Add(escrowTxTTL))
+	txInput := types.NewSpendInput(nil, utxo.SourceID, utxo.AssetID, utxo.Amount, utxo.SourcePos, utxo.ControlProgram)
+	if err := builder.AddInput(txInput, &txbuilder.SigningInstruction{}); err != nil {
+		return nil, nil, err
+	}
+	if err := controlAction.Build(ctx, builder); err != nil {
+		return nil, nil, err
+	}
+
+	tpl, _, err := builder.Build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return tpl, signers, nil
+}
@@ -0,0 +1,212 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bytom-gm/errors"
+)
+
+const (
+	//ExportFormatCSV is the comma separated export format
+	ExportFormatCSV = "csv"
+	//ExportFormatOFX is the Open Financial Exchange export format
+	ExportFormatOFX = "ofx"
+)
+
+// ErrExportFormat means the requested export format is not supported
+var ErrExportFormat = errors.New("unsupported export format")
+
+// exportRecord is one exported flow, confirmed transactions are decomposed
+// into one record per account-owned input and output
+type exportRecord struct {
+	txID           string
+	blockHeight    uint64
+	timestamp      uint64
+	accountID      string
+	accountAlias   string
+	assetID        string
+	assetAlias     string
+	direction      string
+	address        string
+	amount         uint64
+	runningBalance int64
+	labels         string
+}
+
+// ExportTransactions render the confirmed transaction history of the wallet
+// (optionally narrowed to one account) in the given format, with per-output
+// decomposition, base unit amounts and per account/asset running balances
+func (w *Wallet) ExportTransactions(accountID, format string) (string, error) {
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	records, err := w.buildExportRecords(accountID)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case ExportFormatCSV:
+		return renderCSV(records)
+	case ExportFormatOFX:
+		return renderOFX(records), nil
+	default:
+		return "", errors.WithDetailf(ErrExportFormat, "format %s is unsupported, use csv or ofx", format)
+	}
+}
+
+func (w *Wallet) buildExportRecords(accountID string) ([]exportRecord, error) {
+	annotatedTxs, err := w.GetTransactions(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// GetTransactions returns newest first, running balances accumulate oldest first
+	sort.SliceStable(annotatedTxs, func(i, j int) bool {
+		if annotatedTxs[i].BlockHeight != annotatedTxs[j].BlockHeight {
+			return annotatedTxs[i].BlockHeight < annotatedTxs[j].BlockHeight
+		}
+		return annotatedTxs[i].Position < annotatedTxs[j].Position
+	})
+
+	balances := map[string]int64{}
+	records := []exportRecord{}
+	for _, annotatedTx := range annotatedTxs {
+		labels := ""
+		if metadata, err := w.GetTxMetadata(annotatedTx.ID.String()); err == nil {
+			labels = formatLabels(metadata.Labels)
+		}
+
+		for _, input := range annotatedTx.Inputs {
+			if input.AccountID == "" || (accountID != "" && input.AccountID != accountID) {
+				continue
+			}
+
+			balanceKey := input.AccountID + ":" + input.AssetID.String()
+			balances[balanceKey] -= int64(input.Amount)
+			records = append(records, exportRecord{
+				txID:           annotatedTx.ID.String(),
+				blockHeight:    annotatedTx.BlockHeight,
+				timestamp:      annotatedTx.Timestamp,
+				accountID:      input.AccountID,
+				accountAlias:   input.AccountAlias,
+				assetID:        input.AssetID.String(),
+				assetAlias:     input.AssetAlias,
+				direction:      "send",
+				address:        input.Address,
+				amount:         input.Amount,
+				runningBalance: balances[balanceKey],
+				labels:         labels,
+			})
+		}
+
+		for _, output := range annotatedTx.Outputs {
+			if output.AccountID == "" || (accountID != "" && output.AccountID != accountID) {
+				continue
+			}
+
+			balanceKey := output.AccountID + ":" + output.AssetID.String()
+			balances[balanceKey] += int64(output.Amount)
+			records = append(records, exportRecord{
+				txID:           annotatedTx.ID.String(),
+				blockHeight:    annotatedTx.BlockHeight,
+				timestamp:      annotatedTx.Timestamp,
+				accountID:      output.AccountID,
+				accountAlias:   output.AccountAlias,
+				assetID:        output.AssetID.String(),
+				assetAlias:     output.AssetAlias,
+				direction:      "receive",
+				address:        output.Address,
+				amount:         output.Amount,
+				runningBalance: balances[balanceKey],
+				labels:         labels,
+			})
+		}
+	}
+	return records, nil
+}
+
+func formatLabels(labels map[string]string) string {
+	keys := []string{}
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	formatted := ""
+	for _, key := range keys {
+		if formatted != "" {
+			formatted += ";"
+		}
+		formatted += key + "=" + labels[key]
+	}
+	return formatted
+}
+
+func renderCSV(records []exportRecord) (string, error) {
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+
+	header := []string{"tx_id", "block_height", "block_time", "account_id", "account_alias", "asset_id", "asset_alias", "direction", "address", "amount", "running_balance", "labels"}
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, record := range records {
+		row := []string{
+			record.txID,
+			strconv.FormatUint(record.blockHeight, 10),
+			time.Unix(int64(record.timestamp), 0).UTC().Format(time.RFC3339),
+			record.accountID,
+			record.accountAlias,
+			record.assetID,
+			record.assetAlias,
+			record.direction,
+			record.address,
+			strconv.FormatUint(record.amount, 10),
+			strconv.FormatInt(record.runningBalance, 10),
+			record.labels,
+		}
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderOFX(records []exportRecord) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString("OFXHEADER:100\nDATA:OFXSGML\nVERSION:102\nSECURITY:NONE\nENCODING:UTF-8\n\n")
+	buf.WriteString("<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>\n")
+
+	for i, record := range records {
+		amount := int64(record.amount)
+		trnType := "CREDIT"
+		if record.direction == "send" {
+			amount = -amount
+			trnType = "DEBIT"
+		}
+
+		buf.WriteString("<STMTTRN>\n")
+		fmt.Fprintf(buf, "<TRNTYPE>%s\n", trnType)
+		fmt.Fprintf(buf, "<DTPOSTED>%s\n", time.Unix(int64(record.timestamp), 0).UTC().Format("20060102150405"))
+		fmt.Fprintf(buf, "<TRNAMT>%d\n", amount)
+		fmt.Fprintf(buf, "<FITID>%s-%d\n", record.txID, i)
+		fmt.Fprintf(buf, "<MEMO>%s %s %s\n", record.accountID, record.assetID, record.labels)
+		buf.WriteString("</STMTTRN>\n")
+	}
+
+	buf.WriteString("</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\n")
+	return buf.String()
+}
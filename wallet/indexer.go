@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -15,6 +16,7 @@ import (
 	chainjson "github.com/bytom-gm/encoding/json"
 	"github.com/bytom-gm/protocol/bc"
 	"github.com/bytom-gm/protocol/bc/types"
+	"github.com/bytom-gm/protocol/vm/vmutil"
 )
 
 const (
@@ -64,12 +66,60 @@ func saveExternalAssetDefinition(b *types.Block, walletDB db.DB) {
 	for _, tx := range b.Transactions {
 		for _, orig := range tx.Inputs {
 			if ii, ok := orig.TypedInput.(*types.IssuanceInput); ok {
+				assetID := ii.AssetID()
 				if isValidJSON(ii.AssetDefinition) {
-					assetID := ii.AssetID()
 					if assetExist := walletDB.Get(asset.ExtAssetKey(&assetID)); assetExist == nil {
 						storeBatch.Set(asset.ExtAssetKey(&assetID), ii.AssetDefinition)
 					}
 				}
+
+				if anchorExist := walletDB.Get(asset.DefinitionAnchorKey(&assetID)); anchorExist == nil {
+					anchor := &asset.DefinitionAnchor{
+						AssetID:         assetID,
+						DefinitionHash:  ii.AssetDefinitionHash(),
+						RawDefinition:   ii.AssetDefinition,
+						IssuanceProgram: ii.IssuanceProgram,
+						VMVersion:       ii.VMVersion,
+						BlockHeight:     b.Height,
+						TxID:            tx.ID,
+					}
+					if rawAnchor, err := json.Marshal(anchor); err == nil {
+						storeBatch.Set(asset.DefinitionAnchorKey(&assetID), rawAnchor)
+					}
+				}
+			}
+		}
+	}
+}
+
+// updateSupplyCounters maintain the cumulative issued and retired counters of
+// every asset observed in a block, attach adds and detach rolls back
+func updateSupplyCounters(b *types.Block, txStatus *bc.TransactionStatus, walletDB db.DB, attach bool) {
+	for txIndex, tx := range b.Transactions {
+		statusFail, err := txStatus.GetStatus(txIndex)
+		if err != nil || statusFail {
+			continue
+		}
+
+		for _, orig := range tx.Inputs {
+			if ii, ok := orig.TypedInput.(*types.IssuanceInput); ok {
+				assetID := ii.AssetID()
+				if attach {
+					asset.AddIssuance(walletDB, &assetID, ii.Amount)
+				} else {
+					asset.SubIssuance(walletDB, &assetID, ii.Amount)
+				}
+			}
+		}
+
+		for _, output := range tx.Outputs {
+			if !vmutil.IsUnspendable(output.ControlProgram) {
+				continue
+			}
+			if attach {
+				asset.AddRetirement(walletDB, output.AssetId, output.Amount)
+			} else {
+				asset.SubRetirement(walletDB, output.AssetId, output.Amount)
 			}
 		}
 	}
@@ -98,6 +148,7 @@ type TxSummary struct {
 func (w *Wallet) indexTransactions(batch db.Batch, b *types.Block, txStatus *bc.TransactionStatus) error {
 	annotatedTxs := w.filterAccountTxs(b, txStatus)
 	saveExternalAssetDefinition(b, w.DB)
+	updateSupplyCounters(b, txStatus, w.DB, true)
 	annotateTxsAccount(annotatedTxs, w.DB)
 
 	for _, tx := range annotatedTxs {
@@ -113,6 +164,8 @@ func (w *Wallet) indexTransactions(batch db.Batch, b *types.Block, txStatus *bc.
 		// delete unconfirmed transaction
 		batch.Delete(calcUnconfirmedTxKey(tx.ID.String()))
 	}
+
+	w.Hub.notifyConfirmedTxs(annotatedTxs, b.Height)
 	return nil
 }
 
@@ -228,6 +281,10 @@ func (w *Wallet) GetTransactions(accountID string) ([]*query.AnnotatedTx, error)
 			return nil, err
 		}
 
+		if w.txOnlyDeniedAssets(annotatedTx) {
+			continue
+		}
+
 		if accountID == "" || findTransactionsByAccount(annotatedTx, accountID) {
 			annotateTxsAsset(w, []*query.AnnotatedTx{annotatedTx})
 			annotatedTxs = append([]*query.AnnotatedTx{annotatedTx}, annotatedTxs...)
@@ -237,9 +294,134 @@ func (w *Wallet) GetTransactions(accountID string) ([]*query.AnnotatedTx, error)
 	return annotatedTxs, nil
 }
 
-// GetAccountBalances return all account balances
-func (w *Wallet) GetAccountBalances(accountID string, id string) ([]AccountBalance, error) {
-	return w.indexBalances(w.GetAccountUtxos(accountID, "", false, false))
+// TxFilter is the server-side criteria set for transaction history queries,
+// zero values leave the corresponding dimension unfiltered
+type TxFilter struct {
+	AccountID string
+	AssetID   string
+	Direction string
+	StartTime uint64
+	EndTime   uint64
+	MinAmount uint64
+	// IncludeDenied also returns transactions that only touch operator
+	// denied assets, naming an AssetID explicitly has the same effect
+	IncludeDenied bool
+}
+
+// match reports whether the annotated transaction satisfies every criterion
+func (f *TxFilter) match(annotatedTx *query.AnnotatedTx) bool {
+	if f.AccountID != "" && !findTransactionsByAccount(annotatedTx, f.AccountID) {
+		return false
+	}
+	if f.StartTime != 0 && annotatedTx.Timestamp < f.StartTime {
+		return false
+	}
+	if f.EndTime != 0 && annotatedTx.Timestamp > f.EndTime {
+		return false
+	}
+
+	sendAmount := uint64(0)
+	for _, input := range annotatedTx.Inputs {
+		if input.AccountID == "" || (f.AccountID != "" && input.AccountID != f.AccountID) {
+			continue
+		}
+		if f.AssetID != "" && input.AssetID.String() != f.AssetID {
+			continue
+		}
+		sendAmount += input.Amount
+	}
+
+	receiveAmount := uint64(0)
+	for _, output := range annotatedTx.Outputs {
+		if output.AccountID == "" || (f.AccountID != "" && output.AccountID != f.AccountID) {
+			continue
+		}
+		if f.AssetID != "" && output.AssetID.String() != f.AssetID {
+			continue
+		}
+		receiveAmount += output.Amount
+	}
+
+	switch f.Direction {
+	case "send":
+		if sendAmount == 0 {
+			return false
+		}
+	case "receive":
+		if receiveAmount == 0 {
+			return false
+		}
+	}
+
+	if f.AssetID != "" && sendAmount == 0 && receiveAmount == 0 {
+		return false
+	}
+	if f.MinAmount != 0 && sendAmount < f.MinAmount && receiveAmount < f.MinAmount {
+		return false
+	}
+	return true
+}
+
+// GetTransactionsByFilter return annotated transactions matching the filter
+// newest first, paged by the sortable key returned with the previous page. An
+// empty after starts from the most recent transaction, count limits the page
+// size with 0 meaning unlimited, and last is the cursor to resume from
+func (w *Wallet) GetTransactionsByFilter(filter TxFilter, after string, count int) ([]*query.AnnotatedTx, string, error) {
+	if after != "" {
+		if len(after) != 24 {
+			return nil, "", query.ErrBadAfter
+		}
+		if _, err := hex.DecodeString(after); err != nil {
+			return nil, "", query.ErrBadAfter
+		}
+	}
+
+	matchedTxs := []*query.AnnotatedTx{}
+	matchedKeys := []string{}
+
+	txIter := w.DB.IteratorPrefix([]byte(TxPrefix))
+	defer txIter.Release()
+	for txIter.Next() {
+		key := string(txIter.Key())[len(TxPrefix):]
+		// keys iterate in ascending height/position order, everything from
+		// the cursor up has been served already
+		if after != "" && key >= after {
+			break
+		}
+
+		annotatedTx := &query.AnnotatedTx{}
+		if err := json.Unmarshal(txIter.Value(), annotatedTx); err != nil {
+			return nil, "", err
+		}
+
+		if !filter.IncludeDenied && filter.AssetID == "" && w.txOnlyDeniedAssets(annotatedTx) {
+			continue
+		}
+
+		if filter.match(annotatedTx) {
+			matchedTxs = append(matchedTxs, annotatedTx)
+			matchedKeys = append(matchedKeys, key)
+		}
+	}
+
+	if count <= 0 || count > len(matchedTxs) {
+		count = len(matchedTxs)
+	}
+
+	annotatedTxs := []*query.AnnotatedTx{}
+	last := ""
+	for i := len(matchedTxs) - 1; i >= len(matchedTxs)-count; i-- {
+		annotateTxsAsset(w, []*query.AnnotatedTx{matchedTxs[i]})
+		annotatedTxs = append(annotatedTxs, matchedTxs[i])
+		last = matchedKeys[i]
+	}
+	return annotatedTxs, last, nil
+}
+
+// GetAccountBalances return all account balances, denied assets are skipped
+// unless includeDenied is set
+func (w *Wallet) GetAccountBalances(accountID string, id string, includeDenied bool) ([]AccountBalance, error) {
+	return w.indexBalances(w.GetAccountUtxos(accountID, "", false, false), includeDenied)
 }
 
 // AccountBalance account balance
@@ -249,15 +431,22 @@ type AccountBalance struct {
 	AssetAlias      string                 `json:"asset_alias"`
 	AssetID         string                 `json:"asset_id"`
 	Amount          uint64                 `json:"amount"`
+	AvailableAmount uint64                 `json:"available_amount"`
+	LockedAmount    uint64                 `json:"locked_amount"`
 	AssetDefinition map[string]interface{} `json:"asset_definition"`
 }
 
-func (w *Wallet) indexBalances(accountUTXOs []*account.UTXO) ([]AccountBalance, error) {
+func (w *Wallet) indexBalances(accountUTXOs []*account.UTXO, includeDenied bool) ([]AccountBalance, error) {
 	accBalance := make(map[string]map[string]uint64)
+	lockedBalance := make(map[string]map[string]uint64)
 	balances := []AccountBalance{}
 
+	bestHeight := w.chain.BestBlockHeight()
 	for _, accountUTXO := range accountUTXOs {
 		assetID := accountUTXO.AssetID.String()
+		if !includeDenied && w.isAssetDenied(assetID) {
+			continue
+		}
 		if _, ok := accBalance[accountUTXO.AccountID]; ok {
 			if _, ok := accBalance[accountUTXO.AccountID][assetID]; ok {
 				accBalance[accountUTXO.AccountID][assetID] += accountUTXO.Amount
@@ -267,6 +456,15 @@ func (w *Wallet) indexBalances(accountUTXOs []*account.UTXO) ([]AccountBalance,
 		} else {
 			accBalance[accountUTXO.AccountID] = map[string]uint64{assetID: accountUTXO.Amount}
 		}
+
+		// outputs whose timelock has not expired are reported but can't be spent yet
+		if accountUTXO.ValidHeight > bestHeight {
+			if _, ok := lockedBalance[accountUTXO.AccountID]; ok {
+				lockedBalance[accountUTXO.AccountID][assetID] += accountUTXO.Amount
+			} else {
+				lockedBalance[accountUTXO.AccountID] = map[string]uint64{assetID: accountUTXO.Amount}
+			}
+		}
 	}
 
 	var sortedAccount []string
@@ -290,12 +488,15 @@ func (w *Wallet) indexBalances(accountUTXOs []*account.UTXO) ([]AccountBalance,
 			}
 
 			assetAlias := *targetAsset.Alias
+			locked := lockedBalance[id][assetID]
 			balances = append(balances, AccountBalance{
 				Alias:           alias,
 				AccountID:       id,
 				AssetID:         assetID,
 				AssetAlias:      assetAlias,
 				Amount:          accBalance[id][assetID],
+				AvailableAmount: accBalance[id][assetID] - locked,
+				LockedAmount:    locked,
 				AssetDefinition: targetAsset.DefinitionMap,
 			})
 		}
@@ -0,0 +1,95 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	//TxMetaPrefix is wallet database transaction metadata prefix
+	TxMetaPrefix = "TXMETA:"
+)
+
+func calcTxMetadataKey(txID string) []byte {
+	return []byte(TxMetaPrefix + txID)
+}
+
+// TxMetadata holds externally known context attached to a confirmed wallet
+// transaction, such as an off-chain trade ID or a counterparty KYC reference,
+// so reconciliation data lives next to the chain record.
+type TxMetadata struct {
+	TxID      string            `json:"tx_id"`
+	Labels    map[string]string `json:"labels"`
+	UpdatedAt uint64            `json:"updated_at"`
+}
+
+// SetTxMetadata attach the given labels to a confirmed wallet transaction,
+// existing labels of the transaction are replaced
+func (w *Wallet) SetTxMetadata(txID string, labels map[string]string) (*TxMetadata, error) {
+	if _, err := w.GetTransactionByTxID(txID); err != nil {
+		return nil, err
+	}
+
+	metadata := &TxMetadata{
+		TxID:      txID,
+		Labels:    labels,
+		UpdatedAt: uint64(time.Now().Unix()),
+	}
+
+	rawMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	w.DB.Set(calcTxMetadataKey(txID), rawMetadata)
+	return metadata, nil
+}
+
+// GetTxMetadata return the metadata attached to the transaction by txID
+func (w *Wallet) GetTxMetadata(txID string) (*TxMetadata, error) {
+	rawMetadata := w.DB.Get(calcTxMetadataKey(txID))
+	if rawMetadata == nil {
+		return nil, fmt.Errorf("No transaction metadata(tx_id=%s) ", txID)
+	}
+
+	metadata := &TxMetadata{}
+	if err := json.Unmarshal(rawMetadata, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// DeleteTxMetadata remove the metadata attached to the transaction by txID
+func (w *Wallet) DeleteTxMetadata(txID string) error {
+	if rawMetadata := w.DB.Get(calcTxMetadataKey(txID)); rawMetadata == nil {
+		return fmt.Errorf("No transaction metadata(tx_id=%s) ", txID)
+	}
+
+	w.DB.Delete(calcTxMetadataKey(txID))
+	return nil
+}
+
+// ListTxMetadata return all transaction metadata records, optionally narrowed
+// to those carrying the given label key, or key and value when value is set
+func (w *Wallet) ListTxMetadata(key, value string) ([]*TxMetadata, error) {
+	metadatas := []*TxMetadata{}
+	metaIter := w.DB.IteratorPrefix([]byte(TxMetaPrefix))
+	defer metaIter.Release()
+
+	for metaIter.Next() {
+		metadata := &TxMetadata{}
+		if err := json.Unmarshal(metaIter.Value(), metadata); err != nil {
+			return nil, err
+		}
+
+		if key != "" {
+			labelValue, ok := metadata.Labels[key]
+			if !ok || (value != "" && labelValue != value) {
+				continue
+			}
+		}
+		metadatas = append(metadatas, metadata)
+	}
+	return metadatas, nil
+}
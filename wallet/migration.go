@@ -0,0 +1,120 @@
+package wallet
+
+import (
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	cfg "github.com/bytom-gm/config"
+	"github.com/bytom-gm/errors"
+)
+
+// currentSchemaVersion is bumped whenever a migration is appended below
+const currentSchemaVersion = uint64(1)
+
+var (
+	schemaVersionKey = []byte("WalletSchemaVersion")
+	// migrationBackupPrefix shelters a copy of every wallet key taken right
+	// before a migration run, keyed by the schema version it backs up
+	migrationBackupPrefix = "MigrationBackup:"
+
+	// ErrWalletSchemaTooNew means the wallet db was written by a newer release
+	ErrWalletSchemaTooNew = errors.New("wallet db schema is newer than this node supports")
+)
+
+// migration is one ordered schema upgrade step, apply must be idempotent so a
+// crash between apply and the version stamp only repeats work
+type migration struct {
+	version uint64
+	name    string
+	apply   func(w *Wallet) error
+}
+
+// migrations is the append-only registry, ordered by version
+var migrations = []migration{
+	{version: 1, name: "baseline keyspace marker", apply: func(w *Wallet) error { return nil }},
+}
+
+func (w *Wallet) getSchemaVersion() uint64 {
+	rawVersion := w.DB.Get(schemaVersionKey)
+	if rawVersion == nil {
+		return 0
+	}
+
+	version, err := strconv.ParseUint(string(rawVersion), 10, 64)
+	if err != nil {
+		log.WithField("err", err).Error("parse wallet schema version")
+		return 0
+	}
+	return version
+}
+
+func (w *Wallet) setSchemaVersion(version uint64) {
+	w.DB.Set(schemaVersionKey, []byte(strconv.FormatUint(version, 10)))
+}
+
+// backupForMigration copy every wallet key under the migration backup prefix
+// so an interrupted or faulty migration can be recovered by hand
+func (w *Wallet) backupForMigration(fromVersion uint64) {
+	prefix := migrationBackupPrefix + strconv.FormatUint(fromVersion, 10) + ":"
+	batch := w.DB.NewBatch()
+
+	iter := w.DB.Iterator()
+	defer iter.Release()
+	for iter.Next() {
+		key := string(iter.Key())
+		if len(key) >= len(migrationBackupPrefix) && key[:len(migrationBackupPrefix)] == migrationBackupPrefix {
+			continue
+		}
+		batch.Set([]byte(prefix+key), iter.Value())
+	}
+	batch.Write()
+	log.WithField("from_version", fromVersion).Info("wallet db backed up before migration")
+}
+
+// runMigrations bring the wallet db keyspace up to the current schema
+// version. In dry-run mode the pending migrations are only logged, leaving
+// the db untouched for inspection
+func (w *Wallet) runMigrations() error {
+	storedVersion := w.getSchemaVersion()
+	if storedVersion > currentSchemaVersion {
+		return ErrWalletSchemaTooNew
+	}
+
+	// a fresh wallet db starts at the current schema, nothing to replay
+	if storedVersion == 0 && w.DB.Get(walletKey) == nil {
+		w.setSchemaVersion(currentSchemaVersion)
+		return nil
+	}
+
+	pending := []migration{}
+	for _, m := range migrations {
+		if m.version > storedVersion {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	dryRun := cfg.CommonConfig != nil && cfg.CommonConfig.Wallet != nil && cfg.CommonConfig.Wallet.MigrationDryRun
+	if dryRun {
+		for _, m := range pending {
+			log.WithFields(log.Fields{"version": m.version, "name": m.name}).Info("wallet migration pending (dry run)")
+		}
+		return nil
+	}
+
+	if cfg.CommonConfig == nil || cfg.CommonConfig.Wallet == nil || cfg.CommonConfig.Wallet.MigrationBackup {
+		w.backupForMigration(storedVersion)
+	}
+
+	for _, m := range pending {
+		log.WithFields(log.Fields{"version": m.version, "name": m.name}).Info("applying wallet migration")
+		if err := m.apply(w); err != nil {
+			return errors.Wrapf(err, "wallet migration %d (%s)", m.version, m.name)
+		}
+		w.setSchemaVersion(m.version)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package wallet
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func TestRunMigrations(t *testing.T) {
+	testDB := dbm.NewDB("testdb", "leveldb", "temp")
+	defer os.RemoveAll("temp")
+
+	// a fresh wallet db gets stamped with the current schema directly
+	w := &Wallet{DB: testDB}
+	if err := w.runMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.getSchemaVersion(); got != currentSchemaVersion {
+		t.Errorf("fresh db schema version got %d, want %d", got, currentSchemaVersion)
+	}
+
+	// a legacy db without a marker replays every migration and lands on the
+	// current schema, keeping a pre-migration backup copy of its keys
+	testDB.Delete(schemaVersionKey)
+	testDB.Set(walletKey, []byte("{}"))
+	if err := w.runMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.getSchemaVersion(); got != currentSchemaVersion {
+		t.Errorf("migrated db schema version got %d, want %d", got, currentSchemaVersion)
+	}
+	backupKey := []byte(migrationBackupPrefix + "0:" + string(walletKey))
+	if testDB.Get(backupKey) == nil {
+		t.Error("expected pre-migration backup of wallet key")
+	}
+
+	// refuse to open a db written by a newer release
+	testDB.Set(schemaVersionKey, []byte(strconv.FormatUint(currentSchemaVersion+1, 10)))
+	if err := w.runMigrations(); err != ErrWalletSchemaTooNew {
+		t.Errorf("got error %v, want %v", err, ErrWalletSchemaTooNew)
+	}
+}
@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// pre-define unique asset errors
+var (
+	// ErrNotUniqueAsset means the asset is a regular fungible asset
+	ErrNotUniqueAsset = errors.New("asset is not a unique token")
+	// ErrUniqueAssetNotOwned means no unspent output holds the token
+	ErrUniqueAssetNotOwned = errors.New("unique asset is not held by the wallet")
+)
+
+// UniqueAsset describe one owned non-fungible token: the single unspent
+// output holding it together with its definition
+type UniqueAsset struct {
+	AssetID    bc.AssetID             `json:"asset_id"`
+	AssetAlias string                 `json:"asset_alias"`
+	AccountID  string                 `json:"account_id"`
+	Address    string                 `json:"address"`
+	OutputID   bc.Hash                `json:"output_id"`
+	Definition map[string]interface{} `json:"definition"`
+}
+
+// ListUniqueAssets return every unique token held by the account, or by all
+// accounts when accountID is empty. A token is an amount one output of an
+// asset whose supply is capped at one
+func (w *Wallet) ListUniqueAssets(accountID string) ([]*UniqueAsset, error) {
+	tokens := []*UniqueAsset{}
+	for _, utxo := range w.GetAccountUtxos(accountID, "", false, false) {
+		if utxo.Amount != 1 || utxo.AssetID == *consensus.BTMAssetID {
+			continue
+		}
+
+		ass, err := w.AssetReg.GetAsset(utxo.AssetID.String())
+		if err != nil || !ass.IsUnique() {
+			continue
+		}
+
+		alias := ""
+		if ass.Alias != nil {
+			alias = *ass.Alias
+		}
+		tokens = append(tokens, &UniqueAsset{
+			AssetID:    utxo.AssetID,
+			AssetAlias: alias,
+			AccountID:  utxo.AccountID,
+			Address:    utxo.Address,
+			OutputID:   utxo.OutputID,
+			Definition: ass.DefinitionMap,
+		})
+	}
+	return tokens, nil
+}
+
+// TransferUniqueAsset build a transaction moving the token output to the
+// given address, the fee is paid in BTM by the owning account
+func (w *Wallet) TransferUniqueAsset(ctx context.Context, accountID string, assetID bc.AssetID, address string) (*txbuilder.Template, error) {
+	ass, err := w.AssetReg.GetAsset(assetID.String())
+	if err != nil {
+		return nil, err
+	}
+	if !ass.IsUnique() {
+		return nil, ErrNotUniqueAsset
+	}
+
+	var owned *UniqueAsset
+	tokens, err := w.ListUniqueAssets(accountID)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		if token.AssetID == assetID {
+			owned = token
+			break
+		}
+	}
+	if owned == nil {
+		return nil, ErrUniqueAssetNotOwned
+	}
+
+	spendData, err := json.Marshal(&struct {
+		OutputID *bc.Hash `json:"output_id"`
+	}{OutputID: &owned.OutputID})
+	if err != nil {
+		return nil, err
+	}
+
+	spendAction, err := w.AccountMgr.DecodeSpendUTXOAction(spendData)
+	if err != nil {
+		return nil, err
+	}
+
+	feeData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		AccountID string `json:"account_id"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: consensus.BTMAssetID, Amount: estimateConsolidateFee(2)},
+		AccountID:   owned.AccountID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	feeAction, err := w.AccountMgr.DecodeSpendAction(feeData)
+	if err != nil {
+		return nil, err
+	}
+
+	controlData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		Address string `json:"address"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: &assetID, Amount: 1},
+		Address:     address,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	controlAction, err := txbuilder.DecodeControlAddressAction(controlData)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []txbuilder.Action{spendAction, feeAction, controlAction}
+	return txbuilder.Build(ctx, nil, actions, time.Now().Add(consolidateTxTTL), 0)
+}
@@ -0,0 +1,254 @@
+package wallet
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bytom-gm/blockchain/query"
+)
+
+const (
+	//EventIncomingPayment is emitted when a confirmed output pays an account
+	EventIncomingPayment = "incoming_payment"
+	//EventConfirmationChange is emitted while a wallet transaction accumulates confirmations
+	EventConfirmationChange = "confirmation_change"
+	//EventTxRejected is emitted when a wallet transaction is rejected from the mempool
+	EventTxRejected = "tx_rejected"
+	//EventRecurringPaymentFailed is emitted when a recurring payment run fails
+	EventRecurringPaymentFailed = "recurring_payment_failed"
+	//EventDoubleSpend is emitted when an arriving transaction spends an
+	//outpoint a mempool transaction already spends
+	EventDoubleSpend = "double_spend"
+
+	// confirmations are reported until a transaction is this deep
+	maxTrackedConfirmations = 6
+	// a slow subscriber loses events rather than stalling the wallet
+	subscriberBuffer = 256
+)
+
+// Event is one wallet notification pushed to subscribers
+type Event struct {
+	Type          string `json:"type"`
+	TxID          string `json:"tx_id,omitempty"`
+	AccountID     string `json:"account_id,omitempty"`
+	AccountAlias  string `json:"account_alias,omitempty"`
+	AssetID       string `json:"asset_id,omitempty"`
+	Address       string `json:"address,omitempty"`
+	Amount        uint64 `json:"amount,omitempty"`
+	BlockHeight   uint64 `json:"block_height,omitempty"`
+	Confirmations uint64 `json:"confirmations,omitempty"`
+	// ConflictTxID/OutputID identify a double spend: the mempool transaction
+	// already spending OutputID that TxID tried to spend again
+	ConflictTxID string `json:"conflict_tx_id,omitempty"`
+	OutputID     string `json:"output_id,omitempty"`
+	Timestamp    uint64 `json:"timestamp"`
+}
+
+// EventFilter narrows a subscription, empty fields match everything. Events
+// that carry no value for a filtered field (e.g. confirmation changes carry no
+// address) are not delivered to subscriptions filtering on that field.
+type EventFilter struct {
+	AccountID string `json:"account_id"`
+	Address   string `json:"address"`
+	AssetID   string `json:"asset_id"`
+}
+
+func (f *EventFilter) match(event *Event) bool {
+	if f.AccountID != "" && event.AccountID != f.AccountID {
+		return false
+	}
+	if f.Address != "" && event.Address != f.Address {
+		return false
+	}
+	if f.AssetID != "" && event.AssetID != f.AssetID {
+		return false
+	}
+	return true
+}
+
+// trackedTx is a recently confirmed wallet transaction whose confirmation
+// count is still being reported
+type trackedTx struct {
+	height     uint64
+	accountIDs []string
+}
+
+// NotificationHub fans wallet events out to websocket subscriptions
+type NotificationHub struct {
+	mtx     sync.Mutex
+	subs    map[chan *Event]*EventFilter
+	tracked map[string]*trackedTx
+	// deniedAsset suppresses payment events for operator denied assets, a
+	// nil func means no asset is hidden
+	deniedAsset func(assetID string) bool
+}
+
+// NewNotificationHub return an empty hub
+func NewNotificationHub() *NotificationHub {
+	return &NotificationHub{
+		subs:    map[chan *Event]*EventFilter{},
+		tracked: map[string]*trackedTx{},
+	}
+}
+
+// Subscribe register a new subscription with the given filter, the returned
+// channel is closed by Unsubscribe
+func (h *NotificationHub) Subscribe(filter *EventFilter) chan *Event {
+	ch := make(chan *Event, subscriberBuffer)
+
+	h.mtx.Lock()
+	h.subs[ch] = filter
+	h.mtx.Unlock()
+	return ch
+}
+
+// Unsubscribe drop the subscription and close its channel
+func (h *NotificationHub) Unsubscribe(ch chan *Event) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// Publish deliver the event to every matching subscription without blocking,
+// a nil hub silently drops everything so bare Wallet values stay usable
+func (h *NotificationHub) Publish(event *Event) {
+	if h == nil {
+		return
+	}
+	if event.Timestamp == 0 {
+		event.Timestamp = uint64(time.Now().Unix())
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for ch, filter := range h.subs {
+		if !filter.match(event) {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// notifyConfirmedTxs emit payment events for the freshly confirmed wallet
+// transactions and start tracking their confirmation counts
+func (h *NotificationHub) notifyConfirmedTxs(annotatedTxs []*query.AnnotatedTx, height uint64) {
+	if h == nil {
+		return
+	}
+	for _, annotatedTx := range annotatedTxs {
+		accountIDs := []string{}
+		seen := map[string]bool{}
+		for _, output := range annotatedTx.Outputs {
+			if output.AccountID == "" {
+				continue
+			}
+			if h.deniedAsset != nil && h.deniedAsset(output.AssetID.String()) {
+				continue
+			}
+
+			h.Publish(&Event{
+				Type:         EventIncomingPayment,
+				TxID:         annotatedTx.ID.String(),
+				AccountID:    output.AccountID,
+				AccountAlias: output.AccountAlias,
+				AssetID:      output.AssetID.String(),
+				Address:      output.Address,
+				Amount:       output.Amount,
+				BlockHeight:  height,
+			})
+
+			if !seen[output.AccountID] {
+				seen[output.AccountID] = true
+				accountIDs = append(accountIDs, output.AccountID)
+			}
+		}
+
+		if len(accountIDs) != 0 {
+			h.mtx.Lock()
+			h.tracked[annotatedTx.ID.String()] = &trackedTx{height: height, accountIDs: accountIDs}
+			h.mtx.Unlock()
+		}
+	}
+}
+
+// notifyConfirmations emit confirmation count updates for tracked
+// transactions as the chain advances, dropping the fully confirmed ones
+func (h *NotificationHub) notifyConfirmations(bestHeight uint64) {
+	if h == nil {
+		return
+	}
+
+	h.mtx.Lock()
+	pending := map[string]*trackedTx{}
+	for txID, tracked := range h.tracked {
+		pending[txID] = tracked
+	}
+	h.mtx.Unlock()
+
+	for txID, tracked := range pending {
+		if bestHeight < tracked.height {
+			continue
+		}
+
+		confirmations := bestHeight - tracked.height + 1
+		for _, accountID := range tracked.accountIDs {
+			h.Publish(&Event{
+				Type:          EventConfirmationChange,
+				TxID:          txID,
+				AccountID:     accountID,
+				BlockHeight:   tracked.height,
+				Confirmations: confirmations,
+			})
+		}
+
+		if confirmations >= maxTrackedConfirmations {
+			h.mtx.Lock()
+			delete(h.tracked, txID)
+			h.mtx.Unlock()
+		}
+	}
+}
+
+// notifyRejectedTx emit a rejection event for every account involved in the
+// dropped wallet transaction
+func (h *NotificationHub) notifyRejectedTx(annotatedTx *query.AnnotatedTx) {
+	if h == nil {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, input := range annotatedTx.Inputs {
+		if input.AccountID == "" || seen[input.AccountID] {
+			continue
+		}
+		seen[input.AccountID] = true
+
+		h.Publish(&Event{
+			Type:         EventTxRejected,
+			TxID:         annotatedTx.ID.String(),
+			AccountID:    input.AccountID,
+			AccountAlias: input.AccountAlias,
+		})
+	}
+
+	for _, output := range annotatedTx.Outputs {
+		if output.AccountID == "" || seen[output.AccountID] {
+			continue
+		}
+		seen[output.AccountID] = true
+
+		h.Publish(&Event{
+			Type:         EventTxRejected,
+			TxID:         annotatedTx.ID.String(),
+			AccountID:    output.AccountID,
+			AccountAlias: output.AccountAlias,
+		})
+	}
+}
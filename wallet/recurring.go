@@ -0,0 +1,430 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto/sm2/chainkd"
+	"github.com/bytom-gm/crypto/sm3"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+const (
+	//RecurringPaymentPrefix is wallet database recurring payments prefix
+	RecurringPaymentPrefix = "RECURRING:"
+	//RecurringCheckPeriod is the interval for checking due recurring payments
+	RecurringCheckPeriod = 30 * time.Second
+	// recurringTxTTL bounds how long the reserved utxos of one run stay locked
+	recurringTxTTL = 10 * time.Minute
+	// recurringHistoryLimit caps the run history kept per payment
+	recurringHistoryLimit = 50
+	// recurringMinInterval rejects cadences which would drain the account by accident
+	recurringMinInterval = uint64(60)
+)
+
+// pre-define recurring payment errors
+var (
+	// ErrBadCadence means the payment interval is missing or too short
+	ErrBadCadence = errors.New("recurring payment interval must be at least 60 seconds")
+	// ErrRecurringExist means a payment with the same alias already exists
+	ErrRecurringExist = errors.New("recurring payment alias already exists")
+	// ErrFindRecurring means the recurring payment is unknown
+	ErrFindRecurring = errors.New("fail to find recurring payment")
+	// ErrRecurringPassword means the password doesn't unlock any account key
+	ErrRecurringPassword = errors.New("password doesn't match any key of the account")
+)
+
+func calcRecurringPaymentKey(id string) []byte {
+	return []byte(RecurringPaymentPrefix + id)
+}
+
+// RecurringRun records one scheduler attempt of a recurring payment
+type RecurringRun struct {
+	RunAt   uint64 `json:"run_at"`
+	TxID    string `json:"tx_id,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RecurringPayment is a payroll-style standing order: the node builds, signs
+// and submits a fixed payment on the stored cadence. The signing password is
+// held in memory only, after a restart the payment must be re-armed through
+// ResumeRecurringPayment before runs continue.
+type RecurringPayment struct {
+	ID              string          `json:"id"`
+	Alias           string          `json:"alias"`
+	AccountID       string          `json:"account_id"`
+	Address         string          `json:"address"`
+	AssetID         bc.AssetID      `json:"asset_id"`
+	Amount          uint64          `json:"amount"`
+	IntervalSeconds uint64          `json:"interval_seconds"`
+	NextRunAt       uint64          `json:"next_run_at"`
+	Paused          bool            `json:"paused"`
+	CreatedAt       uint64          `json:"created_at"`
+	History         []*RecurringRun `json:"history"`
+}
+
+// RecurringPaymentStatus is one payment together with its in-memory arming
+// state, which is not persisted
+type RecurringPaymentStatus struct {
+	*RecurringPayment
+	Armed bool `json:"armed"`
+}
+
+// checkRecurringPassword verifies the password unlocks at least one key of
+// the paying account, so a payment is never armed with a password that will
+// fail at signing time
+func (w *Wallet) checkRecurringPassword(accountID, password string) error {
+	acct, err := w.AccountMgr.FindByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	for _, xpub := range acct.XPubs {
+		if _, err := w.Hsm.LoadChainKDKey(xpub, password); err == nil {
+			return nil
+		}
+	}
+	return ErrRecurringPassword
+}
+
+// CreateRecurringPayment validate and store a new recurring payment, armed
+// with the given signing password. The first run fires at startAt, or one
+// interval from now when startAt is zero.
+func (w *Wallet) CreateRecurringPayment(accountID, alias, address, password string, assetID bc.AssetID, amount, intervalSeconds, startAt uint64) (*RecurringPaymentStatus, error) {
+	if intervalSeconds < recurringMinInterval {
+		return nil, ErrBadCadence
+	}
+	if amount == 0 {
+		return nil, errors.New("recurring payment amount must be non-zero")
+	}
+	if err := w.checkRecurringPassword(accountID, password); err != nil {
+		return nil, err
+	}
+
+	w.recurringMtx.Lock()
+	defer w.recurringMtx.Unlock()
+
+	payments, err := w.listRecurringPayments()
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		if payment.Alias == alias {
+			return nil, ErrRecurringExist
+		}
+	}
+
+	now := uint64(time.Now().Unix())
+	nextRunAt := startAt
+	if nextRunAt == 0 {
+		nextRunAt = now + intervalSeconds
+	}
+
+	idHash := sm3.Sum256([]byte(fmt.Sprintf("%s:%s:%s:%d:%d", accountID, address, assetID.String(), amount, now)))
+	payment := &RecurringPayment{
+		ID:              hex.EncodeToString(idHash[:]),
+		Alias:           alias,
+		AccountID:       accountID,
+		Address:         address,
+		AssetID:         assetID,
+		Amount:          amount,
+		IntervalSeconds: intervalSeconds,
+		NextRunAt:       nextRunAt,
+		CreatedAt:       now,
+		History:         []*RecurringRun{},
+	}
+
+	if err := w.saveRecurringPayment(payment); err != nil {
+		return nil, err
+	}
+	w.recurringSecrets[payment.ID] = password
+	return &RecurringPaymentStatus{RecurringPayment: payment, Armed: true}, nil
+}
+
+func (w *Wallet) saveRecurringPayment(payment *RecurringPayment) error {
+	rawPayment, err := json.Marshal(payment)
+	if err != nil {
+		return err
+	}
+
+	w.DB.Set(calcRecurringPaymentKey(payment.ID), rawPayment)
+	return nil
+}
+
+func (w *Wallet) getRecurringPayment(id string) (*RecurringPayment, error) {
+	rawPayment := w.DB.Get(calcRecurringPaymentKey(id))
+	if rawPayment == nil {
+		return nil, ErrFindRecurring
+	}
+
+	payment := &RecurringPayment{}
+	return payment, json.Unmarshal(rawPayment, payment)
+}
+
+// listRecurringPayments return all the stored payments, callers hold recurringMtx
+func (w *Wallet) listRecurringPayments() ([]*RecurringPayment, error) {
+	payments := []*RecurringPayment{}
+	paymentIter := w.DB.IteratorPrefix([]byte(RecurringPaymentPrefix))
+	defer paymentIter.Release()
+
+	for paymentIter.Next() {
+		payment := &RecurringPayment{}
+		if err := json.Unmarshal(paymentIter.Value(), payment); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, nil
+}
+
+// ListRecurringPayments return every recurring payment with its arming state
+func (w *Wallet) ListRecurringPayments() ([]*RecurringPaymentStatus, error) {
+	w.recurringMtx.Lock()
+	defer w.recurringMtx.Unlock()
+
+	payments, err := w.listRecurringPayments()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []*RecurringPaymentStatus{}
+	for _, payment := range payments {
+		_, armed := w.recurringSecrets[payment.ID]
+		statuses = append(statuses, &RecurringPaymentStatus{RecurringPayment: payment, Armed: armed})
+	}
+	return statuses, nil
+}
+
+// PauseRecurringPayment stop further runs, the password stays loaded so the
+// payment can be resumed without it
+func (w *Wallet) PauseRecurringPayment(id string) error {
+	w.recurringMtx.Lock()
+	defer w.recurringMtx.Unlock()
+
+	payment, err := w.getRecurringPayment(id)
+	if err != nil {
+		return err
+	}
+
+	payment.Paused = true
+	return w.saveRecurringPayment(payment)
+}
+
+// ResumeRecurringPayment re-enable a paused payment. A password must be given
+// when the payment isn't armed, typically after a node restart.
+func (w *Wallet) ResumeRecurringPayment(id, password string) error {
+	w.recurringMtx.Lock()
+	defer w.recurringMtx.Unlock()
+
+	payment, err := w.getRecurringPayment(id)
+	if err != nil {
+		return err
+	}
+
+	if password != "" {
+		if err := w.checkRecurringPassword(payment.AccountID, password); err != nil {
+			return err
+		}
+		w.recurringSecrets[id] = password
+	} else if _, armed := w.recurringSecrets[id]; !armed {
+		return errors.New("recurring payment isn't armed, resume it with the signing password")
+	}
+
+	payment.Paused = false
+	return w.saveRecurringPayment(payment)
+}
+
+// DeleteRecurringPayment remove the payment and its in-memory password
+func (w *Wallet) DeleteRecurringPayment(id string) error {
+	w.recurringMtx.Lock()
+	defer w.recurringMtx.Unlock()
+
+	if _, err := w.getRecurringPayment(id); err != nil {
+		return err
+	}
+
+	w.DB.Delete(calcRecurringPaymentKey(id))
+	delete(w.recurringSecrets, id)
+	return nil
+}
+
+// buildRecurringTx assemble the payment transaction: the account pays amount
+// of the asset to the stored address, the fee comes on top in BTM
+func (w *Wallet) buildRecurringTx(ctx context.Context, payment *RecurringPayment) (*txbuilder.Template, error) {
+	fee := estimateConsolidateFee(2)
+	actions := []txbuilder.Action{}
+
+	spendAmount := payment.Amount
+	if payment.AssetID == *consensus.BTMAssetID {
+		spendAmount += fee
+	}
+
+	spendData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		AccountID string `json:"account_id"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: &payment.AssetID, Amount: spendAmount},
+		AccountID:   payment.AccountID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spendAction, err := w.AccountMgr.DecodeSpendAction(spendData)
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, spendAction)
+
+	if payment.AssetID != *consensus.BTMAssetID {
+		feeData, err := json.Marshal(&struct {
+			bc.AssetAmount
+			AccountID string `json:"account_id"`
+		}{
+			AssetAmount: bc.AssetAmount{AssetId: consensus.BTMAssetID, Amount: fee},
+			AccountID:   payment.AccountID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		feeAction, err := w.AccountMgr.DecodeSpendAction(feeData)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, feeAction)
+	}
+
+	controlData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		Address string `json:"address"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: &payment.AssetID, Amount: payment.Amount},
+		Address:     payment.Address,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	controlAction, err := txbuilder.DecodeControlAddressAction(controlData)
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, controlAction)
+
+	return txbuilder.Build(ctx, nil, actions, time.Now().Add(recurringTxTTL), 0)
+}
+
+// hsmSignTemplate adapts the wallet HSM to the txbuilder signing callback
+func (w *Wallet) hsmSignTemplate(ctx context.Context, xpub chainkd.XPub, path [][]byte, data [32]byte, password string) ([]byte, error) {
+	return w.Hsm.XSign(xpub, path, data[:], password)
+}
+
+// executeRecurringPayment build, policy-check, sign and submit one run
+func (w *Wallet) executeRecurringPayment(payment *RecurringPayment, password string) (string, error) {
+	ctx := context.Background()
+	tpl, err := w.buildRecurringTx(ctx, payment)
+	if err != nil {
+		return "", errors.Wrap(err, "build recurring tx")
+	}
+
+	if err := w.AccountMgr.EnforceSpendPolicy(tpl.Transaction); err != nil {
+		return "", errors.Wrap(err, "spend policy")
+	}
+
+	if err := txbuilder.Sign(ctx, tpl, password, w.hsmSignTemplate); err != nil {
+		return "", errors.Wrap(err, "sign recurring tx")
+	}
+	if !txbuilder.SignProgress(tpl) {
+		return "", errors.New("recurring tx not fully signed, multi-key accounts aren't supported by the scheduler")
+	}
+
+	if err := txbuilder.FinalizeTx(ctx, w.chain, tpl.Transaction); err != nil {
+		return "", errors.Wrap(err, "submit recurring tx")
+	}
+	return tpl.Transaction.ID.String(), nil
+}
+
+// recordRecurringRun append the run to the capped history and advance the
+// schedule past now, missed intervals are skipped rather than replayed
+func (w *Wallet) recordRecurringRun(payment *RecurringPayment, run *RecurringRun, now uint64) error {
+	payment.History = append(payment.History, run)
+	if len(payment.History) > recurringHistoryLimit {
+		payment.History = payment.History[len(payment.History)-recurringHistoryLimit:]
+	}
+
+	for payment.NextRunAt <= now {
+		payment.NextRunAt += payment.IntervalSeconds
+	}
+	return w.saveRecurringPayment(payment)
+}
+
+// runDueRecurringPayments execute every due payment once, recording the
+// outcome and alerting subscribers on failure
+func (w *Wallet) runDueRecurringPayments() error {
+	w.recurringMtx.Lock()
+	defer w.recurringMtx.Unlock()
+
+	payments, err := w.listRecurringPayments()
+	if err != nil {
+		return err
+	}
+
+	now := uint64(time.Now().Unix())
+	for _, payment := range payments {
+		if payment.Paused || payment.NextRunAt > now {
+			continue
+		}
+
+		run := &RecurringRun{RunAt: now}
+		password, armed := w.recurringSecrets[payment.ID]
+		if !armed {
+			run.Error = "signing password not loaded, resume the payment to re-arm it"
+		} else if txID, err := w.executeRecurringPayment(payment, password); err != nil {
+			run.Error = err.Error()
+		} else {
+			run.Success = true
+			run.TxID = txID
+		}
+
+		if run.Success {
+			log.WithFields(log.Fields{"id": payment.ID, "tx_id": run.TxID}).Info("submit recurring payment")
+		} else {
+			log.WithFields(log.Fields{"id": payment.ID, "err": run.Error}).Warning("recurring payment run failed")
+			w.Hub.Publish(&Event{
+				Type:      EventRecurringPaymentFailed,
+				TxID:      run.TxID,
+				AccountID: payment.AccountID,
+				AssetID:   payment.AssetID.String(),
+				Address:   payment.Address,
+				Amount:    payment.Amount,
+			})
+		}
+
+		if err := w.recordRecurringRun(payment, run, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runRecurringPayments periodically check the recurring payments and run the due ones
+func (w *Wallet) runRecurringPayments() {
+	ticker := time.NewTicker(RecurringCheckPeriod)
+	defer ticker.Stop()
+	for {
+		<-ticker.C
+		if err := w.runDueRecurringPayments(); err != nil {
+			log.WithField("err", err).Error("wallet fail on runRecurringPayments")
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc/types"
+)
+
+const (
+	//ScheduledTxPrefix is wallet database scheduled transactions prefix
+	ScheduledTxPrefix = "STXS:"
+	//ScheduledTxCheckPeriod is the interval for checking due scheduled transactions
+	ScheduledTxCheckPeriod = 30 * time.Second
+)
+
+func calcScheduledTxKey(txID string) []byte {
+	return []byte(ScheduledTxPrefix + txID)
+}
+
+// ScheduledTx is a signed transaction held by the node until it becomes valid,
+// then submitted automatically.
+type ScheduledTx struct {
+	Tx                *types.Tx `json:"raw_transaction"`
+	SubmitAfterHeight uint64    `json:"submit_after_height"`
+	SubmitAfterTime   uint64    `json:"submit_after_time"`
+	CreatedAt         uint64    `json:"created_at"`
+}
+
+// isDue reports whether the scheduled transaction may be submitted at the
+// given chain height and wall clock time.
+func (s *ScheduledTx) isDue(height uint64, now time.Time) bool {
+	if s.SubmitAfterHeight > height {
+		return false
+	}
+	if s.SubmitAfterTime > uint64(now.Unix()) {
+		return false
+	}
+	return true
+}
+
+// ScheduleTx persist a signed transaction together with its earliest submit
+// height/time, it will be submitted by the wallet once due
+func (w *Wallet) ScheduleTx(tx *types.Tx, submitAfterHeight, submitAfterTime uint64) error {
+	scheduledTx := &ScheduledTx{
+		Tx:                tx,
+		SubmitAfterHeight: submitAfterHeight,
+		SubmitAfterTime:   submitAfterTime,
+		CreatedAt:         uint64(time.Now().Unix()),
+	}
+
+	rawTx, err := json.Marshal(scheduledTx)
+	if err != nil {
+		return err
+	}
+
+	w.DB.Set(calcScheduledTxKey(tx.ID.String()), rawTx)
+	return nil
+}
+
+// GetScheduledTxs return all the scheduled transactions which wait for submitting
+func (w *Wallet) GetScheduledTxs() ([]*ScheduledTx, error) {
+	scheduledTxs := []*ScheduledTx{}
+	txIter := w.DB.IteratorPrefix([]byte(ScheduledTxPrefix))
+	defer txIter.Release()
+
+	for txIter.Next() {
+		scheduledTx := &ScheduledTx{}
+		if err := json.Unmarshal(txIter.Value(), scheduledTx); err != nil {
+			return nil, err
+		}
+		scheduledTxs = append(scheduledTxs, scheduledTx)
+	}
+	return scheduledTxs, nil
+}
+
+// RemoveScheduledTx delete the scheduled transaction by txID
+func (w *Wallet) RemoveScheduledTx(txID string) error {
+	if txInfo := w.DB.Get(calcScheduledTxKey(txID)); txInfo == nil {
+		return fmt.Errorf("No scheduled transaction(tx_id=%s) ", txID)
+	}
+
+	w.DB.Delete(calcScheduledTxKey(txID))
+	return nil
+}
+
+// submitDueTxs submit all due scheduled transactions, the submitted and the
+// permanently rejected ones are removed from the database
+func (w *Wallet) submitDueTxs() error {
+	scheduledTxs, err := w.GetScheduledTxs()
+	if err != nil {
+		return err
+	}
+
+	bestHeight := w.chain.BestBlockHeight()
+	now := time.Now()
+	for _, scheduledTx := range scheduledTxs {
+		if !scheduledTx.isDue(bestHeight, now) {
+			continue
+		}
+
+		err := txbuilder.FinalizeTx(context.Background(), w.chain, scheduledTx.Tx)
+		if err == nil || errors.Root(err) == txbuilder.ErrRejected {
+			w.DB.Delete(calcScheduledTxKey(scheduledTx.Tx.ID.String()))
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"tx_id": scheduledTx.Tx.ID.String(), "err": err}).Warning("wallet fail on submit scheduled tx")
+			continue
+		}
+		log.WithField("tx_id", scheduledTx.Tx.ID.String()).Info("submit scheduled tx")
+	}
+	return nil
+}
+
+//submitScheduledTx periodically check the scheduled transactions and submit the due ones
+func (w *Wallet) submitScheduledTx() {
+	ticker := time.NewTicker(ScheduledTxCheckPeriod)
+	defer ticker.Stop()
+	for {
+		<-ticker.C
+		if err := w.submitDueTxs(); err != nil {
+			log.WithField("err", err).Error("wallet fail on submitScheduledTx")
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/blockchain/txbuilder"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/bc"
+)
+
+// sendAllMaxInputs caps the inputs of one sweep, a wallet with more utxos
+// should consolidate first
+const sendAllMaxInputs = 250
+
+// pre-define send-all errors
+var (
+	// ErrNoSweepUTXOs means the account holds nothing spendable of the asset
+	ErrNoSweepUTXOs = errors.New("no spendable utxos of the asset to sweep")
+	// ErrSweepBelowFee means the swept total doesn't cover the fee
+	ErrSweepBelowFee = errors.New("swept amount doesn't cover the transaction fee")
+	// ErrTooManySweepInputs means the sweep exceeds the input cap
+	ErrTooManySweepInputs = errors.New("too many utxos to sweep in one transaction, consolidate first")
+)
+
+// listSweepUTXOs return the mature spendable utxos of the asset in the account
+func (w *Wallet) listSweepUTXOs(accountID string, assetID bc.AssetID) []*account.UTXO {
+	bestHeight := w.chain.BestBlockHeight()
+	utxos := []*account.UTXO{}
+	for _, utxo := range w.GetAccountUtxos(accountID, "", false, false) {
+		if utxo.AccountID != accountID || utxo.AssetID != assetID {
+			continue
+		}
+		if utxo.ValidHeight > bestHeight {
+			continue
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos
+}
+
+// BuildSendAllTx build a transaction sweeping every spendable utxo of the
+// asset in the account to the destination address with no change output. For
+// BTM the fee is deducted from the swept amount, inputs worth less than the
+// marginal fee they add are dropped iteratively. For other assets the fee is
+// paid on top in BTM through a regular spend action.
+func (w *Wallet) BuildSendAllTx(ctx context.Context, accountID string, assetID bc.AssetID, address string) (*txbuilder.Template, error) {
+	if accountID == "" {
+		return nil, account.ErrFindAccount
+	}
+
+	utxos := w.listSweepUTXOs(accountID, assetID)
+	if len(utxos) == 0 {
+		return nil, ErrNoSweepUTXOs
+	}
+	if len(utxos) > sendAllMaxInputs {
+		return nil, ErrTooManySweepInputs
+	}
+
+	amount := uint64(0)
+	for _, utxo := range utxos {
+		amount += utxo.Amount
+	}
+
+	fee := uint64(0)
+	if assetID == *consensus.BTMAssetID {
+		// the fee grows with the input count, so dropping an uneconomic input
+		// changes the fee of the remaining set; iterate until it stabilizes
+		for {
+			fee = estimateConsolidateFee(len(utxos))
+			marginalFee := fee - estimateConsolidateFee(len(utxos)-1)
+
+			dropped := false
+			for i, utxo := range utxos {
+				if utxo.Amount <= marginalFee {
+					utxos = append(utxos[:i], utxos[i+1:]...)
+					amount -= utxo.Amount
+					dropped = true
+					break
+				}
+			}
+			if !dropped || len(utxos) == 0 {
+				break
+			}
+		}
+
+		if len(utxos) == 0 || amount <= fee {
+			return nil, ErrSweepBelowFee
+		}
+		amount -= fee
+	}
+
+	actions := []txbuilder.Action{}
+	for _, utxo := range utxos {
+		data, err := json.Marshal(&struct {
+			OutputID *bc.Hash `json:"output_id"`
+		}{OutputID: &utxo.OutputID})
+		if err != nil {
+			return nil, err
+		}
+
+		action, err := w.AccountMgr.DecodeSpendUTXOAction(data)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	if assetID != *consensus.BTMAssetID {
+		feeData, err := json.Marshal(&struct {
+			bc.AssetAmount
+			AccountID string `json:"account_id"`
+		}{
+			AssetAmount: bc.AssetAmount{AssetId: consensus.BTMAssetID, Amount: estimateConsolidateFee(len(utxos) + 1)},
+			AccountID:   accountID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		feeAction, err := w.AccountMgr.DecodeSpendAction(feeData)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, feeAction)
+	}
+
+	controlData, err := json.Marshal(&struct {
+		bc.AssetAmount
+		Address string `json:"address"`
+	}{
+		AssetAmount: bc.AssetAmount{AssetId: &assetID, Amount: amount},
+		Address:     address,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	controlAction, err := txbuilder.DecodeControlAddressAction(controlData)
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, controlAction)
+
+	return txbuilder.Build(ctx, nil, actions, time.Now().Add(consolidateTxTTL), 0)
+}
@@ -85,6 +85,36 @@ func (w *Wallet) GetUnconfirmedTxByTxID(txID string) (*query.AnnotatedTx, error)
 // RemoveUnconfirmedTx handle wallet status update when tx removed from txpool
 func (w *Wallet) RemoveUnconfirmedTx(txD *protocol.TxDesc) {
 	w.AccountMgr.RemoveUnconfirmedUtxo(txD.Tx.ResultIds)
+
+	// a removal with an error cache entry is a rejection, not a confirmation
+	if w.chain.GetTxPool().IsTransactionInErrCache(&txD.Tx.ID) {
+		if annotatedTx, err := w.GetUnconfirmedTxByTxID(txD.Tx.ID.String()); err == nil {
+			w.Hub.notifyRejectedTx(annotatedTx)
+		}
+	}
+}
+
+// NotifyConflictTx emit a double spend event per conflicting outpoint, account
+// fields are filled when the transaction being spent against belongs to the wallet
+func (w *Wallet) NotifyConflictTx(txD *protocol.TxDesc, conflicts []*protocol.SpendConflict) {
+	for _, conflict := range conflicts {
+		event := &Event{
+			Type:         EventDoubleSpend,
+			TxID:         txD.Tx.ID.String(),
+			ConflictTxID: conflict.TxID.String(),
+			OutputID:     conflict.OutputID.String(),
+		}
+		if annotatedTx, err := w.GetUnconfirmedTxByTxID(conflict.TxID.String()); err == nil {
+			for _, input := range annotatedTx.Inputs {
+				if input.AccountID != "" {
+					event.AccountID = input.AccountID
+					event.AccountAlias = input.AccountAlias
+					break
+				}
+			}
+		}
+		w.Hub.Publish(event)
+	}
 }
 
 func (w *Wallet) buildAnnotatedUnconfirmedTx(tx *types.Tx) *query.AnnotatedTx {
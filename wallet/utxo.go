@@ -140,6 +140,7 @@ func (w *Wallet) filterAccountUtxo(utxos []*account.UTXO) []*account.UTXO {
 			utxo.Address = cp.Address
 			utxo.ControlProgramIndex = cp.KeyIndex
 			utxo.Change = cp.Change
+			utxo.Imported = cp.Imported
 			result = append(result, utxo)
 		}
 	}
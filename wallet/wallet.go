@@ -22,7 +22,7 @@ const (
 
 var walletKey = []byte("walletInfo")
 
-//StatusInfo is base valid block info to handle orphan block rollback
+// StatusInfo is base valid block info to handle orphan block rollback
 type StatusInfo struct {
 	WorkHeight uint64
 	WorkHash   bc.Hash
@@ -30,27 +30,44 @@ type StatusInfo struct {
 	BestHash   bc.Hash
 }
 
-//Wallet is related to storing account unspent outputs
+// Wallet is related to storing account unspent outputs
 type Wallet struct {
-	DB         db.DB
-	rw         sync.RWMutex
-	status     StatusInfo
-	AccountMgr *account.Manager
-	AssetReg   *asset.Registry
-	Hsm        *pseudohsm.HSM
-	chain      *protocol.Chain
-	rescanCh   chan struct{}
+	DB           db.DB
+	rw           sync.RWMutex
+	status       StatusInfo
+	AccountMgr   *account.Manager
+	AssetReg     *asset.Registry
+	Hsm          *pseudohsm.HSM
+	chain        *protocol.Chain
+	rescanCh     chan struct{}
+	rescanFromCh chan uint64
+	Hub          *NotificationHub
+	assetFilter  *assetFilter
+
+	// recurring payment state, the secrets live in memory only
+	recurringMtx     sync.Mutex
+	recurringSecrets map[string]string
 }
 
-//NewWallet return a new wallet instance
+// NewWallet return a new wallet instance
 func NewWallet(walletDB db.DB, account *account.Manager, asset *asset.Registry, hsm *pseudohsm.HSM, chain *protocol.Chain) (*Wallet, error) {
 	w := &Wallet{
-		DB:         walletDB,
-		AccountMgr: account,
-		AssetReg:   asset,
-		chain:      chain,
-		Hsm:        hsm,
-		rescanCh:   make(chan struct{}, 1),
+		DB:           walletDB,
+		AccountMgr:   account,
+		AssetReg:     asset,
+		chain:        chain,
+		Hsm:          hsm,
+		rescanCh:     make(chan struct{}, 1),
+		rescanFromCh: make(chan uint64, 1),
+		Hub:          NewNotificationHub(),
+		assetFilter:  newAssetFilter(),
+
+		recurringSecrets: map[string]string{},
+	}
+	w.Hub.deniedAsset = w.isAssetDenied
+
+	if err := w.runMigrations(); err != nil {
+		return nil, err
 	}
 
 	if err := w.loadWalletInfo(); err != nil {
@@ -59,11 +76,13 @@ func NewWallet(walletDB db.DB, account *account.Manager, asset *asset.Registry,
 
 	go w.walletUpdater()
 	go w.delUnconfirmedTx()
+	go w.submitScheduledTx()
+	go w.runRecurringPayments()
 	return w, nil
 }
 
-//GetWalletInfo return stored wallet info and nil,if error,
-//return initial wallet info and err
+// GetWalletInfo return stored wallet info and nil,if error,
+// return initial wallet info and err
 func (w *Wallet) loadWalletInfo() error {
 	if rawWallet := w.DB.Get(walletKey); rawWallet != nil {
 		return json.Unmarshal(rawWallet, &w.status)
@@ -116,7 +135,13 @@ func (w *Wallet) AttachBlock(block *types.Block) error {
 		w.status.BestHeight = w.status.WorkHeight
 		w.status.BestHash = w.status.WorkHash
 	}
-	return w.commitWalletInfo(storeBatch)
+
+	if err := w.commitWalletInfo(storeBatch); err != nil {
+		return err
+	}
+
+	w.Hub.notifyConfirmations(w.status.BestHeight)
+	return nil
 }
 
 // DetachBlock detach a block and rollback state
@@ -133,6 +158,7 @@ func (w *Wallet) DetachBlock(block *types.Block) error {
 	storeBatch := w.DB.NewBatch()
 	w.detachUtxos(storeBatch, block, txStatus)
 	w.deleteTransactions(storeBatch, w.status.BestHeight)
+	updateSupplyCounters(block, txStatus, w.DB, false)
 
 	w.status.BestHeight = block.Height - 1
 	w.status.BestHash = block.PreviousBlockHash
@@ -145,7 +171,7 @@ func (w *Wallet) DetachBlock(block *types.Block) error {
 	return w.commitWalletInfo(storeBatch)
 }
 
-//WalletUpdate process every valid block and reverse every invalid block which need to rollback
+// WalletUpdate process every valid block and reverse every invalid block which need to rollback
 func (w *Wallet) walletUpdater() {
 	for {
 		w.getRescanNotification()
@@ -175,7 +201,7 @@ func (w *Wallet) walletUpdater() {
 	}
 }
 
-//RescanBlocks provide a trigger to rescan blocks
+// RescanBlocks provide a trigger to rescan blocks
 func (w *Wallet) RescanBlocks() {
 	select {
 	case w.rescanCh <- struct{}{}:
@@ -184,10 +210,23 @@ func (w *Wallet) RescanBlocks() {
 	}
 }
 
+// RescanBlocksFrom provide a trigger to rescan blocks from the given height,
+// the wallet indexes above that height are rewound and blocks are re-attached
+// forward by the updater
+func (w *Wallet) RescanBlocksFrom(height uint64) {
+	select {
+	case w.rescanFromCh <- height:
+	default:
+		return
+	}
+}
+
 func (w *Wallet) getRescanNotification() {
 	select {
 	case <-w.rescanCh:
 		w.setRescanStatus()
+	case height := <-w.rescanFromCh:
+		w.setRescanFromStatus(height)
 	default:
 		return
 	}
@@ -199,11 +238,28 @@ func (w *Wallet) setRescanStatus() {
 	w.AttachBlock(block)
 }
 
+func (w *Wallet) setRescanFromStatus(height uint64) {
+	for w.status.BestHeight > height {
+		block, err := w.chain.GetBlockByHash(&w.status.BestHash)
+		if err != nil {
+			log.WithField("err", err).Error("setRescanFromStatus GetBlockByHash")
+			return
+		}
+
+		if err := w.DetachBlock(block); err != nil {
+			log.WithField("err", err).Error("setRescanFromStatus DetachBlock")
+			return
+		}
+	}
+}
+
 func (w *Wallet) walletBlockWaiter() {
 	select {
 	case <-w.chain.BlockWaiter(w.status.WorkHeight + 1):
 	case <-w.rescanCh:
 		w.setRescanStatus()
+	case height := <-w.rescanFromCh:
+		w.setRescanFromStatus(height)
 	}
 }
 
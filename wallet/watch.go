@@ -0,0 +1,219 @@
+package wallet
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/bytom-gm/account"
+	"github.com/bytom-gm/common"
+	"github.com/bytom-gm/consensus"
+	"github.com/bytom-gm/crypto/sm3"
+	chainjson "github.com/bytom-gm/encoding/json"
+	"github.com/bytom-gm/errors"
+	"github.com/bytom-gm/protocol/vm/vmutil"
+)
+
+// WatchProgramPrefix is the leveldb key prefix of imported watch programs
+const WatchProgramPrefix = "WATCH:"
+
+var (
+	// ErrBadWatchAddress means the watch address can't be decoded
+	ErrBadWatchAddress = errors.New("invalid watch address")
+	// ErrWatchProgramExist means the program is already watched or account owned
+	ErrWatchProgramExist = errors.New("control program is already tracked by the wallet")
+	// ErrWatchProgramEmpty means neither an address nor a program was supplied
+	ErrWatchProgramEmpty = errors.New("either address or control program must be given")
+)
+
+// WatchedProgram is an imported watch-only address or raw control program,
+// its UTXOs and transactions are indexed like account owned ones
+type WatchedProgram struct {
+	Alias   string             `json:"alias"`
+	Address string             `json:"address"`
+	Program chainjson.HexBytes `json:"program"`
+}
+
+func watchProgramKey(program []byte) []byte {
+	return []byte(WatchProgramPrefix + hex.EncodeToString(program))
+}
+
+// addressToProgram derive the control program of a bech32 address
+func addressToProgram(address string) ([]byte, error) {
+	decoded, err := common.DecodeAddress(address, &consensus.ActiveNetParams)
+	if err != nil {
+		return nil, errors.Sub(ErrBadWatchAddress, err)
+	}
+
+	redeemContract := decoded.ScriptAddress()
+	switch decoded.(type) {
+	case *common.AddressWitnessPubKeyHash:
+		return vmutil.P2WPKHProgram(redeemContract)
+	case *common.AddressWitnessScriptHash:
+		return vmutil.P2WSHProgram(redeemContract)
+	}
+	return nil, ErrBadWatchAddress
+}
+
+// ImportWatchProgram start watching the given address or raw control program.
+// The program is registered alongside the account control programs so the
+// regular indexing picks up its UTXOs and transactions, then a rescan
+// backfills the history
+func (w *Wallet) ImportWatchProgram(alias, address string, program []byte) (*WatchedProgram, error) {
+	if address != "" {
+		derived, err := addressToProgram(address)
+		if err != nil {
+			return nil, err
+		}
+		program = derived
+	}
+	if len(program) == 0 {
+		return nil, ErrWatchProgramEmpty
+	}
+
+	var hash [32]byte
+	sm3.Sum(hash[:], program)
+	if w.DB.Get(account.ContractKey(hash)) != nil {
+		return nil, ErrWatchProgramExist
+	}
+
+	cp := &account.CtrlProgram{
+		Address:        address,
+		ControlProgram: program,
+		Imported:       true,
+	}
+	rawProgram, err := json.Marshal(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	watched := &WatchedProgram{
+		Alias:   alias,
+		Address: address,
+		Program: program,
+	}
+	rawWatched, err := json.Marshal(watched)
+	if err != nil {
+		return nil, err
+	}
+
+	w.DB.Set(account.ContractKey(hash), rawProgram)
+	w.DB.Set(watchProgramKey(program), rawWatched)
+
+	w.RescanBlocks()
+	return watched, nil
+}
+
+// bulkImportBatchSize is how many addresses are buffered into one leveldb
+// batch write during a bulk import
+const bulkImportBatchSize = 10000
+
+// BulkImportResult summarizes one bulk watch address import
+type BulkImportResult struct {
+	Imported   uint64 `json:"imported"`
+	Skipped    uint64 `json:"skipped"`
+	Invalid    uint64 `json:"invalid"`
+	RescanFrom uint64 `json:"rescan_from"`
+}
+
+// ImportWatchAddressStream reads newline separated bech32 addresses and
+// registers them as watch-only programs in large batch writes, so exchanges
+// can preload millions of externally derived deposit addresses. Addresses the
+// wallet already tracks are skipped and undecodable lines are counted but do
+// not abort the stream. One targeted rescan from startHeight is triggered at
+// the end instead of one per address
+func (w *Wallet) ImportWatchAddressStream(r io.Reader, startHeight uint64) (*BulkImportResult, error) {
+	result := &BulkImportResult{RescanFrom: startHeight}
+	batch := w.DB.NewBatch()
+	batched := map[[32]byte]bool{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		address := strings.TrimSpace(scanner.Text())
+		if address == "" {
+			continue
+		}
+
+		program, err := addressToProgram(address)
+		if err != nil {
+			result.Invalid++
+			continue
+		}
+
+		var hash [32]byte
+		sm3.Sum(hash[:], program)
+		if batched[hash] || w.DB.Get(account.ContractKey(hash)) != nil {
+			result.Skipped++
+			continue
+		}
+
+		rawProgram, err := json.Marshal(&account.CtrlProgram{
+			Address:        address,
+			ControlProgram: program,
+			Imported:       true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		rawWatched, err := json.Marshal(&WatchedProgram{
+			Address: address,
+			Program: program,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		batch.Set(account.ContractKey(hash), rawProgram)
+		batch.Set(watchProgramKey(program), rawWatched)
+		batched[hash] = true
+		result.Imported++
+
+		if len(batched) >= bulkImportBatchSize {
+			batch.Write()
+			batch = w.DB.NewBatch()
+			batched = map[[32]byte]bool{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	batch.Write()
+
+	if result.Imported > 0 {
+		w.RescanBlocksFrom(startHeight)
+	}
+	return result, nil
+}
+
+// ListWatchPrograms return every imported watch-only program
+func (w *Wallet) ListWatchPrograms() ([]*WatchedProgram, error) {
+	watched := []*WatchedProgram{}
+
+	iter := w.DB.IteratorPrefix([]byte(WatchProgramPrefix))
+	defer iter.Release()
+	for iter.Next() {
+		program := &WatchedProgram{}
+		if err := json.Unmarshal(iter.Value(), program); err != nil {
+			return nil, err
+		}
+		watched = append(watched, program)
+	}
+	return watched, nil
+}
+
+// DeleteWatchProgram stop watching a program, already indexed history stays
+// until the next full rescan
+func (w *Wallet) DeleteWatchProgram(program []byte) error {
+	if w.DB.Get(watchProgramKey(program)) == nil {
+		return errors.New("watch program not found")
+	}
+
+	var hash [32]byte
+	sm3.Sum(hash[:], program)
+	w.DB.Delete(account.ContractKey(hash))
+	w.DB.Delete(watchProgramKey(program))
+	return nil
+}